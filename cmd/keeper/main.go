@@ -82,7 +82,16 @@ func main() {
 	}
 	logger.Info("[3/5] Dependency: Health client Initialised")
 
-	codeExecutor, err := docker.NewCodeExecutor(context.Background(), docker.DefaultConfig(), logger)
+	executorCfg := docker.DefaultConfig()
+	executorCfg.Pool = docker.DefaultPoolConfig()
+	executorCfg.Docker.Sandbox.Network.AllowedHosts = config.GetScriptAllowedHosts()
+	if err := executorCfg.PinRuntimes(config.GetRuntimeImageDigests()); err != nil {
+		logger.Fatal("Failed to pin script runtimes", "error", err)
+	}
+	if host := config.GetIpfsHost(); host != "" {
+		executorCfg.IPFS.Gateways = append([]string{"https://" + host}, executorCfg.IPFS.Gateways...)
+	}
+	codeExecutor, err := docker.NewCodeExecutor(context.Background(), executorCfg, logger)
 	if err != nil {
 		logger.Fatal("Failed to initialize code executor", "error", err)
 	}
@@ -91,6 +100,7 @@ func main() {
 	// Initialize task executor and validator
 	validator := validation.NewTaskValidator(config.GetAlchemyAPIKey(), config.GetEtherscanAPIKey(), codeExecutor, aggregatorClient, logger)
 	executor := execution.NewTaskExecutor(config.GetAlchemyAPIKey(), codeExecutor, validator, aggregatorClient, logger)
+	validator.SetCallPacker(executor)
 
 	// Initialize API server
 	serverCfg := api.Config{
@@ -11,9 +11,11 @@ import (
 	"github.com/trigg3rX/triggerx-backend-imua/internal/redis/api"
 	"github.com/trigg3rX/triggerx-backend-imua/internal/redis/config"
 	"github.com/trigg3rX/triggerx-backend-imua/internal/redis/metrics"
+	"github.com/trigg3rX/triggerx-backend-imua/internal/redis/pins"
 	"github.com/trigg3rX/triggerx-backend-imua/internal/redis/streams/jobs"
 	"github.com/trigg3rX/triggerx-backend-imua/internal/redis/streams/tasks"
 	redisClient "github.com/trigg3rX/triggerx-backend-imua/pkg/client/redis"
+	"github.com/trigg3rX/triggerx-backend-imua/pkg/ipfs"
 	"github.com/trigg3rX/triggerx-backend-imua/pkg/logging"
 )
 
@@ -83,12 +85,40 @@ func main() {
 	}
 	logger.Info("Redis streams initialized successfully")
 
+	// Initialize IPFS store proofs of task are fetched from
+	ipfsStore, err := ipfs.NewStore(ipfs.Config{
+		Backend:     ipfs.Backend(config.GetIPFSBackend()),
+		GatewayHost: config.GetIPFSGateway(),
+		APIURL:      config.GetIPFSAPIURL(),
+		Token:       ipfsToken(),
+		Dir:         config.GetIPFSStoreDir(),
+	})
+	if err != nil {
+		logger.Fatal("Failed to initialize IPFS store", "error", err)
+	}
+	logger.Info("IPFS store Initialised", "backend", config.GetIPFSBackend())
+
+	// Initialize pinning manager, which keeps proofs pinned until they can no longer be challenged
+	pinningCfg := ipfs.PinningConfig{
+		ChallengeWindow: config.GetIPFSChallengeWindow(),
+		DisputePeriod:   config.GetIPFSDisputePeriod(),
+		SweepInterval:   config.GetIPFSPinSweepInterval(),
+	}
+	if dir := config.GetIPFSArchiveDir(); dir != "" {
+		if pinningCfg.ColdStore, err = ipfs.NewFileStore(dir); err != nil {
+			logger.Fatal("Failed to initialize IPFS archive", "error", err)
+		}
+	}
+	pinManager := ipfs.NewPinManager(ipfsStore, pins.NewRegistry(client), pinningCfg, logger)
+	logger.Info("Pinning manager Initialised", "archive", config.GetIPFSArchiveDir() != "")
+
 	// Initialize API server
 	serverCfg := api.Config{
 		Port:           config.GetRedisRPCPort(),
 		ReadTimeout:    config.GetReadTimeout(),
 		WriteTimeout:   config.GetWriteTimeout(),
 		MaxHeaderBytes: 1 << 20,
+		AdminToken:     config.GetIPFSPinsAdminToken(),
 	}
 
 	deps := api.Dependencies{
@@ -96,6 +126,8 @@ func main() {
 		TaskStreamMgr:    taskStreamMgr,
 		JobStreamMgr:     jobStreamMgr,
 		MetricsCollector: collector,
+		IPFSStore:        ipfsStore,
+		PinManager:       pinManager,
 	}
 
 	server := api.NewServer(serverCfg, deps)
@@ -120,6 +152,10 @@ func main() {
 	go taskStreamMgr.StartRetryWorker(ctx)
 	logger.Info("Started task retry worker")
 
+	// Pin Sweeper - archives and unpins proofs past their dispute period
+	go pinManager.Run(ctx)
+	logger.Info("Started pin sweeper")
+
 	// Stream Health Monitor - monitors stream health
 	go startStreamHealthMonitor(ctx, jobStreamMgr, taskStreamMgr, logger)
 
@@ -231,3 +267,11 @@ func performGracefulShutdown(ctx context.Context, client redisClient.RedisClient
 		os.Exit(0)
 	}
 }
+
+// ipfsToken is the credential of the configured IPFS backend
+func ipfsToken() string {
+	if ipfs.Backend(config.GetIPFSBackend()) == ipfs.BackendPinata {
+		return config.GetPinataJWT()
+	}
+	return config.GetIPFSAPIToken()
+}
@@ -57,7 +57,7 @@ func main() {
 	}
 
 	// Initialize condition-based scheduler with Redis integration
-	managerID := fmt.Sprintf("condition-scheduler-%d", time.Now().Unix())
+	managerID := config.GetInstanceID()
 	conditionScheduler, err := scheduler.NewConditionBasedScheduler(managerID, logger, dbClient)
 	if err != nil {
 		logger.Fatal("Failed to initialize condition-based scheduler", "error", err)
@@ -95,7 +95,7 @@ func main() {
 		"api_port":             config.GetSchedulerRPCPort(),
 		"max_workers":          config.GetMaxWorkers(),
 		"poll_interval":        "1s",
-		"supported_conditions": []string{"greater_than", "less_than", "between", "equals", "not_equals", "greater_equal", "less_equal"},
+		"supported_conditions": []string{"greater_than", "less_than", "between", "equals", "not_equals", "greater_equal", "less_equal", "percent_change_above", "percent_change_below", "sma_cross_above", "sma_cross_below", "ema_cross_above", "ema_cross_below", "zscore_above"},
 		"supported_sources":    []string{"api", "oracle", "static"},
 		"request_timeout":      "10s",
 		"value_cache_ttl":      "30s",
@@ -43,6 +43,7 @@ func main() {
 	if err != nil {
 		logger.Fatal("Failed to initialize database client", "error", err)
 	}
+	dbClient.SetServiceToken(config.GetDBServerServiceToken())
 	logger.Info("Database client initialized successfully")
 
 	// Initialize time-based scheduler with Redis integration via HTTP API
@@ -41,6 +41,18 @@ type Config struct {
 
 	// Polling Look Ahead
 	timeSchedulerPollingLookAhead int
+
+	// Hand condition jobs to scheduler instances through the Redis catalog
+	conditionJobCatalogEnabled bool
+
+	// Digests the images of the script runtimes are pinned to, as language=digest
+	runtimeImageDigests string
+
+	// Token the other services authenticate with on their routes
+	serviceToken string
+
+	// Proxy hubs take several calls on one chain in one transaction
+	proxyHubBatch bool
 }
 
 var cfg Config
@@ -65,6 +77,10 @@ func Init() error {
 		upstashRedisRestToken:         env.GetEnvString("UPSTASH_REDIS_REST_TOKEN", ""),
 		devMode:                       env.GetEnvBool("DEV_MODE", false),
 		timeSchedulerPollingLookAhead: env.GetEnvInt("TIME_SCHEDULER_POLLING_LOOKAHEAD", 40),
+		conditionJobCatalogEnabled:    env.GetEnvBool("CONDITION_JOB_CATALOG_ENABLED", false),
+		runtimeImageDigests:           env.GetEnvString("RUNTIME_IMAGE_DIGESTS", ""),
+		serviceToken:                  env.GetEnvString("DBSERVER_SERVICE_TOKEN", ""),
+		proxyHubBatch:                 env.GetEnvBool("PROXY_HUB_BATCH", false),
 	}
 	if err := validateConfig(cfg); err != nil {
 		return fmt.Errorf("invalid config: %w", err)
@@ -79,7 +95,8 @@ func validateConfig(cfg Config) error {
 	if !env.IsValidURL(cfg.timeSchedulerRPCUrl) {
 		return fmt.Errorf("invalid time scheduler RPC URL: %s", cfg.timeSchedulerRPCUrl)
 	}
-	if !env.IsValidURL(cfg.conditionSchedulerRPCUrl) {
+	// The condition scheduler URL is not used when jobs go through the catalog
+	if !cfg.conditionJobCatalogEnabled && !env.IsValidURL(cfg.conditionSchedulerRPCUrl) {
 		return fmt.Errorf("invalid condition scheduler RPC URL: %s", cfg.conditionSchedulerRPCUrl)
 	}
 	if !env.IsValidPort(cfg.dbserverRPCPort) {
@@ -103,6 +120,9 @@ func validateConfig(cfg Config) error {
 	if env.IsEmpty(cfg.upstashRedisRestToken) {
 		return fmt.Errorf("invalid upstash redis rest token: %s", cfg.upstashRedisRestToken)
 	}
+	if env.IsEmpty(cfg.serviceToken) {
+		return fmt.Errorf("DBSERVER_SERVICE_TOKEN is required, the schedulers and Redis service authenticate with it")
+	}
 	if !cfg.devMode {
 		if !env.IsValidEmail(cfg.emailUser) {
 			return fmt.Errorf("invalid email user: %s", cfg.emailUser)
@@ -125,6 +145,10 @@ func GetConditionSchedulerRPCUrl() string {
 	return cfg.conditionSchedulerRPCUrl
 }
 
+func IsConditionJobCatalogEnabled() bool {
+	return cfg.conditionJobCatalogEnabled
+}
+
 func GetDBServerRPCPort() string {
 	return cfg.dbserverRPCPort
 }
@@ -176,3 +200,18 @@ func IsDevMode() bool {
 func GetPollingLookAhead() int {
 	return cfg.timeSchedulerPollingLookAhead
 }
+
+// GetRuntimeImageDigests are the digests the script runtimes are pinned to
+func GetRuntimeImageDigests() string {
+	return cfg.runtimeImageDigests
+}
+
+// GetServiceToken is the token the other services authenticate with
+func GetServiceToken() string {
+	return cfg.serviceToken
+}
+
+// IsProxyHubBatch reports whether a job may make several calls on one chain
+func IsProxyHubBatch() bool {
+	return cfg.proxyHubBatch
+}
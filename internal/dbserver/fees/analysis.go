@@ -0,0 +1,85 @@
+package fees
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"strings"
+	"time"
+)
+
+// What a script is assumed to cost to run, before it has ever been executed
+const (
+	baseExecutionTime = 2 * time.Second // go run compiles the script before executing it
+	loopExecutionTime = 50 * time.Millisecond
+	callExecutionTime = 500 * time.Millisecond // per network call
+	baseMemoryMB      = 64.0
+	importMemoryMB    = 4.0
+)
+
+// Packages whose calls go over the network
+var networkPackages = map[string]bool{
+	"http":      true,
+	"net":       true,
+	"ethclient": true,
+	"rpc":       true,
+}
+
+// Analysis is what can be told about a script's cost from its source alone
+type Analysis struct {
+	SizeKB       float64 `json:"size_kb"`
+	Imports      int     `json:"imports"`
+	Functions    int     `json:"functions"`
+	Loops        int     `json:"loops"`
+	NetworkCalls int     `json:"network_calls"`
+	Parsed       bool    `json:"parsed"`
+}
+
+// AnalyzeScript counts the constructs of a Go script that drive its execution
+// time and memory. Sources that do not parse are scanned for the same tokens.
+func AnalyzeScript(content []byte) Analysis {
+	analysis := Analysis{SizeKB: float64(len(content)) / 1024}
+
+	file, err := parser.ParseFile(token.NewFileSet(), "code.go", content, parser.SkipObjectResolution)
+	if err != nil {
+		source := string(content)
+		analysis.Imports = strings.Count(source, "\n\t\"") + strings.Count(source, "import \"")
+		analysis.Functions = strings.Count(source, "func ")
+		analysis.Loops = strings.Count(source, "for ")
+		for pkg := range networkPackages {
+			analysis.NetworkCalls += strings.Count(source, pkg+".")
+		}
+		return analysis
+	}
+
+	analysis.Parsed = true
+	analysis.Imports = len(file.Imports)
+	ast.Inspect(file, func(node ast.Node) bool {
+		switch n := node.(type) {
+		case *ast.FuncDecl, *ast.FuncLit:
+			analysis.Functions++
+		case *ast.ForStmt, *ast.RangeStmt:
+			analysis.Loops++
+		case *ast.CallExpr:
+			if selector, ok := n.Fun.(*ast.SelectorExpr); ok {
+				if pkg, ok := selector.X.(*ast.Ident); ok && networkPackages[pkg.Name] {
+					analysis.NetworkCalls++
+				}
+			}
+		}
+		return true
+	})
+	return analysis
+}
+
+// ExecutionTime is the run time expected of the script
+func (a Analysis) ExecutionTime() time.Duration {
+	return baseExecutionTime +
+		time.Duration(a.Loops)*loopExecutionTime +
+		time.Duration(a.NetworkCalls)*callExecutionTime
+}
+
+// MemoryMB is the memory expected to be used by the script's container
+func (a Analysis) MemoryMB() float64 {
+	return baseMemoryMB + float64(a.Imports)*importMemoryMB
+}
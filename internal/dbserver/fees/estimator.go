@@ -0,0 +1,258 @@
+package fees
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/trigg3rX/triggerx-backend-imua/internal/dbserver/types"
+	"github.com/trigg3rX/triggerx-backend-imua/pkg/docker"
+	"github.com/trigg3rX/triggerx-backend-imua/pkg/logging"
+)
+
+// Where a fee estimate comes from, most reliable first
+const (
+	SourceHistory = "history" // Average of the script's settled executions
+	SourceSandbox = "sandbox" // Measured by running the script in a container
+	SourceStatic  = "static"  // Predicted from the script's source
+)
+
+const (
+	// Executions needed before a script's history is trusted over its analysis
+	minHistoryExecutions = 3
+	// Attesters priced into an estimate
+	estimateAttesters = 10
+	// Scripts whose analysis or measured fee is kept in memory
+	maxCachedScripts = 4096
+
+	SimulationQueueSize = 16
+	simulationWorkers   = 2
+	simulationTimeout   = 2 * time.Minute
+)
+
+// ErrSimulationQueueFull is returned when too many sandboxed runs are waiting
+var ErrSimulationQueueFull = errors.New("fee simulation queue is full")
+
+// Estimate is the expected fee of one execution of a script
+type Estimate struct {
+	ScriptCID  string  `json:"script_cid"`
+	Fee        float64 `json:"fee"`
+	Source     string  `json:"source"`
+	Executions int64   `json:"executions,omitempty"`
+}
+
+// History holds the cost of each script's settled executions
+type History interface {
+	GetScriptCost(scriptCID string) (types.ScriptCostData, error)
+	RecordScriptCost(scriptCID string, cost float64) error
+}
+
+type simulation struct {
+	url    string
+	cid    string
+	result chan simulationResult
+}
+
+type simulationResult struct {
+	fee float64
+	err error
+}
+
+// Estimator quotes script fees without running them where it can: from the
+// script's execution history, or failing that from a static analysis of its
+// source. Sandboxed runs are only made on request, one queue for all callers.
+type Estimator struct {
+	fees    docker.FeeConfig
+	history History
+	logger  logging.Logger
+	fetch   func(ctx context.Context, url string) ([]byte, error)
+	sandbox func(ctx context.Context, url string) (float64, error)
+
+	mu        sync.Mutex
+	analyses  map[string]Analysis
+	simulated map[string]float64
+
+	queue chan simulation
+}
+
+// NewEstimator creates an estimator pricing with cfg, and starts its sandbox workers
+func NewEstimator(cfg docker.ExecutorConfig, history History, logger logging.Logger) (*Estimator, error) {
+	downloader, err := docker.NewDownloader(cfg.IPFS, logger)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create downloader: %w", err)
+	}
+
+	e := newEstimator(cfg.Fees, history, logger,
+		func(ctx context.Context, url string) ([]byte, error) {
+			return downloader.DownloadScript(ctx, url)
+		},
+		sandboxRunner(cfg, logger))
+	for i := 0; i < simulationWorkers; i++ {
+		go e.runSimulations()
+	}
+	return e, nil
+}
+
+func newEstimator(fees docker.FeeConfig, history History, logger logging.Logger,
+	fetch func(ctx context.Context, url string) ([]byte, error),
+	sandbox func(ctx context.Context, url string) (float64, error)) *Estimator {
+	return &Estimator{
+		fees:      fees,
+		history:   history,
+		logger:    logger,
+		fetch:     fetch,
+		sandbox:   sandbox,
+		analyses:  make(map[string]Analysis),
+		simulated: make(map[string]float64),
+		queue:     make(chan simulation, SimulationQueueSize),
+	}
+}
+
+// Estimate quotes a script from its history, an earlier sandboxed run, or its source
+func (e *Estimator) Estimate(ctx context.Context, url string) (Estimate, error) {
+	cid := docker.ScriptCID(url)
+	if estimate, ok := e.fromHistory(cid); ok {
+		return estimate, nil
+	}
+
+	e.mu.Lock()
+	fee, ok := e.simulated[cid]
+	e.mu.Unlock()
+	if ok {
+		return Estimate{ScriptCID: cid, Fee: fee, Source: SourceSandbox}, nil
+	}
+
+	analysis, err := e.analyze(ctx, url, cid)
+	if err != nil {
+		return Estimate{}, err
+	}
+	fee = docker.ComputeFee(e.fees, analysis.SizeKB, analysis.MemoryMB(), analysis.ExecutionTime(), estimateAttesters)
+	return Estimate{ScriptCID: cid, Fee: fee, Source: SourceStatic}, nil
+}
+
+// Simulate measures a script without history by running it in the sandbox. Runs
+// wait in a bounded queue; ErrSimulationQueueFull is returned when it is full.
+func (e *Estimator) Simulate(ctx context.Context, url string) (Estimate, error) {
+	cid := docker.ScriptCID(url)
+	if estimate, ok := e.fromHistory(cid); ok {
+		return estimate, nil
+	}
+
+	sim := simulation{url: url, cid: cid, result: make(chan simulationResult, 1)}
+	select {
+	case e.queue <- sim:
+	default:
+		return Estimate{}, ErrSimulationQueueFull
+	}
+
+	select {
+	case result := <-sim.result:
+		if result.err != nil {
+			return Estimate{}, result.err
+		}
+		return Estimate{ScriptCID: cid, Fee: result.fee, Source: SourceSandbox}, nil
+	case <-ctx.Done():
+		return Estimate{}, ctx.Err()
+	}
+}
+
+// RecordExecution adds the settled cost of an execution to the script's history
+func (e *Estimator) RecordExecution(url string, cost float64) error {
+	if url == "" || cost <= 0 {
+		return nil
+	}
+	return e.history.RecordScriptCost(docker.ScriptCID(url), cost)
+}
+
+func (e *Estimator) fromHistory(cid string) (Estimate, bool) {
+	scriptCost, err := e.history.GetScriptCost(cid)
+	if err != nil {
+		e.logger.Errorf("Error getting cost history of script %s: %v", cid, err)
+		return Estimate{}, false
+	}
+	if scriptCost.Executions < minHistoryExecutions {
+		return Estimate{}, false
+	}
+	return Estimate{
+		ScriptCID:  cid,
+		Fee:        scriptCost.AverageCost(),
+		Source:     SourceHistory,
+		Executions: scriptCost.Executions,
+	}, true
+}
+
+// analyze returns the script's analysis, fetching it only the first time. Content
+// behind a CID does not change, so analyses are never refreshed.
+func (e *Estimator) analyze(ctx context.Context, url string, cid string) (Analysis, error) {
+	e.mu.Lock()
+	analysis, ok := e.analyses[cid]
+	e.mu.Unlock()
+	if ok {
+		return analysis, nil
+	}
+
+	content, err := e.fetch(ctx, url)
+	if err != nil {
+		return Analysis{}, fmt.Errorf("failed to fetch script %s: %w", url, err)
+	}
+	analysis = AnalyzeScript(content)
+
+	e.mu.Lock()
+	if len(e.analyses) >= maxCachedScripts {
+		e.analyses = make(map[string]Analysis)
+	}
+	e.analyses[cid] = analysis
+	e.mu.Unlock()
+	return analysis, nil
+}
+
+func (e *Estimator) runSimulations() {
+	for sim := range e.queue {
+		ctx, cancel := context.WithTimeout(context.Background(), simulationTimeout)
+		fee, err := e.sandbox(ctx, sim.url)
+		cancel()
+
+		if err == nil {
+			e.mu.Lock()
+			if len(e.simulated) >= maxCachedScripts {
+				e.simulated = make(map[string]float64)
+			}
+			e.simulated[sim.cid] = fee
+			e.mu.Unlock()
+		} else {
+			e.logger.Errorf("Error simulating script %s: %v", sim.url, err)
+		}
+		sim.result <- simulationResult{fee: fee, err: err}
+	}
+}
+
+// sandboxRunner runs scripts in fresh containers of one executor, created on
+// first use, and prices what they used
+func sandboxRunner(cfg docker.ExecutorConfig, logger logging.Logger) func(ctx context.Context, url string) (float64, error) {
+	var mu sync.Mutex
+	var executor *docker.CodeExecutor
+
+	return func(ctx context.Context, url string) (float64, error) {
+		mu.Lock()
+		if executor == nil {
+			created, err := docker.NewCodeExecutor(ctx, cfg, logger)
+			if err != nil {
+				mu.Unlock()
+				return 0, fmt.Errorf("failed to create code executor: %v", err)
+			}
+			executor = created
+		}
+		mu.Unlock()
+
+		result, err := executor.Execute(ctx, url, estimateAttesters)
+		if err != nil {
+			return 0, err
+		}
+		if !result.Success {
+			return 0, fmt.Errorf("failed to run script: %v", result.Error)
+		}
+		return result.Stats.TotalCost, nil
+	}
+}
@@ -0,0 +1,153 @@
+package fees
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/trigg3rX/triggerx-backend-imua/internal/dbserver/types"
+	"github.com/trigg3rX/triggerx-backend-imua/pkg/docker"
+	"github.com/trigg3rX/triggerx-backend-imua/pkg/logging"
+)
+
+type MockLogger struct{}
+
+func (l *MockLogger) Debug(msg string, tags ...any)               {}
+func (l *MockLogger) Info(msg string, tags ...any)                {}
+func (l *MockLogger) Warn(msg string, tags ...any)                {}
+func (l *MockLogger) Error(msg string, tags ...any)               {}
+func (l *MockLogger) Fatal(msg string, tags ...any)               {}
+func (l *MockLogger) Debugf(template string, args ...interface{}) {}
+func (l *MockLogger) Infof(template string, args ...interface{})  {}
+func (l *MockLogger) Warnf(template string, args ...interface{})  {}
+func (l *MockLogger) Errorf(template string, args ...interface{}) {}
+func (l *MockLogger) Fatalf(template string, args ...interface{}) {}
+func (l *MockLogger) With(tags ...any) logging.Logger             { return l }
+
+type fakeHistory map[string]types.ScriptCostData
+
+func (h fakeHistory) GetScriptCost(scriptCID string) (types.ScriptCostData, error) {
+	return h[scriptCID], nil
+}
+
+func (h fakeHistory) RecordScriptCost(scriptCID string, cost float64) error {
+	data := h[scriptCID]
+	data.ScriptCID = scriptCID
+	data.Executions++
+	data.TotalCost += cost
+	data.LastCost = cost
+	h[scriptCID] = data
+	return nil
+}
+
+const testScript = `package main
+
+import (
+	"fmt"
+	"net/http"
+)
+
+func main() {
+	for i := 0; i < 3; i++ {
+		resp, err := http.Get("https://example.com")
+		if err != nil {
+			continue
+		}
+		resp.Body.Close()
+	}
+	for _, v := range []int{1, 2} {
+		fmt.Println(v)
+	}
+}
+`
+
+var testFees = docker.FeeConfig{
+	PricePerTG:            0.0001,
+	FixedCost:             1,
+	TransactionSimulation: 1,
+	OverheadCost:          0.1,
+}
+
+func TestAnalyzeScript(t *testing.T) {
+	analysis := AnalyzeScript([]byte(testScript))
+	assert.True(t, analysis.Parsed)
+	assert.Equal(t, 2, analysis.Imports)
+	assert.Equal(t, 1, analysis.Functions)
+	assert.Equal(t, 2, analysis.Loops)
+	assert.Equal(t, 1, analysis.NetworkCalls)
+	assert.Equal(t, baseExecutionTime+2*loopExecutionTime+callExecutionTime, analysis.ExecutionTime())
+
+	// Broken sources are still priced by their tokens
+	broken := AnalyzeScript([]byte("package main\nfunc main() { for {\n http.Get(\"x\")"))
+	assert.False(t, broken.Parsed)
+	assert.Equal(t, 1, broken.Loops)
+	assert.Equal(t, 1, broken.NetworkCalls)
+}
+
+func TestEstimatePrefersHistoryAndCachesAnalysis(t *testing.T) {
+	history := fakeHistory{}
+	var fetches int32
+	estimator := newEstimator(testFees, history, &MockLogger{},
+		func(ctx context.Context, url string) ([]byte, error) {
+			atomic.AddInt32(&fetches, 1)
+			return []byte(testScript), nil
+		}, nil)
+	url := "https://gateway.example.com/ipfs/bafyscript"
+
+	estimate, err := estimator.Estimate(context.Background(), url)
+	require.NoError(t, err)
+	assert.Equal(t, "bafyscript", estimate.ScriptCID)
+	assert.Equal(t, SourceStatic, estimate.Source)
+	assert.Greater(t, estimate.Fee, 0.0)
+
+	_, err = estimator.Estimate(context.Background(), url)
+	require.NoError(t, err)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&fetches))
+
+	// Too few executions to go by
+	require.NoError(t, estimator.RecordExecution(url, 0.2))
+	require.NoError(t, estimator.RecordExecution(url, 0.4))
+	estimate, err = estimator.Estimate(context.Background(), url)
+	require.NoError(t, err)
+	assert.Equal(t, SourceStatic, estimate.Source)
+
+	require.NoError(t, estimator.RecordExecution(url, 0.6))
+	estimate, err = estimator.Estimate(context.Background(), url)
+	require.NoError(t, err)
+	assert.Equal(t, SourceHistory, estimate.Source)
+	assert.Equal(t, int64(3), estimate.Executions)
+	assert.InDelta(t, 0.4, estimate.Fee, 1e-9)
+}
+
+func TestSimulateQueueFull(t *testing.T) {
+	release := make(chan struct{})
+	estimator := newEstimator(testFees, fakeHistory{}, &MockLogger{}, nil,
+		func(ctx context.Context, url string) (float64, error) {
+			<-release
+			return 0.3, nil
+		})
+	go estimator.runSimulations()
+
+	// One run is taken by the worker, the rest wait in the queue
+	ctx, cancel := context.WithCancel(context.Background())
+	for i := 0; i <= SimulationQueueSize; i++ {
+		go func() { _, _ = estimator.Simulate(ctx, "https://gateway.example.com/ipfs/bafyslow") }()
+	}
+	assert.Eventually(t, func() bool { return len(estimator.queue) == SimulationQueueSize }, time.Second, time.Millisecond)
+
+	_, err := estimator.Simulate(context.Background(), "https://gateway.example.com/ipfs/bafyother")
+	assert.True(t, errors.Is(err, ErrSimulationQueueFull))
+
+	cancel()
+	close(release)
+
+	// Measured fees are used by later estimates
+	assert.Eventually(t, func() bool {
+		estimate, err := estimator.Estimate(context.Background(), "https://gateway.example.com/ipfs/bafyslow")
+		return err == nil && estimate.Source == SourceSandbox && estimate.Fee == 0.3
+	}, time.Second, time.Millisecond)
+}
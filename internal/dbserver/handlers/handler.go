@@ -4,6 +4,7 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/trigg3rX/triggerx-backend-imua/internal/dbserver/fees"
 	"github.com/trigg3rX/triggerx-backend-imua/internal/dbserver/repository"
 	"github.com/trigg3rX/triggerx-backend-imua/pkg/database"
 	"github.com/trigg3rX/triggerx-backend-imua/pkg/docker"
@@ -29,6 +30,8 @@ type Handler struct {
 	userRepository         repository.UserRepository
 	keeperRepository       repository.KeeperRepository
 	apiKeysRepository      repository.ApiKeysRepository
+	conditionJobCatalog    ConditionJobCatalog // Shared with condition scheduler instances, nil to notify over HTTP
+	feeEstimator           *fees.Estimator
 
 	scanNowQuery func(*time.Time) error // for testability
 }
@@ -49,9 +52,22 @@ func NewHandler(db *database.Connection, logger logging.Logger, config Notificat
 		apiKeysRepository:      repository.NewApiKeysRepository(db),
 	}
 	h.scanNowQuery = h.defaultScanNowQuery
+
+	feeEstimator, err := fees.NewEstimator(executor, repository.NewScriptCostRepository(db), logger)
+	if err != nil {
+		logger.Errorf("Failed to create fee estimator: %v", err)
+	} else {
+		h.feeEstimator = feeEstimator
+	}
 	return h
 }
 
+// SetConditionJobCatalog makes condition jobs go through the shared catalog
+// instead of a single condition scheduler URL
+func (h *Handler) SetConditionJobCatalog(catalog ConditionJobCatalog) {
+	h.conditionJobCatalog = catalog
+}
+
 func (h *Handler) defaultScanNowQuery(timestamp *time.Time) error {
 	return h.db.Session().Query("SELECT now() FROM system.local").Scan(timestamp)
 }
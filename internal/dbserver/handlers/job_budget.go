@@ -0,0 +1,176 @@
+package handlers
+
+import (
+	"fmt"
+	"math/big"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/trigg3rX/triggerx-backend-imua/internal/dbserver/metrics"
+	"github.com/trigg3rX/triggerx-backend-imua/internal/dbserver/types"
+	"github.com/trigg3rX/triggerx-backend-imua/pkg/email"
+	commonTypes "github.com/trigg3rX/triggerx-backend-imua/pkg/types"
+)
+
+// A user is warned once their balance covers fewer than this many tasks of a job
+const lowBalanceTaskCount = 10
+
+// Task fees are in TG, balances in its wei
+var tgDecimals = new(big.Float).SetFloat64(1e18)
+
+// GetJobBudget returns the balance the job's user has left, and the fee expected
+// for one task of the job. The Redis service reserves this fee before it hands a
+// task to the keepers.
+func (h *Handler) GetJobBudget(c *gin.Context) {
+	traceID := h.getTraceID(c)
+	h.logger.Infof("[GetJobBudget] trace_id=%s - Getting job budget", traceID)
+
+	jobID, err := strconv.ParseInt(c.Param("job_id"), 10, 64)
+	if err != nil {
+		h.logger.Errorf("[GetJobBudget] Invalid job ID format: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid job ID format",
+			"code":  "INVALID_JOB_ID",
+		})
+		return
+	}
+
+	trackDBOp := metrics.TrackDBOperation("read", "job_data")
+	job, err := h.jobRepository.GetJobByID(jobID)
+	trackDBOp(err)
+	if err != nil {
+		h.logger.Errorf("[GetJobBudget] Error getting job data for jobID %d: %v", jobID, err)
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Job not found",
+			"code":  "JOB_NOT_FOUND",
+		})
+		return
+	}
+
+	trackDBOp = metrics.TrackDBOperation("read", "user_data")
+	balance, err := h.userRepository.GetUserBalanceByID(job.UserID)
+	trackDBOp(err)
+	if err != nil {
+		h.logger.Errorf("[GetJobBudget] Error getting balance of user %d: %v", job.UserID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error getting user balance: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, commonTypes.JobBudgetData{
+		JobID:        jobID,
+		UserID:       job.UserID,
+		Balance:      weiToTG(balance.TokenBalance),
+		EstimatedFee: estimateTaskFee(job),
+	})
+}
+
+// chargeTaskCost settles the actual cost of an executed task against the balance
+// of the job's user, and warns the user when the balance is running low. A task
+// is charged once however often its execution is reported, as the Redis service
+// retries reports that time out. It reports whether the execution was settled
+// now, rather than by an earlier report.
+func (h *Handler) chargeTaskCost(taskID int64, cost float64) bool {
+	if cost <= 0 {
+		return true
+	}
+
+	trackDBOp := metrics.TrackDBOperation("create", "task_charge_data")
+	claimed, err := h.taskRepository.MarkTaskCharged(taskID, cost)
+	trackDBOp(err)
+	if err != nil {
+		h.logger.Errorf("[ChargeTaskCost] Error marking task %d as charged: %v", taskID, err)
+		return false
+	}
+	if !claimed {
+		h.logger.Infof("[ChargeTaskCost] Task %d is already charged", taskID)
+		return false
+	}
+
+	if err := h.debitTaskCost(taskID, cost); err != nil {
+		h.logger.Errorf("[ChargeTaskCost] %v", err)
+		// Let a later report of the task charge it
+		if err := h.taskRepository.UnmarkTaskCharged(taskID); err != nil {
+			h.logger.Errorf("[ChargeTaskCost] Error unmarking task %d as charged: %v", taskID, err)
+		}
+		return false
+	}
+	return true
+}
+
+func (h *Handler) debitTaskCost(taskID int64, cost float64) error {
+	trackDBOp := metrics.TrackDBOperation("read", "task_data")
+	task, err := h.taskRepository.GetTaskDataByID(taskID)
+	trackDBOp(err)
+	if err != nil {
+		return fmt.Errorf("error getting task data for task %d: %v", taskID, err)
+	}
+
+	trackDBOp = metrics.TrackDBOperation("read", "job_data")
+	job, err := h.jobRepository.GetJobByID(task.JobID)
+	trackDBOp(err)
+	if err != nil {
+		return fmt.Errorf("error getting job data for job %d: %v", task.JobID, err)
+	}
+
+	trackDBOp = metrics.TrackDBOperation("update", "user_data")
+	before, after, err := h.userRepository.DebitUserTokenBalance(job.UserID, tgToWei(cost))
+	trackDBOp(err)
+	if err != nil {
+		return fmt.Errorf("error charging %f TG for task %d to user %d: %v", cost, taskID, job.UserID, err)
+	}
+	h.logger.Infof("[ChargeTaskCost] Charged %f TG for task %d to user %d", cost, taskID, job.UserID)
+
+	// Warn only when this task took the balance below the threshold, not on every
+	// task after. The email is sent in the background, so as not to hold up the report.
+	threshold := tgToWei(estimateTaskFee(job) * lowBalanceTaskCount)
+	if before.Cmp(threshold) >= 0 && after.Cmp(threshold) < 0 {
+		go h.warnLowBalance(job, after)
+	}
+	return nil
+}
+
+func (h *Handler) warnLowBalance(job *types.JobData, remaining *big.Int) {
+	h.logger.Warnf("[ChargeTaskCost] Balance of user %d is low: %f TG left for job %d", job.UserID, weiToTG(remaining), job.JobID)
+
+	sender := email.NewSender(h.config.EmailFrom, h.config.EmailPassword)
+	if !sender.Enabled() {
+		return
+	}
+	balance, err := h.userRepository.GetUserBalanceByID(job.UserID)
+	if err != nil || balance.Email == "" {
+		return
+	}
+
+	subject := "TriggerX: your balance is running low"
+	body := fmt.Sprintf(`
+		<p>Your TriggerX balance is down to <b>%f TG</b>.</p>
+		<p>This covers fewer than %d more executions of job <b>%d</b> (%s). Jobs are paused once the balance cannot pay for their next execution.</p>
+		<p>Top up your balance to keep your jobs running.</p>`,
+		weiToTG(remaining), lowBalanceTaskCount, job.JobID, job.JobTitle)
+	if err := sender.Send(balance.Email, subject, body); err != nil {
+		h.logger.Errorf("[ChargeTaskCost] Error sending low balance email to user %d: %v", job.UserID, err)
+	}
+}
+
+// estimateTaskFee is the average cost of the job's tasks so far, or the predicted
+// cost if none has been charged yet
+func estimateTaskFee(job *types.JobData) float64 {
+	if len(job.TaskIDs) > 0 && job.JobCostActual > 0 {
+		return job.JobCostActual / float64(len(job.TaskIDs))
+	}
+	return job.JobCostPrediction
+}
+
+func tgToWei(amount float64) *big.Int {
+	wei, _ := new(big.Float).Mul(big.NewFloat(amount), tgDecimals).Int(nil)
+	return wei
+}
+
+func weiToTG(amount *big.Int) float64 {
+	if amount == nil {
+		return 0
+	}
+	tg, _ := new(big.Float).Quo(new(big.Float).SetInt(amount), tgDecimals).Float64()
+	return tg
+}
@@ -0,0 +1,89 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/trigg3rX/triggerx-backend-imua/internal/dbserver/types"
+	commonTypes "github.com/trigg3rX/triggerx-backend-imua/pkg/types"
+)
+
+func TestGetJobBudget(t *testing.T) {
+	jobRepo := new(MockJobRepository)
+	userRepo := new(MockUserRepository)
+	handler := &Handler{jobRepository: jobRepo, userRepository: userRepo, logger: &MockLogger{}}
+
+	jobRepo.On("GetJobByID", int64(7)).Return(&types.JobData{
+		JobID: 7, UserID: 3, JobCostPrediction: 0.5, JobCostActual: 0.6, TaskIDs: []int64{1, 2, 3},
+	}, nil)
+	userRepo.On("GetUserBalanceByID", int64(3)).Return(types.UserBalance{
+		UserID: 3, TokenBalance: tgToWei(4.5),
+	}, nil)
+
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest("GET", "/", nil)
+	c.Params = []gin.Param{{Key: "job_id", Value: "7"}}
+	handler.GetJobBudget(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var budget commonTypes.JobBudgetData
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &budget))
+	assert.Equal(t, int64(3), budget.UserID)
+	assert.InDelta(t, 4.5, budget.Balance, 1e-9)
+	// Averaged over the tasks run so far, not the prediction
+	assert.InDelta(t, 0.2, budget.EstimatedFee, 1e-9)
+}
+
+func TestChargeTaskCost(t *testing.T) {
+	jobRepo := new(MockJobRepository)
+	userRepo := new(MockUserRepository)
+	taskRepo := new(MockTaskRepository)
+	handler := &Handler{jobRepository: jobRepo, userRepository: userRepo, taskRepository: taskRepo, logger: &MockLogger{}}
+
+	taskRepo.On("MarkTaskCharged", int64(11), 0.25).Return(true, nil).Once()
+	taskRepo.On("GetTaskDataByID", int64(11)).Return(types.TaskData{TaskID: 11, JobID: 7}, nil)
+	jobRepo.On("GetJobByID", int64(7)).Return(&types.JobData{JobID: 7, UserID: 3, JobCostPrediction: 0.1}, nil)
+	userRepo.On("DebitUserTokenBalance", int64(3), tgToWei(0.25)).Return(tgToWei(1.1), tgToWei(0.85), nil).Once()
+
+	assert.True(t, handler.chargeTaskCost(11, 0.25))
+	userRepo.AssertExpectations(t)
+
+	// A repeated report of the task is not charged again
+	taskRepo.On("MarkTaskCharged", int64(11), 0.25).Return(false, nil).Once()
+	assert.False(t, handler.chargeTaskCost(11, 0.25))
+	userRepo.AssertNumberOfCalls(t, "DebitUserTokenBalance", 1)
+
+	// Nothing to settle for a task that cost nothing
+	assert.True(t, handler.chargeTaskCost(12, 0))
+	taskRepo.AssertNotCalled(t, "GetTaskDataByID", int64(12))
+}
+
+func TestChargeTaskCostFailureCanBeRetried(t *testing.T) {
+	jobRepo := new(MockJobRepository)
+	userRepo := new(MockUserRepository)
+	taskRepo := new(MockTaskRepository)
+	handler := &Handler{jobRepository: jobRepo, userRepository: userRepo, taskRepository: taskRepo, logger: &MockLogger{}}
+
+	taskRepo.On("MarkTaskCharged", int64(13), 0.5).Return(true, nil)
+	taskRepo.On("GetTaskDataByID", int64(13)).Return(types.TaskData{TaskID: 13, JobID: 7}, nil)
+	jobRepo.On("GetJobByID", int64(7)).Return(&types.JobData{JobID: 7, UserID: 3}, nil)
+	userRepo.On("DebitUserTokenBalance", int64(3), tgToWei(0.5)).Return((*big.Int)(nil), (*big.Int)(nil), errors.New("timeout"))
+	taskRepo.On("UnmarkTaskCharged", int64(13)).Return(nil)
+
+	assert.False(t, handler.chargeTaskCost(13, 0.5))
+	taskRepo.AssertCalled(t, "UnmarkTaskCharged", int64(13))
+}
+
+func TestTGConversion(t *testing.T) {
+	assert.Equal(t, 0, tgToWei(1.5).Cmp(big.NewInt(1500000000000000000)))
+	assert.InDelta(t, 1.5, weiToTG(big.NewInt(1500000000000000000)), 1e-12)
+	assert.Equal(t, float64(0), weiToTG(nil))
+}
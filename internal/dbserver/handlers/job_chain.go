@@ -0,0 +1,334 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/trigg3rX/triggerx-backend-imua/internal/dbserver/metrics"
+	"github.com/trigg3rX/triggerx-backend-imua/internal/dbserver/types"
+	"github.com/trigg3rX/triggerx-backend-imua/pkg/parser"
+	commonTypes "github.com/trigg3rX/triggerx-backend-imua/pkg/types"
+)
+
+// maxJobChainLength bounds a walk along LinkJobID, in case a chain loops back on itself
+const maxJobChainLength = 100
+
+// GetJobChain returns the status of a job and of every job chained after it.
+// Pass the head of the chain to see the whole workflow.
+func (h *Handler) GetJobChain(c *gin.Context) {
+	traceID := h.getTraceID(c)
+	h.logger.Infof("[GetJobChain] trace_id=%s - Retrieving job chain", traceID)
+
+	jobID, err := strconv.ParseInt(c.Param("job_id"), 10, 64)
+	if err != nil {
+		h.logger.Errorf("[GetJobChain] Invalid job ID format: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid job ID format",
+			"code":  "INVALID_JOB_ID",
+		})
+		return
+	}
+
+	var chain []types.JobChainEntry
+	for len(chain) < maxJobChainLength {
+		trackDBOp := metrics.TrackDBOperation("read", "job_data")
+		job, err := h.jobRepository.GetJobByID(jobID)
+		trackDBOp(err)
+		if err != nil {
+			if len(chain) == 0 {
+				h.logger.Errorf("[GetJobChain] Error getting job data for jobID %d: %v", jobID, err)
+				c.JSON(http.StatusNotFound, gin.H{
+					"error": "Job not found",
+					"code":  "JOB_NOT_FOUND",
+				})
+				return
+			}
+			h.logger.Errorf("[GetJobChain] Error getting chained job %d: %v", jobID, err)
+			break
+		}
+
+		chain = append(chain, types.JobChainEntry{
+			JobID:            job.JobID,
+			JobTitle:         job.JobTitle,
+			TaskDefinitionID: job.TaskDefinitionID,
+			ChainStatus:      job.ChainStatus,
+			LinkJobID:        job.LinkJobID,
+			Status:           job.Status,
+			TaskIDs:          job.TaskIDs,
+			LastExecutedAt:   job.LastExecutedAt,
+		})
+		if job.LinkJobID <= 0 {
+			break
+		}
+		jobID = job.LinkJobID
+	}
+
+	c.JSON(http.StatusOK, chain)
+}
+
+// advanceJobChain runs once a task has finished. If the task's job has a
+// successor still waiting on it, a successful task activates the successor and
+// a failed one fails it together with the rest of the chain.
+func (h *Handler) advanceJobChain(taskID int64, succeeded bool, txHash string) {
+	trackDBOp := metrics.TrackDBOperation("read", "task_data")
+	task, err := h.taskRepository.GetTaskDataByID(taskID)
+	trackDBOp(err)
+	if err != nil {
+		h.logger.Errorf("[AdvanceJobChain] Error getting task data for task %d: %v", taskID, err)
+		return
+	}
+
+	trackDBOp = metrics.TrackDBOperation("read", "job_data")
+	job, err := h.jobRepository.GetJobByID(task.JobID)
+	trackDBOp(err)
+	if err != nil {
+		h.logger.Errorf("[AdvanceJobChain] Error getting job data for job %d: %v", task.JobID, err)
+		return
+	}
+	if job.LinkJobID <= 0 {
+		return
+	}
+
+	trackDBOp = metrics.TrackDBOperation("read", "job_data")
+	next, err := h.jobRepository.GetJobByID(job.LinkJobID)
+	trackDBOp(err)
+	if err != nil {
+		h.logger.Errorf("[AdvanceJobChain] Error getting chained job %d: %v", job.LinkJobID, err)
+		return
+	}
+	// Already activated by an earlier execution of a recurring predecessor, or failed
+	if next.Status != string(types.JobStatusAwaitingPredecessor) {
+		return
+	}
+
+	// Reports of two tasks of a recurring predecessor, or a retried report, may
+	// arrive together. Only the one that moves the job out of waiting goes on.
+	to := types.JobStatusPending
+	if !succeeded {
+		to = types.JobStatusChainFailed
+	}
+	trackDBOp = metrics.TrackDBOperation("update", "job_data")
+	applied, err := h.jobRepository.CompareAndSetJobStatus(next.JobID, string(types.JobStatusAwaitingPredecessor), string(to))
+	trackDBOp(err)
+	if err != nil {
+		h.logger.Errorf("[AdvanceJobChain] Error moving chained job %d to %s: %v", next.JobID, to, err)
+		return
+	}
+	if !applied {
+		return
+	}
+
+	if !succeeded {
+		h.failJobChain(next)
+		return
+	}
+	if err := h.startJob(next, txHash); err != nil {
+		if err == errJobExpired {
+			h.expireJob(next.JobID)
+			return
+		}
+		h.logger.Errorf("[AdvanceJobChain] Error activating job %d after job %d: %v", next.JobID, job.JobID, err)
+		// Back to waiting, for the next successful task of its predecessor
+		if _, err := h.jobRepository.CompareAndSetJobStatus(next.JobID, string(types.JobStatusPending), string(types.JobStatusAwaitingPredecessor)); err != nil {
+			h.logger.Errorf("[AdvanceJobChain] Error returning job %d to waiting: %v", next.JobID, err)
+		}
+		return
+	}
+	h.logger.Infof("[AdvanceJobChain] Task %d of job %d succeeded, activated job %d", taskID, job.JobID, next.JobID)
+}
+
+// startJob (re)starts scheduling a job that was waiting for its predecessor or
+// paused, and marks it pending. With txHash set, the predecessor placeholder in
+// the job's arguments is replaced with it.
+func (h *Handler) startJob(job *types.JobData, txHash string) error {
+	switch job.TaskDefinitionID {
+	case 1, 2:
+		timeJob, err := h.timeJobRepository.GetTimeJobByJobID(job.JobID)
+		if err != nil {
+			return fmt.Errorf("failed to get time job: %v", err)
+		}
+		if isPastExpiration(timeJob.ExpirationTime) {
+			return errJobExpired
+		}
+		if arguments, extraTargets, ok := substituteChainTargets(timeJob.Arguments, timeJob.ExtraTargets, txHash); ok && txHash != "" {
+			if err := h.timeJobRepository.UpdateTimeJobArguments(job.JobID, arguments, extraTargets); err != nil {
+				return err
+			}
+		}
+		// The schedule starts when the job does, not when it was created
+		nextExecution, err := parser.CalculateNextExecutionTime(time.Now(), timeJob.ScheduleType, timeJob.TimeInterval, timeJob.CronExpression, timeJob.SpecificSchedule)
+		if err != nil {
+			return fmt.Errorf("failed to calculate next execution: %v", err)
+		}
+		if err := h.timeJobRepository.UpdateTimeJobNextExecutionTimestamp(job.JobID, nextExecution); err != nil {
+			return err
+		}
+		if err := h.timeJobRepository.UpdateTimeJobStatus(job.JobID, true); err != nil {
+			return err
+		}
+	case 3, 4:
+		eventJob, err := h.eventJobRepository.GetEventJobByJobID(job.JobID)
+		if err != nil {
+			return fmt.Errorf("failed to get event job: %v", err)
+		}
+		if isPastExpiration(eventJob.ExpirationTime) {
+			return errJobExpired
+		}
+		if arguments, extraTargets, ok := substituteChainTargets(eventJob.Arguments, eventJob.ExtraTargets, txHash); ok && txHash != "" {
+			if err := h.eventJobRepository.UpdateEventJobArguments(job.JobID, arguments, extraTargets); err != nil {
+				return err
+			}
+			eventJob.Arguments = arguments
+			eventJob.ExtraTargets = extraTargets
+		}
+		if err := h.eventJobRepository.UpdateEventJobStatus(job.JobID, true); err != nil {
+			return err
+		}
+		if _, err := h.notifyConditionScheduler(job.JobID, eventJobScheduleData(eventJob)); err != nil {
+			return err
+		}
+	case 5, 6:
+		conditionJob, err := h.conditionJobRepository.GetConditionJobByJobID(job.JobID)
+		if err != nil {
+			return fmt.Errorf("failed to get condition job: %v", err)
+		}
+		if isPastExpiration(conditionJob.ExpirationTime) {
+			return errJobExpired
+		}
+		if arguments, extraTargets, ok := substituteChainTargets(conditionJob.Arguments, conditionJob.ExtraTargets, txHash); ok && txHash != "" {
+			if err := h.conditionJobRepository.UpdateConditionJobArguments(job.JobID, arguments, extraTargets); err != nil {
+				return err
+			}
+			conditionJob.Arguments = arguments
+			conditionJob.ExtraTargets = extraTargets
+		}
+		if err := h.conditionJobRepository.UpdateConditionJobStatus(job.JobID, true); err != nil {
+			return err
+		}
+		if _, err := h.notifyConditionScheduler(job.JobID, conditionJobScheduleData(conditionJob)); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("invalid task definition ID %d", job.TaskDefinitionID)
+	}
+
+	return h.jobRepository.UpdateJobStatus(job.JobID, string(types.JobStatusPending))
+}
+
+// failJobChain marks a job and every job chained after it as failed. None of them were started.
+func (h *Handler) failJobChain(job *types.JobData) {
+	for i := 0; i < maxJobChainLength; i++ {
+		if err := h.jobRepository.UpdateJobStatus(job.JobID, string(types.JobStatusChainFailed)); err != nil {
+			h.logger.Errorf("[AdvanceJobChain] Error failing chained job %d: %v", job.JobID, err)
+			return
+		}
+		h.logger.Warnf("[AdvanceJobChain] Job %d will not run, a predecessor in its chain failed", job.JobID)
+
+		if job.LinkJobID <= 0 {
+			return
+		}
+		next, err := h.jobRepository.GetJobByID(job.LinkJobID)
+		if err != nil {
+			h.logger.Errorf("[AdvanceJobChain] Error getting chained job %d: %v", job.LinkJobID, err)
+			return
+		}
+		job = next
+	}
+}
+
+// substituteChainTargets replaces the predecessor placeholder in the arguments of
+// the job's target function and of its extra targets. It returns false if there
+// was nothing to replace.
+func substituteChainTargets(arguments []string, extraTargets []types.TargetCallData, txHash string) ([]string, []types.TargetCallData, bool) {
+	arguments, replaced := substituteChainArguments(arguments, txHash)
+	substituted := make([]types.TargetCallData, len(extraTargets))
+	for i, target := range extraTargets {
+		var ok bool
+		substituted[i] = target
+		substituted[i].Arguments, ok = substituteChainArguments(target.Arguments, txHash)
+		replaced = replaced || ok
+	}
+	return arguments, substituted, replaced
+}
+
+// substituteChainArguments replaces the predecessor placeholder in arguments.
+// It returns false if there was nothing to replace.
+func substituteChainArguments(arguments []string, txHash string) ([]string, bool) {
+	replaced := false
+	substituted := make([]string, len(arguments))
+	for i, argument := range arguments {
+		substituted[i] = argument
+		if strings.Contains(argument, types.ChainTxHashPlaceholder) {
+			substituted[i] = strings.ReplaceAll(argument, types.ChainTxHashPlaceholder, txHash)
+			replaced = true
+		}
+	}
+	return substituted, replaced
+}
+
+func eventJobScheduleData(eventJob types.EventJobData) commonTypes.ScheduleConditionJobData {
+	return commonTypes.ScheduleConditionJobData{
+		JobID:            eventJob.JobID,
+		TaskDefinitionID: eventJob.TaskDefinitionID,
+		LastExecutedAt:   time.Now(),
+		TaskTargetData: commonTypes.TaskTargetData{
+			JobID:                     eventJob.JobID,
+			TaskDefinitionID:          eventJob.TaskDefinitionID,
+			TargetChainID:             eventJob.TargetChainID,
+			TargetContractAddress:     eventJob.TargetContractAddress,
+			TargetFunction:            eventJob.TargetFunction,
+			ABI:                       eventJob.ABI,
+			ArgType:                   eventJob.ArgType,
+			Arguments:                 eventJob.Arguments,
+			DynamicArgumentsScriptUrl: eventJob.DynamicArgumentsScriptUrl,
+			ExtraTargets:              toTargetCalls(eventJob.ExtraTargets),
+		},
+		EventWorkerData: commonTypes.EventWorkerData{
+			JobID:                  eventJob.JobID,
+			ExpirationTime:         eventJob.ExpirationTime,
+			Recurring:              eventJob.Recurring,
+			TriggerChainID:         eventJob.TriggerChainID,
+			TriggerContractAddress: eventJob.TriggerContractAddress,
+			TriggerEvent:           eventJob.TriggerEvent,
+		},
+	}
+}
+
+func conditionJobScheduleData(conditionJob types.ConditionJobData) commonTypes.ScheduleConditionJobData {
+	return commonTypes.ScheduleConditionJobData{
+		JobID:            conditionJob.JobID,
+		TaskDefinitionID: conditionJob.TaskDefinitionID,
+		LastExecutedAt:   time.Now(),
+		TaskTargetData: commonTypes.TaskTargetData{
+			JobID:                     conditionJob.JobID,
+			TaskDefinitionID:          conditionJob.TaskDefinitionID,
+			TargetChainID:             conditionJob.TargetChainID,
+			TargetContractAddress:     conditionJob.TargetContractAddress,
+			TargetFunction:            conditionJob.TargetFunction,
+			ABI:                       conditionJob.ABI,
+			ArgType:                   conditionJob.ArgType,
+			Arguments:                 conditionJob.Arguments,
+			DynamicArgumentsScriptUrl: conditionJob.DynamicArgumentsScriptUrl,
+			ExtraTargets:              toTargetCalls(conditionJob.ExtraTargets),
+		},
+		ConditionWorkerData: commonTypes.ConditionWorkerData{
+			JobID:           conditionJob.JobID,
+			ExpirationTime:  conditionJob.ExpirationTime,
+			Recurring:       conditionJob.Recurring,
+			ConditionType:   conditionJob.ConditionType,
+			UpperLimit:      conditionJob.UpperLimit,
+			LowerLimit:      conditionJob.LowerLimit,
+			ValueSourceType: conditionJob.ValueSourceType,
+			ValueSourceUrl:  conditionJob.ValueSourceUrl,
+			ConsecutiveHits: conditionJob.ConsecutiveHits,
+			CooldownSeconds: conditionJob.CooldownSeconds,
+			EdgeTriggered:   conditionJob.EdgeTriggered,
+			RearmPercent:    conditionJob.RearmPercent,
+			WindowSeconds:   conditionJob.WindowSeconds,
+		},
+	}
+}
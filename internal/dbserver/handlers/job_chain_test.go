@@ -0,0 +1,192 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/trigg3rX/triggerx-backend-imua/internal/dbserver/types"
+)
+
+type jobChainMocks struct {
+	job     *MockJobRepository
+	timeJob *MockTimeJobRepository
+	task    *MockTaskRepository
+}
+
+func setupTestJobChainHandler() (*Handler, jobChainMocks) {
+	mocks := jobChainMocks{
+		job:     new(MockJobRepository),
+		timeJob: new(MockTimeJobRepository),
+		task:    new(MockTaskRepository),
+	}
+	handler := &Handler{
+		jobRepository:     mocks.job,
+		timeJobRepository: mocks.timeJob,
+		taskRepository:    mocks.task,
+		logger:            &MockLogger{},
+	}
+	return handler, mocks
+}
+
+func TestAdvanceJobChainActivatesSuccessor(t *testing.T) {
+	handler, mocks := setupTestJobChainHandler()
+
+	mocks.task.On("GetTaskDataByID", int64(11)).Return(types.TaskData{TaskID: 11, JobID: 1}, nil)
+	mocks.job.On("GetJobByID", int64(1)).Return(&types.JobData{JobID: 1, LinkJobID: 2}, nil)
+	mocks.job.On("GetJobByID", int64(2)).Return(&types.JobData{
+		JobID:            2,
+		TaskDefinitionID: 1,
+		ChainStatus:      1,
+		LinkJobID:        -1,
+		Status:           string(types.JobStatusAwaitingPredecessor),
+	}, nil)
+	mocks.timeJob.On("GetTimeJobByJobID", int64(2)).Return(types.TimeJobData{
+		JobID:        2,
+		ScheduleType: "interval",
+		TimeInterval: 60,
+		Arguments:    []string{"1", types.ChainTxHashPlaceholder},
+		ExtraTargets: []types.TargetCallData{{TargetFunction: "settle", Arguments: []string{types.ChainTxHashPlaceholder}}},
+	}, nil)
+	mocks.timeJob.On("UpdateTimeJobArguments", int64(2), []string{"1", "0xabc"},
+		[]types.TargetCallData{{TargetFunction: "settle", Arguments: []string{"0xabc"}}}).Return(nil)
+	mocks.timeJob.On("UpdateTimeJobNextExecutionTimestamp", int64(2), mock.AnythingOfType("time.Time")).Return(nil)
+	mocks.timeJob.On("UpdateTimeJobStatus", int64(2), true).Return(nil)
+	mocks.job.On("CompareAndSetJobStatus", int64(2), string(types.JobStatusAwaitingPredecessor), string(types.JobStatusPending)).Return(true, nil).Once()
+	mocks.job.On("UpdateJobStatus", int64(2), string(types.JobStatusPending)).Return(nil)
+
+	handler.advanceJobChain(11, true, "0xabc")
+
+	mocks.timeJob.AssertExpectations(t)
+	mocks.job.AssertExpectations(t)
+
+	// A second report that read the job still waiting finds it activated
+	mocks.job.On("CompareAndSetJobStatus", int64(2), string(types.JobStatusAwaitingPredecessor), string(types.JobStatusPending)).Return(false, nil).Once()
+
+	handler.advanceJobChain(11, true, "0xabc")
+
+	mocks.timeJob.AssertNumberOfCalls(t, "UpdateTimeJobArguments", 1)
+	mocks.timeJob.AssertNumberOfCalls(t, "UpdateTimeJobStatus", 1)
+}
+
+func TestAdvanceJobChainFailsRestOfChain(t *testing.T) {
+	handler, mocks := setupTestJobChainHandler()
+
+	mocks.task.On("GetTaskDataByID", int64(11)).Return(types.TaskData{TaskID: 11, JobID: 1}, nil)
+	mocks.job.On("GetJobByID", int64(1)).Return(&types.JobData{JobID: 1, LinkJobID: 2}, nil)
+	mocks.job.On("GetJobByID", int64(2)).Return(&types.JobData{
+		JobID: 2, LinkJobID: 3, ChainStatus: 1, Status: string(types.JobStatusAwaitingPredecessor),
+	}, nil)
+	mocks.job.On("GetJobByID", int64(3)).Return(&types.JobData{
+		JobID: 3, LinkJobID: -1, ChainStatus: 1, Status: string(types.JobStatusAwaitingPredecessor),
+	}, nil)
+	mocks.job.On("CompareAndSetJobStatus", int64(2), string(types.JobStatusAwaitingPredecessor), string(types.JobStatusChainFailed)).Return(true, nil)
+	mocks.job.On("UpdateJobStatus", int64(2), string(types.JobStatusChainFailed)).Return(nil)
+	mocks.job.On("UpdateJobStatus", int64(3), string(types.JobStatusChainFailed)).Return(nil)
+
+	handler.advanceJobChain(11, false, "0xabc")
+
+	mocks.job.AssertExpectations(t)
+	mocks.timeJob.AssertNotCalled(t, "UpdateTimeJobStatus", mock.Anything, mock.Anything)
+}
+
+func TestAdvanceJobChainIgnoresActivatedSuccessor(t *testing.T) {
+	handler, mocks := setupTestJobChainHandler()
+
+	// A recurring predecessor runs again after its successor was activated
+	mocks.task.On("GetTaskDataByID", int64(12)).Return(types.TaskData{TaskID: 12, JobID: 1}, nil)
+	mocks.job.On("GetJobByID", int64(1)).Return(&types.JobData{JobID: 1, LinkJobID: 2}, nil)
+	mocks.job.On("GetJobByID", int64(2)).Return(&types.JobData{
+		JobID: 2, LinkJobID: -1, ChainStatus: 1, Status: string(types.JobStatusRunning),
+	}, nil)
+
+	handler.advanceJobChain(12, false, "")
+
+	mocks.job.AssertNotCalled(t, "UpdateJobStatus", mock.Anything, mock.Anything)
+}
+
+func TestGetJobChain(t *testing.T) {
+	handler, mocks := setupTestJobChainHandler()
+
+	executedAt := time.Date(2025, time.June, 2, 17, 41, 39, 0, time.UTC)
+	mocks.job.On("GetJobByID", int64(1)).Return(&types.JobData{
+		JobID: 1, TaskDefinitionID: 1, LinkJobID: 2, Status: "running", TaskIDs: []int64{11}, LastExecutedAt: executedAt,
+	}, nil)
+	mocks.job.On("GetJobByID", int64(2)).Return(&types.JobData{
+		JobID: 2, TaskDefinitionID: 5, ChainStatus: 1, LinkJobID: -1, Status: string(types.JobStatusAwaitingPredecessor),
+	}, nil)
+	mocks.job.On("GetJobByID", int64(9)).Return((*types.JobData)(nil), assert.AnError)
+
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest("GET", "/", nil)
+	c.Params = []gin.Param{{Key: "job_id", Value: "1"}}
+	handler.GetJobChain(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var chain []types.JobChainEntry
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &chain))
+	assert.Len(t, chain, 2)
+	assert.Equal(t, []int64{11}, chain[0].TaskIDs)
+	assert.Equal(t, string(types.JobStatusAwaitingPredecessor), chain[1].Status)
+
+	w = httptest.NewRecorder()
+	c, _ = gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest("GET", "/", nil)
+	c.Params = []gin.Param{{Key: "job_id", Value: "9"}}
+	handler.GetJobChain(c)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestFailTaskExecutionFailsRestOfChain(t *testing.T) {
+	handler, mocks := setupTestJobChainHandler()
+
+	// A task the keepers gave up on never has an execution to update
+	mocks.task.On("UpdateTaskStatus", int64(11), types.TaskStatusFailed).Return(nil)
+	mocks.task.On("GetTaskDataByID", int64(11)).Return(types.TaskData{TaskID: 11, JobID: 1}, nil)
+	mocks.job.On("GetJobByID", int64(1)).Return(&types.JobData{JobID: 1, LinkJobID: 2}, nil)
+	mocks.job.On("GetJobByID", int64(2)).Return(&types.JobData{
+		JobID: 2, LinkJobID: -1, ChainStatus: 1, Status: string(types.JobStatusAwaitingPredecessor),
+	}, nil)
+	mocks.job.On("CompareAndSetJobStatus", int64(2), string(types.JobStatusAwaitingPredecessor), string(types.JobStatusChainFailed)).Return(true, nil)
+	mocks.job.On("UpdateJobStatus", int64(2), string(types.JobStatusChainFailed)).Return(nil)
+
+	w := failTaskRequest(handler, 11)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	mocks.task.AssertExpectations(t)
+	mocks.job.AssertExpectations(t)
+}
+
+func TestFailTaskExecutionRefusesExecutedTask(t *testing.T) {
+	handler, mocks := setupTestJobChainHandler()
+
+	mocks.task.On("GetTaskDataByID", int64(12)).Return(types.TaskData{
+		TaskID: 12, JobID: 1, ExecutionTimestamp: time.Now(), ExecutionTxHash: "0xabc",
+	}, nil)
+	w := failTaskRequest(handler, 12)
+
+	assert.Equal(t, http.StatusConflict, w.Code)
+	mocks.task.AssertNotCalled(t, "UpdateTaskStatus", mock.Anything, mock.Anything)
+	mocks.job.AssertNotCalled(t, "UpdateJobStatus", mock.Anything, mock.Anything)
+}
+
+func failTaskRequest(handler *Handler, taskID int64) *httptest.ResponseRecorder {
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	id := strconv.FormatInt(taskID, 10)
+	c.Request = httptest.NewRequest("PUT", "/", strings.NewReader(`{"task_id":`+id+`,"error":"performer send failed"}`))
+	c.Request.Header.Set("Content-Type", "application/json")
+	c.Params = []gin.Param{{Key: "id", Value: id}}
+	handler.FailTaskExecution(c)
+	return w
+}
@@ -1,12 +1,14 @@
 package handlers
 
 import (
+	"fmt"
 	"net/http"
 	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/gocql/gocql"
+	"github.com/trigg3rX/triggerx-backend-imua/internal/dbserver/config"
 	"github.com/trigg3rX/triggerx-backend-imua/internal/dbserver/metrics"
 	"github.com/trigg3rX/triggerx-backend-imua/internal/dbserver/types"
 	"github.com/trigg3rX/triggerx-backend-imua/pkg/parser"
@@ -35,6 +37,17 @@ func (h *Handler) CreateJobData(c *gin.Context) {
 		return
 	}
 
+	for i := range tempJobs {
+		if err := checkTargetChains(tempJobs[i].TargetChainID, tempJobs[i].ExtraTargets); err != nil {
+			h.logger.Errorf("[CreateJobData] Invalid targets of job %d: %v", i, err)
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": err.Error(),
+				"code":  "INVALID_TARGET",
+			})
+			return
+		}
+	}
+
 	var existingUserID int64
 	var existingUser types.UserData
 	var err error
@@ -92,6 +105,12 @@ func (h *Handler) CreateJobData(c *gin.Context) {
 		if i < len(tempJobs)-1 {
 			linkJobID = createdJobs.JobIDs[i+1]
 		}
+		// Only the head of a chain starts right away, the rest wait for their predecessor
+		chained := chainStatus == 1
+		status := string(types.JobStatusPending)
+		if chained {
+			status = string(types.JobStatusAwaitingPredecessor)
+		}
 
 		jobData := &types.JobData{
 			JobID:             tempJobs[i].JobID,
@@ -103,7 +122,7 @@ func (h *Handler) CreateJobData(c *gin.Context) {
 			Custom:            tempJobs[i].Custom,
 			TimeFrame:         tempJobs[i].TimeFrame,
 			Recurring:         tempJobs[i].Recurring,
-			Status:            "pending",
+			Status:            status,
 			JobCostPrediction: tempJobs[i].JobCostPrediction,
 			Timezone:          tempJobs[i].Timezone,
 			IsImua:            tempJobs[i].IsImua,
@@ -135,6 +154,10 @@ func (h *Handler) CreateJobData(c *gin.Context) {
 				nextExecutionTimestamp = time.Now().Add(time.Duration(tempJobs[i].TimeInterval) * time.Second)
 			}
 
+			if tempJobs[i].MisfirePolicy == "" {
+				tempJobs[i].MisfirePolicy = commonTypes.DefaultMisfirePolicy
+			}
+
 			timeJobData := types.TimeJobData{
 				JobID:            jobID,
 				TaskDefinitionID: tempJobs[i].TaskDefinitionID,
@@ -145,6 +168,9 @@ func (h *Handler) CreateJobData(c *gin.Context) {
 				CronExpression:            tempJobs[i].CronExpression,
 				SpecificSchedule:          tempJobs[i].SpecificSchedule,
 				NextExecutionTimestamp:    nextExecutionTimestamp,
+				MisfirePolicy:             tempJobs[i].MisfirePolicy,
+				MisfireMaxCatchup:         tempJobs[i].MisfireMaxCatchup,
+				MaxLatenessSeconds:        tempJobs[i].MaxLatenessSeconds,
 				TargetChainID:             tempJobs[i].TargetChainID,
 				TargetContractAddress:     tempJobs[i].TargetContractAddress,
 				TargetFunction:            tempJobs[i].TargetFunction,
@@ -152,8 +178,9 @@ func (h *Handler) CreateJobData(c *gin.Context) {
 				ArgType:                   tempJobs[i].ArgType,
 				Arguments:                 tempJobs[i].Arguments,
 				DynamicArgumentsScriptUrl: tempJobs[i].DynamicArgumentsScriptUrl,
+				ExtraTargets:              tempJobs[i].ExtraTargets,
 				IsCompleted:               false,
-				IsActive:                  true,
+				IsActive:                  !chained,
 			}
 
 			// Track time job creation
@@ -185,8 +212,9 @@ func (h *Handler) CreateJobData(c *gin.Context) {
 				ArgType:                   tempJobs[i].ArgType,
 				Arguments:                 tempJobs[i].Arguments,
 				DynamicArgumentsScriptUrl: tempJobs[i].DynamicArgumentsScriptUrl,
+				ExtraTargets:              tempJobs[i].ExtraTargets,
 				IsCompleted:               false,
-				IsActive:                  true,
+				IsActive:                  !chained,
 			}
 
 			if err := h.eventJobRepository.CreateEventJob(&eventJobData); err != nil {
@@ -207,6 +235,7 @@ func (h *Handler) CreateJobData(c *gin.Context) {
 				ArgType:                   tempJobs[i].ArgType,
 				Arguments:                 tempJobs[i].Arguments,
 				DynamicArgumentsScriptUrl: tempJobs[i].DynamicArgumentsScriptUrl,
+				ExtraTargets:              toTargetCalls(tempJobs[i].ExtraTargets),
 			}
 			scheduleConditionJobData.EventWorkerData = commonTypes.EventWorkerData{
 				JobID:                  jobID,
@@ -231,6 +260,11 @@ func (h *Handler) CreateJobData(c *gin.Context) {
 				LowerLimit:                tempJobs[i].LowerLimit,
 				ValueSourceType:           tempJobs[i].ValueSourceType,
 				ValueSourceUrl:            tempJobs[i].ValueSourceUrl,
+				ConsecutiveHits:           tempJobs[i].ConsecutiveHits,
+				CooldownSeconds:           tempJobs[i].CooldownSeconds,
+				EdgeTriggered:             tempJobs[i].EdgeTriggered,
+				RearmPercent:              tempJobs[i].RearmPercent,
+				WindowSeconds:             tempJobs[i].WindowSeconds,
 				TargetChainID:             tempJobs[i].TargetChainID,
 				TargetContractAddress:     tempJobs[i].TargetContractAddress,
 				TargetFunction:            tempJobs[i].TargetFunction,
@@ -238,8 +272,9 @@ func (h *Handler) CreateJobData(c *gin.Context) {
 				ArgType:                   tempJobs[i].ArgType,
 				Arguments:                 tempJobs[i].Arguments,
 				DynamicArgumentsScriptUrl: tempJobs[i].DynamicArgumentsScriptUrl,
+				ExtraTargets:              tempJobs[i].ExtraTargets,
 				IsCompleted:               false,
-				IsActive:                  true,
+				IsActive:                  !chained,
 			}
 
 			if err := h.conditionJobRepository.CreateConditionJob(&conditionJobData); err != nil {
@@ -260,6 +295,7 @@ func (h *Handler) CreateJobData(c *gin.Context) {
 				ArgType:                   tempJobs[i].ArgType,
 				Arguments:                 tempJobs[i].Arguments,
 				DynamicArgumentsScriptUrl: tempJobs[i].DynamicArgumentsScriptUrl,
+				ExtraTargets:              toTargetCalls(tempJobs[i].ExtraTargets),
 			}
 			scheduleConditionJobData.ConditionWorkerData = commonTypes.ConditionWorkerData{
 				JobID:           jobID,
@@ -270,6 +306,11 @@ func (h *Handler) CreateJobData(c *gin.Context) {
 				LowerLimit:      tempJobs[i].LowerLimit,
 				ValueSourceType: tempJobs[i].ValueSourceType,
 				ValueSourceUrl:  tempJobs[i].ValueSourceUrl,
+				ConsecutiveHits: tempJobs[i].ConsecutiveHits,
+				CooldownSeconds: tempJobs[i].CooldownSeconds,
+				EdgeTriggered:   tempJobs[i].EdgeTriggered,
+				RearmPercent:    tempJobs[i].RearmPercent,
+				WindowSeconds:   tempJobs[i].WindowSeconds,
 			}
 			h.logger.Infof("[CreateJobData] Successfully created condition-based job %d with condition type %s (limits: %f-%f)",
 				jobID, conditionJobData.ConditionType, conditionJobData.LowerLimit, conditionJobData.UpperLimit)
@@ -279,7 +320,7 @@ func (h *Handler) CreateJobData(c *gin.Context) {
 			return
 		}
 
-		if tempJobs[i].TaskDefinitionID == 3 || tempJobs[i].TaskDefinitionID == 4 || tempJobs[i].TaskDefinitionID == 5 || tempJobs[i].TaskDefinitionID == 6 {
+		if !chained && (tempJobs[i].TaskDefinitionID == 3 || tempJobs[i].TaskDefinitionID == 4 || tempJobs[i].TaskDefinitionID == 5 || tempJobs[i].TaskDefinitionID == 6) {
 			go func() {
 				success, err := h.notifyConditionScheduler(jobID, scheduleConditionJobData)
 				if !success {
@@ -331,3 +372,32 @@ func (h *Handler) CreateJobData(c *gin.Context) {
 	h.logger.Infof("[CreateJobData] Successfully completed job creation for user %d with %d new jobs",
 		existingUser.UserID, len(tempJobs))
 }
+
+// toTargetCalls converts stored extra targets into the form schedulers and keepers use
+func toTargetCalls(extraTargets []types.TargetCallData) []commonTypes.TargetCall {
+	if len(extraTargets) == 0 {
+		return nil
+	}
+	calls := make([]commonTypes.TargetCall, len(extraTargets))
+	for i, target := range extraTargets {
+		calls[i] = commonTypes.TargetCall(target)
+	}
+	return calls
+}
+
+// checkTargetChains refuses a job with several calls on one chain unless the
+// proxy hubs can make them in one transaction. Keepers would fail every task of
+// the job otherwise.
+func checkTargetChains(primaryChainID string, extraTargets []types.TargetCallData) error {
+	if config.IsProxyHubBatch() {
+		return nil
+	}
+	chains := map[string]bool{primaryChainID: true}
+	for _, target := range extraTargets {
+		if chains[target.TargetChainID] {
+			return fmt.Errorf("more than one call on chain %s, proxy hubs only take one call per chain", target.TargetChainID)
+		}
+		chains[target.TargetChainID] = true
+	}
+	return nil
+}
@@ -1 +1,41 @@
-package handlers
\ No newline at end of file
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/trigg3rX/triggerx-backend-imua/internal/dbserver/types"
+)
+
+func TestCheckTargetChains(t *testing.T) {
+	assert.NoError(t, checkTargetChains("84532", nil))
+	assert.NoError(t, checkTargetChains("84532", []types.TargetCallData{{TargetChainID: "11155420"}, {TargetChainID: "11155111"}}))
+
+	// Without batching proxy hubs, a chain takes one call per task
+	assert.Error(t, checkTargetChains("84532", []types.TargetCallData{{TargetChainID: "84532"}}))
+	assert.Error(t, checkTargetChains("84532", []types.TargetCallData{{TargetChainID: "11155420"}, {TargetChainID: "11155420"}}))
+}
+
+func TestCreateJobDataRejectsSeveralCallsOnOneChain(t *testing.T) {
+	// Refused before anything is looked up or written
+	handler := &Handler{logger: &MockLogger{}}
+
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest("POST", "/", strings.NewReader(`[{
+		"user_address": "0x1234567890123456789012345678901234567890",
+		"task_definition_id": 1,
+		"target_chain_id": "84532",
+		"extra_targets": [{"target_chain_id": "84532", "target_function": "settle"}]
+	}]`))
+	c.Request.Header.Set("Content-Type", "application/json")
+	handler.CreateJobData(c)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "INVALID_TARGET")
+}
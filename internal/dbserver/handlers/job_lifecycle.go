@@ -0,0 +1,312 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/trigg3rX/triggerx-backend-imua/internal/dbserver/metrics"
+	"github.com/trigg3rX/triggerx-backend-imua/internal/dbserver/types"
+	commonTypes "github.com/trigg3rX/triggerx-backend-imua/pkg/types"
+)
+
+// errJobExpired is returned when a job is started after its expiration time
+var errJobExpired = errors.New("job has expired")
+
+// PauseJob stops scheduling an active job until it is resumed
+func (h *Handler) PauseJob(c *gin.Context) {
+	h.transitionJob(c, "PauseJob", types.JobStatusPaused)
+}
+
+// ResumeJob restarts a paused job. Time jobs continue from their next
+// execution after now, not from the ones missed while paused.
+func (h *Handler) ResumeJob(c *gin.Context) {
+	h.transitionJob(c, "ResumeJob", types.JobStatusPending)
+}
+
+// CancelJob stops a job for good. Jobs chained after it will not run.
+func (h *Handler) CancelJob(c *gin.Context) {
+	h.transitionJob(c, "CancelJob", types.JobStatusCancelled)
+}
+
+// transitionJob moves a job to the next lifecycle status if its current one allows it
+func (h *Handler) transitionJob(c *gin.Context, name string, next types.JobStatus) {
+	traceID := h.getTraceID(c)
+	h.logger.Infof("[%s] trace_id=%s - Moving job to %s", name, traceID, next)
+
+	jobID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		h.logger.Errorf("[%s] Invalid job ID format: %v", name, err)
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid job ID format",
+			"code":  "INVALID_JOB_ID",
+		})
+		return
+	}
+
+	trackDBOp := metrics.TrackDBOperation("read", "job_data")
+	job, err := h.jobRepository.GetJobByID(jobID)
+	trackDBOp(err)
+	if err != nil {
+		h.logger.Errorf("[%s] Error getting job data for jobID %d: %v", name, jobID, err)
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Job not found",
+			"code":  "JOB_NOT_FOUND",
+		})
+		return
+	}
+
+	current := types.JobStatus(job.Status)
+	if !current.CanTransitionTo(next) {
+		c.JSON(http.StatusConflict, gin.H{
+			"error": fmt.Sprintf("Job is %s and cannot be moved to %s", current, next),
+			"code":  "INVALID_TRANSITION",
+		})
+		return
+	}
+
+	started := false
+	switch {
+	case current.IsActive() && next.IsActive():
+		// The job stays with its scheduler, only its progress is recorded
+	case next == types.JobStatusPending:
+		err = h.startJob(job, "")
+		if err == errJobExpired {
+			h.expireJob(jobID)
+			c.JSON(http.StatusConflict, gin.H{
+				"error": "Job has expired and cannot be resumed",
+				"code":  "JOB_EXPIRED",
+			})
+			return
+		}
+		started = true
+	default:
+		err = h.stopJob(job)
+	}
+	if err != nil {
+		h.logger.Errorf("[%s] Error moving job %d to %s: %v", name, jobID, next, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error updating job: " + err.Error()})
+		return
+	}
+
+	// startJob already marked the job pending
+	if !started {
+		trackDBOp = metrics.TrackDBOperation("update", "job_data")
+		err = h.jobRepository.UpdateJobStatus(jobID, string(next))
+		trackDBOp(err)
+		if err != nil {
+			h.logger.Errorf("[%s] Error updating job status for jobID %d: %v", name, jobID, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error updating job status: " + err.Error()})
+			return
+		}
+	}
+
+	if (next == types.JobStatusCancelled || next == types.JobStatusDeleted) && job.LinkJobID > 0 {
+		if successor, err := h.jobRepository.GetJobByID(job.LinkJobID); err == nil && successor.Status == string(types.JobStatusAwaitingPredecessor) {
+			h.failJobChain(successor)
+		}
+	}
+
+	h.logger.Infof("[%s] Job %d moved from %s to %s", name, jobID, current, next)
+	c.JSON(http.StatusOK, gin.H{
+		"message":    "Job status updated successfully",
+		"job_id":     jobID,
+		"status":     next,
+		"updated_at": time.Now().UTC(),
+	})
+}
+
+// stopJob deactivates a job and takes it off the schedulers. Time jobs are no
+// longer claimed once inactive; event and condition jobs have their worker stopped.
+func (h *Handler) stopJob(job *types.JobData) error {
+	switch job.TaskDefinitionID {
+	case 1, 2:
+		return h.timeJobRepository.UpdateTimeJobStatus(job.JobID, false)
+	case 3, 4:
+		if err := h.eventJobRepository.UpdateEventJobStatus(job.JobID, false); err != nil {
+			return err
+		}
+	case 5, 6:
+		if err := h.conditionJobRepository.UpdateConditionJobStatus(job.JobID, false); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("invalid task definition ID %d", job.TaskDefinitionID)
+	}
+
+	// Only an active job is with the scheduler. A paused or finished one was
+	// taken off it already, and one waiting on its predecessor never handed to it.
+	if !types.JobStatus(job.Status).IsActive() {
+		return nil
+	}
+	_, err := h.notifyPauseToConditionScheduler(job.JobID)
+	return err
+}
+
+// expireJob marks a job that was found past its expiration time
+func (h *Handler) expireJob(jobID int64) {
+	if err := h.jobRepository.UpdateJobStatus(jobID, string(types.JobStatusExpired)); err != nil {
+		h.logger.Errorf("Error marking job %d expired: %v", jobID, err)
+		return
+	}
+	h.logger.Infof("Job %d has expired", jobID)
+}
+
+func isPastExpiration(expirationTime time.Time) bool {
+	return !expirationTime.IsZero() && expirationTime.Before(time.Now())
+}
+
+// UpdateJobDefinition edits the target calls of a job, and the condition of a
+// condition job, without recreating it. Each section is written in one update,
+// and a running event or condition job has its worker swapped to the new
+// definition. Time jobs pick the change up on their next claim.
+func (h *Handler) UpdateJobDefinition(c *gin.Context) {
+	traceID := h.getTraceID(c)
+	h.logger.Infof("[UpdateJobDefinition] trace_id=%s - Updating job definition", traceID)
+
+	jobID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		h.logger.Errorf("[UpdateJobDefinition] Invalid job ID format: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid job ID format",
+			"code":  "INVALID_JOB_ID",
+		})
+		return
+	}
+
+	var request types.UpdateJobDefinitionRequest
+	if err := c.ShouldBindJSON(&request); err != nil || (request.Target == nil && request.Condition == nil) {
+		h.logger.Errorf("[UpdateJobDefinition] Error decoding request body: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request format",
+			"code":  "INVALID_REQUEST",
+		})
+		return
+	}
+
+	trackDBOp := metrics.TrackDBOperation("read", "job_data")
+	job, err := h.jobRepository.GetJobByID(jobID)
+	trackDBOp(err)
+	if err != nil {
+		h.logger.Errorf("[UpdateJobDefinition] Error getting job data for jobID %d: %v", jobID, err)
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Job not found",
+			"code":  "JOB_NOT_FOUND",
+		})
+		return
+	}
+	if types.JobStatus(job.Status).IsTerminal() {
+		c.JSON(http.StatusConflict, gin.H{
+			"error": fmt.Sprintf("Job is %s and can no longer be edited", job.Status),
+			"code":  "INVALID_TRANSITION",
+		})
+		return
+	}
+
+	// The task definition says whether the job runs a dynamic arguments script
+	if request.Target != nil && (job.TaskDefinitionID%2 == 0) != (request.Target.DynamicArgumentsScriptUrl != "") {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "dynamic_arguments_script_url must be set exactly for jobs with dynamic arguments",
+			"code":  "INVALID_TARGET",
+		})
+		return
+	}
+	if request.Target != nil {
+		if err := checkTargetChains(request.Target.TargetChainID, request.Target.ExtraTargets); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": err.Error(),
+				"code":  "INVALID_TARGET",
+			})
+			return
+		}
+	}
+	if request.Condition != nil && job.TaskDefinitionID != 5 && job.TaskDefinitionID != 6 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Only condition jobs have a condition",
+			"code":  "INVALID_CONDITION",
+		})
+		return
+	}
+
+	if err := h.writeJobDefinition(job, &request); err != nil {
+		h.logger.Errorf("[UpdateJobDefinition] Error updating job %d: %v", jobID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error updating job: " + err.Error()})
+		return
+	}
+
+	if types.JobStatus(job.Status).IsActive() {
+		if err := h.refreshScheduledJob(job); err != nil {
+			h.logger.Errorf("[UpdateJobDefinition] Error updating scheduled job %d: %v", jobID, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Job updated, but the scheduler did not take the change: " + err.Error()})
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":    "Job updated successfully",
+		"job_id":     jobID,
+		"updated_at": time.Now().UTC(),
+	})
+}
+
+func (h *Handler) writeJobDefinition(job *types.JobData, request *types.UpdateJobDefinitionRequest) error {
+	if request.Target != nil {
+		var err error
+		switch job.TaskDefinitionID {
+		case 1, 2:
+			trackDBOp := metrics.TrackDBOperation("update", "time_job")
+			err = h.timeJobRepository.UpdateTimeJobTarget(job.JobID, request.Target)
+			trackDBOp(err)
+		case 3, 4:
+			trackDBOp := metrics.TrackDBOperation("update", "event_job")
+			err = h.eventJobRepository.UpdateEventJobTarget(job.JobID, request.Target)
+			trackDBOp(err)
+		case 5, 6:
+			trackDBOp := metrics.TrackDBOperation("update", "condition_job")
+			err = h.conditionJobRepository.UpdateConditionJobTarget(job.JobID, request.Target)
+			trackDBOp(err)
+		default:
+			err = fmt.Errorf("invalid task definition ID %d", job.TaskDefinitionID)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	if request.Condition != nil {
+		trackDBOp := metrics.TrackDBOperation("update", "condition_job")
+		err := h.conditionJobRepository.UpdateConditionJobCondition(job.JobID, request.Condition)
+		trackDBOp(err)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// refreshScheduledJob sends the stored definition of a running event or condition job to its scheduler
+func (h *Handler) refreshScheduledJob(job *types.JobData) error {
+	var scheduleData commonTypes.ScheduleConditionJobData
+	switch job.TaskDefinitionID {
+	case 3, 4:
+		eventJob, err := h.eventJobRepository.GetEventJobByJobID(job.JobID)
+		if err != nil {
+			return fmt.Errorf("failed to get event job: %v", err)
+		}
+		scheduleData = eventJobScheduleData(eventJob)
+	case 5, 6:
+		conditionJob, err := h.conditionJobRepository.GetConditionJobByJobID(job.JobID)
+		if err != nil {
+			return fmt.Errorf("failed to get condition job: %v", err)
+		}
+		scheduleData = conditionJobScheduleData(conditionJob)
+	default:
+		return nil
+	}
+
+	_, err := h.notifyUpdateToConditionScheduler(job.JobID, scheduleData)
+	return err
+}
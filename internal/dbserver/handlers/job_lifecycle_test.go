@@ -0,0 +1,156 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/trigg3rX/triggerx-backend-imua/internal/dbserver/types"
+	commonTypes "github.com/trigg3rX/triggerx-backend-imua/pkg/types"
+)
+
+type jobLifecycleMocks struct {
+	job          *MockJobRepository
+	timeJob      *MockTimeJobRepository
+	conditionJob *MockConditionJobRepository
+	catalog      *fakeConditionJobCatalog
+}
+
+func setupTestJobLifecycleHandler() (*Handler, jobLifecycleMocks) {
+	mocks := jobLifecycleMocks{
+		job:          new(MockJobRepository),
+		timeJob:      new(MockTimeJobRepository),
+		conditionJob: new(MockConditionJobRepository),
+		catalog:      &fakeConditionJobCatalog{jobs: make(map[string]string)},
+	}
+	handler := &Handler{
+		jobRepository:          mocks.job,
+		timeJobRepository:      mocks.timeJob,
+		conditionJobRepository: mocks.conditionJob,
+		logger:                 &MockLogger{},
+	}
+	handler.SetConditionJobCatalog(mocks.catalog)
+	return handler, mocks
+}
+
+func lifecycleRequest(handler gin.HandlerFunc, jobID string, body interface{}) *httptest.ResponseRecorder {
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	var payload []byte
+	if body != nil {
+		payload, _ = json.Marshal(body)
+	}
+	c.Request = httptest.NewRequest("PUT", "/", bytes.NewReader(payload))
+	c.Request.Header.Set("Content-Type", "application/json")
+	c.Params = []gin.Param{{Key: "id", Value: jobID}}
+	handler(c)
+	return w
+}
+
+func TestJobStatusTransitions(t *testing.T) {
+	assert.True(t, types.JobStatusRunning.CanTransitionTo(types.JobStatusPaused))
+	assert.True(t, types.JobStatusPaused.CanTransitionTo(types.JobStatusPending))
+	assert.True(t, types.JobStatusPaused.CanTransitionTo(types.JobStatusCancelled))
+	assert.False(t, types.JobStatusPaused.CanTransitionTo(types.JobStatusPaused))
+	assert.False(t, types.JobStatusAwaitingPredecessor.CanTransitionTo(types.JobStatusPaused))
+	assert.False(t, types.JobStatusAwaitingPredecessor.CanTransitionTo(types.JobStatusPending))
+	assert.True(t, types.JobStatusAwaitingPredecessor.CanTransitionTo(types.JobStatusCancelled))
+	assert.False(t, types.JobStatusCompleted.CanTransitionTo(types.JobStatusPending))
+	assert.False(t, types.JobStatusCancelled.CanTransitionTo(types.JobStatusPaused))
+	assert.True(t, types.JobStatusCompleted.CanTransitionTo(types.JobStatusDeleted))
+	assert.False(t, types.JobStatusDeleted.CanTransitionTo(types.JobStatusDeleted))
+}
+
+func TestPauseAndResumeConditionJob(t *testing.T) {
+	handler, mocks := setupTestJobLifecycleHandler()
+	mocks.catalog.jobs["5"] = "{}"
+
+	mocks.job.On("GetJobByID", int64(5)).Return(&types.JobData{JobID: 5, TaskDefinitionID: 5, Status: "running"}, nil).Once()
+	mocks.conditionJob.On("UpdateConditionJobStatus", int64(5), false).Return(nil)
+	mocks.job.On("UpdateJobStatus", int64(5), string(types.JobStatusPaused)).Return(nil)
+
+	w := lifecycleRequest(handler.PauseJob, "5", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Empty(t, mocks.catalog.jobs)
+
+	mocks.job.On("GetJobByID", int64(5)).Return(&types.JobData{JobID: 5, TaskDefinitionID: 5, Status: "paused"}, nil).Once()
+	mocks.conditionJob.On("GetConditionJobByJobID", int64(5)).Return(types.ConditionJobData{
+		JobID: 5, TaskDefinitionID: 5, ConditionType: "greater_than", ExpirationTime: time.Now().Add(time.Hour),
+	}, nil)
+	mocks.conditionJob.On("UpdateConditionJobStatus", int64(5), true).Return(nil)
+	mocks.job.On("UpdateJobStatus", int64(5), string(types.JobStatusPending)).Return(nil)
+
+	w = lifecycleRequest(handler.ResumeJob, "5", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, mocks.catalog.jobs, "5")
+	mocks.job.AssertExpectations(t)
+	mocks.conditionJob.AssertExpectations(t)
+}
+
+func TestResumeRejectsInvalidTransitionAndExpiredJob(t *testing.T) {
+	handler, mocks := setupTestJobLifecycleHandler()
+
+	mocks.job.On("GetJobByID", int64(1)).Return(&types.JobData{JobID: 1, TaskDefinitionID: 1, Status: "completed"}, nil)
+	w := lifecycleRequest(handler.ResumeJob, "1", nil)
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	mocks.job.On("GetJobByID", int64(2)).Return(&types.JobData{JobID: 2, TaskDefinitionID: 1, Status: "paused"}, nil)
+	mocks.timeJob.On("GetTimeJobByJobID", int64(2)).Return(types.TimeJobData{
+		JobID: 2, ScheduleType: "interval", TimeInterval: 60, ExpirationTime: time.Now().Add(-time.Minute),
+	}, nil)
+	mocks.job.On("UpdateJobStatus", int64(2), string(types.JobStatusExpired)).Return(nil)
+
+	w = lifecycleRequest(handler.ResumeJob, "2", nil)
+	assert.Equal(t, http.StatusConflict, w.Code)
+	mocks.job.AssertExpectations(t)
+	mocks.timeJob.AssertNotCalled(t, "UpdateTimeJobStatus", mock.Anything, mock.Anything)
+}
+
+func TestResumeRejectsJobAwaitingPredecessor(t *testing.T) {
+	handler, mocks := setupTestJobLifecycleHandler()
+
+	// Only a successful task of its predecessor starts a chained job
+	mocks.job.On("GetJobByID", int64(3)).Return(&types.JobData{
+		JobID: 3, TaskDefinitionID: 1, ChainStatus: 1, Status: string(types.JobStatusAwaitingPredecessor),
+	}, nil)
+	w := lifecycleRequest(handler.ResumeJob, "3", nil)
+	assert.Equal(t, http.StatusConflict, w.Code)
+	mocks.job.AssertNotCalled(t, "UpdateJobStatus", mock.Anything, mock.Anything)
+	mocks.timeJob.AssertNotCalled(t, "UpdateTimeJobStatus", mock.Anything, mock.Anything)
+}
+
+func TestUpdateJobDefinitionSwapsLiveConditionJob(t *testing.T) {
+	handler, mocks := setupTestJobLifecycleHandler()
+
+	target := &types.JobTargetDefinition{
+		TargetChainID:         "84532",
+		TargetContractAddress: "0x68605feB94a8FeBe5e1fBEF0A9D3fE6e80cEC126",
+		TargetFunction:        "rebalance",
+		ABI:                   "[]",
+		ArgType:               1,
+	}
+	mocks.job.On("GetJobByID", int64(5)).Return(&types.JobData{JobID: 5, TaskDefinitionID: 5, Status: "running"}, nil)
+	mocks.conditionJob.On("UpdateConditionJobTarget", int64(5), target).Return(nil)
+	mocks.conditionJob.On("GetConditionJobByJobID", int64(5)).Return(types.ConditionJobData{
+		JobID: 5, TaskDefinitionID: 5, TargetFunction: "rebalance",
+	}, nil)
+
+	w := lifecycleRequest(handler.UpdateJobDefinition, "5", types.UpdateJobDefinitionRequest{Target: target})
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var published commonTypes.ScheduleConditionJobData
+	assert.NoError(t, json.Unmarshal([]byte(mocks.catalog.jobs["5"]), &published))
+	assert.Equal(t, "rebalance", published.TaskTargetData.TargetFunction)
+
+	// A static job cannot be given a dynamic arguments script
+	target.DynamicArgumentsScriptUrl = "https://ipfs.io/ipfs/QmScript"
+	w = lifecycleRequest(handler.UpdateJobDefinition, "5", types.UpdateJobDefinitionRequest{Target: target})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
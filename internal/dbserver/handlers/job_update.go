@@ -2,7 +2,6 @@ package handlers
 
 import (
 	"net/http"
-	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -10,98 +9,9 @@ import (
 	"github.com/trigg3rX/triggerx-backend-imua/internal/dbserver/types"
 )
 
+// DeleteJobData marks a job deleted and takes it off the schedulers
 func (h *Handler) DeleteJobData(c *gin.Context) {
-	traceID := h.getTraceID(c)
-	h.logger.Infof("[DeleteJobData] trace_id=%s - Deleting job data", traceID)
-
-	jobID := c.Param("id")
-	if jobID == "" {
-		h.logger.Error("[DeleteJobData] No job ID provided")
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "No job ID provided",
-			"code":  "MISSING_JOB_ID",
-		})
-		return
-	}
-
-	jobIDInt, err := strconv.ParseInt(jobID, 10, 64)
-	if err != nil {
-		h.logger.Errorf("[DeleteJobData] Invalid job ID format: %v", err)
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid job ID format",
-			"code":  "INVALID_JOB_ID",
-		})
-		return
-	}
-
-	// Track database operation
-	trackDBOp := metrics.TrackDBOperation("read", "job_data")
-	taskDefinitionID, err := h.jobRepository.GetTaskDefinitionIDByJobID(jobIDInt)
-	trackDBOp(err)
-	if err != nil {
-		h.logger.Errorf("[DeleteJobData] Error getting job data for jobID %s: %v", jobID, err)
-		c.JSON(http.StatusNotFound, gin.H{
-			"error": "Job not found",
-			"code":  "JOB_NOT_FOUND",
-		})
-		return
-	}
-
-	// Track job status update
-	trackDBOp = metrics.TrackDBOperation("update", "job_data")
-	err = h.jobRepository.UpdateJobStatus(jobIDInt, "deleted")
-	trackDBOp(err)
-	if err != nil {
-		h.logger.Errorf("[DeleteJobData] Error updating job status for jobID %s: %v", jobID, err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error updating job status: " + err.Error()})
-		return
-	}
-
-	switch taskDefinitionID {
-	case 1, 2:
-		trackDBOp = metrics.TrackDBOperation("update", "time_job")
-		err = h.timeJobRepository.UpdateTimeJobStatus(jobIDInt, false)
-		trackDBOp(err)
-		if err != nil {
-			h.logger.Errorf("[DeleteJobData] Error updating time job status for jobID %s: %v", jobID, err)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error updating time job status: " + err.Error()})
-			return
-		}
-	case 3, 4:
-		trackDBOp = metrics.TrackDBOperation("update", "event_job")
-		err = h.eventJobRepository.UpdateEventJobStatus(jobIDInt, false)
-		trackDBOp(err)
-		if err != nil {
-			h.logger.Errorf("[DeleteJobData] Error updating event job status for jobID %s: %v", jobID, err)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error updating event job status: " + err.Error()})
-			return
-		}
-
-		_, err = h.notifyPauseToConditionScheduler(jobIDInt)
-		if err != nil {
-			h.logger.Errorf("[DeleteJobData] Error sending pause to event scheduler for jobID %s: %v", jobID, err)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error sending pause to event scheduler: " + err.Error()})
-			return
-		}
-	case 5, 6:
-		trackDBOp = metrics.TrackDBOperation("update", "condition_job")
-		err = h.conditionJobRepository.UpdateConditionJobStatus(jobIDInt, false)
-		trackDBOp(err)
-		if err != nil {
-			h.logger.Errorf("[DeleteJobData] Error updating condition job status for jobID %s: %v", jobID, err)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error updating condition job status: " + err.Error()})
-			return
-		}
-
-		_, err = h.notifyPauseToConditionScheduler(jobIDInt)
-		if err != nil {
-			h.logger.Errorf("[DeleteJobData] Error sending pause to condition scheduler for jobID %s: %v", jobID, err)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error sending pause to condition scheduler: " + err.Error()})
-			return
-		}
-	}
-
-	c.JSON(http.StatusOK, gin.H{"message": "Job deleted successfully"})
+	h.transitionJob(c, "DeleteJobData", types.JobStatusDeleted)
 }
 
 func (h *Handler) UpdateJobDataFromUser(c *gin.Context) {
@@ -154,55 +64,20 @@ func (h *Handler) UpdateJobDataFromUser(c *gin.Context) {
 	})
 }
 
+// UpdateJobStatus moves a job to the status named in the path, if its current
+// status allows it
 func (h *Handler) UpdateJobStatus(c *gin.Context) {
-	traceID := h.getTraceID(c)
-	h.logger.Infof("[UpdateJobStatus] trace_id=%s - Updating job status", traceID)
-
-	jobID := c.Param("job_id")
-	status := c.Param("status")
-
-	// Validate status
-	validStatuses := map[string]bool{
-		"pending":  true,
-		"in-queue": true,
-		"running":  true,
-	}
-
-	if !validStatuses[status] {
+	status := types.JobStatus(c.Param("status"))
+	if !status.IsValid() {
+		h.logger.Errorf("[UpdateJobStatus] Invalid status: %s", status)
 		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid status. Must be one of: pending, in-queue, running",
+			"error": "Invalid status",
 			"code":  "INVALID_STATUS",
 		})
 		return
 	}
 
-	// Convert jobID string to int64
-	jobIDInt, err := strconv.ParseInt(jobID, 10, 64)
-	if err != nil {
-		h.logger.Errorf("[UpdateJobStatus] Error converting job ID to int64: %v", err)
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid job ID format",
-			"code":  "INVALID_JOB_ID",
-		})
-		return
-	}
-
-	// Update the job status
-	trackDBOp := metrics.TrackDBOperation("update", "job_data")
-	if err := h.jobRepository.UpdateJobStatus(jobIDInt, status); err != nil {
-		trackDBOp(err)
-		h.logger.Errorf("[UpdateJobStatus] Error updating job status: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		return
-	}
-	trackDBOp(nil)
-
-	c.JSON(http.StatusOK, gin.H{
-		"message":    "Job status updated successfully",
-		"job_id":     jobID,
-		"status":     status,
-		"updated_at": time.Now().UTC(),
-	})
+	h.transitionJob(c, "UpdateJobStatus", status)
 }
 
 func (h *Handler) UpdateJobLastExecutedAt(c *gin.Context) {
@@ -98,6 +98,16 @@ func (m *MockUserRepository) UpdateUserEmail(address, email string) error {
 	return args.Error(0)
 }
 
+func (m *MockUserRepository) GetUserBalanceByID(userID int64) (types.UserBalance, error) {
+	args := m.Called(userID)
+	return args.Get(0).(types.UserBalance), args.Error(1)
+}
+
+func (m *MockUserRepository) DebitUserTokenBalance(userID int64, amount *big.Int) (*big.Int, *big.Int, error) {
+	args := m.Called(userID, amount)
+	return args.Get(0).(*big.Int), args.Get(1).(*big.Int), args.Error(2)
+}
+
 func (m *MockJobRepository) CreateNewJob(job *types.JobData) (int64, error) {
 	args := m.Called(job)
 	return args.Get(0).(int64), args.Error(1)
@@ -113,6 +123,11 @@ func (m *MockJobRepository) UpdateJobStatus(jobID int64, status string) error {
 	return args.Error(0)
 }
 
+func (m *MockJobRepository) CompareAndSetJobStatus(jobID int64, from string, to string) (bool, error) {
+	args := m.Called(jobID, from, to)
+	return args.Bool(0), args.Error(1)
+}
+
 func (m *MockJobRepository) UpdateJobLastExecutedAt(jobID int64, taskID int64, jobCostActual float64, lastExecutedAt time.Time) error {
 	args := m.Called(jobID, taskID, jobCostActual, lastExecutedAt)
 	return args.Error(0)
@@ -153,11 +168,26 @@ func (m *MockTimeJobRepository) CompleteTimeJob(jobID int64) error {
 	return args.Error(0)
 }
 
-func (m *MockTimeJobRepository) GetTimeJobsByNextExecutionTimestamp(timestamp time.Time) ([]pkgtypes.ScheduleTimeTaskData, error) {
-	args := m.Called(timestamp)
+func (m *MockTimeJobRepository) ClaimTimeJobs(owner string, lookAheadTime time.Time, leaseTTL time.Duration) ([]pkgtypes.ScheduleTimeTaskData, error) {
+	args := m.Called(owner, lookAheadTime, leaseTTL)
 	return args.Get(0).([]pkgtypes.ScheduleTimeTaskData), args.Error(1)
 }
 
+func (m *MockTimeJobRepository) SetTimeJobLeaseTask(jobID int64, owner string, taskID int64) error {
+	args := m.Called(jobID, owner, taskID)
+	return args.Error(0)
+}
+
+func (m *MockTimeJobRepository) AckTimeJob(jobID int64, owner string) (bool, error) {
+	args := m.Called(jobID, owner)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockTimeJobRepository) ReleaseTimeJob(jobID int64, owner string) (bool, error) {
+	args := m.Called(jobID, owner)
+	return args.Bool(0), args.Error(1)
+}
+
 func (m *MockTimeJobRepository) UpdateTimeJobNextExecutionTimestamp(jobID int64, nextExecutionTimestamp time.Time) error {
 	args := m.Called(jobID, nextExecutionTimestamp)
 	return args.Error(0)
@@ -168,6 +198,16 @@ func (m *MockTimeJobRepository) UpdateTimeJobInterval(jobID int64, timeInterval
 	return args.Error(0)
 }
 
+func (m *MockTimeJobRepository) UpdateTimeJobArguments(jobID int64, arguments []string, extraTargets []types.TargetCallData) error {
+	args := m.Called(jobID, arguments, extraTargets)
+	return args.Error(0)
+}
+
+func (m *MockTimeJobRepository) UpdateTimeJobTarget(jobID int64, target *types.JobTargetDefinition) error {
+	args := m.Called(jobID, target)
+	return args.Error(0)
+}
+
 func (m *MockEventJobRepository) CreateEventJob(job *types.EventJobData) error {
 	args := m.Called(job)
 	return args.Error(0)
@@ -188,6 +228,16 @@ func (m *MockEventJobRepository) CompleteEventJob(jobID int64) error {
 	return args.Error(0)
 }
 
+func (m *MockEventJobRepository) UpdateEventJobArguments(jobID int64, arguments []string, extraTargets []types.TargetCallData) error {
+	args := m.Called(jobID, arguments, extraTargets)
+	return args.Error(0)
+}
+
+func (m *MockEventJobRepository) UpdateEventJobTarget(jobID int64, target *types.JobTargetDefinition) error {
+	args := m.Called(jobID, target)
+	return args.Error(0)
+}
+
 func (m *MockConditionJobRepository) CreateConditionJob(job *types.ConditionJobData) error {
 	args := m.Called(job)
 	return args.Error(0)
@@ -208,6 +258,21 @@ func (m *MockConditionJobRepository) CompleteConditionJob(jobID int64) error {
 	return args.Error(0)
 }
 
+func (m *MockConditionJobRepository) UpdateConditionJobArguments(jobID int64, arguments []string, extraTargets []types.TargetCallData) error {
+	args := m.Called(jobID, arguments, extraTargets)
+	return args.Error(0)
+}
+
+func (m *MockConditionJobRepository) UpdateConditionJobTarget(jobID int64, target *types.JobTargetDefinition) error {
+	args := m.Called(jobID, target)
+	return args.Error(0)
+}
+
+func (m *MockConditionJobRepository) UpdateConditionJobCondition(jobID int64, condition *types.JobConditionDefinition) error {
+	args := m.Called(jobID, condition)
+	return args.Error(0)
+}
+
 // Test setup helper
 func setupTestHandler() (*Handler, *MockUserRepository, *MockJobRepository, *MockTimeJobRepository, *MockEventJobRepository, *MockConditionJobRepository) {
 	mockUserRepo := new(MockUserRepository)
@@ -338,10 +403,21 @@ func TestUpdateJobStatus(t *testing.T) {
 			jobID:  "1",
 			status: "running",
 			setupMocks: func() {
+				mockJobRepo.On("GetJobByID", int64(1)).Return(&types.JobData{JobID: 1, TaskDefinitionID: 1, Status: "in-queue"}, nil).Once()
 				mockJobRepo.On("UpdateJobStatus", int64(1), "running").Return(nil)
 			},
 			expectedCode: http.StatusOK,
 		},
+		{
+			name:   "Error - Transition Not Allowed",
+			jobID:  "1",
+			status: "running",
+			setupMocks: func() {
+				mockJobRepo.On("GetJobByID", int64(1)).Return(&types.JobData{JobID: 1, TaskDefinitionID: 1, Status: "deleted"}, nil).Once()
+			},
+			expectedCode:  http.StatusConflict,
+			expectedError: "cannot be moved to running",
+		},
 		{
 			name:          "Error - Invalid Status",
 			jobID:         "1",
@@ -369,7 +445,7 @@ func TestUpdateJobStatus(t *testing.T) {
 
 			// Setup request
 			c.Params = []gin.Param{
-				{Key: "job_id", Value: tt.jobID},
+				{Key: "id", Value: tt.jobID},
 				{Key: "status", Value: tt.status},
 			}
 
@@ -407,7 +483,7 @@ func TestDeleteJobData(t *testing.T) {
 			jobID:     "1",
 			taskDefID: 1,
 			setupMocks: func() {
-				mockJobRepo.On("GetTaskDefinitionIDByJobID", int64(1)).Return(1, nil)
+				mockJobRepo.On("GetJobByID", int64(1)).Return(&types.JobData{JobID: 1, TaskDefinitionID: 1, Status: "running"}, nil)
 				mockJobRepo.On("UpdateJobStatus", int64(1), "deleted").Return(nil)
 				mockTimeJobRepo.On("UpdateTimeJobStatus", int64(1), false).Return(nil)
 			},
@@ -418,12 +494,22 @@ func TestDeleteJobData(t *testing.T) {
 			jobID:     "2",
 			taskDefID: 3,
 			setupMocks: func() {
-				mockJobRepo.On("GetTaskDefinitionIDByJobID", int64(2)).Return(3, nil)
+				mockJobRepo.On("GetJobByID", int64(2)).Return(&types.JobData{JobID: 2, TaskDefinitionID: 3, Status: "paused"}, nil)
 				mockJobRepo.On("UpdateJobStatus", int64(2), "deleted").Return(nil)
 				mockEventJobRepo.On("UpdateEventJobStatus", int64(2), false).Return(nil)
 			},
 			expectedCode: http.StatusOK,
 		},
+		{
+			name:      "Error - Already Deleted",
+			jobID:     "3",
+			taskDefID: 1,
+			setupMocks: func() {
+				mockJobRepo.On("GetJobByID", int64(3)).Return(&types.JobData{JobID: 3, TaskDefinitionID: 1, Status: "deleted"}, nil)
+			},
+			expectedCode:  http.StatusConflict,
+			expectedError: "cannot be moved to deleted",
+		},
 		{
 			name:          "Error - Invalid Job ID",
 			jobID:         "invalid",
@@ -136,6 +136,21 @@ func (m *MockTaskRepository) UpdateTaskNumberAndStatus(taskID int64, taskNumber
 }
 
 // Test setup helper
+func (m *MockTaskRepository) UpdateTaskStatus(taskID int64, status string) error {
+	args := m.Called(taskID, status)
+	return args.Error(0)
+}
+
+func (m *MockTaskRepository) MarkTaskCharged(taskID int64, cost float64) (bool, error) {
+	args := m.Called(taskID, cost)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockTaskRepository) UnmarkTaskCharged(taskID int64) error {
+	args := m.Called(taskID)
+	return args.Error(0)
+}
+
 func setupTestKeeperHandler() (*Handler, *MockKeeperRepository, *MockTaskRepository) {
 	mockKeeperRepo := new(MockKeeperRepository)
 	mockTaskRepo := new(MockTaskRepository)
@@ -2,10 +2,12 @@ package handlers
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/trigg3rX/triggerx-backend-imua/internal/dbserver/config"
@@ -13,8 +15,24 @@ import (
 	"github.com/trigg3rX/triggerx-backend-imua/pkg/types"
 )
 
+// ConditionJobCatalog is the Redis catalog that condition scheduler instances
+// distribute jobs from
+type ConditionJobCatalog interface {
+	HSet(ctx context.Context, key string, values ...interface{}) error
+	HDel(ctx context.Context, key string, fields ...string) error
+	Publish(ctx context.Context, channel string, message interface{}) error
+}
+
 // notifyConditionScheduler sends a notification to the condition scheduler
 func (h *Handler) notifyConditionScheduler(jobID int64, scheduleConditionJobData types.ScheduleConditionJobData) (bool, error) {
+	if h.conditionJobCatalog != nil {
+		if err := h.publishConditionJob(jobID, &scheduleConditionJobData); err != nil {
+			h.logger.Errorf("[NotifyConditionScheduler] Failed to publish job %d to condition job catalog: %v", jobID, err)
+			return false, err
+		}
+		return true, nil
+	}
+
 	success, err := h.sendDataToScheduler("/api/v1/job/schedule", scheduleConditionJobData)
 	if err != nil {
 		h.logger.Errorf("[NotifyConditionScheduler] Failed to notify condition scheduler for job %d: %v", jobID, err)
@@ -27,8 +45,40 @@ func (h *Handler) notifyConditionScheduler(jobID int64, scheduleConditionJobData
 	return true, nil
 }
 
+// notifyUpdateToConditionScheduler hands an edited job to the condition scheduler,
+// which swaps it into the running worker
+func (h *Handler) notifyUpdateToConditionScheduler(jobID int64, scheduleConditionJobData types.ScheduleConditionJobData) (bool, error) {
+	if h.conditionJobCatalog != nil {
+		// The instance running the job sees its catalog entry change
+		if err := h.publishConditionJob(jobID, &scheduleConditionJobData); err != nil {
+			h.logger.Errorf("[NotifyConditionScheduler] Failed to publish edited job %d to condition job catalog: %v", jobID, err)
+			return false, err
+		}
+		return true, nil
+	}
+
+	success, err := h.sendDataToScheduler("/api/v1/job/update", scheduleConditionJobData)
+	if err != nil {
+		h.logger.Errorf("[NotifyConditionScheduler] Failed to send edited job %d to condition scheduler: %v", jobID, err)
+		return false, err
+	}
+	if !success {
+		h.logger.Errorf("[NotifyConditionScheduler] Failed to send edited job %d to condition scheduler", jobID)
+		return false, fmt.Errorf("failed to update job %d in condition scheduler", jobID)
+	}
+	return true, nil
+}
+
 // SendPauseToEventScheduler sends a DELETE request to the event scheduler
 func (h *Handler) notifyPauseToConditionScheduler(jobID int64) (bool, error) {
+	if h.conditionJobCatalog != nil {
+		if err := h.removeConditionJob(jobID); err != nil {
+			h.logger.Errorf("[NotifyEventScheduler] Failed to remove job %d from condition job catalog: %v", jobID, err)
+			return false, err
+		}
+		return true, nil
+	}
+
 	success, err := h.sendDataToScheduler("/api/v1/job/pause", types.ScheduleConditionJobData{JobID: jobID})
 	if err != nil {
 		h.logger.Errorf("[NotifyEventScheduler] Failed to notify event scheduler for job %d: %v", jobID, err)
@@ -42,6 +92,44 @@ func (h *Handler) notifyPauseToConditionScheduler(jobID int64) (bool, error) {
 	return true, nil
 }
 
+// publishConditionJob adds a job to the catalog; the instance owning it starts it
+func (h *Handler) publishConditionJob(jobID int64, data *types.ScheduleConditionJobData) error {
+	jsonData, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("error marshaling data: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := h.conditionJobCatalog.HSet(ctx, types.ConditionJobCatalogKey, strconv.FormatInt(jobID, 10), string(jsonData)); err != nil {
+		return fmt.Errorf("error adding job to catalog: %v", err)
+	}
+	if err := h.conditionJobCatalog.Publish(ctx, types.ConditionJobCatalogChannel, "changed"); err != nil {
+		// Instances still pick the job up on their next heartbeat
+		h.logger.Warnf("Failed to announce condition job catalog change: %v", err)
+	}
+
+	h.logger.Infof("Published job %d to condition job catalog", jobID)
+	return nil
+}
+
+// removeConditionJob deletes a job from the catalog; the instance running it stops it
+func (h *Handler) removeConditionJob(jobID int64) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := h.conditionJobCatalog.HDel(ctx, types.ConditionJobCatalogKey, strconv.FormatInt(jobID, 10)); err != nil {
+		return fmt.Errorf("error removing job from catalog: %v", err)
+	}
+	if err := h.conditionJobCatalog.Publish(ctx, types.ConditionJobCatalogChannel, "changed"); err != nil {
+		h.logger.Warnf("Failed to announce condition job catalog change: %v", err)
+	}
+
+	h.logger.Infof("Removed job %d from condition job catalog", jobID)
+	return nil
+}
+
 // sendDataToScheduler is a generic function to send data to any scheduler
 func (h *Handler) sendDataToScheduler(route string, data types.ScheduleConditionJobData) (bool, error) {
 	jsonData, err := json.Marshal(data)
@@ -1 +1,67 @@
-package handlers
\ No newline at end of file
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/trigg3rX/triggerx-backend-imua/pkg/types"
+)
+
+type fakeConditionJobCatalog struct {
+	jobs      map[string]string
+	published int
+	err       error
+}
+
+func (f *fakeConditionJobCatalog) HSet(ctx context.Context, key string, values ...interface{}) error {
+	if f.err != nil {
+		return f.err
+	}
+	f.jobs[values[0].(string)] = values[1].(string)
+	return nil
+}
+
+func (f *fakeConditionJobCatalog) HDel(ctx context.Context, key string, fields ...string) error {
+	if f.err != nil {
+		return f.err
+	}
+	for _, field := range fields {
+		delete(f.jobs, field)
+	}
+	return nil
+}
+
+func (f *fakeConditionJobCatalog) Publish(ctx context.Context, channel string, message interface{}) error {
+	f.published++
+	return nil
+}
+
+func TestNotifyConditionSchedulerUsesCatalog(t *testing.T) {
+	catalog := &fakeConditionJobCatalog{jobs: make(map[string]string)}
+	handler := &Handler{logger: &MockLogger{}}
+	handler.SetConditionJobCatalog(catalog)
+
+	jobData := types.ScheduleConditionJobData{JobID: 7, TaskDefinitionID: 5}
+	ok, err := handler.notifyConditionScheduler(7, jobData)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+
+	var stored types.ScheduleConditionJobData
+	assert.NoError(t, json.Unmarshal([]byte(catalog.jobs["7"]), &stored))
+	assert.Equal(t, jobData.JobID, stored.JobID)
+	assert.Equal(t, 1, catalog.published)
+
+	ok, err = handler.notifyPauseToConditionScheduler(7)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Empty(t, catalog.jobs)
+	assert.Equal(t, 2, catalog.published)
+
+	catalog.err = errors.New("connection refused")
+	ok, err = handler.notifyConditionScheduler(8, types.ScheduleConditionJobData{JobID: 8})
+	assert.Error(t, err)
+	assert.False(t, ok)
+}
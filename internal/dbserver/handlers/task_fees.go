@@ -2,81 +2,54 @@ package handlers
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/http"
-	"os"
-	"path/filepath"
 	"strings"
-	"sync"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/trigg3rX/triggerx-backend-imua/internal/dbserver/fees"
 	"github.com/trigg3rX/triggerx-backend-imua/internal/dbserver/metrics"
-	"github.com/trigg3rX/triggerx-backend-imua/pkg/docker"
 )
 
-func (h *Handler) CalculateTaskFees(ipfsURLs string) (float64, error) {
+// Time a caller waits for its sandboxed runs, queueing included
+const simulateFeesTimeout = 5 * time.Minute
+
+// CalculateTaskFees estimates the fee of each script in a comma separated list of
+// IPFS URLs, from the script's cost history or its source. Scripts are not run.
+func (h *Handler) CalculateTaskFees(ipfsURLs string) (float64, []fees.Estimate, error) {
+	return h.estimateTaskFees(context.Background(), ipfsURLs, h.feeEstimator.Estimate)
+}
+
+func (h *Handler) estimateTaskFees(ctx context.Context, ipfsURLs string,
+	estimate func(ctx context.Context, url string) (fees.Estimate, error)) (float64, []fees.Estimate, error) {
 	if ipfsURLs == "" {
-		return 0, fmt.Errorf("missing IPFS URLs")
+		return 0, nil, fmt.Errorf("missing IPFS URLs")
+	}
+	if h.feeEstimator == nil {
+		return 0, nil, fmt.Errorf("fee estimator is not available")
 	}
 
 	trackDBOp := metrics.TrackDBOperation("read", "task_fees")
-	urlList := strings.Split(ipfsURLs, ",")
 	totalFee := 0.0
-	var mu sync.Mutex
-	var wg sync.WaitGroup
-
-	ctx := context.Background()
-
-	executor, err := docker.NewCodeExecutor(ctx, h.executor, h.logger)
-	if err != nil {
-		trackDBOp(err)
-		return 0, fmt.Errorf("failed to create code executor: %v", err)
-	}
-
-	for _, ipfsURL := range urlList {
+	var estimates []fees.Estimate
+	for _, ipfsURL := range strings.Split(ipfsURLs, ",") {
 		ipfsURL = strings.TrimSpace(ipfsURL)
-		wg.Add(1)
-
-		go func(url string) {
-			defer wg.Done()
-
-			codePath, err := executor.Downloader.DownloadFile(ctx, url, h.logger)
-			if err != nil {
-				h.logger.Errorf("Error downloading IPFS file: %v", err)
-				return
-			}
-			defer func() {
-				if err := os.RemoveAll(filepath.Dir(codePath)); err != nil {
-					h.logger.Errorf("Error removing temporary directory: %v", err)
-				}
-			}()
-
-			containerID, err := executor.DockerManager.CreateContainer(ctx, filepath.Dir(codePath))
-			if err != nil {
-				h.logger.Errorf("Error creating container: %v", err)
-				return
-			}
-			defer func(id string) {
-				if err := executor.DockerManager.CleanupContainer(ctx, id); err != nil {
-					h.logger.Errorf("Error removing container: %v", err)
-				}
-			}(containerID)
-
-			result, err := executor.MonitorExecution(ctx, executor.DockerManager.Cli, containerID, 10)
-			if err != nil {
-				h.logger.Errorf("Error monitoring resources: %v", err)
-				return
-			}
-
-			mu.Lock()
-			totalFee += result.Stats.TotalCost
-			mu.Unlock()
-		}(ipfsURL)
+		if ipfsURL == "" {
+			continue
+		}
+
+		result, err := estimate(ctx, ipfsURL)
+		if err != nil {
+			trackDBOp(err)
+			return 0, nil, fmt.Errorf("failed to estimate fee of %s: %w", ipfsURL, err)
+		}
+		totalFee += result.Fee
+		estimates = append(estimates, result)
 	}
-
-	wg.Wait()
 	trackDBOp(nil)
-	return totalFee, nil
+	return totalFee, estimates, nil
 }
 
 func (h *Handler) GetTaskFees(c *gin.Context) {
@@ -84,7 +57,7 @@ func (h *Handler) GetTaskFees(c *gin.Context) {
 	h.logger.Infof("[GetTaskFees] trace_id=%s - Getting task fees", traceID)
 	ipfsURLs := c.Query("ipfs_url")
 
-	totalFee, err := h.CalculateTaskFees(ipfsURLs)
+	totalFee, estimates, err := h.CalculateTaskFees(ipfsURLs)
 	if err != nil {
 		h.logger.Errorf("[GetTaskFees] Error calculating fees: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
@@ -93,5 +66,43 @@ func (h *Handler) GetTaskFees(c *gin.Context) {
 
 	c.JSON(http.StatusOK, gin.H{
 		"total_fee": totalFee,
+		"estimates": estimates,
+	})
+}
+
+// SimulateTaskFees measures the fee of scripts without a cost history by running
+// them in the sandbox. Runs are queued across callers, so it is only open to API
+// key holders.
+func (h *Handler) SimulateTaskFees(c *gin.Context) {
+	traceID := h.getTraceID(c)
+	h.logger.Infof("[SimulateTaskFees] trace_id=%s - Simulating task fees", traceID)
+	ipfsURLs := c.Query("ipfs_url")
+
+	if h.feeEstimator == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "fee estimator is not available"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), simulateFeesTimeout)
+	defer cancel()
+
+	totalFee, estimates, err := h.estimateTaskFees(ctx, ipfsURLs, h.feeEstimator.Simulate)
+	if errors.Is(err, fees.ErrSimulationQueueFull) {
+		h.logger.Warnf("[SimulateTaskFees] Simulation queue is full")
+		c.JSON(http.StatusTooManyRequests, gin.H{
+			"error": "Too many fee simulations in progress, try again later",
+			"code":  "SIMULATION_QUEUE_FULL",
+		})
+		return
+	}
+	if err != nil {
+		h.logger.Errorf("[SimulateTaskFees] Error simulating fees: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"total_fee": totalFee,
+		"estimates": estimates,
 	})
 }
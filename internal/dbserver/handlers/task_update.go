@@ -27,7 +27,8 @@ func (h *Handler) UpdateTaskExecutionData(c *gin.Context) {
 	}
 
 	// Validate required fields
-	if taskData.TaskID == 0 || taskData.ExecutionTimestamp.IsZero() || taskData.ExecutionTxHash == "" {
+	// A skipped task sent no transaction
+	if taskData.TaskID == 0 || taskData.ExecutionTimestamp.IsZero() || (taskData.ExecutionTxHash == "" && !taskData.IsSkipped) {
 		h.logger.Errorf("[UpdateTaskExecutionData] Missing required fields")
 		c.JSON(http.StatusBadRequest, gin.H{
 			"error": "Missing required fields",
@@ -48,10 +49,90 @@ func (h *Handler) UpdateTaskExecutionData(c *gin.Context) {
 	}
 	trackDBOp(nil)
 
+	// Settle the task's cost against the user's balance, and feed the script's cost
+	// history, which fee estimates are drawn from. A repeated report is not settled again.
+	settled := h.chargeTaskCost(taskData.TaskID, taskData.TaskOpXCost)
+	if settled && h.feeEstimator != nil {
+		if err := h.feeEstimator.RecordExecution(taskData.DynamicArgumentsScriptUrl, taskData.TaskOpXCost); err != nil {
+			h.logger.Errorf("[UpdateTaskExecutionData] Error recording script cost for task %d: %v", taskData.TaskID, err)
+		}
+	}
+
+	// Start or fail the next job, if the task's job is part of a chain. A skipped
+	// task did not act, so the next job keeps waiting for one that does.
+	if !taskData.IsSkipped {
+		h.advanceJobChain(taskData.TaskID, taskData.IsSuccessful, taskData.ExecutionTxHash)
+	}
+
 	h.logger.Infof("[UpdateTaskExecutionData] Successfully updated task execution data for task with ID: %s", taskID)
 	c.JSON(http.StatusOK, gin.H{"message": "Task execution data updated successfully"})
 }
 
+// FailTaskExecution records that the keepers gave up on a task, and fails the
+// jobs chained after its job. Such a task never reaches UpdateTaskExecutionData,
+// which only takes executions with a validated proof.
+func (h *Handler) FailTaskExecution(c *gin.Context) {
+	traceID := h.getTraceID(c)
+	h.logger.Infof("[FailTaskExecution] trace_id=%s - Failing task execution", traceID)
+
+	taskID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		h.logger.Errorf("[FailTaskExecution] Invalid task ID format: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid task ID format",
+			"code":  "INVALID_TASK_ID",
+		})
+		return
+	}
+
+	var request types.FailTaskExecutionRequest
+	if err := c.ShouldBindJSON(&request); err != nil || request.TaskID != taskID {
+		h.logger.Errorf("[FailTaskExecution] Error decoding request body: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request format",
+			"code":  "INVALID_REQUEST",
+		})
+		return
+	}
+
+	trackDBOp := metrics.TrackDBOperation("read", "task_data")
+	task, err := h.taskRepository.GetTaskDataByID(taskID)
+	trackDBOp(err)
+	if err != nil {
+		h.logger.Errorf("[FailTaskExecution] Error getting task data for task %d: %v", taskID, err)
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Task not found",
+			"code":  "TASK_NOT_FOUND",
+		})
+		return
+	}
+	// Its execution decides how the chain goes on
+	if !task.ExecutionTimestamp.IsZero() || task.ExecutionTxHash != "" {
+		c.JSON(http.StatusConflict, gin.H{
+			"error": "Task already has an execution",
+			"code":  "TASK_ALREADY_EXECUTED",
+		})
+		return
+	}
+
+	trackDBOp = metrics.TrackDBOperation("update", "task_data")
+	err = h.taskRepository.UpdateTaskStatus(taskID, types.TaskStatusFailed)
+	trackDBOp(err)
+	if err != nil {
+		h.logger.Errorf("[FailTaskExecution] Error updating status of task %d: %v", taskID, err)
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Task not found or update failed",
+			"code":  "TASK_UPDATE_ERROR",
+		})
+		return
+	}
+	h.logger.Warnf("[FailTaskExecution] Task %d failed: %s", taskID, request.Error)
+
+	h.advanceJobChain(taskID, false, "")
+
+	c.JSON(http.StatusOK, gin.H{"message": "Task execution failed"})
+}
+
 func (h *Handler) UpdateTaskAttestationData(c *gin.Context) {
 	traceID := h.getTraceID(c)
 	h.logger.Infof("[UpdateTaskAttestationData] trace_id=%s - Updating task attestation data", traceID)
@@ -143,6 +143,8 @@ func TestUpdateTaskExecutionData(t *testing.T) {
 					ProofOfTask:        "proof",
 					TaskOpXCost:        10.5,
 				}).Return(nil)
+				// The task is not found for the chain lookup; the update still succeeds
+				mockTaskRepo.On("GetTaskDataByID", int64(1)).Return(types.TaskData{}, assert.AnError)
 			},
 			expectedCode: http.StatusOK,
 		},
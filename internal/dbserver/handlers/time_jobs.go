@@ -11,44 +11,158 @@ import (
 	commonTypes "github.com/trigg3rX/triggerx-backend-imua/pkg/types"
 )
 
-func (h *Handler) GetTimeBasedTasks(c *gin.Context) {
+// defaultTimeJobLeaseSeconds applies when the scheduler does not ask for a lease length
+const defaultTimeJobLeaseSeconds = 60
+
+// maxTimeJobLeaseSeconds bounds the lease a scheduler may ask for, so that a
+// scheduler that stops keeps the others off its jobs for a few minutes at most
+const maxTimeJobLeaseSeconds = 5 * defaultTimeJobLeaseSeconds
+
+// misfiredTaskStatus marks a task the fail misfire policy dropped without executing
+const misfiredTaskStatus = "misfired"
+
+// ClaimTimeBasedTasks leases the due time jobs to the calling scheduler and
+// creates a task for each. The schedule is not advanced until the scheduler
+// acknowledges the jobs, so an execution that never reaches the keepers is
+// claimed again once its lease expires.
+func (h *Handler) ClaimTimeBasedTasks(c *gin.Context) {
+	var req commonTypes.ClaimTimeJobsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.Errorf("[ClaimTimeBasedTasks] Error decoding request body: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+	if req.SchedulerID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "scheduler_id is required"})
+		return
+	}
+	if req.LeaseSeconds <= 0 {
+		req.LeaseSeconds = defaultTimeJobLeaseSeconds
+	}
+	req.LeaseSeconds = min(req.LeaseSeconds, maxTimeJobLeaseSeconds)
+
 	pollLookAhead := config.GetPollingLookAhead()
 	lookAheadTime := time.Now().Add(time.Duration(pollLookAhead) * time.Second)
+	leaseTTL := time.Duration(req.LeaseSeconds) * time.Second
 
-	var tasks []commonTypes.ScheduleTimeTaskData
-	var err error
-
-	trackDBOp := metrics.TrackDBOperation("read", "time_jobs")
-	tasks, err = h.timeJobRepository.GetTimeJobsByNextExecutionTimestamp(lookAheadTime)
+	trackDBOp := metrics.TrackDBOperation("update", "time_jobs")
+	tasks, err := h.timeJobRepository.ClaimTimeJobs(req.SchedulerID, lookAheadTime, leaseTTL)
 	trackDBOp(err)
 	if err != nil {
-		h.logger.Errorf("[GetTimeBasedTasks] Error retrieving time based tasks: %v", err)
+		h.logger.Errorf("[ClaimTimeBasedTasks] Error claiming time based tasks: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to retrieve time based tasks",
-			"code":  "TIME_TASKS_FETCH_ERROR",
-			"tasks": tasks,
+			"error": "Failed to claim time based tasks",
+			"code":  "TIME_TASKS_CLAIM_ERROR",
 		})
 		return
 	}
 
+	claimed := make([]commonTypes.ScheduleTimeTaskData, 0, len(tasks))
 	for i := range tasks {
-		trackDBOp = metrics.TrackDBOperation("create", "task_data")
-		taskID, err := h.taskRepository.CreateTaskDataInDB(&types.CreateTaskDataRequest{
-			JobID:            tasks[i].TaskTargetData.JobID,
-			TaskDefinitionID: tasks[i].TaskDefinitionID,
-		})
+		if tasks[i].TaskID == 0 {
+			trackDBOp = metrics.TrackDBOperation("create", "task_data")
+			taskID, err := h.taskRepository.CreateTaskDataInDB(&types.CreateTaskDataRequest{
+				JobID:            tasks[i].TaskTargetData.JobID,
+				TaskDefinitionID: tasks[i].TaskDefinitionID,
+			})
+			trackDBOp(err)
+			if err != nil {
+				h.logger.Errorf("[ClaimTimeBasedTasks] Error creating task data for job %d: %v", tasks[i].TaskTargetData.JobID, err)
+				h.releaseTimeJob(tasks[i].TaskTargetData.JobID, req.SchedulerID)
+				continue
+			}
+			// Remember the task, so a reclaim of this execution reuses it. Without
+			// it the lease would hold the job until it expires, for nothing.
+			if err := h.timeJobRepository.SetTimeJobLeaseTask(tasks[i].TaskTargetData.JobID, req.SchedulerID, taskID); err != nil {
+				h.logger.Errorf("[ClaimTimeBasedTasks] Error recording task %d for job %d: %v", taskID, tasks[i].TaskTargetData.JobID, err)
+				h.releaseTimeJob(tasks[i].TaskTargetData.JobID, req.SchedulerID)
+				continue
+			}
+			tasks[i].TaskID = taskID
+			tasks[i].TaskTargetData.TaskID = taskID
+		}
+		claimed = append(claimed, tasks[i])
+	}
+
+	h.logger.Infof("[ClaimTimeBasedTasks] Scheduler %s claimed %d time based jobs", req.SchedulerID, len(claimed))
+	c.JSON(http.StatusOK, claimed)
+}
+
+// AckTimeBasedTasks advances the schedule of jobs the scheduler has handed to the keepers.
+// Tasks dropped by the fail misfire policy are marked misfired, so the job owner sees them.
+func (h *Handler) AckTimeBasedTasks(c *gin.Context) {
+	req, ok := h.bindTimeJobLeaseRequest(c, "AckTimeBasedTasks")
+	if !ok {
+		return
+	}
+
+	for _, taskID := range req.MisfiredTaskIDs {
+		trackDBOp := metrics.TrackDBOperation("update", "task_data")
+		err := h.taskRepository.UpdateTaskNumberAndStatus(taskID, 0, misfiredTaskStatus, "")
+		trackDBOp(err)
+		if err != nil {
+			h.logger.Errorf("[AckTimeBasedTasks] Error marking task %d as misfired: %v", taskID, err)
+			continue
+		}
+		h.logger.Warnf("[AckTimeBasedTasks] Task %d misfired and was not executed", taskID)
+	}
+
+	h.applyTimeJobLeases(c, "AckTimeBasedTasks", req, h.timeJobRepository.AckTimeJob)
+}
+
+// ReleaseTimeBasedTasks hands claimed jobs back without advancing their schedule
+func (h *Handler) ReleaseTimeBasedTasks(c *gin.Context) {
+	req, ok := h.bindTimeJobLeaseRequest(c, "ReleaseTimeBasedTasks")
+	if !ok {
+		return
+	}
+	h.applyTimeJobLeases(c, "ReleaseTimeBasedTasks", req, h.timeJobRepository.ReleaseTimeJob)
+}
+
+func (h *Handler) bindTimeJobLeaseRequest(c *gin.Context, op string) (commonTypes.TimeJobLeaseRequest, bool) {
+	var req commonTypes.TimeJobLeaseRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.Errorf("[%s] Error decoding request body: %v", op, err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return req, false
+	}
+	if req.SchedulerID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "scheduler_id is required"})
+		return req, false
+	}
+	return req, true
+}
+
+func (h *Handler) applyTimeJobLeases(c *gin.Context, op string, req commonTypes.TimeJobLeaseRequest, apply func(jobID int64, owner string) (bool, error)) {
+	response := commonTypes.TimeJobLeaseResponse{
+		Applied: []int64{},
+		Lost:    []int64{},
+	}
+	for _, jobID := range req.JobIDs {
+		trackDBOp := metrics.TrackDBOperation("update", "time_jobs")
+		applied, err := apply(jobID, req.SchedulerID)
 		trackDBOp(err)
 		if err != nil {
-			h.logger.Errorf("[GetTimeBasedJobs] Error creating task data: %v", err)
+			h.logger.Errorf("[%s] Error updating lease of job %d: %v", op, jobID, err)
 			c.JSON(http.StatusInternalServerError, gin.H{
-				"error": "Failed to create task data",
-				"code":  "TASK_CREATION_ERROR",
+				"error": "Failed to update time job lease",
+				"code":  "TIME_JOB_LEASE_ERROR",
 			})
-			continue
+			return
+		}
+		if applied {
+			response.Applied = append(response.Applied, jobID)
+		} else {
+			h.logger.Warnf("[%s] Scheduler %s no longer holds the lease on job %d", op, req.SchedulerID, jobID)
+			response.Lost = append(response.Lost, jobID)
 		}
-		tasks[i].TaskID = taskID
 	}
 
-	h.logger.Infof("[GetTimeBasedJobs] Successfully retrieved %d time based jobs", len(tasks))
-	c.JSON(http.StatusOK, tasks)
+	c.JSON(http.StatusOK, response)
+}
+
+func (h *Handler) releaseTimeJob(jobID int64, owner string) {
+	if _, err := h.timeJobRepository.ReleaseTimeJob(jobID, owner); err != nil {
+		h.logger.Errorf("[ClaimTimeBasedTasks] Error releasing job %d: %v", jobID, err)
+	}
 }
@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"bytes"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
@@ -9,184 +10,174 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 	"github.com/trigg3rX/triggerx-backend-imua/internal/dbserver/types"
+	pkgtypes "github.com/trigg3rX/triggerx-backend-imua/pkg/types"
 )
 
 // Mock time.Now() for testing
 var mockTime = time.Date(2025, time.June, 2, 17, 41, 39, 0, time.Local)
 
-func setupTestTimeJobHandler() (*Handler, *MockTimeJobRepository) {
+func setupTestTimeJobHandler() (*Handler, *MockTimeJobRepository, *MockTaskRepository) {
 	mockTimeJobRepo := new(MockTimeJobRepository)
+	mockTaskRepo := new(MockTaskRepository)
 	handler := &Handler{
 		timeJobRepository: mockTimeJobRepo,
+		taskRepository:    mockTaskRepo,
 		logger:            &MockLogger{},
 	}
-	return handler, mockTimeJobRepo
+	return handler, mockTimeJobRepo, mockTaskRepo
 }
 
-func TestGetTimeBasedJobs(t *testing.T) {
-	handler, mockTimeJobRepo := setupTestTimeJobHandler()
-
-	tests := []struct {
-		name          string
-		pollInterval  string
-		setupMocks    func()
-		expectedCode  int
-		expectedError string
-		expectedJobs  []types.TimeJobData
-	}{
-		{
-			name:         "Success - Get Time Based Jobs",
-			pollInterval: "60",
-			setupMocks: func() {
-				nextExecutionTime := mockTime.Add(60 * time.Second)
-				mockTimeJobRepo.On("GetTimeJobsByNextExecutionTimestamp", nextExecutionTime).Return([]types.TimeJobData{
-					{
-						JobID:          1,
-						ExpirationTime: mockTime.Add(60 * time.Second),
-						// Recurring:                 true,
-						TimeInterval:              60,
-						ScheduleType:              "interval",
-						CronExpression:            "",
-						SpecificSchedule:          "",
-						NextExecutionTimestamp:    nextExecutionTime,
-						TargetChainID:             "1",
-						TargetContractAddress:     "0x123",
-						TargetFunction:            "execute",
-						ABI:                       "[]",
-						ArgType:                   1,
-						Arguments:                 []string{"arg1", "arg2"},
-						DynamicArgumentsScriptUrl: "",
-						IsCompleted:               false,
-						IsActive:                  true,
-					},
-					{
-						JobID:          2,
-						ExpirationTime: mockTime.Add(60 * time.Second),
-						// Recurring:                 false,
-						TimeInterval:              120,
-						ScheduleType:              "specific",
-						CronExpression:            "",
-						SpecificSchedule:          "2025-06-02T18:00:00Z",
-						NextExecutionTimestamp:    nextExecutionTime,
-						TargetChainID:             "1",
-						TargetContractAddress:     "0x456",
-						TargetFunction:            "execute",
-						ABI:                       "[]",
-						ArgType:                   1,
-						Arguments:                 []string{"arg3", "arg4"},
-						DynamicArgumentsScriptUrl: "",
-						IsCompleted:               false,
-						IsActive:                  true,
-					},
-				}, nil)
-			},
-			expectedCode: http.StatusOK,
-			expectedJobs: []types.TimeJobData{
-				{
-					JobID:          1,
-					ExpirationTime: mockTime.Add(60 * time.Second),
-					// Recurring:                 true,
-					TimeInterval:              60,
-					ScheduleType:              "interval",
-					CronExpression:            "",
-					SpecificSchedule:          "",
-					NextExecutionTimestamp:    mockTime.Add(60 * time.Second),
-					TargetChainID:             "1",
-					TargetContractAddress:     "0x123",
-					TargetFunction:            "execute",
-					ABI:                       "[]",
-					ArgType:                   1,
-					Arguments:                 []string{"arg1", "arg2"},
-					DynamicArgumentsScriptUrl: "",
-					IsCompleted:               false,
-					IsActive:                  true,
-				},
-				{
-					JobID:          2,
-					ExpirationTime: mockTime.Add(60 * time.Second),
-					// Recurring:                 false,
-					TimeInterval:              120,
-					ScheduleType:              "specific",
-					CronExpression:            "",
-					SpecificSchedule:          "2025-06-02T18:00:00Z",
-					NextExecutionTimestamp:    mockTime.Add(60 * time.Second),
-					TargetChainID:             "1",
-					TargetContractAddress:     "0x456",
-					TargetFunction:            "execute",
-					ABI:                       "[]",
-					ArgType:                   1,
-					Arguments:                 []string{"arg3", "arg4"},
-					DynamicArgumentsScriptUrl: "",
-					IsCompleted:               false,
-					IsActive:                  true,
-				},
-			},
-		},
-		{
-			name:          "Error - Invalid Poll Interval",
-			pollInterval:  "invalid",
-			setupMocks:    func() {},
-			expectedCode:  http.StatusBadRequest,
-			expectedError: "invalid poll interval",
-		},
+func newTimeJobRequest(t *testing.T, body interface{}) (*gin.Context, *httptest.ResponseRecorder) {
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+
+	payload, err := json.Marshal(body)
+	assert.NoError(t, err)
+	c.Request = httptest.NewRequest("POST", "/", bytes.NewBuffer(payload))
+	c.Request.Header.Set("Content-Type", "application/json")
+	return c, w
+}
+
+func TestClaimTimeBasedTasks(t *testing.T) {
+	handler, mockTimeJobRepo, mockTaskRepo := setupTestTimeJobHandler()
+
+	nextExecutionTime := mockTime.Add(60 * time.Second)
+	leaseExpiresAt := mockTime.Add(30 * time.Second)
+	claimedJobs := []pkgtypes.ScheduleTimeTaskData{
 		{
-			name:         "Error - Database Error",
-			pollInterval: "60",
-			setupMocks: func() {
-				nextExecutionTime := mockTime.Add(60 * time.Second)
-				mockTimeJobRepo.On("GetTimeJobsByNextExecutionTimestamp", nextExecutionTime).Return([]types.TimeJobData{}, assert.AnError)
-			},
-			expectedCode:  http.StatusInternalServerError,
-			expectedError: assert.AnError.Error(),
+			TaskDefinitionID:       1,
+			ExpirationTime:         mockTime.Add(time.Hour),
+			NextExecutionTimestamp: nextExecutionTime,
+			ScheduleType:           "interval",
+			TimeInterval:           60,
+			TaskTargetData:         pkgtypes.TaskTargetData{JobID: 1, TaskDefinitionID: 1},
+			LeaseExpiresAt:         leaseExpiresAt,
 		},
 		{
-			name:         "Success - No Jobs Found",
-			pollInterval: "60",
-			setupMocks: func() {
-				nextExecutionTime := mockTime.Add(60 * time.Second)
-				mockTimeJobRepo.On("GetTimeJobsByNextExecutionTimestamp", nextExecutionTime).Return([]types.TimeJobData{}, nil)
-			},
-			expectedCode: http.StatusOK,
-			expectedJobs: []types.TimeJobData{},
+			// Reclaimed after an earlier claim was never acknowledged
+			TaskID:                 77,
+			TaskDefinitionID:       1,
+			ExpirationTime:         mockTime.Add(time.Hour),
+			NextExecutionTimestamp: nextExecutionTime,
+			ScheduleType:           "interval",
+			TimeInterval:           120,
+			TaskTargetData:         pkgtypes.TaskTargetData{JobID: 2, TaskID: 77, TaskDefinitionID: 1},
+			LeaseExpiresAt:         leaseExpiresAt,
 		},
 	}
 
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			// Reset mocks for isolation
-			mockTimeJobRepo.ExpectedCalls = nil
-
-			// Setup
-			gin.SetMode(gin.TestMode)
-			w := httptest.NewRecorder()
-			c, _ := gin.CreateTestContext(w)
-
-			// Setup request
-			c.Request = httptest.NewRequest("GET", "/", nil)
-			q := c.Request.URL.Query()
-			q.Add("pollInterval", tt.pollInterval)
-			c.Request.URL.RawQuery = q.Encode()
-
-			// Setup mocks
-			tt.setupMocks()
-
-			// Execute
-			handler.GetTimeBasedTasks(c)
-
-			// Assert
-			assert.Equal(t, tt.expectedCode, w.Code)
-			if tt.expectedError != "" {
-				var response map[string]string
-				err := json.Unmarshal(w.Body.Bytes(), &response)
-				assert.NoError(t, err)
-				assert.Contains(t, response["error"], tt.expectedError)
-			} else {
-				var response []types.TimeJobData
-				err := json.Unmarshal(w.Body.Bytes(), &response)
-				assert.NoError(t, err)
-				assert.Equal(t, tt.expectedJobs, response)
-			}
-		})
-	}
+	mockTimeJobRepo.On("ClaimTimeJobs", "scheduler-a", mock.AnythingOfType("time.Time"), 30*time.Second).Return(claimedJobs, nil)
+	mockTaskRepo.On("CreateTaskDataInDB", &types.CreateTaskDataRequest{JobID: 1, TaskDefinitionID: 1}).Return(int64(101), nil)
+	mockTimeJobRepo.On("SetTimeJobLeaseTask", int64(1), "scheduler-a", int64(101)).Return(nil)
+
+	c, w := newTimeJobRequest(t, pkgtypes.ClaimTimeJobsRequest{SchedulerID: "scheduler-a", LeaseSeconds: 30})
+	handler.ClaimTimeBasedTasks(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var response []pkgtypes.ScheduleTimeTaskData
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Len(t, response, 2)
+	assert.Equal(t, int64(101), response[0].TaskID)
+	assert.Equal(t, int64(101), response[0].TaskTargetData.TaskID)
+	assert.Equal(t, int64(77), response[1].TaskID)
+
+	// The reclaimed execution keeps its task, no second one is created
+	mockTaskRepo.AssertNumberOfCalls(t, "CreateTaskDataInDB", 1)
+	mockTimeJobRepo.AssertExpectations(t)
+}
+
+func TestClaimTimeBasedTasksErrors(t *testing.T) {
+	handler, mockTimeJobRepo, mockTaskRepo := setupTestTimeJobHandler()
+
+	c, w := newTimeJobRequest(t, pkgtypes.ClaimTimeJobsRequest{})
+	handler.ClaimTimeBasedTasks(c)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	mockTimeJobRepo.On("ClaimTimeJobs", "scheduler-a", mock.AnythingOfType("time.Time"), defaultTimeJobLeaseSeconds*time.Second).
+		Return([]pkgtypes.ScheduleTimeTaskData{}, assert.AnError).Once()
+	c, w = newTimeJobRequest(t, pkgtypes.ClaimTimeJobsRequest{SchedulerID: "scheduler-a"})
+	handler.ClaimTimeBasedTasks(c)
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+
+	// A job whose task cannot be created is released for the next poll
+	mockTimeJobRepo.On("ClaimTimeJobs", "scheduler-a", mock.AnythingOfType("time.Time"), defaultTimeJobLeaseSeconds*time.Second).
+		Return([]pkgtypes.ScheduleTimeTaskData{{TaskDefinitionID: 1, TaskTargetData: pkgtypes.TaskTargetData{JobID: 3}}}, nil).Once()
+	mockTaskRepo.On("CreateTaskDataInDB", &types.CreateTaskDataRequest{JobID: 3, TaskDefinitionID: 1}).Return(int64(0), assert.AnError)
+	mockTimeJobRepo.On("ReleaseTimeJob", int64(3), "scheduler-a").Return(true, nil)
+
+	c, w = newTimeJobRequest(t, pkgtypes.ClaimTimeJobsRequest{SchedulerID: "scheduler-a"})
+	handler.ClaimTimeBasedTasks(c)
+	assert.Equal(t, http.StatusOK, w.Code)
+	var response []pkgtypes.ScheduleTimeTaskData
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Empty(t, response)
+	mockTimeJobRepo.AssertCalled(t, "ReleaseTimeJob", int64(3), "scheduler-a")
+
+	// So is a job whose task cannot be recorded on its lease
+	mockTimeJobRepo.On("ClaimTimeJobs", "scheduler-a", mock.AnythingOfType("time.Time"), defaultTimeJobLeaseSeconds*time.Second).
+		Return([]pkgtypes.ScheduleTimeTaskData{{TaskDefinitionID: 1, TaskTargetData: pkgtypes.TaskTargetData{JobID: 4}}}, nil).Once()
+	mockTaskRepo.On("CreateTaskDataInDB", &types.CreateTaskDataRequest{JobID: 4, TaskDefinitionID: 1}).Return(int64(104), nil)
+	mockTimeJobRepo.On("SetTimeJobLeaseTask", int64(4), "scheduler-a", int64(104)).Return(assert.AnError)
+	mockTimeJobRepo.On("ReleaseTimeJob", int64(4), "scheduler-a").Return(true, nil)
+
+	c, w = newTimeJobRequest(t, pkgtypes.ClaimTimeJobsRequest{SchedulerID: "scheduler-a"})
+	handler.ClaimTimeBasedTasks(c)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Empty(t, response)
+	mockTimeJobRepo.AssertCalled(t, "ReleaseTimeJob", int64(4), "scheduler-a")
+}
+
+func TestClaimTimeBasedTasksCapsLease(t *testing.T) {
+	handler, mockTimeJobRepo, _ := setupTestTimeJobHandler()
+
+	mockTimeJobRepo.On("ClaimTimeJobs", "scheduler-a", mock.AnythingOfType("time.Time"), maxTimeJobLeaseSeconds*time.Second).
+		Return([]pkgtypes.ScheduleTimeTaskData{}, nil).Once()
+	c, w := newTimeJobRequest(t, pkgtypes.ClaimTimeJobsRequest{SchedulerID: "scheduler-a", LeaseSeconds: 1e9})
+	handler.ClaimTimeBasedTasks(c)
+	assert.Equal(t, http.StatusOK, w.Code)
+	mockTimeJobRepo.AssertExpectations(t)
+}
+
+func TestAckTimeBasedTasks(t *testing.T) {
+	handler, mockTimeJobRepo, _ := setupTestTimeJobHandler()
+
+	mockTimeJobRepo.On("AckTimeJob", int64(1), "scheduler-a").Return(true, nil)
+	// Lease expired and was taken over by another scheduler
+	mockTimeJobRepo.On("AckTimeJob", int64(2), "scheduler-a").Return(false, nil)
+
+	c, w := newTimeJobRequest(t, pkgtypes.TimeJobLeaseRequest{SchedulerID: "scheduler-a", JobIDs: []int64{1, 2}})
+	handler.AckTimeBasedTasks(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var response pkgtypes.TimeJobLeaseResponse
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, []int64{1}, response.Applied)
+	assert.Equal(t, []int64{2}, response.Lost)
+
+	mockTimeJobRepo.On("ReleaseTimeJob", int64(3), "scheduler-a").Return(false, assert.AnError)
+	c, w = newTimeJobRequest(t, pkgtypes.TimeJobLeaseRequest{SchedulerID: "scheduler-a", JobIDs: []int64{3}})
+	handler.ReleaseTimeBasedTasks(c)
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+}
+
+func TestAckTimeBasedTasksMarksMisfiredTasks(t *testing.T) {
+	handler, mockTimeJobRepo, mockTaskRepo := setupTestTimeJobHandler()
+
+	mockTaskRepo.On("UpdateTaskNumberAndStatus", int64(55), int64(0), misfiredTaskStatus, "").Return(nil)
+	mockTimeJobRepo.On("AckTimeJob", int64(5), "scheduler-a").Return(true, nil)
+
+	c, w := newTimeJobRequest(t, pkgtypes.TimeJobLeaseRequest{
+		SchedulerID:     "scheduler-a",
+		JobIDs:          []int64{5},
+		MisfiredTaskIDs: []int64{55},
+	})
+	handler.AckTimeBasedTasks(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	mockTaskRepo.AssertExpectations(t)
+	mockTimeJobRepo.AssertExpectations(t)
 }
@@ -0,0 +1,23 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ServiceTokenMiddleware lets through requests of the other TriggerX services,
+// which bear the service token. With no token configured, every request is refused.
+func ServiceTokenMiddleware(token string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		given, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if token == "" || !ok || subtle.ConstantTimeCompare([]byte(given), []byte(token)) != 1 {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Service token is required"})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,34 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServiceTokenMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	request := func(token string, authorization string) int {
+		router := gin.New()
+		router.POST("/api/jobs/time/claim", ServiceTokenMiddleware(token), func(c *gin.Context) {
+			c.Status(http.StatusOK)
+		})
+		req := httptest.NewRequest("POST", "/api/jobs/time/claim", nil)
+		if authorization != "" {
+			req.Header.Set("Authorization", authorization)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	assert.Equal(t, http.StatusOK, request("secret", "Bearer secret"))
+	assert.Equal(t, http.StatusUnauthorized, request("secret", ""))
+	assert.Equal(t, http.StatusUnauthorized, request("secret", "Bearer other"))
+	assert.Equal(t, http.StatusUnauthorized, request("secret", "secret"))
+	// Without a configured token nothing gets through
+	assert.Equal(t, http.StatusUnauthorized, request("", "Bearer "))
+}
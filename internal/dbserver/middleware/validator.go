@@ -62,9 +62,13 @@ func (v *Validator) GinMiddleware() gin.HandlerFunc {
 		// Create a new reader with the body and restore it
 		c.Request.Body = io.NopCloser(bytes.NewBuffer(body))
 
-		// Validate based on the endpoint
+		// Validate based on the endpoint, or the route for paths with parameters
+		route := c.Request.URL.Path
+		if c.FullPath() == "/api/jobs/:id/definition" {
+			route = c.FullPath()
+		}
 		var validationError error
-		switch c.Request.URL.Path {
+		switch route {
 		case "/api/jobs":
 			var jobDataArray []types.CreateJobData
 			if err := c.ShouldBindJSON(&jobDataArray); err != nil {
@@ -86,6 +90,14 @@ func (v *Validator) GinMiddleware() gin.HandlerFunc {
 				validationError = v.validate.Struct(taskData)
 			}
 
+		case "/api/jobs/:id/definition":
+			var definition types.UpdateJobDefinitionRequest
+			if err := c.ShouldBindJSON(&definition); err != nil {
+				validationError = err
+			} else {
+				validationError = v.validate.Struct(definition)
+			}
+
 		case "/api/keepers":
 			var keeperData types.CreateKeeperData
 			if err := c.ShouldBindJSON(&keeperData); err != nil {
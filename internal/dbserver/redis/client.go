@@ -83,6 +83,18 @@ func (c *Client) TTL(ctx context.Context, key string) (time.Duration, error) {
 	return c.client.TTL(ctx, key).Result()
 }
 
+func (c *Client) HSet(ctx context.Context, key string, values ...interface{}) error {
+	return c.client.HSet(ctx, key, values...).Err()
+}
+
+func (c *Client) HDel(ctx context.Context, key string, fields ...string) error {
+	return c.client.HDel(ctx, key, fields...).Err()
+}
+
+func (c *Client) Publish(ctx context.Context, channel string, message interface{}) error {
+	return c.client.Publish(ctx, channel, message).Err()
+}
+
 func (c *Client) Eval(ctx context.Context, script string, keys []string, args ...interface{}) (interface{}, error) {
 	return c.client.Eval(ctx, script, keys, args...).Result()
 }
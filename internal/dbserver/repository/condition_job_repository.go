@@ -14,6 +14,9 @@ type ConditionJobRepository interface {
 	GetConditionJobByJobID(jobID int64) (types.ConditionJobData, error)
 	CompleteConditionJob(jobID int64) error
 	UpdateConditionJobStatus(jobID int64, isActive bool) error
+	UpdateConditionJobArguments(jobID int64, arguments []string, extraTargets []types.TargetCallData) error
+	UpdateConditionJobTarget(jobID int64, target *types.JobTargetDefinition) error
+	UpdateConditionJobCondition(jobID int64, condition *types.JobConditionDefinition) error
 }
 
 type conditionJobRepository struct {
@@ -27,13 +30,20 @@ func NewConditionJobRepository(db *database.Connection) ConditionJobRepository {
 }
 
 func (r *conditionJobRepository) CreateConditionJob(conditionJob *types.ConditionJobData) error {
-	err := r.db.Session().Query(queries.CreateConditionJobDataQuery,
+	extraTargets, err := encodeExtraTargets(conditionJob.ExtraTargets)
+	if err != nil {
+		return err
+	}
+
+	err = r.db.Session().Query(queries.CreateConditionJobDataQuery,
 		conditionJob.JobID, conditionJob.TaskDefinitionID, conditionJob.ExpirationTime, conditionJob.Recurring,
 		conditionJob.ConditionType, conditionJob.UpperLimit, conditionJob.LowerLimit,
-		conditionJob.ValueSourceType, conditionJob.ValueSourceUrl, conditionJob.TargetChainID,
+		conditionJob.ValueSourceType, conditionJob.ValueSourceUrl, conditionJob.ConsecutiveHits,
+		conditionJob.CooldownSeconds, conditionJob.EdgeTriggered, conditionJob.RearmPercent, conditionJob.WindowSeconds,
+		conditionJob.TargetChainID,
 		conditionJob.TargetContractAddress, conditionJob.TargetFunction,
 		conditionJob.ABI, conditionJob.ArgType, conditionJob.Arguments,
-		conditionJob.DynamicArgumentsScriptUrl, conditionJob.IsCompleted, conditionJob.IsActive,
+		conditionJob.DynamicArgumentsScriptUrl, extraTargets, conditionJob.IsCompleted, conditionJob.IsActive,
 		time.Now(), time.Now()).Exec()
 
 	if err != nil {
@@ -45,16 +55,21 @@ func (r *conditionJobRepository) CreateConditionJob(conditionJob *types.Conditio
 
 func (r *conditionJobRepository) GetConditionJobByJobID(jobID int64) (types.ConditionJobData, error) {
 	var conditionJob types.ConditionJobData
+	var extraTargets string
 	err := r.db.Session().Query(queries.GetConditionJobDataByJobIDQuery, jobID).Scan(
 		&conditionJob.JobID, &conditionJob.ExpirationTime, &conditionJob.Recurring, &conditionJob.ConditionType,
 		&conditionJob.UpperLimit, &conditionJob.LowerLimit, &conditionJob.ValueSourceType,
-		&conditionJob.ValueSourceUrl, &conditionJob.TargetChainID, &conditionJob.TargetContractAddress,
+		&conditionJob.ValueSourceUrl, &conditionJob.ConsecutiveHits, &conditionJob.CooldownSeconds,
+		&conditionJob.EdgeTriggered, &conditionJob.RearmPercent, &conditionJob.WindowSeconds, &conditionJob.TargetChainID, &conditionJob.TargetContractAddress,
 		&conditionJob.TargetFunction, &conditionJob.ABI, &conditionJob.ArgType, &conditionJob.Arguments,
-		&conditionJob.DynamicArgumentsScriptUrl, &conditionJob.IsCompleted, &conditionJob.IsActive,
+		&conditionJob.DynamicArgumentsScriptUrl, &extraTargets, &conditionJob.IsCompleted, &conditionJob.IsActive,
 	)
 	if err != nil {
 		return types.ConditionJobData{}, errors.New("failed to get condition job by job ID")
 	}
+	if err := decodeExtraTargets(extraTargets, &conditionJob.ExtraTargets); err != nil {
+		return types.ConditionJobData{}, err
+	}
 
 	return conditionJob, nil
 }
@@ -81,3 +96,45 @@ func (r *conditionJobRepository) UpdateConditionJobStatus(jobID int64, isActive
 
 	return nil
 }
+
+// UpdateConditionJobArguments replaces the arguments of the job's target function and
+// of its extra targets
+func (r *conditionJobRepository) UpdateConditionJobArguments(jobID int64, arguments []string, extraTargets []types.TargetCallData) error {
+	encoded, err := encodeExtraTargets(extraTargets)
+	if err != nil {
+		return err
+	}
+	err = r.db.Session().Query(queries.UpdateConditionJobArgumentsQuery, arguments, encoded, jobID).Exec()
+	if err != nil {
+		return errors.New("failed to update condition job arguments")
+	}
+	return nil
+}
+
+// UpdateConditionJobTarget replaces the target calls of the job in a single write
+func (r *conditionJobRepository) UpdateConditionJobTarget(jobID int64, target *types.JobTargetDefinition) error {
+	extraTargets, err := encodeExtraTargets(target.ExtraTargets)
+	if err != nil {
+		return err
+	}
+
+	err = r.db.Session().Query(queries.UpdateConditionJobTargetQuery,
+		target.TargetChainID, target.TargetContractAddress, target.TargetFunction, target.ABI,
+		target.ArgType, target.Arguments, target.DynamicArgumentsScriptUrl, extraTargets, time.Now(), jobID).Exec()
+	if err != nil {
+		return errors.New("failed to update condition job target")
+	}
+	return nil
+}
+
+// UpdateConditionJobCondition replaces the condition and trigger policy of the job in a single write
+func (r *conditionJobRepository) UpdateConditionJobCondition(jobID int64, condition *types.JobConditionDefinition) error {
+	err := r.db.Session().Query(queries.UpdateConditionJobConditionQuery,
+		condition.ConditionType, condition.UpperLimit, condition.LowerLimit, condition.ValueSourceType,
+		condition.ValueSourceUrl, condition.ConsecutiveHits, condition.CooldownSeconds, condition.EdgeTriggered,
+		condition.RearmPercent, condition.WindowSeconds, time.Now(), jobID).Exec()
+	if err != nil {
+		return errors.New("failed to update condition job condition")
+	}
+	return nil
+}
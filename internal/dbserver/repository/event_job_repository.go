@@ -14,6 +14,8 @@ type EventJobRepository interface {
 	GetEventJobByJobID(jobID int64) (types.EventJobData, error)
 	CompleteEventJob(jobID int64) error
 	UpdateEventJobStatus(jobID int64, isActive bool) error
+	UpdateEventJobArguments(jobID int64, arguments []string, extraTargets []types.TargetCallData) error
+	UpdateEventJobTarget(jobID int64, target *types.JobTargetDefinition) error
 }
 
 type eventJobRepository struct {
@@ -27,11 +29,16 @@ func NewEventJobRepository(db *database.Connection) EventJobRepository {
 }
 
 func (r *eventJobRepository) CreateEventJob(eventJob *types.EventJobData) error {
-	err := r.db.Session().Query(queries.CreateEventJobDataQuery,
+	extraTargets, err := encodeExtraTargets(eventJob.ExtraTargets)
+	if err != nil {
+		return err
+	}
+
+	err = r.db.Session().Query(queries.CreateEventJobDataQuery,
 		eventJob.JobID, eventJob.TaskDefinitionID, eventJob.ExpirationTime, eventJob.Recurring,
 		eventJob.TriggerChainID, eventJob.TriggerContractAddress, eventJob.TriggerEvent,
 		eventJob.TargetChainID, eventJob.TargetContractAddress, eventJob.TargetFunction,
-		eventJob.ABI, eventJob.ArgType, eventJob.Arguments, eventJob.DynamicArgumentsScriptUrl,
+		eventJob.ABI, eventJob.ArgType, eventJob.Arguments, eventJob.DynamicArgumentsScriptUrl, extraTargets,
 		eventJob.IsCompleted, eventJob.IsActive, time.Now(), time.Now()).Exec()
 
 	if err != nil {
@@ -43,15 +50,19 @@ func (r *eventJobRepository) CreateEventJob(eventJob *types.EventJobData) error
 
 func (r *eventJobRepository) GetEventJobByJobID(jobID int64) (types.EventJobData, error) {
 	var eventJob types.EventJobData
+	var extraTargets string
 	err := r.db.Session().Query(queries.GetEventJobDataByJobIDQuery, jobID).Scan(
 		&eventJob.JobID, &eventJob.ExpirationTime, &eventJob.Recurring, &eventJob.TriggerChainID,
 		&eventJob.TriggerContractAddress, &eventJob.TriggerEvent, &eventJob.TargetChainID,
 		&eventJob.TargetContractAddress, &eventJob.TargetFunction, &eventJob.ABI, &eventJob.ArgType,
-		&eventJob.Arguments, &eventJob.DynamicArgumentsScriptUrl, &eventJob.IsCompleted, &eventJob.IsActive,
+		&eventJob.Arguments, &eventJob.DynamicArgumentsScriptUrl, &extraTargets, &eventJob.IsCompleted, &eventJob.IsActive,
 	)
 	if err != nil {
 		return types.EventJobData{}, errors.New("failed to get event job by job ID")
 	}
+	if err := decodeExtraTargets(extraTargets, &eventJob.ExtraTargets); err != nil {
+		return types.EventJobData{}, err
+	}
 
 	return eventJob, nil
 }
@@ -78,3 +89,33 @@ func (r *eventJobRepository) UpdateEventJobStatus(jobID int64, isActive bool) er
 
 	return nil
 }
+
+// UpdateEventJobArguments replaces the arguments of the job's target function and
+// of its extra targets
+func (r *eventJobRepository) UpdateEventJobArguments(jobID int64, arguments []string, extraTargets []types.TargetCallData) error {
+	encoded, err := encodeExtraTargets(extraTargets)
+	if err != nil {
+		return err
+	}
+	err = r.db.Session().Query(queries.UpdateEventJobArgumentsQuery, arguments, encoded, jobID).Exec()
+	if err != nil {
+		return errors.New("failed to update event job arguments")
+	}
+	return nil
+}
+
+// UpdateEventJobTarget replaces the target calls of the job in a single write
+func (r *eventJobRepository) UpdateEventJobTarget(jobID int64, target *types.JobTargetDefinition) error {
+	extraTargets, err := encodeExtraTargets(target.ExtraTargets)
+	if err != nil {
+		return err
+	}
+
+	err = r.db.Session().Query(queries.UpdateEventJobTargetQuery,
+		target.TargetChainID, target.TargetContractAddress, target.TargetFunction, target.ABI,
+		target.ArgType, target.Arguments, target.DynamicArgumentsScriptUrl, extraTargets, time.Now(), jobID).Exec()
+	if err != nil {
+		return errors.New("failed to update event job target")
+	}
+	return nil
+}
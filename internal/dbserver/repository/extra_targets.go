@@ -0,0 +1,32 @@
+package repository
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/trigg3rX/triggerx-backend-imua/internal/dbserver/types"
+)
+
+// Extra targets are stored as a JSON list in a text column of each job table
+
+func encodeExtraTargets(targets []types.TargetCallData) (string, error) {
+	if len(targets) == 0 {
+		return "", nil
+	}
+	encoded, err := json.Marshal(targets)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode extra targets: %v", err)
+	}
+	return string(encoded), nil
+}
+
+// decodeExtraTargets fills targets, a pointer to a slice of calls, from the stored column
+func decodeExtraTargets(raw string, targets interface{}) error {
+	if raw == "" {
+		return nil
+	}
+	if err := json.Unmarshal([]byte(raw), targets); err != nil {
+		return fmt.Errorf("failed to decode extra targets: %v", err)
+	}
+	return nil
+}
@@ -14,6 +14,7 @@ type JobRepository interface {
 	UpdateJobFromUserInDB(job *types.UpdateJobDataFromUserRequest) error
 	UpdateJobLastExecutedAt(jobID int64, taskID int64, jobCostActual float64, lastExecutedAt time.Time) error
 	UpdateJobStatus(jobID int64, status string) error
+	CompareAndSetJobStatus(jobID int64, from string, to string) (bool, error)
 	GetJobByID(jobID int64) (*types.JobData, error)
 	GetTaskDefinitionIDByJobID(jobID int64) (int, error)
 	GetTaskFeesByJobID(jobID int64) ([]types.TaskFeeResponse, error)
@@ -82,6 +83,17 @@ func (r *jobRepository) UpdateJobStatus(jobID int64, status string) error {
 	return nil
 }
 
+// CompareAndSetJobStatus moves a job to status to if it is in status from. It
+// reports false if the job was not, so that of two racing updates only one applies.
+func (r *jobRepository) CompareAndSetJobStatus(jobID int64, from string, to string) (bool, error) {
+	applied, err := r.db.Session().Query(queries.CompareAndSetJobDataStatusQuery,
+		to, time.Now(), jobID, from).MapScanCAS(make(map[string]interface{}))
+	if err != nil {
+		return false, errors.New("failed to compare and set job status")
+	}
+	return applied, nil
+}
+
 func (r *jobRepository) GetJobByID(jobID int64) (*types.JobData, error) {
 	var jobData types.JobData
 	err := r.db.Session().Query(queries.GetJobDataByJobIDQuery, jobID).Scan(
@@ -31,6 +31,12 @@ const (
 			SET status = ?, updated_at = ?
 			WHERE job_id = ?`
 
+	CompareAndSetJobDataStatusQuery = `
+			UPDATE triggerx.job_data
+			SET status = ?, updated_at = ?
+			WHERE job_id = ?
+			IF status = ?`
+
 	UpdateTimeJobIntervalQuery = `
 		UPDATE triggerx.time_job_data
 		SET time_interval = ?
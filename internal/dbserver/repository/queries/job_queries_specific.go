@@ -5,29 +5,31 @@ const (
 	CreateTimeJobDataQuery = `
 			INSERT INTO triggerx.time_job_data (
 				job_id, task_definition_id, expiration_time, next_execution_timestamp, schedule_type,
-				time_interval, cron_expression, specific_schedule, timezone, target_chain_id, 
+				time_interval, cron_expression, specific_schedule, timezone,
+				misfire_policy, misfire_max_catchup, max_lateness_seconds, target_chain_id, 
 				target_contract_address, target_function, abi, arg_type, arguments, 
-				dynamic_arguments_script_url, is_completed, is_active, created_at, updated_at
-			) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
-	// 20 values to be inserted, so 20 ?s
+				dynamic_arguments_script_url, extra_targets, is_completed, is_active, created_at, updated_at
+			) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+	// 24 values to be inserted, so 24 ?s
 
 	CreateEventJobDataQuery = `
 			INSERT INTO triggerx.event_job_data (
 				job_id, task_definition_id, expiration_time, recurring, trigger_chain_id, trigger_contract_address, 
 				trigger_event, target_chain_id, target_contract_address, target_function,
-				abi, arg_type, arguments, dynamic_arguments_script_url, is_completed, is_active,
+				abi, arg_type, arguments, dynamic_arguments_script_url, extra_targets, is_completed, is_active,
 				created_at, updated_at
-			) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ? )`
-	// 18 values to be inserted, so 18 ?s
+			) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ? )`
+	// 19 values to be inserted, so 19 ?s
 
 	CreateConditionJobDataQuery = `
 			INSERT INTO triggerx.condition_job_data (
 				job_id, task_definition_id, expiration_time, recurring, condition_type, upper_limit, lower_limit, 
-				value_source_type, value_source_url, target_chain_id, target_contract_address, 
-				target_function, abi, arg_type, arguments, dynamic_arguments_script_url,
+				value_source_type, value_source_url, consecutive_hits, cooldown_seconds, edge_triggered,
+				rearm_percent, window_seconds, target_chain_id, target_contract_address, 
+				target_function, abi, arg_type, arguments, dynamic_arguments_script_url, extra_targets,
 				is_completed, is_active, created_at, updated_at
-			) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ? )`
-	// 20 values to be inserted, so 20 ?s
+			) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ? )`
+	// 26 values to be inserted, so 26 ?s
 )
 
 // Write Queries
@@ -62,6 +64,46 @@ const (
 			SET is_active = ?
 			WHERE job_id = ?`
 
+	UpdateTimeJobArgumentsQuery = `
+			UPDATE triggerx.time_job_data
+			SET arguments = ?, extra_targets = ?
+			WHERE job_id = ?`
+
+	UpdateEventJobArgumentsQuery = `
+			UPDATE triggerx.event_job_data
+			SET arguments = ?, extra_targets = ?
+			WHERE job_id = ?`
+
+	UpdateConditionJobArgumentsQuery = `
+			UPDATE triggerx.condition_job_data
+			SET arguments = ?, extra_targets = ?
+			WHERE job_id = ?`
+
+	UpdateTimeJobTargetQuery = `
+			UPDATE triggerx.time_job_data
+			SET target_chain_id = ?, target_contract_address = ?, target_function = ?, abi = ?,
+				arg_type = ?, arguments = ?, dynamic_arguments_script_url = ?, extra_targets = ?, updated_at = ?
+			WHERE job_id = ?`
+
+	UpdateEventJobTargetQuery = `
+			UPDATE triggerx.event_job_data
+			SET target_chain_id = ?, target_contract_address = ?, target_function = ?, abi = ?,
+				arg_type = ?, arguments = ?, dynamic_arguments_script_url = ?, extra_targets = ?, updated_at = ?
+			WHERE job_id = ?`
+
+	UpdateConditionJobTargetQuery = `
+			UPDATE triggerx.condition_job_data
+			SET target_chain_id = ?, target_contract_address = ?, target_function = ?, abi = ?,
+				arg_type = ?, arguments = ?, dynamic_arguments_script_url = ?, extra_targets = ?, updated_at = ?
+			WHERE job_id = ?`
+
+	UpdateConditionJobConditionQuery = `
+			UPDATE triggerx.condition_job_data
+			SET condition_type = ?, upper_limit = ?, lower_limit = ?, value_source_type = ?, value_source_url = ?,
+				consecutive_hits = ?, cooldown_seconds = ?, edge_triggered = ?, rearm_percent = ?, window_seconds = ?,
+				updated_at = ?
+			WHERE job_id = ?`
+
 	UpdateTimeJobNextExecutionTimestampQuery = `
 			UPDATE triggerx.time_job_data
 			SET next_execution_timestamp = ?
@@ -71,6 +113,37 @@ const (
 			UPDATE triggerx.job_data 
 			SET status = 'completed'
 			WHERE job_id = ?`
+
+	// Time job leases are lightweight transactions, so only one scheduler holds a job at a time
+	ClaimTimeJobLeaseQuery = `
+			UPDATE triggerx.time_job_data
+			SET lease_owner = ?, lease_expires_at = ?
+			WHERE job_id = ?
+			IF lease_expires_at = ?`
+
+	SetTimeJobLeaseTaskQuery = `
+			UPDATE triggerx.time_job_data
+			SET lease_task_id = ?
+			WHERE job_id = ?
+			IF lease_owner = ?`
+
+	AckTimeJobLeaseQuery = `
+			UPDATE triggerx.time_job_data
+			SET next_execution_timestamp = ?, lease_owner = null, lease_expires_at = null, lease_task_id = null
+			WHERE job_id = ?
+			IF lease_owner = ?`
+
+	AckFinalTimeJobLeaseQuery = `
+			UPDATE triggerx.time_job_data
+			SET is_completed = true, is_active = false, lease_owner = null, lease_expires_at = null, lease_task_id = null
+			WHERE job_id = ?
+			IF lease_owner = ?`
+
+	ReleaseTimeJobLeaseQuery = `
+			UPDATE triggerx.time_job_data
+			SET lease_owner = null, lease_expires_at = null
+			WHERE job_id = ?
+			IF lease_owner = ?`
 )
 
 // Read Queries
@@ -79,8 +152,9 @@ const (
 			SELECT job_id, expiration_time, 
 				next_execution_timestamp, schedule_type,
 				time_interval, cron_expression, specific_schedule, 
-				timezone, target_chain_id, target_contract_address, target_function, 
-				abi, arg_type, arguments, dynamic_arguments_script_url,
+				timezone, misfire_policy, misfire_max_catchup, max_lateness_seconds,
+				target_chain_id, target_contract_address, target_function, 
+				abi, arg_type, arguments, dynamic_arguments_script_url, extra_targets,
 				is_completed, is_active
 			FROM triggerx.time_job_data
 			WHERE job_id = ?`
@@ -89,7 +163,7 @@ const (
 			SELECT job_id, expiration_time, recurring,
 				trigger_chain_id, trigger_contract_address, trigger_event,
 				target_chain_id, target_contract_address, target_function,
-				abi, arg_type, arguments, dynamic_arguments_script_url,
+				abi, arg_type, arguments, dynamic_arguments_script_url, extra_targets,
 				is_completed, is_active
 			FROM triggerx.event_job_data
 			WHERE job_id = ?`
@@ -98,18 +172,21 @@ const (
 			SELECT job_id, expiration_time, recurring,
 				condition_type, upper_limit, lower_limit,
 				value_source_type, value_source_url,
+				consecutive_hits, cooldown_seconds, edge_triggered, rearm_percent, window_seconds,
 				target_chain_id, target_contract_address, target_function,
-				abi, arg_type, arguments, dynamic_arguments_script_url,
+				abi, arg_type, arguments, dynamic_arguments_script_url, extra_targets,
 				is_completed, is_active
 			FROM triggerx.condition_job_data
 			WHERE job_id = ?`
 
-	GetTimeJobsByNextExecutionTimestampQuery = `
+	GetDueTimeJobsQuery = `
 			SELECT job_id, last_executed_at, expiration_time, time_interval,
 				schedule_type, cron_expression, specific_schedule, next_execution_timestamp,
 				target_chain_id, target_contract_address, target_function, 
-				abi, arg_type, arguments, dynamic_arguments_script_url
+				abi, arg_type, arguments, dynamic_arguments_script_url, extra_targets,
+				misfire_policy, misfire_max_catchup, max_lateness_seconds,
+				lease_owner, lease_expires_at, lease_task_id
 			FROM triggerx.time_job_data
-			WHERE next_execution_timestamp >= ? AND next_execution_timestamp <= ? AND is_active = true
+			WHERE next_execution_timestamp <= ? AND is_active = true
 			ALLOW FILTERING`
 )
@@ -0,0 +1,26 @@
+package queries
+
+// Write Queries
+const (
+	// Record the first settled execution of a script
+	CreateScriptCostQuery = `
+			INSERT INTO triggerx.script_cost_data (
+				script_cid, executions, total_cost, last_cost, updated_at
+			) VALUES (?, 1, ?, ?, ?)
+			IF NOT EXISTS`
+
+	// Add a settled execution, if no other was added since the row was read
+	UpdateScriptCostQuery = `
+			UPDATE triggerx.script_cost_data 
+			SET executions = ?, total_cost = ?, last_cost = ?, updated_at = ?
+			WHERE script_cid = ?
+			IF executions = ?`
+)
+
+// Read Queries
+const (
+	GetScriptCostQuery = `
+			SELECT script_cid, executions, total_cost, last_cost, updated_at
+			FROM triggerx.script_cost_data 
+			WHERE script_cid = ?`
+)
@@ -20,7 +20,12 @@ const (
 
 	UpdateTaskExecutionDataQuery = `
 		UPDATE triggerx.task_data
-		SET task_performer_id = ?, execution_timestamp = ?, execution_tx_hash = ?, proof_of_task = ?, task_opx_cost = ?
+		SET task_performer_id = ?, execution_timestamp = ?, execution_tx_hash = ?, proof_of_task = ?, task_opx_cost = ?, is_successful = ?
+		WHERE task_id = ?`
+
+	UpdateTaskStatusQuery = `
+		UPDATE triggerx.task_data
+		SET task_status = ?
 		WHERE task_id = ?`
 
 	UpdateTaskAttestationDataQuery = `
@@ -28,6 +33,18 @@ const (
 		SET task_number = ?, task_attester_ids = ?, tp_signature = ?, ta_signature = ?, task_submission_tx_hash = ?, is_successful = ?
 		WHERE task_id = ?`
 
+	// Mark the cost of a task as charged, unless it already is
+	MarkTaskChargedQuery = `
+		INSERT INTO triggerx.task_charge_data (
+			task_id, cost, charged_at
+		) VALUES (?, ?, ?)
+		IF NOT EXISTS`
+
+	UnmarkTaskChargedQuery = `
+		DELETE FROM triggerx.task_charge_data
+		WHERE task_id = ?
+		IF EXISTS`
+
 	UpdateTaskFeeQuery = `
 		UPDATE triggerx.task_data
 		SET task_fee = ?
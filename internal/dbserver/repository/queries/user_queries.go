@@ -32,6 +32,13 @@ const (
 			SET total_tasks = ?, user_points = ?
 			WHERE user_id = ?`
 
+	// Debit the user's token balance on task execution, if it did not change since it was read
+	DebitUserTokenBalanceQuery = `
+			UPDATE triggerx.user_data 
+			SET token_balance = ?
+			WHERE user_id = ?
+			IF token_balance = ?`
+
 	UpdateUserEmailByAddressQuery = `
 		UPDATE triggerx.user_data
 		SET email_id = ?
@@ -59,6 +66,17 @@ const (
 			FROM triggerx.user_data 
 			WHERE user_id = ?`
 
+	// Get User Balance by ID for Budget Checks before and after Task Execution
+	GetUserBalanceByIDQuery = `
+			SELECT user_id, ether_balance, token_balance, email_id
+			FROM triggerx.user_data 
+			WHERE user_id = ?`
+
+	GetUserTokenBalanceByIDQuery = `
+			SELECT token_balance
+			FROM triggerx.user_data 
+			WHERE user_id = ?`
+
 	// Get User Points by ID for Update after Task Execution
 	GetUserPointsByIDQuery = `
 			SELECT user_points 
@@ -0,0 +1,72 @@
+package repository
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/gocql/gocql"
+	"github.com/trigg3rX/triggerx-backend-imua/internal/dbserver/repository/queries"
+	"github.com/trigg3rX/triggerx-backend-imua/internal/dbserver/types"
+	"github.com/trigg3rX/triggerx-backend-imua/pkg/database"
+)
+
+type ScriptCostRepository interface {
+	GetScriptCost(scriptCID string) (types.ScriptCostData, error)
+	RecordScriptCost(scriptCID string, cost float64) error
+}
+
+// Attempts at adding an execution to a script that other settlements keep updating
+const maxScriptCostAttempts = 5
+
+type scriptCostRepository struct {
+	db *database.Connection
+}
+
+func NewScriptCostRepository(db *database.Connection) ScriptCostRepository {
+	return &scriptCostRepository{
+		db: db,
+	}
+}
+
+// GetScriptCost returns the cost history of a script, with no executions if it has none
+func (r *scriptCostRepository) GetScriptCost(scriptCID string) (types.ScriptCostData, error) {
+	var scriptCost types.ScriptCostData
+	err := r.db.Session().Query(queries.GetScriptCostQuery, scriptCID).Scan(
+		&scriptCost.ScriptCID, &scriptCost.Executions, &scriptCost.TotalCost,
+		&scriptCost.LastCost, &scriptCost.UpdatedAt)
+	if err == gocql.ErrNotFound {
+		return types.ScriptCostData{ScriptCID: scriptCID}, nil
+	}
+	if err != nil {
+		return types.ScriptCostData{}, err
+	}
+	return scriptCost, nil
+}
+
+// RecordScriptCost adds the cost of one execution to the script's history. Writes
+// are conditional on the execution count read, so concurrent settlements all count.
+func (r *scriptCostRepository) RecordScriptCost(scriptCID string, cost float64) error {
+	for attempt := 0; attempt < maxScriptCostAttempts; attempt++ {
+		current, err := r.GetScriptCost(scriptCID)
+		if err != nil {
+			return err
+		}
+
+		var applied bool
+		if current.Executions == 0 {
+			applied, err = r.db.Session().Query(queries.CreateScriptCostQuery,
+				scriptCID, cost, cost, time.Now()).MapScanCAS(make(map[string]interface{}))
+		} else {
+			applied, err = r.db.Session().Query(queries.UpdateScriptCostQuery,
+				current.Executions+1, current.TotalCost+cost, cost, time.Now(),
+				scriptCID, current.Executions).MapScanCAS(make(map[string]interface{}))
+		}
+		if err != nil {
+			return fmt.Errorf("failed to record cost of script %s: %v", scriptCID, err)
+		}
+		if applied {
+			return nil
+		}
+	}
+	return fmt.Errorf("cost of script %s kept changing, gave up after %d attempts", scriptCID, maxScriptCostAttempts)
+}
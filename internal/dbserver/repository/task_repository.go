@@ -15,10 +15,13 @@ type TaskRepository interface {
 	UpdateTaskExecutionDataInDB(task *types.UpdateTaskExecutionDataRequest) error
 	UpdateTaskAttestationDataInDB(task *types.UpdateTaskAttestationDataRequest) error
 	UpdateTaskNumberAndStatus(taskID int64, taskNumber int64, status string, txHash string) error
+	UpdateTaskStatus(taskID int64, status string) error
 	GetTaskDataByID(taskID int64) (types.TaskData, error)
 	GetTasksByJobID(jobID int64) ([]types.TasksByJobIDResponse, error)
 	UpdateTaskFee(taskID int64, fee float64) error
 	GetTaskFee(taskID int64) (float64, error)
+	MarkTaskCharged(taskID int64, cost float64) (bool, error)
+	UnmarkTaskCharged(taskID int64) error
 }
 
 type taskRepository struct {
@@ -53,10 +56,33 @@ func (r *taskRepository) AddTaskPerformerID(taskID int64, performerID int64) err
 }
 
 func (r *taskRepository) UpdateTaskExecutionDataInDB(task *types.UpdateTaskExecutionDataRequest) error {
-	err := r.db.Session().Query(queries.UpdateTaskExecutionDataQuery, task.TaskPerformerID, task.ExecutionTimestamp, task.ExecutionTxHash, task.ProofOfTask, task.TaskOpXCost, task.TaskID).Exec()
+	err := r.db.Session().Query(queries.UpdateTaskExecutionDataQuery, task.TaskPerformerID, task.ExecutionTimestamp, task.ExecutionTxHash, task.ProofOfTask, task.TaskOpXCost, task.IsSuccessful, task.TaskID).Exec()
 	if err != nil {
 		return errors.New("error updating task execution data")
 	}
+	if task.IsSkipped {
+		if err := r.db.Session().Query(queries.UpdateTaskStatusQuery, types.TaskStatusSkipped, task.TaskID).Exec(); err != nil {
+			return errors.New("error updating task status")
+		}
+	}
+	return nil
+}
+
+// MarkTaskCharged records that the cost of a task was charged. It reports false
+// if the task already was, so that it is charged only once.
+func (r *taskRepository) MarkTaskCharged(taskID int64, cost float64) (bool, error) {
+	applied, err := r.db.Session().Query(queries.MarkTaskChargedQuery, taskID, cost, time.Now()).MapScanCAS(make(map[string]interface{}))
+	if err != nil {
+		return false, errors.New("error marking task as charged")
+	}
+	return applied, nil
+}
+
+// UnmarkTaskCharged lets a task whose charge failed be charged again
+func (r *taskRepository) UnmarkTaskCharged(taskID int64) error {
+	if _, err := r.db.Session().Query(queries.UnmarkTaskChargedQuery, taskID).MapScanCAS(make(map[string]interface{})); err != nil {
+		return errors.New("error unmarking task as charged")
+	}
 	return nil
 }
 
@@ -76,6 +102,13 @@ func (r *taskRepository) UpdateTaskNumberAndStatus(taskID int64, taskNumber int6
 	return nil
 }
 
+func (r *taskRepository) UpdateTaskStatus(taskID int64, status string) error {
+	if err := r.db.Session().Query(queries.UpdateTaskStatusQuery, status, taskID).Exec(); err != nil {
+		return errors.New("error updating task status")
+	}
+	return nil
+}
+
 func (r *taskRepository) GetTaskDataByID(taskID int64) (types.TaskData, error) {
 	var task types.TaskData
 	err := r.db.Session().Query(queries.GetTaskDataByIDQuery, taskID).Scan(&task.TaskID, &task.TaskNumber, &task.JobID, &task.TaskDefinitionID, &task.CreatedAt, &task.TaskOpXCost, &task.ExecutionTimestamp, &task.ExecutionTxHash, &task.TaskPerformerID, &task.ProofOfTask, &task.TaskAttesterIDs, &task.TpSignature, &task.TaSignature, &task.TaskSubmissionTxHash, &task.IsSuccessful, &task.TaskStatus, &task.IsImua)
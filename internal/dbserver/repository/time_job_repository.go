@@ -17,9 +17,14 @@ type TimeJobRepository interface {
 	GetTimeJobByJobID(jobID int64) (types.TimeJobData, error)
 	CompleteTimeJob(jobID int64) error
 	UpdateTimeJobStatus(jobID int64, isActive bool) error
-	GetTimeJobsByNextExecutionTimestamp(lookAheadTime time.Time) ([]commonTypes.ScheduleTimeTaskData, error)
+	ClaimTimeJobs(owner string, lookAheadTime time.Time, leaseTTL time.Duration) ([]commonTypes.ScheduleTimeTaskData, error)
+	SetTimeJobLeaseTask(jobID int64, owner string, taskID int64) error
+	AckTimeJob(jobID int64, owner string) (bool, error)
+	ReleaseTimeJob(jobID int64, owner string) (bool, error)
 	UpdateTimeJobNextExecutionTimestamp(jobID int64, nextExecutionTimestamp time.Time) error
 	UpdateTimeJobInterval(jobID int64, timeInterval int64) error
+	UpdateTimeJobArguments(jobID int64, arguments []string, extraTargets []types.TargetCallData) error
+	UpdateTimeJobTarget(jobID int64, target *types.JobTargetDefinition) error
 }
 
 type timeJobRepository struct {
@@ -33,11 +38,16 @@ func NewTimeJobRepository(db *database.Connection) TimeJobRepository {
 }
 
 func (r *timeJobRepository) CreateTimeJob(timeJob *types.TimeJobData) error {
-	err := r.db.Session().Query(queries.CreateTimeJobDataQuery,
+	extraTargets, err := encodeExtraTargets(timeJob.ExtraTargets)
+	if err != nil {
+		return err
+	}
+
+	err = r.db.Session().Query(queries.CreateTimeJobDataQuery,
 		timeJob.JobID, timeJob.TaskDefinitionID, timeJob.ExpirationTime, timeJob.NextExecutionTimestamp,
 		timeJob.ScheduleType, timeJob.TimeInterval, timeJob.CronExpression, timeJob.SpecificSchedule,
-		timeJob.Timezone, timeJob.TargetChainID, timeJob.TargetContractAddress, timeJob.TargetFunction,
-		timeJob.ABI, timeJob.ArgType, timeJob.Arguments, timeJob.DynamicArgumentsScriptUrl,
+		timeJob.Timezone, timeJob.MisfirePolicy, timeJob.MisfireMaxCatchup, timeJob.MaxLatenessSeconds, timeJob.TargetChainID, timeJob.TargetContractAddress, timeJob.TargetFunction,
+		timeJob.ABI, timeJob.ArgType, timeJob.Arguments, timeJob.DynamicArgumentsScriptUrl, extraTargets,
 		timeJob.IsCompleted, timeJob.IsActive, time.Now(), time.Now()).Exec()
 
 	if err != nil {
@@ -49,15 +59,20 @@ func (r *timeJobRepository) CreateTimeJob(timeJob *types.TimeJobData) error {
 
 func (r *timeJobRepository) GetTimeJobByJobID(jobID int64) (types.TimeJobData, error) {
 	var timeJob types.TimeJobData
+	var extraTargets string
 	err := r.db.Session().Query(queries.GetTimeJobDataByJobIDQuery, jobID).Scan(
 		&timeJob.JobID, &timeJob.ExpirationTime, &timeJob.NextExecutionTimestamp,
 		&timeJob.ScheduleType, &timeJob.TimeInterval, &timeJob.CronExpression,
-		&timeJob.SpecificSchedule, &timeJob.Timezone, &timeJob.TargetChainID,
+		&timeJob.SpecificSchedule, &timeJob.Timezone,
+		&timeJob.MisfirePolicy, &timeJob.MisfireMaxCatchup, &timeJob.MaxLatenessSeconds, &timeJob.TargetChainID,
 		&timeJob.TargetContractAddress, &timeJob.TargetFunction, &timeJob.ABI, &timeJob.ArgType,
-		&timeJob.Arguments, &timeJob.DynamicArgumentsScriptUrl, &timeJob.IsCompleted, &timeJob.IsActive)
+		&timeJob.Arguments, &timeJob.DynamicArgumentsScriptUrl, &extraTargets, &timeJob.IsCompleted, &timeJob.IsActive)
 	if err != nil {
 		return types.TimeJobData{}, fmt.Errorf("failed to get time job by job ID: %v", err)
 	}
+	if err := decodeExtraTargets(extraTargets, &timeJob.ExtraTargets); err != nil {
+		return types.TimeJobData{}, err
+	}
 
 	return timeJob, nil
 }
@@ -85,19 +100,48 @@ func (r *timeJobRepository) UpdateTimeJobStatus(jobID int64, isActive bool) erro
 	return nil
 }
 
-func (r *timeJobRepository) GetTimeJobsByNextExecutionTimestamp(lookAheadTime time.Time) ([]commonTypes.ScheduleTimeTaskData, error) {
-	currentTime := time.Now()
-	iter := r.db.Session().Query(queries.GetTimeJobsByNextExecutionTimestampQuery, currentTime, lookAheadTime).Iter()
+// ClaimTimeJobs leases every active job due before lookAheadTime to owner. Jobs
+// leased to another scheduler are skipped until that lease expires. Nothing is
+// advanced here; the schedule only moves on AckTimeJob.
+func (r *timeJobRepository) ClaimTimeJobs(owner string, lookAheadTime time.Time, leaseTTL time.Duration) ([]commonTypes.ScheduleTimeTaskData, error) {
+	now := time.Now()
+	leaseExpiresAt := now.Add(leaseTTL)
+	iter := r.db.Session().Query(queries.GetDueTimeJobsQuery, lookAheadTime).Iter()
 
 	var timeJobs []commonTypes.ScheduleTimeTaskData
 	var timeJob commonTypes.ScheduleTimeTaskData
+	var leaseOwner string
+	var currentLeaseExpiresAt time.Time
+	var leaseTaskID int64
+	var extraTargets string
 
 	for iter.Scan(
 		&timeJob.TaskTargetData.JobID, &timeJob.LastExecutedAt, &timeJob.ExpirationTime, &timeJob.TimeInterval,
 		&timeJob.ScheduleType, &timeJob.CronExpression, &timeJob.SpecificSchedule, &timeJob.NextExecutionTimestamp,
 		&timeJob.TaskTargetData.TargetChainID, &timeJob.TaskTargetData.TargetContractAddress, &timeJob.TaskTargetData.TargetFunction, &timeJob.TaskTargetData.ABI, &timeJob.TaskTargetData.ArgType,
-		&timeJob.TaskTargetData.Arguments, &timeJob.TaskTargetData.DynamicArgumentsScriptUrl,
+		&timeJob.TaskTargetData.Arguments, &timeJob.TaskTargetData.DynamicArgumentsScriptUrl, &extraTargets,
+		&timeJob.MisfirePolicy, &timeJob.MisfireMaxCatchup, &timeJob.MaxLatenessSeconds,
+		&leaseOwner, &currentLeaseExpiresAt, &leaseTaskID,
 	) {
+		if leaseOwner != "" && leaseOwner != owner && currentLeaseExpiresAt.After(now) {
+			continue
+		}
+
+		// Compare against the lease we read, so two schedulers racing for the same job cannot both win
+		var previousLease interface{}
+		if !currentLeaseExpiresAt.IsZero() {
+			previousLease = currentLeaseExpiresAt
+		}
+		applied, err := r.db.Session().Query(queries.ClaimTimeJobLeaseQuery,
+			owner, leaseExpiresAt, timeJob.TaskTargetData.JobID, previousLease).MapScanCAS(make(map[string]interface{}))
+		if err != nil {
+			_ = iter.Close()
+			return nil, fmt.Errorf("failed to claim time job %d: %v", timeJob.TaskTargetData.JobID, err)
+		}
+		if !applied {
+			continue
+		}
+
 		if timeJob.TaskTargetData.DynamicArgumentsScriptUrl != "" {
 			timeJob.TaskDefinitionID = 2
 			timeJob.TaskTargetData.TaskDefinitionID = 2
@@ -105,29 +149,15 @@ func (r *timeJobRepository) GetTimeJobsByNextExecutionTimestamp(lookAheadTime ti
 			timeJob.TaskDefinitionID = 1
 			timeJob.TaskTargetData.TaskDefinitionID = 1
 		}
-
-		// Calculate next execution time after the current execution time
-		nextExecutionTime, err := parser.CalculateNextExecutionTime(timeJob.NextExecutionTimestamp, timeJob.ScheduleType, timeJob.TimeInterval, timeJob.CronExpression, timeJob.SpecificSchedule)
-		if err != nil {
-			return nil, err
-		}
-
-		// If the next execution time is after the expiration time, That means the job will be completed after current execution time that is being passed
-		if nextExecutionTime.After(timeJob.ExpirationTime) {
-			err = r.CompleteTimeJob(timeJob.TaskTargetData.JobID)
-			if err != nil {
-				return nil, err
-			}
-			err = r.UpdateTimeJobStatus(timeJob.TaskTargetData.JobID, false)
-			if err != nil {
-				return nil, err
-			}
-		} else {
-			err = r.UpdateTimeJobNextExecutionTimestamp(timeJob.TaskTargetData.JobID, nextExecutionTime)
-			if err != nil {
-				return nil, err
-			}
+		timeJob.TaskTargetData.ExtraTargets = nil
+		if err := decodeExtraTargets(extraTargets, &timeJob.TaskTargetData.ExtraTargets); err != nil {
+			_ = iter.Close()
+			return nil, fmt.Errorf("time job %d: %v", timeJob.TaskTargetData.JobID, err)
 		}
+		// A task created by an earlier, unacknowledged claim is for this same execution
+		timeJob.TaskID = leaseTaskID
+		timeJob.TaskTargetData.TaskID = leaseTaskID
+		timeJob.LeaseExpiresAt = leaseExpiresAt
 
 		timeJobs = append(timeJobs, timeJob)
 	}
@@ -138,6 +168,67 @@ func (r *timeJobRepository) GetTimeJobsByNextExecutionTimestamp(lookAheadTime ti
 	return timeJobs, nil
 }
 
+// SetTimeJobLeaseTask records the task created for the claimed execution
+func (r *timeJobRepository) SetTimeJobLeaseTask(jobID int64, owner string, taskID int64) error {
+	applied, err := r.db.Session().Query(queries.SetTimeJobLeaseTaskQuery, taskID, jobID, owner).MapScanCAS(make(map[string]interface{}))
+	if err != nil {
+		return fmt.Errorf("failed to set lease task for time job %d: %v", jobID, err)
+	}
+	if !applied {
+		return fmt.Errorf("lease on time job %d is no longer held by %s", jobID, owner)
+	}
+	return nil
+}
+
+// AckTimeJob advances the job to its next execution and clears the lease, or
+// completes the job when no execution is left before its expiration. Executions
+// missed in the meantime are resolved by the job's misfire policy. It returns
+// false if owner no longer holds the lease.
+func (r *timeJobRepository) AckTimeJob(jobID int64, owner string) (bool, error) {
+	timeJob, err := r.GetTimeJobByJobID(jobID)
+	if err != nil {
+		return false, err
+	}
+
+	nextExecutionTime, err := parser.CalculateNextExecutionAfterAck(timeJob.NextExecutionTimestamp, time.Now(),
+		timeJob.ScheduleType, timeJob.TimeInterval, timeJob.CronExpression, timeJob.SpecificSchedule,
+		timeJob.MisfirePolicy, timeJob.MisfireMaxCatchup, parser.MaxLateness(timeJob.MaxLatenessSeconds))
+	if err != nil {
+		return false, err
+	}
+
+	// If the next execution time is after the expiration time, the acknowledged execution was the last one
+	if nextExecutionTime.After(timeJob.ExpirationTime) {
+		applied, err := r.db.Session().Query(queries.AckFinalTimeJobLeaseQuery, jobID, owner).MapScanCAS(make(map[string]interface{}))
+		if err != nil {
+			return false, fmt.Errorf("failed to acknowledge time job %d: %v", jobID, err)
+		}
+		if !applied {
+			return false, nil
+		}
+		if err := r.db.Session().Query(queries.UpdateJobDataToCompletedQuery, jobID).Exec(); err != nil {
+			return true, errors.New("failed to update job_data status to completed")
+		}
+		return true, nil
+	}
+
+	applied, err := r.db.Session().Query(queries.AckTimeJobLeaseQuery, nextExecutionTime, jobID, owner).MapScanCAS(make(map[string]interface{}))
+	if err != nil {
+		return false, fmt.Errorf("failed to acknowledge time job %d: %v", jobID, err)
+	}
+	return applied, nil
+}
+
+// ReleaseTimeJob gives the lease back without advancing the schedule, so the
+// execution is claimed again on the next poll
+func (r *timeJobRepository) ReleaseTimeJob(jobID int64, owner string) (bool, error) {
+	applied, err := r.db.Session().Query(queries.ReleaseTimeJobLeaseQuery, jobID, owner).MapScanCAS(make(map[string]interface{}))
+	if err != nil {
+		return false, fmt.Errorf("failed to release time job %d: %v", jobID, err)
+	}
+	return applied, nil
+}
+
 func (r *timeJobRepository) UpdateTimeJobNextExecutionTimestamp(jobID int64, nextExecutionTimestamp time.Time) error {
 	err := r.db.Session().Query(queries.UpdateTimeJobNextExecutionTimestampQuery, nextExecutionTimestamp, jobID).Exec()
 	if err != nil {
@@ -154,3 +245,33 @@ func (r *timeJobRepository) UpdateTimeJobInterval(jobID int64, timeInterval int6
 	}
 	return nil
 }
+
+// UpdateTimeJobArguments replaces the arguments of the job's target function and
+// of its extra targets
+func (r *timeJobRepository) UpdateTimeJobArguments(jobID int64, arguments []string, extraTargets []types.TargetCallData) error {
+	encoded, err := encodeExtraTargets(extraTargets)
+	if err != nil {
+		return err
+	}
+	err = r.db.Session().Query(queries.UpdateTimeJobArgumentsQuery, arguments, encoded, jobID).Exec()
+	if err != nil {
+		return fmt.Errorf("failed to update time job arguments: %v", err)
+	}
+	return nil
+}
+
+// UpdateTimeJobTarget replaces the target calls of the job in a single write
+func (r *timeJobRepository) UpdateTimeJobTarget(jobID int64, target *types.JobTargetDefinition) error {
+	extraTargets, err := encodeExtraTargets(target.ExtraTargets)
+	if err != nil {
+		return err
+	}
+
+	err = r.db.Session().Query(queries.UpdateTimeJobTargetQuery,
+		target.TargetChainID, target.TargetContractAddress, target.TargetFunction, target.ABI,
+		target.ArgType, target.Arguments, target.DynamicArgumentsScriptUrl, extraTargets, time.Now(), jobID).Exec()
+	if err != nil {
+		return fmt.Errorf("failed to update time job target: %v", err)
+	}
+	return nil
+}
@@ -2,6 +2,8 @@ package repository
 
 import (
 	"errors"
+	"fmt"
+	"math/big"
 	"sort"
 	"time"
 
@@ -24,8 +26,13 @@ type UserRepository interface {
 	GetUserLeaderboard() ([]types.UserLeaderboardEntry, error)
 	GetUserLeaderboardByAddress(address string) (types.UserLeaderboardEntry, error)
 	UpdateUserEmail(address string, email string) error
+	GetUserBalanceByID(userID int64) (types.UserBalance, error)
+	DebitUserTokenBalance(userID int64, amount *big.Int) (before *big.Int, after *big.Int, err error)
 }
 
+// Attempts at debiting a balance that other writes keep changing under us
+const maxBalanceDebitAttempts = 5
+
 type userRepository struct {
 	db *database.Connection
 }
@@ -75,6 +82,58 @@ func (r *userRepository) UpdateUserBalance(user *types.UpdateUserBalanceRequest)
 	return nil
 }
 
+func (r *userRepository) GetUserBalanceByID(userID int64) (types.UserBalance, error) {
+	var balance types.UserBalance
+	var email *string
+	err := r.db.Session().Query(queries.GetUserBalanceByIDQuery, userID).Scan(
+		&balance.UserID, &balance.EtherBalance, &balance.TokenBalance, &email)
+	if err == gocql.ErrNotFound {
+		return types.UserBalance{}, errors.New("user not found")
+	}
+	if err != nil {
+		return types.UserBalance{}, err
+	}
+	if balance.TokenBalance == nil {
+		balance.TokenBalance = new(big.Int)
+	}
+	if email != nil {
+		balance.Email = *email
+	}
+	return balance, nil
+}
+
+// DebitUserTokenBalance takes amount off the user's token balance, and returns the
+// balance before and after. The write only applies if the balance is still the one
+// read, so concurrent settlements cannot overwrite each other. The balance may go
+// below zero, as the work being paid for is already done.
+func (r *userRepository) DebitUserTokenBalance(userID int64, amount *big.Int) (*big.Int, *big.Int, error) {
+	for attempt := 0; attempt < maxBalanceDebitAttempts; attempt++ {
+		var current *big.Int
+		err := r.db.Session().Query(queries.GetUserTokenBalanceByIDQuery, userID).Scan(&current)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		var previous interface{}
+		before := new(big.Int)
+		if current != nil {
+			previous = current
+			before.Set(current)
+		}
+		after := new(big.Int).Sub(before, amount)
+
+		applied, err := r.db.Session().Query(queries.DebitUserTokenBalanceQuery,
+			after, userID, previous).MapScanCAS(make(map[string]interface{}))
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to debit balance of user %d: %v", userID, err)
+		}
+		if applied {
+			return before, after, nil
+		}
+	}
+	return nil, nil, fmt.Errorf("balance of user %d kept changing, gave up after %d attempts", userID, maxBalanceDebitAttempts)
+}
+
 func (r *userRepository) UpdateUserJobIDs(userID int64, jobIDs []int64) error {
 	err := r.db.Session().Query(queries.UpdateUserJobIDsQuery, jobIDs, len(jobIDs), time.Now(), userID).Exec()
 	if err != nil {
@@ -213,6 +213,11 @@ func NewServer(db *database.Connection, logger logging.Logger) *Server {
 		executor: docker.DefaultConfig(),
 	}
 
+	// Fee simulations of scripts fail without the digests, other routes are unaffected
+	if err := s.executor.PinRuntimes(config.GetRuntimeImageDigests()); err != nil {
+		logger.Errorf("Failed to pin script runtimes: %v", err)
+	}
+
 	s.apiKeyAuth = middleware.NewApiKeyAuth(db, rateLimiter, logger)
 
 	// Apply retry middleware only to API routes
@@ -224,6 +229,9 @@ func NewServer(db *database.Connection, logger logging.Logger) *Server {
 
 func (s *Server) RegisterRoutes(router *gin.Engine) {
 	handler := handlers.NewHandler(s.db, s.logger, s.notificationConfig, s.executor)
+	if s.redisClient != nil && config.IsConditionJobCatalogEnabled() {
+		handler.SetConditionJobCatalog(s.redisClient)
+	}
 
 	// Register metrics endpoint at root level without middleware
 	router.GET("/metrics", gin.WrapH(promhttp.Handler()))
@@ -246,13 +254,26 @@ func (s *Server) RegisterRoutes(router *gin.Engine) {
 	// api.POST("/jobs", s.validator.GinMiddleware(), handler.CreateJobData)
 	protected.POST("/jobs", s.validator.GinMiddleware(), handler.CreateJobData)
 	protected.GET("/jobs/by-apikey", handler.GetJobsByApiKey)
-	api.GET("/jobs/time", handler.GetTimeBasedTasks)
+	// Routes of the schedulers and the Redis service
+	services := api.Group("")
+	services.Use(middleware.ServiceTokenMiddleware(config.GetServiceToken()))
+
+	services.POST("/jobs/time/claim", handler.ClaimTimeBasedTasks)
+	services.POST("/jobs/time/ack", handler.AckTimeBasedTasks)
+	services.POST("/jobs/time/release", handler.ReleaseTimeBasedTasks)
+	services.PUT("/tasks/failed/:id", handler.FailTaskExecution)
 	api.PUT("/jobs/update/:id", handler.UpdateJobDataFromUser)
 	api.PUT("/jobs/:id/status/:status", handler.UpdateJobStatus)
 	api.PUT("/jobs/:id/lastexecuted", handler.UpdateJobLastExecutedAt)
 	api.GET("/jobs/user/:user_address", handler.GetJobsByUserAddress)
 	api.PUT("/jobs/delete/:id", handler.DeleteJobData)
+	api.PUT("/jobs/:id/pause", handler.PauseJob)
+	api.PUT("/jobs/:id/resume", handler.ResumeJob)
+	api.PUT("/jobs/:id/cancel", handler.CancelJob)
+	api.PUT("/jobs/:id/definition", s.validator.GinMiddleware(), handler.UpdateJobDefinition)
 	api.GET("/jobs/:job_id/task-fees", handler.GetTaskFeesByJobID)
+	api.GET("/jobs/:job_id/chain", handler.GetJobChain)
+	api.GET("/jobs/:job_id/budget", handler.GetJobBudget)
 
 	api.POST("/tasks", s.validator.GinMiddleware(), handler.CreateTaskData)
 	api.GET("/tasks/:id", handler.GetTaskDataByID)
@@ -276,6 +297,7 @@ func (s *Server) RegisterRoutes(router *gin.Engine) {
 	api.GET("/leaderboard/keepers/search", handler.GetKeeperByIdentifier)
 
 	api.GET("/fees", handler.GetTaskFees)
+	protected.GET("/fees/simulate", handler.SimulateTaskFees)
 
 	api.POST("/keepers/update-chat-id", handler.UpdateKeeperChatID)
 	api.GET("/keepers/com-info/:id", handler.GetKeeperCommunicationInfo)
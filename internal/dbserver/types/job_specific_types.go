@@ -15,6 +15,9 @@ type TimeJobData struct {
 	SpecificSchedule          string    `json:"specific_schedule"`
 	Timezone                  string    `json:"timezone"`
 	NextExecutionTimestamp    time.Time `json:"next_execution_timestamp"`
+	MisfirePolicy             string    `json:"misfire_policy"`
+	MisfireMaxCatchup         int       `json:"misfire_max_catchup"`
+	MaxLatenessSeconds        int64     `json:"max_lateness_seconds"`
 	TargetChainID             string    `json:"target_chain_id"`
 	TargetContractAddress     string    `json:"target_contract_address"`
 	TargetFunction            string    `json:"target_function"`
@@ -22,6 +25,7 @@ type TimeJobData struct {
 	ArgType                   int       `json:"arg_type"`
 	Arguments                 []string  `json:"arguments"`
 	DynamicArgumentsScriptUrl string    `json:"dynamic_arguments_script_url"`
+	ExtraTargets              []TargetCallData `json:"extra_targets"`
 	IsCompleted               bool      `json:"is_completed"`
 	IsActive                  bool      `json:"is_active"`
 }
@@ -43,6 +47,7 @@ type EventJobData struct {
 	ArgType                   int       `json:"arg_type"`
 	Arguments                 []string  `json:"arguments"`
 	DynamicArgumentsScriptUrl string    `json:"dynamic_arguments_script_url"`
+	ExtraTargets              []TargetCallData `json:"extra_targets"`
 	IsCompleted               bool      `json:"is_completed"`
 	IsActive                  bool      `json:"is_active"`
 }
@@ -59,6 +64,11 @@ type ConditionJobData struct {
 	LowerLimit                float64   `json:"lower_limit"`
 	ValueSourceType           string    `json:"value_source_type"`
 	ValueSourceUrl            string    `json:"value_source_url"`
+	ConsecutiveHits           int       `json:"consecutive_hits"`
+	CooldownSeconds           int64     `json:"cooldown_seconds"`
+	EdgeTriggered             bool      `json:"edge_triggered"`
+	RearmPercent              float64   `json:"rearm_percent"`
+	WindowSeconds             int64     `json:"window_seconds"`
 	TargetChainID             string    `json:"target_chain_id"`
 	TargetContractAddress     string    `json:"target_contract_address"`
 	TargetFunction            string    `json:"target_function"`
@@ -66,6 +76,7 @@ type ConditionJobData struct {
 	ArgType                   int       `json:"arg_type"`
 	Arguments                 []string  `json:"arguments"`
 	DynamicArgumentsScriptUrl string    `json:"dynamic_arguments_script_url"`
+	ExtraTargets              []TargetCallData `json:"extra_targets"`
 	IsCompleted               bool      `json:"is_completed"`
 	IsActive                  bool      `json:"is_active"`
 }
@@ -54,8 +54,82 @@ const (
 	JobStatusPending JobStatus = "pending"
 	JobStatusInQueue JobStatus = "in-queue"
 	JobStatusRunning JobStatus = "running"
+
+	// A chained job waits for a task of its predecessor to succeed
+	JobStatusAwaitingPredecessor JobStatus = "awaiting_predecessor"
+	// A predecessor in the chain failed, so the job never runs
+	JobStatusChainFailed JobStatus = "chain_failed"
+
+	// Lifecycle states a job leaves the active ones for
+	JobStatusPaused    JobStatus = "paused"
+	JobStatusCompleted JobStatus = "completed"
+	JobStatusExpired   JobStatus = "expired"
+	JobStatusFailed    JobStatus = "failed"
+	JobStatusCancelled JobStatus = "cancelled"
+	JobStatusDeleted   JobStatus = "deleted"
 )
 
+// IsActive reports whether a job in this status is being scheduled
+func (s JobStatus) IsActive() bool {
+	switch s {
+	case JobStatusPending, JobStatusInQueue, JobStatusRunning:
+		return true
+	}
+	return false
+}
+
+// IsTerminal reports whether a job in this status can never run again
+func (s JobStatus) IsTerminal() bool {
+	switch s {
+	case JobStatusCompleted, JobStatusExpired, JobStatusFailed, JobStatusCancelled, JobStatusDeleted, JobStatusChainFailed:
+		return true
+	}
+	return false
+}
+
+// IsValid reports whether s is one of the known job statuses
+func (s JobStatus) IsValid() bool {
+	return s.IsActive() || s.IsTerminal() || s == JobStatusPaused || s == JobStatusAwaitingPredecessor
+}
+
+// CanTransitionTo reports whether a job may move from this status to next.
+// Active jobs may be paused or finished, paused jobs resumed (back to pending)
+// or finished, and a chained job waiting on its predecessor only failed with its
+// chain or cancelled: it is activated by its predecessor's successful task, never
+// by a request. Any job not yet deleted may be deleted.
+func (s JobStatus) CanTransitionTo(next JobStatus) bool {
+	switch {
+	case next == JobStatusDeleted:
+		return s != JobStatusDeleted
+	case s.IsTerminal():
+		return false
+	case s.IsActive():
+		return next.IsActive() || next == JobStatusPaused || next == JobStatusCompleted ||
+			next == JobStatusExpired || next == JobStatusFailed || next == JobStatusCancelled
+	case s == JobStatusPaused:
+		return next == JobStatusPending || next == JobStatusExpired || next == JobStatusCancelled
+	case s == JobStatusAwaitingPredecessor:
+		return next == JobStatusChainFailed || next == JobStatusCancelled
+	}
+	return false
+}
+
+// ChainTxHashPlaceholder in the arguments of a chained job is replaced with the
+// action tx hash of its predecessor when the job is activated
+const ChainTxHashPlaceholder = "{{predecessor.tx_hash}}"
+
+// JobChainEntry is one job in the status view of a job chain
+type JobChainEntry struct {
+	JobID            int64     `json:"job_id"`
+	JobTitle         string    `json:"job_title"`
+	TaskDefinitionID int       `json:"task_definition_id"`
+	ChainStatus      int       `json:"chain_status"`
+	LinkJobID        int64     `json:"link_job_id"`
+	Status           string    `json:"status"`
+	TaskIDs          []int64   `json:"task_ids"`
+	LastExecutedAt   time.Time `json:"last_executed_at"`
+}
+
 type CreateJobData struct {
 	// Common fields for all job types
 	JobID        int64    `json:"job_id" validate:"required"`
@@ -76,6 +150,10 @@ type CreateJobData struct {
 	TimeInterval     int64  `json:"time_interval,omitempty" validate:"omitempty,min=1"`
 	CronExpression   string `json:"cron_expression,omitempty" validate:"omitempty,cron"`
 	SpecificSchedule string `json:"specific_schedule,omitempty" validate:"omitempty"`
+	// Handling of executions missed while the schedulers were down
+	MisfirePolicy      string `json:"misfire_policy,omitempty" validate:"omitempty,oneof=fire_once_now fire_all skip fail"`
+	MisfireMaxCatchup  int    `json:"misfire_max_catchup,omitempty" validate:"omitempty,min=1"`
+	MaxLatenessSeconds int64  `json:"max_lateness_seconds,omitempty" validate:"omitempty,min=1"`
 
 	// Event job specific fields
 	TriggerChainID         string `json:"trigger_chain_id,omitempty" validate:"omitempty,chain_id"`
@@ -88,6 +166,11 @@ type CreateJobData struct {
 	LowerLimit      float64 `json:"lower_limit,omitempty" validate:"omitempty,gt=0"`
 	ValueSourceType string  `json:"value_source_type,omitempty" validate:"omitempty"`
 	ValueSourceUrl  string  `json:"value_source_url,omitempty" validate:"omitempty"`
+	ConsecutiveHits int     `json:"consecutive_hits,omitempty" validate:"omitempty,min=1"`
+	CooldownSeconds int64   `json:"cooldown_seconds,omitempty" validate:"omitempty,min=0"`
+	EdgeTriggered   bool    `json:"edge_triggered,omitempty"`
+	RearmPercent    float64 `json:"rearm_percent,omitempty" validate:"omitempty,gt=0,lt=100"`
+	WindowSeconds   int64   `json:"window_seconds,omitempty" validate:"omitempty,min=1"`
 
 	// Target fields (common for all job types)
 	TargetChainID             string   `json:"target_chain_id" validate:"required,chain_id"`
@@ -97,10 +180,55 @@ type CreateJobData struct {
 	ArgType                   int      `json:"arg_type" validate:"required"`
 	Arguments                 []string `json:"arguments" validate:"omitempty"`
 	DynamicArgumentsScriptUrl string   `json:"dynamic_arguments_script_url,omitempty" validate:"omitempty,ipfs_url"`
+	// Further calls made on the same trigger, in order
+	ExtraTargets []TargetCallData `json:"extra_targets,omitempty" validate:"omitempty,max=16,dive"`
 
 	IsImua bool `json:"is_imua"`
 }
 
+// TargetCallData is one more contract call of a job, made after the primary target.
+// Calls on the same chain run in one transaction, calls on other chains independently.
+// A job only has several calls on one chain where the proxy hubs can batch them.
+type TargetCallData struct {
+	TargetChainID         string   `json:"target_chain_id" validate:"required,chain_id"`
+	TargetContractAddress string   `json:"target_contract_address" validate:"required,ethereum_address"`
+	TargetFunction        string   `json:"target_function" validate:"required"`
+	ABI                   string   `json:"abi" validate:"required"`
+	ArgType               int      `json:"arg_type"`
+	Arguments             []string `json:"arguments"`
+}
+
+// UpdateJobDefinitionRequest edits what a live job calls and, for condition
+// jobs, when it fires. Sections left out stay as they are.
+type UpdateJobDefinitionRequest struct {
+	Target    *JobTargetDefinition    `json:"target,omitempty" validate:"omitempty"`
+	Condition *JobConditionDefinition `json:"condition,omitempty" validate:"omitempty"`
+}
+
+type JobTargetDefinition struct {
+	TargetChainID             string           `json:"target_chain_id" validate:"required,chain_id"`
+	TargetContractAddress     string           `json:"target_contract_address" validate:"required,ethereum_address"`
+	TargetFunction            string           `json:"target_function" validate:"required"`
+	ABI                       string           `json:"abi" validate:"required"`
+	ArgType                   int              `json:"arg_type" validate:"required"`
+	Arguments                 []string         `json:"arguments" validate:"omitempty"`
+	DynamicArgumentsScriptUrl string           `json:"dynamic_arguments_script_url,omitempty" validate:"omitempty,ipfs_url"`
+	ExtraTargets              []TargetCallData `json:"extra_targets,omitempty" validate:"omitempty,max=16,dive"`
+}
+
+type JobConditionDefinition struct {
+	ConditionType   string  `json:"condition_type" validate:"required"`
+	UpperLimit      float64 `json:"upper_limit,omitempty" validate:"omitempty,gt=0"`
+	LowerLimit      float64 `json:"lower_limit,omitempty" validate:"omitempty,gt=0"`
+	ValueSourceType string  `json:"value_source_type" validate:"required"`
+	ValueSourceUrl  string  `json:"value_source_url" validate:"required"`
+	ConsecutiveHits int     `json:"consecutive_hits,omitempty" validate:"omitempty,min=1"`
+	CooldownSeconds int64   `json:"cooldown_seconds,omitempty" validate:"omitempty,min=0"`
+	EdgeTriggered   bool    `json:"edge_triggered,omitempty"`
+	RearmPercent    float64 `json:"rearm_percent,omitempty" validate:"omitempty,gt=0,lt=100"`
+	WindowSeconds   int64   `json:"window_seconds,omitempty" validate:"omitempty,min=1"`
+}
+
 type CreateJobResponse struct {
 	UserID            int64    `json:"user_id"`
 	AccountBalance    *big.Int `json:"account_balance"`
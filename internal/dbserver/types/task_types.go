@@ -28,6 +28,18 @@ type CreateTaskDataRequest struct {
 	IsImua           bool  `json:"is_imua"`
 }
 
+// TaskStatusSkipped marks a task whose dynamic arguments script decided not to
+// act. It executed, and is not failed.
+const TaskStatusSkipped = "skipped"
+
+// TaskStatusFailed marks a task the keepers gave up on, without a validated execution
+const TaskStatusFailed = "failed"
+
+type FailTaskExecutionRequest struct {
+	TaskID int64  `json:"task_id" validate:"required"`
+	Error  string `json:"error"`
+}
+
 type UpdateTaskExecutionDataRequest struct {
 	TaskID             int64     `json:"task_id" validate:"required"`
 	TaskPerformerID    int64     `json:"task_performer_id" validate:"required"`
@@ -35,6 +47,11 @@ type UpdateTaskExecutionDataRequest struct {
 	ExecutionTxHash    string    `json:"execution_tx_hash" validate:"required"`
 	ProofOfTask        string    `json:"proof_of_task" validate:"required"`
 	TaskOpXCost        float64   `json:"task_opx_cost" validate:"required"`
+	IsSuccessful       bool      `json:"is_successful"`
+	// Script the task's arguments came from, its cost is recorded against it
+	DynamicArgumentsScriptUrl string `json:"dynamic_arguments_script_url,omitempty"`
+	// The script decided not to act, so there is no transaction
+	IsSkipped bool `json:"is_skipped,omitempty"`
 }
 
 type UpdateTaskAttestationDataRequest struct {
@@ -58,3 +75,21 @@ type TasksByJobIDResponse struct {
 	IsSuccessful       bool      `json:"is_successful"`
 	TaskStatus         string    `json:"task_status"`
 }
+
+// ScriptCostData is the running cost of a dynamic arguments script, from the tasks
+// that executed it
+type ScriptCostData struct {
+	ScriptCID  string    `json:"script_cid"`
+	Executions int64     `json:"executions"`
+	TotalCost  float64   `json:"total_cost"`
+	LastCost   float64   `json:"last_cost"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// AverageCost is the mean cost of the script's executions so far
+func (s ScriptCostData) AverageCost() float64 {
+	if s.Executions == 0 {
+		return 0
+	}
+	return s.TotalCost / float64(s.Executions)
+}
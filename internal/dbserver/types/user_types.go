@@ -32,6 +32,15 @@ type UpdateUserBalanceRequest struct {
 	TokenBalance *big.Int `json:"token_balance"`
 }
 
+// UserBalance is what a user's jobs are paid from. Task fees are charged from the
+// token balance, in wei of the TG token.
+type UserBalance struct {
+	UserID       int64    `json:"user_id"`
+	EtherBalance *big.Int `json:"ether_balance"`
+	TokenBalance *big.Int `json:"token_balance"`
+	Email        string   `json:"email_id"`
+}
+
 type UserLeaderboardEntry struct {
 	UserID      int64   `json:"user_id"`
 	UserAddress string  `json:"user_address"`
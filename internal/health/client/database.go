@@ -10,12 +10,12 @@ import (
 	"strings"
 	"time"
 
-	"github.com/go-gomail/gomail"
 	"github.com/trigg3rX/triggerx-backend-imua/internal/health/config"
 	"github.com/trigg3rX/triggerx-backend-imua/internal/health/telegram"
 
 	"github.com/trigg3rX/triggerx-backend-imua/internal/health/types"
 	"github.com/trigg3rX/triggerx-backend-imua/pkg/database"
+	"github.com/trigg3rX/triggerx-backend-imua/pkg/email"
 	"github.com/trigg3rX/triggerx-backend-imua/pkg/logging"
 	commonTypes "github.com/trigg3rX/triggerx-backend-imua/pkg/types"
 )
@@ -281,14 +281,8 @@ func (dm *DatabaseManager) checkAndNotifyOfflineKeeper(keeperID int64) {
 // }
 
 func (dm *DatabaseManager) sendEmailNotification(to, subject, body string) error {
-	m := gomail.NewMessage()
-	m.SetHeader("From", config.GetEmailUser())
-	m.SetHeader("To", to)
-	m.SetHeader("Subject", subject)
-	m.SetBody("text/html", body)
-
-	d := gomail.NewDialer("smtp.zeptomail.in", 587, config.GetEmailUser(), config.GetEmailPassword())
-	if err := d.DialAndSend(m); err != nil {
+	sender := email.NewSender(config.GetEmailUser(), config.GetEmailPassword())
+	if err := sender.Send(to, subject, body); err != nil {
 		dm.logger.Errorf("[Notification] Failed to send email to %s: %v", to, err)
 		return err
 	}
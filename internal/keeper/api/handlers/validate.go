@@ -60,7 +60,7 @@ package handlers
 // 	decodedData = string(dataBytes)
 // 	h.logger.Infof("Decoded Data CID: %s", decodedData)
 
-// 	ipfsData, err := utils.FetchIPFSContent(decodedData)
+// 	proofs, err := utils.FetchProofs(decodedData)
 // 	if err != nil {
 // 		h.logger.Errorf("Failed to fetch IPFS content: %v", err)
 // 		c.JSON(http.StatusInternalServerError, ValidationResponse{
@@ -76,7 +76,7 @@ package handlers
 // 	var validationErr error
 
 // 	h.logger.Info("Validating task ...", "trace_id", traceID)
-// 	isValid, validationErr = h.validator.ValidateTask(context.Background(), ipfsData, traceID)
+// 	isValid, validationErr = h.validator.ValidateProofs(context.Background(), proofs, taskRequest.ProofOfTask, traceID)
 
 // 	if validationErr != nil {
 // 		h.logger.Error("Validation error", "error", validationErr, "trace_id", traceID)
@@ -2,8 +2,10 @@ package config
 
 import (
 	"fmt"
+	"strings"
 	// "log"
 
+	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/gin-gonic/gin"
 	"github.com/joho/godotenv"
 
@@ -21,6 +23,10 @@ type Config struct {
 	privateKeyController string
 	keeperAddress      string
 
+	// Consensus Key, signing proofs of tasks, and its address
+	privateKeyConsensus string
+	consensusAddress    string
+
 	// Public IP Address and Peer ID
 	publicIPV4Address string
 	peerID            string
@@ -33,6 +39,26 @@ type Config struct {
 	ipfsHost  string
 	pinataJWT string
 
+	// IPFS storage backend, Pinata with the credentials given at check-in by default
+	ipfsBackend  string
+	ipfsAPIURL   string
+	ipfsAPIToken string
+	ipfsStoreDir string
+	ipfsGateway  string
+
+	// Upload one proof for all targets of a task, rather than one per target
+	proofBatchUpload bool
+
+	// Hosts, or *.domain patterns, scripts may reach through the egress proxy
+	scriptAllowedHosts []string
+
+	// Send the calls of a task on one chain in a single executeFunctionBatch
+	// transaction. Needs a proxy hub that has it.
+	proxyHubBatch bool
+
+	// Digests the images of the script runtimes are pinned to, as language=digest
+	runtimeImageDigests string
+
 	// TLS Proof configuration
 	tlsProofHost string
 	tlsProofPort string
@@ -62,6 +88,7 @@ func Init() error {
 		etherscanAPIKey:           env.GetEnvString("ETHERSCAN_API_KEY", ""),
 		privateKeyController:      env.GetEnvString("PRIVATE_KEY_CONTROLLER", ""),
 		keeperAddress:           env.GetEnvString("OPERATOR_ADDRESS", ""),
+		privateKeyConsensus:       env.GetEnvString("PRIVATE_KEY_CONSENSUS", ""),
 		publicIPV4Address:         env.GetEnvString("PUBLIC_IPV4_ADDRESS", ""),
 		peerID:                    env.GetEnvString("PEER_ID", ""),
 		keeperRPCPort:             env.GetEnvString("KEEPER_RPC_PORT", ""),
@@ -72,10 +99,24 @@ func Init() error {
 		ethRpcUrl:                 env.GetEnvString("ETH_RPC_URL", ""),
 		ethWsUrl:                  env.GetEnvString("ETH_WS_URL", ""),
 		blsPrivateKeyStorePath:    env.GetEnvString("BLS_PRIVATE_KEY_STORE_PATH", ""),
+		ipfsBackend:               env.GetEnvString("IPFS_BACKEND", "pinata"),
+		ipfsAPIURL:                env.GetEnvString("IPFS_API_URL", ""),
+		ipfsAPIToken:              env.GetEnvString("IPFS_API_TOKEN", ""),
+		ipfsStoreDir:              env.GetEnvString("IPFS_STORE_DIR", ""),
+		ipfsGateway:               env.GetEnvString("IPFS_GATEWAY", ""),
+		proofBatchUpload:          env.GetEnvBool("PROOF_BATCH_UPLOAD", false),
+		scriptAllowedHosts:        splitList(env.GetEnvString("SCRIPT_ALLOWED_HOSTS", "")),
+		proxyHubBatch:             env.GetEnvBool("PROXY_HUB_BATCH", false),
+		runtimeImageDigests:       env.GetEnvString("RUNTIME_IMAGE_DIGESTS", ""),
 	}
 	if err := validateConfig(cfg); err != nil {
 		return fmt.Errorf("invalid config: %w", err)
 	}
+	consensusKey, err := crypto.HexToECDSA(cfg.privateKeyConsensus)
+	if err != nil {
+		return fmt.Errorf("invalid private key consensus: %w", err)
+	}
+	cfg.consensusAddress = crypto.PubkeyToAddress(consensusKey.PublicKey).Hex()
 	if !cfg.devMode {
 		gin.SetMode(gin.ReleaseMode)
 	}
@@ -97,6 +138,12 @@ func validateConfig(cfg Config) error {
 	if !env.IsValidPrivateKey(cfg.privateKeyController) {
 		return fmt.Errorf("invalid private key controller: %s", cfg.privateKeyController)
 	}
+	if env.IsEmpty(cfg.privateKeyConsensus) {
+		return fmt.Errorf("PRIVATE_KEY_CONSENSUS is empty, the keeper signs the proofs of its tasks with it")
+	}
+	if !env.IsValidPrivateKey(cfg.privateKeyConsensus) {
+		return fmt.Errorf("invalid private key consensus")
+	}
 	if !env.IsValidEthAddress(cfg.keeperAddress) {
 		return fmt.Errorf("invalid operator address: %s", cfg.keeperAddress)
 	}
@@ -112,6 +159,17 @@ func validateConfig(cfg Config) error {
 	return nil
 }
 
+// splitList splits a comma separated list, dropping empty entries
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
 func IsDevMode() bool {
 	return cfg.devMode
 }
@@ -132,6 +190,14 @@ func GetKeeperAddress() string {
 	return cfg.keeperAddress
 }
 
+func GetPrivateKeyConsensus() string {
+	return cfg.privateKeyConsensus
+}
+
+func GetConsensusAddress() string {
+	return cfg.consensusAddress
+}
+
 func GetPublicIPV4Address() string {
 	return cfg.publicIPV4Address
 }
@@ -161,6 +227,50 @@ func GetPinataJWT() string {
 	return cfg.pinataJWT
 }
 
+func GetIPFSBackend() string {
+	return cfg.ipfsBackend
+}
+
+func GetIPFSAPIURL() string {
+	return cfg.ipfsAPIURL
+}
+
+func GetIPFSAPIToken() string {
+	return cfg.ipfsAPIToken
+}
+
+func GetIPFSStoreDir() string {
+	return cfg.ipfsStoreDir
+}
+
+// GetIPFSGateway is the gateway content is fetched from, the Pinata host if none is set
+func GetIPFSGateway() string {
+	if cfg.ipfsGateway != "" {
+		return cfg.ipfsGateway
+	}
+	return cfg.ipfsHost
+}
+
+func IsProofBatchUpload() bool {
+	return cfg.proofBatchUpload
+}
+
+// GetScriptAllowedHosts are the hosts scripts may reach, none if unset
+func GetScriptAllowedHosts() []string {
+	return cfg.scriptAllowedHosts
+}
+
+// GetRuntimeImageDigests are the digests the script runtimes are pinned to. The
+// keepers must agree on them, to run scripts on the same images.
+func GetRuntimeImageDigests() string {
+	return cfg.runtimeImageDigests
+}
+
+// IsProxyHubBatch reports whether the proxy hubs take several calls in one transaction
+func IsProxyHubBatch() bool {
+	return cfg.proxyHubBatch
+}
+
 func SetTLSProofConfig(tlsProofHost string, tlsProofPort string) {
 	cfg.tlsProofHost = tlsProofHost
 	cfg.tlsProofPort = tlsProofPort
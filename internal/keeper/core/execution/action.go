@@ -5,13 +5,9 @@ import (
 	"crypto/ecdsa"
 	"fmt"
 	"math/big"
-	"os"
-	"path/filepath"
 	"strconv"
-	"strings"
 	"time"
 
-	"github.com/ethereum/go-ethereum/accounts/abi"
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	ethcommon "github.com/ethereum/go-ethereum/common"
 	ethtypes "github.com/ethereum/go-ethereum/core/types"
@@ -19,72 +15,76 @@ import (
 	"github.com/ethereum/go-ethereum/ethclient"
 	"github.com/trigg3rX/triggerx-backend-imua/internal/keeper/config"
 	"github.com/trigg3rX/triggerx-backend-imua/internal/keeper/metrics"
-	"github.com/trigg3rX/triggerx-backend-imua/internal/keeper/utils"
 	"github.com/trigg3rX/triggerx-backend-imua/pkg/docker"
 	"github.com/trigg3rX/triggerx-backend-imua/pkg/types"
 )
 
-func (e *TaskExecutor) executeAction(targetData *types.TaskTargetData, triggerData *types.TaskTriggerData, nonce uint64, client *ethclient.Client) (types.PerformerActionData, error) {
+// Longest a keeper waits for the trigger time of a time task it was sent
+const maxTriggerWait = 5 * time.Second
+
+func (e *TaskExecutor) executeAction(targetData *types.TaskTargetData, triggerData *types.TaskTriggerData, nonce uint64, client *ethclient.Client, trace targetTrace) (types.PerformerActionData, error) {
 	if targetData.TargetContractAddress == "" {
 		e.logger.Errorf("Execution contract address not configured")
 		return types.PerformerActionData{}, fmt.Errorf("execution contract address not configured")
 	}
 
+	// Refuse a time task that arrived too late to be worth executing, instead of firing it off-schedule
+	if targetData.TaskDefinitionID == 1 || targetData.TaskDefinitionID == 2 {
+		if lateness := time.Since(triggerData.NextTriggerTimestamp); triggerData.MaxLatenessSeconds > 0 && lateness > time.Duration(triggerData.MaxLatenessSeconds)*time.Second {
+			return types.PerformerActionData{}, fmt.Errorf("task is stale: %s past its trigger time, max lateness is %ds", lateness.Round(time.Second), triggerData.MaxLatenessSeconds)
+		}
+	}
+
+	// The time scheduler dispatches tasks just ahead of their trigger time, so
+	// this only evens out the last moments. A task sent well before it is
+	// refused, rather than holding up the keeper until then.
 	var timeToNextTrigger time.Duration
 	switch targetData.TaskDefinitionID {
 	case 1:
-		timeToNextTrigger = time.Until(triggerData.NextTriggerTimestamp)
-		timeToNextTrigger = timeToNextTrigger - 2*time.Second
+		timeToNextTrigger = time.Until(triggerData.NextTriggerTimestamp) - 2*time.Second
 	case 2:
-		timeToNextTrigger = time.Until(triggerData.NextTriggerTimestamp)
-		timeToNextTrigger = timeToNextTrigger - 10*time.Second
-		if timeToNextTrigger < 0 {
-			timeToNextTrigger = 0
-		}
-	default:
-		timeToNextTrigger = 0
+		timeToNextTrigger = time.Until(triggerData.NextTriggerTimestamp) - 10*time.Second
 	}
-	time.Sleep(timeToNextTrigger)
-
-	taregtContractAddress := ethcommon.HexToAddress(targetData.TargetContractAddress)
-	contractABI, method, err := e.getContractMethodAndABI(targetData.TargetFunction, targetData)
-	if err != nil {
-		return types.PerformerActionData{}, fmt.Errorf("failed to get contract method and ABI: %v", err)
+	if timeToNextTrigger > maxTriggerWait {
+		return types.PerformerActionData{}, fmt.Errorf("task is early: %s before its trigger time, a keeper waits at most %s", timeToNextTrigger.Round(time.Second), maxTriggerWait)
+	}
+	if timeToNextTrigger > 0 {
+		time.Sleep(timeToNextTrigger)
 	}
 
-	var argData []interface{}
+	var argData interface{}
 	var result *docker.ExecutionResult
 	switch targetData.TaskDefinitionID {
 	case 2, 4, 6:
-		codePath, err := e.codeExecutor.Downloader.DownloadFile(context.Background(), targetData.DynamicArgumentsScriptUrl, e.logger)
-		if err != nil {
-			return types.PerformerActionData{}, fmt.Errorf("failed to download dynamic arguments script: %v", err)
-		}
-		defer func() { _ = os.RemoveAll(filepath.Dir(codePath)) }()
-
-		containerID, err := e.codeExecutor.DockerManager.CreateContainer(context.Background(), codePath)
-		if err != nil {
-			return types.PerformerActionData{}, fmt.Errorf("failed to create container: %v", err)
-		}
-		defer func() {
-			if err := e.codeExecutor.DockerManager.CleanupContainer(context.Background(), containerID); err != nil {
-				e.logger.Errorf("failed to cleanup container %s: %v", containerID, err)
-			}
-		}()
-		metrics.DockerContainersCreatedTotal.WithLabelValues("golang").Inc()
-
 		start := time.Now()
-		result, err := e.codeExecutor.MonitorExecution(context.Background(), e.codeExecutor.DockerManager.Cli, containerID, 1)
+		endStep := trace.step(StepScript)
+		var err error
+		result, err = e.codeExecutor.ExecuteScript(context.Background(), targetData.DynamicArgumentsScriptUrl, 1)
 		if err != nil {
-			return types.PerformerActionData{}, fmt.Errorf("failed to monitor execution: %v", err)
+			endStep(targetData.DynamicArgumentsScriptUrl, err)
+			return types.PerformerActionData{}, fmt.Errorf("failed to execute dynamic arguments script: %v", err)
 		}
 
 		if !result.Success {
+			endStep(string(result.Runtime), result.Error)
 			return types.PerformerActionData{}, fmt.Errorf("failed to execute dynamic arguments script: %v", result.Error)
 		}
-		metrics.DockerContainerDurationSeconds.WithLabelValues("golang").Set(time.Since(start).Seconds())
+		if result.Result != nil && result.Result.Skip {
+			endStep("skipped: "+result.Result.Reason, nil)
+			e.logger.Infof("Task ID %d skipped by its dynamic arguments script: %s", targetData.TaskID, result.Result.Reason)
+			metrics.TasksSkippedTotal.Inc()
+			skipped := newActionData(targetData.TaskID, result)
+			skipped.Skipped = true
+			skipped.SkipReason = result.Result.Reason
+			return skipped, nil
+		}
+		if _, pooled := e.codeExecutor.Pools[result.Runtime]; !pooled {
+			metrics.DockerContainersCreatedTotal.WithLabelValues(string(result.Runtime)).Inc()
+		}
+		metrics.DockerContainerDurationSeconds.WithLabelValues(string(result.Runtime)).Set(time.Since(start).Seconds())
+		endStep(string(result.Runtime), nil)
 
-		argData = e.parseDynamicArgs(result.Output)
+		argData = e.dynamicArgs(result)
 	case 1, 3, 5:
 		argData = e.parseStaticArgs(targetData.Arguments)
 		result = &docker.ExecutionResult{
@@ -96,85 +96,41 @@ func (e *TaskExecutor) executeAction(targetData *types.TaskTargetData, triggerDa
 		return types.PerformerActionData{}, fmt.Errorf("unsupported task definition id: %d", targetData.TaskDefinitionID)
 	}
 
-	// Handle args as potentially structured data
-	convertedArgs, err := e.processArguments(argData, method.Inputs, contractABI)
-	if err != nil {
-		return types.PerformerActionData{}, fmt.Errorf("error processing arguments: %v", err)
-	}
-
-	// Pack the target contract's function call data
-	var callData []byte
-	callData, err = contractABI.Pack(method.Name, convertedArgs...)
+	calls, err := e.packTaskCalls(targetData, argData)
 	if err != nil {
-		e.logger.Warnf("Error packing arguments: %v", err)
-		return types.PerformerActionData{}, fmt.Errorf("error packing arguments: %v", err)
+		e.logger.Warnf("Error packing target call: %v", err)
+		return types.PerformerActionData{}, err
 	}
 
-	// Create transaction data for execution contract
 	privateKey, err := crypto.HexToECDSA(config.GetPrivateKeyController())
 	if err != nil {
 		return types.PerformerActionData{}, fmt.Errorf("failed to parse private key: %v", err)
 	}
 	e.logger.Debugf("Using nonce: %d", nonce)
 
-	gasPrice, err := client.SuggestGasPrice(context.Background())
+	targetResults, receipts, gasUsed, err := e.executeTargetCalls(calls, privateKey, client, nonce, trace)
 	if err != nil {
 		return types.PerformerActionData{}, err
 	}
-
-	// Pack the execution contract's executeFunction call
-	executionABI, err := abi.JSON(strings.NewReader(`[{"inputs":[{"name":"target","type":"address"},{"name":"data","type":"bytes"}],"name":"executeFunction","outputs":[],"stateMutability":"payable","type":"function"}]`))
-	if err != nil {
-		return types.PerformerActionData{}, fmt.Errorf("failed to parse execution contract ABI: %v", err)
+	// The validators check the task against the primary target's transaction, so
+	// there is nothing to validate without it, whatever the other chains did
+	if targetResults[0].ActionTxHash == "" {
+		return types.PerformerActionData{}, fmt.Errorf("primary target call failed: %s", targetResults[0].Error)
 	}
-
-	executionInput, err := executionABI.Pack("executeFunction", taregtContractAddress, callData)
-	if err != nil {
-		return types.PerformerActionData{}, fmt.Errorf("failed to pack execution contract input: %v", err)
-	}
-
-	executionContractAddress := utils.GetProxyHubAddress(targetData.TargetChainID)
-	chainID, err := client.ChainID(context.Background())
-	if err != nil {
-		return types.PerformerActionData{}, fmt.Errorf("failed to get chain ID: %v", err)
-	}
-
-	// Create and sign transaction with retry mechanism
-	receipt, finalTxHash, err := e.submitTransactionWithRetry(
-		client,
-		privateKey,
-		nonce,
-		ethcommon.HexToAddress(executionContractAddress),
-		executionInput,
-		chainID,
-		gasPrice,
-	)
-	if err != nil {
-		return types.PerformerActionData{}, err
+	status := true
+	for _, targetResult := range targetResults {
+		status = status && targetResult.Status
 	}
 
-	executionResult := types.PerformerActionData{
-		TaskID:             targetData.TaskID,
-		ActionTxHash:       finalTxHash,
-		GasUsed:            strconv.FormatUint(receipt.GasUsed, 10),
-		Status:             receipt.Status == ethtypes.ReceiptStatusSuccessful,
-		MemoryUsage:        result.Stats.MemoryUsage,
-		CPUPercentage:      result.Stats.CPUPercentage,
-		NetworkRx:          result.Stats.RxBytes,
-		NetworkTx:          result.Stats.TxBytes,
-		BlockRead:          result.Stats.BlockRead,
-		BlockWrite:         result.Stats.BlockWrite,
-		BandwidthRate:      result.Stats.BandwidthRate,
-		TotalFee:           result.Stats.TotalCost,
-		StaticComplexity:   result.Stats.StaticComplexity,
-		DynamicComplexity:  result.Stats.DynamicComplexity,
-		ExecutionTimestamp: time.Now().UTC(),
-	}
-	metrics.TransactionsSentTotal.WithLabelValues(targetData.TargetChainID, "success").Inc()
-	metrics.GasUsedTotal.WithLabelValues(targetData.TargetChainID).Add(float64(receipt.GasUsed))
+	executionResult := newActionData(targetData.TaskID, result)
+	executionResult.ActionTxHash = targetResults[0].ActionTxHash
+	executionResult.GasUsed = strconv.FormatUint(gasUsed, 10)
+	executionResult.Status = status
+	executionResult.TargetResults = targetResults
+	executionResult.Receipts = receipts
 	metrics.TransactionFeesTotal.WithLabelValues(targetData.TargetChainID).Add(result.Stats.TotalCost)
 
-	e.logger.Infof("Task ID %d executed successfully. Transaction: %s", targetData.TaskID, finalTxHash)
+	e.logger.Infof("Task ID %d executed %d target calls. Transaction: %s", targetData.TaskID, len(targetResults), executionResult.ActionTxHash)
 
 	return executionResult, nil
 }
@@ -188,6 +144,8 @@ func (e *TaskExecutor) submitTransactionWithRetry(
 	data []byte,
 	chainID *big.Int,
 	initialGasPrice *big.Int,
+	gasLimit uint64,
+	trace targetTrace,
 ) (*ethtypes.Receipt, string, error) {
 	const (
 		txTimeout     = 5 * time.Second // Wait 5 seconds before resubmitting
@@ -199,15 +157,23 @@ func (e *TaskExecutor) submitTransactionWithRetry(
 	var lastTxHash string
 
 	for attempt := 0; attempt < maxRetries; attempt++ {
+		// Later attempts replace the transaction at the same nonce with higher fees
+		endStep := trace.step(StepTxSubmit)
+		if attempt > 0 {
+			endStep = trace.step(StepTxReplace)
+		}
+
 		// Create and sign transaction
-		tx := ethtypes.NewTransaction(nonce, to, big.NewInt(0), 300000, currentGasPrice, data)
+		tx := ethtypes.NewTransaction(nonce, to, big.NewInt(0), gasLimit, currentGasPrice, data)
 		signedTx, err := ethtypes.SignTx(tx, ethtypes.NewEIP155Signer(chainID), privateKey)
 		if err != nil {
+			endStep("", err)
 			return nil, "", fmt.Errorf("failed to sign transaction: %v", err)
 		}
 
 		// Send transaction
 		err = client.SendTransaction(context.Background(), signedTx)
+		endStep(fmt.Sprintf("chain %s nonce %d gas price %s tx %s", chainID, nonce, currentGasPrice, signedTx.Hash().Hex()), err)
 		if err != nil {
 			e.logger.Warnf("Failed to send transaction (attempt %d): %v", attempt+1, err)
 			if attempt == maxRetries-1 {
@@ -221,11 +187,13 @@ func (e *TaskExecutor) submitTransactionWithRetry(
 			attempt+1, lastTxHash, currentGasPrice.String())
 
 		// Wait for transaction with timeout
+		endStep = trace.step(StepReceipt)
 		ctx, cancel := context.WithTimeout(context.Background(), txTimeout)
 		receipt, err := bind.WaitMined(ctx, client, signedTx)
 		cancel()
 
 		if err == nil {
+			endStep(fmt.Sprintf("tx %s block %s status %d", lastTxHash, receipt.BlockNumber, receipt.Status), nil)
 			// Transaction was mined successfully
 			e.logger.Infof("Transaction confirmed: %s", lastTxHash)
 			return receipt, lastTxHash, nil
@@ -233,6 +201,7 @@ func (e *TaskExecutor) submitTransactionWithRetry(
 
 		// Check if it's a timeout or other error
 		if ctx.Err() == context.DeadlineExceeded {
+			endStep("tx "+lastTxHash, fmt.Errorf("not mined within %v", txTimeout))
 			e.logger.Warnf("Transaction %s timed out after %v, attempting resubmission with higher fees",
 				lastTxHash, txTimeout)
 
@@ -244,6 +213,7 @@ func (e *TaskExecutor) submitTransactionWithRetry(
 		}
 
 		// Other error occurred
+		endStep("tx "+lastTxHash, err)
 		e.logger.Warnf("Error waiting for transaction %s: %v", lastTxHash, err)
 		if attempt == maxRetries-1 {
 			return nil, "", fmt.Errorf("transaction failed after %d attempts: %v", maxRetries, err)
@@ -252,3 +222,22 @@ func (e *TaskExecutor) submitTransactionWithRetry(
 
 	return nil, "", fmt.Errorf("transaction failed after %d attempts", maxRetries)
 }
+
+// newActionData is the action data of a task from the execution of its script
+func newActionData(taskID int64, result *docker.ExecutionResult) types.PerformerActionData {
+	return types.PerformerActionData{
+		TaskID:             taskID,
+		MemoryUsage:        result.Stats.MemoryUsage,
+		CPUPercentage:      result.Stats.CPUPercentage,
+		NetworkRx:          result.Stats.RxBytes,
+		NetworkTx:          result.Stats.TxBytes,
+		BlockRead:          result.Stats.BlockRead,
+		BlockWrite:         result.Stats.BlockWrite,
+		BandwidthRate:      result.Stats.BandwidthRate,
+		TotalFee:           result.Stats.TotalCost,
+		StaticComplexity:   result.Stats.StaticComplexity,
+		DynamicComplexity:  result.Stats.DynamicComplexity,
+		ExecutionTimestamp: time.Now().UTC(),
+		ScriptRecording:    result.Recording,
+	}
+}
@@ -6,11 +6,13 @@ import (
 	"math"
 	"math/big"
 	"reflect"
+	"regexp"
 	"strconv"
 	"strings"
 
 	"github.com/ethereum/go-ethereum/accounts/abi"
 	ethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/trigg3rX/triggerx-backend-imua/pkg/docker"
 )
 
 type ArgumentConverter struct{}
@@ -317,3 +319,128 @@ func (ac *ArgumentConverter) convertToStruct(value interface{}, targetType abi.T
 
 	return structValue.Interface(), nil
 }
+
+// convertTypedArgument converts an argument a script gave with its ABI type to
+// the value packed for a parameter of type targetType. The script's type has to
+// be the parameter's. Integers are parsed exactly, not through a float.
+func (ac *ArgumentConverter) convertTypedArgument(argument docker.Argument, targetType abi.Type) (interface{}, error) {
+	declared := abiTypeWord.ReplaceAllStringFunc(strings.ReplaceAll(argument.Type, " ", ""), func(word string) string {
+		if alias, ok := abiTypeAliases[word]; ok {
+			return alias
+		}
+		return word
+	})
+	if declared != targetType.String() {
+		return nil, fmt.Errorf("argument is a %s, the function takes a %s", argument.Type, targetType.String())
+	}
+	return ac.convertTypedValue(argument.Value, targetType)
+}
+
+// Solidity's shorthands for ABI types
+var (
+	abiTypeWord    = regexp.MustCompile(`[A-Za-z0-9]+`)
+	abiTypeAliases = map[string]string{"uint": "uint256", "int": "int256", "byte": "bytes1"}
+)
+
+func (ac *ArgumentConverter) convertTypedValue(raw json.RawMessage, targetType abi.Type) (interface{}, error) {
+	switch targetType.T {
+	case abi.UintTy, abi.IntTy:
+		return convertExactInteger(raw, targetType)
+	case abi.SliceTy, abi.ArrayTy:
+		var elems []json.RawMessage
+		if err := json.Unmarshal(raw, &elems); err != nil {
+			return nil, fmt.Errorf("expected a JSON array for %s: %v", targetType.String(), err)
+		}
+		if targetType.T == abi.ArrayTy && len(elems) != targetType.Size {
+			return nil, fmt.Errorf("expected %d elements for %s, got %d", targetType.Size, targetType.String(), len(elems))
+		}
+
+		var result reflect.Value
+		if targetType.T == abi.ArrayTy {
+			result = reflect.New(targetType.GetType()).Elem()
+		} else {
+			result = reflect.MakeSlice(targetType.GetType(), len(elems), len(elems))
+		}
+		for i, elem := range elems {
+			converted, err := ac.convertTypedValue(elem, *targetType.Elem)
+			if err != nil {
+				return nil, fmt.Errorf("error converting element %d: %v", i, err)
+			}
+			result.Index(i).Set(reflect.ValueOf(converted))
+		}
+		return result.Interface(), nil
+	case abi.TupleTy:
+		var value interface{}
+		if err := json.Unmarshal(raw, &value); err != nil {
+			return nil, err
+		}
+		return ac.convertToType(value, targetType)
+	default:
+		var value interface{}
+		if err := json.Unmarshal(raw, &value); err != nil {
+			return nil, err
+		}
+		converted, err := ac.convertToType(value, targetType)
+		if err != nil {
+			return nil, err
+		}
+		return asGoType(converted, targetType.GetType())
+	}
+}
+
+// convertExactInteger parses a JSON number, or a decimal or 0x string, into the
+// Go type go-ethereum packs targetType from, refusing values out of its range
+func convertExactInteger(raw json.RawMessage, targetType abi.Type) (interface{}, error) {
+	var text string
+	if err := json.Unmarshal(raw, &text); err != nil {
+		var number json.Number
+		decoder := json.NewDecoder(strings.NewReader(string(raw)))
+		decoder.UseNumber()
+		if err := decoder.Decode(&number); err != nil {
+			return nil, fmt.Errorf("expected an integer for %s, got %s", targetType.String(), string(raw))
+		}
+		text = number.String()
+	}
+
+	value, ok := new(big.Int).SetString(strings.TrimSpace(text), 0)
+	if !ok {
+		return nil, fmt.Errorf("expected an integer for %s, got %q", targetType.String(), text)
+	}
+
+	min, max := new(big.Int), new(big.Int).Lsh(big.NewInt(1), uint(targetType.Size))
+	if targetType.T == abi.IntTy {
+		max.Rsh(max, 1)
+		min.Neg(max)
+	}
+	max.Sub(max, big.NewInt(1))
+	if value.Cmp(min) < 0 || value.Cmp(max) > 0 {
+		return nil, fmt.Errorf("%s is out of range for %s", value.String(), targetType.String())
+	}
+
+	if targetType.Size > 64 {
+		return value, nil
+	}
+	result := reflect.New(targetType.GetType()).Elem()
+	if targetType.T == abi.UintTy {
+		result.SetUint(value.Uint64())
+	} else {
+		result.SetInt(value.Int64())
+	}
+	return result.Interface(), nil
+}
+
+// asGoType converts a value to the exact Go type go-ethereum packs, such as a
+// fixed size array for bytes32
+func asGoType(value interface{}, goType reflect.Type) (interface{}, error) {
+	v := reflect.ValueOf(value)
+	if v.Type() == goType {
+		return value, nil
+	}
+	if v.Kind() == reflect.Slice && goType.Kind() == reflect.Array && v.Len() != goType.Len() {
+		return nil, fmt.Errorf("expected %d bytes, got %d", goType.Len(), v.Len())
+	}
+	if !v.Type().ConvertibleTo(goType) {
+		return nil, fmt.Errorf("cannot convert %s to %s", v.Type(), goType)
+	}
+	return v.Convert(goType).Interface(), nil
+}
@@ -8,6 +8,7 @@ import (
 	"github.com/ethereum/go-ethereum/accounts/abi"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/stretchr/testify/assert"
+	"github.com/trigg3rX/triggerx-backend-imua/pkg/docker"
 )
 
 // ===========================================
@@ -402,3 +403,83 @@ func TestConvertToAddress_UnsupportedType(t *testing.T) {
 	assert.Equal(t, common.Address{}, result)
 	assert.Contains(t, err.Error(), "cannot convert")
 }
+
+// ===========================================
+// UNIT TESTS FOR ArgumentConverter.convertTypedArgument
+// ===========================================
+
+func typedArgument(abiType string, value string) docker.Argument {
+	return docker.Argument{Type: abiType, Value: []byte(value)}
+}
+
+func TestConvertTypedArgument_ExactUint256(t *testing.T) {
+	converter := &ArgumentConverter{}
+	uintType, _ := abi.NewType("uint256", "", nil)
+
+	// Past float64 precision, as a string and as a number
+	for _, value := range []string{`"123456789012345678901234567890"`, `123456789012345678901234567890`} {
+		result, err := converter.convertTypedArgument(typedArgument("uint256", value), uintType)
+		assert.NoError(t, err)
+		assert.Equal(t, "123456789012345678901234567890", result.(*big.Int).String())
+	}
+
+	// uint is uint256
+	result, err := converter.convertTypedArgument(typedArgument("uint", `"0xff"`), uintType)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(255), result.(*big.Int).Int64())
+}
+
+func TestConvertTypedArgument_SmallIntegersAndRange(t *testing.T) {
+	converter := &ArgumentConverter{}
+	uint8Type, _ := abi.NewType("uint8", "", nil)
+	int8Type, _ := abi.NewType("int8", "", nil)
+
+	result, err := converter.convertTypedArgument(typedArgument("uint8", `"255"`), uint8Type)
+	assert.NoError(t, err)
+	assert.Equal(t, uint8(255), result)
+
+	result, err = converter.convertTypedArgument(typedArgument("int8", `-128`), int8Type)
+	assert.NoError(t, err)
+	assert.Equal(t, int8(-128), result)
+
+	_, err = converter.convertTypedArgument(typedArgument("uint8", `"256"`), uint8Type)
+	assert.Error(t, err)
+	_, err = converter.convertTypedArgument(typedArgument("int8", `128`), int8Type)
+	assert.Error(t, err)
+	_, err = converter.convertTypedArgument(typedArgument("uint8", `1.5`), uint8Type)
+	assert.Error(t, err)
+}
+
+func TestConvertTypedArgument_TypeMismatch(t *testing.T) {
+	converter := &ArgumentConverter{}
+	addressType, _ := abi.NewType("address", "", nil)
+
+	_, err := converter.convertTypedArgument(typedArgument("uint256", `"1"`), addressType)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "the function takes a address")
+}
+
+func TestConvertTypedArgument_ArraysAndFixedBytes(t *testing.T) {
+	converter := &ArgumentConverter{}
+
+	sliceType, _ := abi.NewType("uint256[]", "", nil)
+	result, err := converter.convertTypedArgument(typedArgument("uint256[]", `["1", "2"]`), sliceType)
+	assert.NoError(t, err)
+	values := result.([]*big.Int)
+	assert.Len(t, values, 2)
+	assert.Equal(t, int64(2), values[1].Int64())
+
+	arrayType, _ := abi.NewType("address[2]", "", nil)
+	result, err = converter.convertTypedArgument(typedArgument("address[2]",
+		`["0x1234567890123456789012345678901234567890", "0x0000000000000000000000000000000000000001"]`), arrayType)
+	assert.NoError(t, err)
+	assert.Equal(t, common.HexToAddress("0x1234567890123456789012345678901234567890"), result.([2]common.Address)[0])
+
+	_, err = converter.convertTypedArgument(typedArgument("address[2]", `["0x1234567890123456789012345678901234567890"]`), arrayType)
+	assert.Error(t, err)
+
+	bytes32Type, _ := abi.NewType("bytes32", "", nil)
+	result, err = converter.convertTypedArgument(typedArgument("bytes32", `"0x`+strings.Repeat("ab", 32)+`"`), bytes32Type)
+	assert.NoError(t, err)
+	assert.Equal(t, byte(0xab), result.([32]byte)[31])
+}
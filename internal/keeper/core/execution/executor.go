@@ -2,9 +2,8 @@ package execution
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
-	// "strconv"
+	"strconv"
 	"time"
 
 	"github.com/ethereum/go-ethereum/common"
@@ -54,6 +53,14 @@ func (e *TaskExecutor) ExecuteTask(ctx context.Context, task *types.SendTaskData
 		return false, fmt.Errorf("task data cannot be nil")
 	}
 
+	trace := newTaskTrace(task.TaskID, traceID)
+	success, err := e.executeTask(ctx, task, trace, traceID)
+	e.reportResult(ctx, task, trace.finish(success, err), traceID)
+	return success, err
+}
+
+// executeTask executes every target of a task concurrently, timing each step in the trace
+func (e *TaskExecutor) executeTask(ctx context.Context, task *types.SendTaskDataToKeeper, trace *taskTrace, traceID string) (bool, error) {
 	// Check for nil TargetData and TriggerData
 	if task.TargetData == nil {
 		e.logger.Error("TargetData is nil", "task_id", task.TaskID, "trace_id", traceID)
@@ -65,7 +72,9 @@ func (e *TaskExecutor) ExecuteTask(ctx context.Context, task *types.SendTaskData
 	}
 
 	// check if the scheduler signature is valid
+	endStep := trace.step(wholeTask, StepSchedulerSignature)
 	isSchedulerSignatureTrue, err := e.validator.ValidateSchedulerSignature(task, traceID)
+	endStep(validity(isSchedulerSignatureTrue), err)
 	if !isSchedulerSignatureTrue {
 		e.logger.Error("Scheduler signature validation failed", "task_id", task.TaskID, "trace_id", traceID, "error", err)
 		return false, err
@@ -77,12 +86,19 @@ func (e *TaskExecutor) ExecuteTask(ctx context.Context, task *types.SendTaskData
 			success bool
 			err     error
 		}, len(task.TargetData))
+		// one proof per target, each written by the target's goroutine
+		batch  = config.IsProofBatchUpload() && len(task.TargetData) > 1
+		proofs = make([]types.IPFSData, len(task.TargetData))
 	)
 
 	for i := range len(task.TargetData) {
 		go func(idx int) {
+			target := trace.target(idx)
+
 			// check if trigger is valid
+			endStep := target.step(StepTriggerValidation)
 			isTriggerTrue, err := e.validator.ValidateTrigger(&task.TriggerData[idx], traceID)
+			endStep(validity(isTriggerTrue), err)
 			if !isTriggerTrue {
 				e.logger.Error("Trigger validation failed", "task_id", task.TaskID, "trace_id", traceID, "error", err)
 				resultCh <- struct {
@@ -95,7 +111,9 @@ func (e *TaskExecutor) ExecuteTask(ctx context.Context, task *types.SendTaskData
 
 			// create a client for validating event based and performing action
 			rpcURL := utils.GetChainRpcUrl(task.TargetData[idx].TargetChainID)
+			endStep = target.step(StepRPCConnect)
 			client, err := ethclient.Dial(rpcURL)
+			endStep("chain "+task.TargetData[idx].TargetChainID, err)
 			if err != nil {
 				e.logger.Error("Failed to connect to chain", "task_id", task.TaskID, "trace_id", traceID, "error", err)
 				resultCh <- struct {
@@ -107,7 +125,9 @@ func (e *TaskExecutor) ExecuteTask(ctx context.Context, task *types.SendTaskData
 			defer client.Close()
 			e.logger.Debugf("Connected to chain: %s", rpcURL)
 
+			endStep = target.step(StepNonce)
 			nonce, err := client.PendingNonceAt(context.Background(), common.HexToAddress(config.GetKeeperAddress()))
+			endStep(strconv.FormatUint(nonce, 10), err)
 			if err != nil {
 				e.logger.Error("Failed to get pending nonce", "task_id", task.TaskID, "trace_id", traceID, "error", err)
 				resultCh <- struct {
@@ -119,7 +139,7 @@ func (e *TaskExecutor) ExecuteTask(ctx context.Context, task *types.SendTaskData
 
 			// execute the action
 			var actionData types.PerformerActionData
-			actionData, err = e.executeAction(&task.TargetData[idx], &task.TriggerData[idx], nonce, client)
+			actionData, err = e.executeAction(&task.TargetData[idx], &task.TriggerData[idx], nonce, client, target)
 			if err != nil {
 				e.logger.Error("Failed to execute action", "task_id", task.TaskID, "trace_id", traceID, "error", err)
 				resultCh <- struct {
@@ -147,14 +167,19 @@ func (e *TaskExecutor) ExecuteTask(ctx context.Context, task *types.SendTaskData
 			ipfsData.PerformerSignature.TaskID = task.TaskID
 			ipfsData.PerformerSignature.PerformerSigningAddress = config.GetConsensusAddress()
 
-			tlsConfig := proof.DefaultTLSProofConfig(config.GetTLSProofHost())
-			tlsConfig.TargetPort = config.GetTLSProofPort()
-			proofData, err := proof.GenerateProofWithTLSConnection(ipfsData, tlsConfig)
+			// commit to the task, trigger, receipts and performer; the performer signature covers the root
+			endStep = target.step(StepProof)
+			proofData, err := proof.BuildProof(ipfsData)
+			endStep(proofData.ProofOfTask, err)
 			if err != nil {
-				e.logger.Error("Failed to generate TLS proof, falling back to mock", "task_id", task.TaskID, "trace_id", traceID, "error", err)
-			} else {
-				e.logger.Info("TLS proof generated successfully", "task_id", task.TaskID, "trace_id", traceID)
+				e.logger.Error("Failed to generate proof of task", "task_id", task.TaskID, "trace_id", traceID, "error", err)
+				resultCh <- struct {
+					success bool
+					err     error
+				}{false, err}
+				return
 			}
+			e.logger.Info("Proof of task generated", "task_id", task.TaskID, "trace_id", traceID, "proof_of_task", proofData.ProofOfTask)
 
 			ipfsData.ProofData = &proofData
 			performerSignature, err := cryptography.SignJSONMessage(ipfsData, config.GetPrivateKeyConsensus())
@@ -167,48 +192,29 @@ func (e *TaskExecutor) ExecuteTask(ctx context.Context, task *types.SendTaskData
 				return
 			}
 			ipfsData.PerformerSignature = &types.PerformerSignatureData{
+				TaskID:                  task.TaskID,
 				PerformerSignature:      performerSignature,
 				PerformerSigningAddress: config.GetConsensusAddress(),
 			}
 			e.logger.Info("IPFS data signed", "task_id", task.TaskID, "trace_id", traceID)
 
-			filename := fmt.Sprintf("proof_of_task_%d_%s.json", task.TaskID, time.Now().Format("20060102150405"))
-			ipfsDataBytes, err := json.Marshal(ipfsData)
-			if err != nil {
-				resultCh <- struct {
-					success bool
-					err     error
-				}{false, err}
-				return
-			}
-			cid, err := utils.UploadToIPFS(filename, ipfsDataBytes)
-			if err != nil {
-				e.logger.Error("Failed to upload IPFS data", "task_id", task.TaskID, "trace_id", traceID, "error", err)
+			// in batch mode the proofs of all targets are uploaded together once all are done
+			if batch {
+				proofs[idx] = ipfsData
 				resultCh <- struct {
 					success bool
 					err     error
-				}{false, err}
+				}{true, nil}
 				return
 			}
-			e.logger.Info("IPFS data uploaded", "task_id", task.TaskID, "trace_id", traceID)
 
-			aggregatorData := types.BroadcastDataForValidators{
-				ProofOfTask:        proofData.ProofOfTask,
-				Data:               []byte(cid),
-				TaskDefinitionID:   task.TargetData[idx].TaskDefinitionID,
-				PerformerAddress:   config.GetConsensusAddress(),
-			}
-
-			success, err := e.aggregatorClient.SendTaskToValidators(ctx, &aggregatorData)
-			if !success {
-				e.logger.Error("Failed to send task result to aggregator", "task_id", task.TaskID, "error", err, "trace_id", traceID)
+			if err := e.submitProofs(ctx, task.TaskID, task.TargetData[idx].TaskDefinitionID, []types.IPFSData{ipfsData}, target, traceID); err != nil {
 				resultCh <- struct {
 					success bool
 					err     error
-				}{false, fmt.Errorf("failed to send task result to aggregator")}
+				}{false, err}
 				return
 			}
-			e.logger.Info("Task result sent to aggregator", "task_id", task.TaskID, "trace_id", traceID)
 			resultCh <- struct {
 				success bool
 				err     error
@@ -222,9 +228,77 @@ func (e *TaskExecutor) ExecuteTask(ctx context.Context, task *types.SendTaskData
 			return false, res.err
 		}
 	}
+
+	if batch {
+		if err := e.submitProofs(ctx, task.TaskID, task.TargetData[0].TaskDefinitionID, proofs, trace.target(wholeTask), traceID); err != nil {
+			return false, err
+		}
+	}
 	return true, nil
 }
 
+// submitProofs uploads signed proofs as one envelope and sends its CID to the
+// aggregator, with the root committing to all of them as the proof of task
+func (e *TaskExecutor) submitProofs(ctx context.Context, taskID int64, taskDefinitionID int, proofs []types.IPFSData, trace targetTrace, traceID string) error {
+	proofOfTask, err := proof.BatchRoot(proofs)
+	if err != nil {
+		e.logger.Error("Failed to commit to the proofs", "task_id", taskID, "trace_id", traceID, "error", err)
+		return err
+	}
+
+	filename := fmt.Sprintf("proof_of_task_%d_%s.json.zst", taskID, time.Now().Format("20060102150405"))
+	endStep := trace.step(StepIPFSUpload)
+	cid, err := utils.UploadProofs(filename, proofs)
+	endStep(cid, err)
+	if err != nil {
+		e.logger.Error("Failed to upload IPFS data", "task_id", taskID, "trace_id", traceID, "error", err)
+		return err
+	}
+	e.logger.Info("IPFS data uploaded", "task_id", taskID, "trace_id", traceID, "proofs", len(proofs))
+
+	aggregatorData := types.BroadcastDataForValidators{
+		ProofOfTask:      proofOfTask,
+		Data:             []byte(cid),
+		TaskDefinitionID: taskDefinitionID,
+		PerformerAddress: config.GetConsensusAddress(),
+	}
+
+	endStep = trace.step(StepAggregatorSend)
+	success, err := e.aggregatorClient.SendTaskToValidators(ctx, &aggregatorData)
+	if err == nil && !success {
+		err = fmt.Errorf("aggregator did not accept the task result")
+	}
+	endStep(proofOfTask, err)
+	if !success {
+		e.logger.Error("Failed to send task result to aggregator", "task_id", taskID, "error", err, "trace_id", traceID)
+		return fmt.Errorf("failed to send task result to aggregator")
+	}
+	e.logger.Info("Task result sent to aggregator", "task_id", taskID, "trace_id", traceID)
+	return nil
+}
+
+// reportResult signs the trace of a task with the keeper key and reports it to
+// the orchestrator, so a failed task carries the performer's own account of why
+func (e *TaskExecutor) reportResult(ctx context.Context, task *types.SendTaskDataToKeeper, trace types.ExecutionTrace, traceID string) {
+	trace.PerformerAddress = config.GetKeeperAddress()
+	signature, err := cryptography.SignJSONMessage(trace, config.GetPrivateKeyController())
+	if err != nil {
+		e.logger.Error("Failed to sign execution trace", "task_id", task.TaskID, "trace_id", traceID, "error", err)
+		return
+	}
+	trace.Signature = signature
+
+	taskDefinitionID := 0
+	if len(task.TargetData) > 0 {
+		taskDefinitionID = task.TargetData[0].TaskDefinitionID
+	}
+	if _, err := e.aggregatorClient.SendTaskResult(ctx, &types.TaskResultData{ExecutionTrace: &trace}, taskDefinitionID); err != nil {
+		e.logger.Error("Failed to report task result", "task_id", task.TaskID, "trace_id", traceID, "error", err)
+		return
+	}
+	e.logger.Info("Task result reported", "task_id", task.TaskID, "trace_id", traceID, "success", trace.Success, "steps", len(trace.Steps))
+}
+
 // func parseStringToInt(str string) int {
 // 	num, err := strconv.Atoi(str)
 // 	if err != nil {
@@ -0,0 +1,313 @@
+package execution
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	ethcommon "github.com/ethereum/go-ethereum/common"
+	ethtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/trigg3rX/triggerx-backend-imua/internal/keeper/config"
+	"github.com/trigg3rX/triggerx-backend-imua/internal/keeper/metrics"
+	"github.com/trigg3rX/triggerx-backend-imua/internal/keeper/utils"
+	"github.com/trigg3rX/triggerx-backend-imua/pkg/docker"
+	"github.com/trigg3rX/triggerx-backend-imua/pkg/proof"
+	"github.com/trigg3rX/triggerx-backend-imua/pkg/types"
+)
+
+// Proxy hub functions used by the keeper. executeFunctionBatch makes the calls in
+// order within one transaction, and reverts all of them if any one reverts. Not
+// every proxy hub has it, so it is only used with PROXY_HUB_BATCH set.
+const proxyHubABI = `[
+	{"inputs":[{"name":"target","type":"address"},{"name":"data","type":"bytes"}],"name":"executeFunction","outputs":[],"stateMutability":"payable","type":"function"},
+	{"inputs":[{"name":"targets","type":"address[]"},{"name":"data","type":"bytes[]"}],"name":"executeFunctionBatch","outputs":[],"stateMutability":"payable","type":"function"}
+]`
+
+// Gas limit of a transaction, per target call it makes
+const gasLimitPerCall = 300000
+
+// packedCall is a target call of a task with its call data ready
+type packedCall struct {
+	index  int
+	call   types.TargetCall
+	target ethcommon.Address
+	data   []byte
+}
+
+// chainCalls are the calls of a task on one chain, sent in a single transaction
+type chainCalls struct {
+	chainID string
+	calls   []packedCall
+}
+
+// packTargetCall packs the call data of a target call from its arguments
+func (e *TaskExecutor) packTargetCall(index int, call types.TargetCall, args interface{}) (packedCall, error) {
+	contractABI, method, err := e.getContractMethodAndABI(call.TargetFunction, &types.TaskTargetData{
+		TargetContractAddress: call.TargetContractAddress,
+		ABI:                   call.ABI,
+	})
+	if err != nil {
+		return packedCall{}, fmt.Errorf("target %d: failed to get contract method and ABI: %v", index, err)
+	}
+
+	convertedArgs, err := e.processArguments(args, method.Inputs, contractABI)
+	if err != nil {
+		return packedCall{}, fmt.Errorf("target %d: error processing arguments: %v", index, err)
+	}
+
+	data, err := contractABI.Pack(method.Name, convertedArgs...)
+	if err != nil {
+		return packedCall{}, fmt.Errorf("target %d: error packing arguments: %v", index, err)
+	}
+
+	return packedCall{
+		index:  index,
+		call:   call,
+		target: ethcommon.HexToAddress(call.TargetContractAddress),
+		data:   data,
+	}, nil
+}
+
+// packTaskCalls packs the primary target's call with the arguments given, then the
+// extra targets', which take static arguments
+func (e *TaskExecutor) packTaskCalls(targetData *types.TaskTargetData, argData interface{}) ([]packedCall, error) {
+	calls := make([]packedCall, 0, 1+len(targetData.ExtraTargets))
+	for i, call := range targetData.TargetCalls() {
+		var args interface{} = argData
+		if i > 0 {
+			args = e.parseStaticArgs(call.Arguments)
+		}
+		packed, err := e.packTargetCall(i, call, args)
+		if err != nil {
+			return nil, err
+		}
+		calls = append(calls, packed)
+	}
+	return calls, nil
+}
+
+// ProxyHubInputs packs the proxy hub input the calls of a performed task were
+// sent with, by chain. The primary target takes the task's static arguments, or
+// those in the recorded output of its dynamic arguments script, which validators
+// check by replaying it.
+func (e *TaskExecutor) ProxyHubInputs(targetData *types.TaskTargetData, actionData *types.PerformerActionData) (map[string][]byte, error) {
+	var argData interface{}
+	switch targetData.TaskDefinitionID {
+	case 2, 4, 6:
+		if actionData.ScriptRecording == nil {
+			return nil, fmt.Errorf("no recording of the dynamic arguments script")
+		}
+		result := &docker.ExecutionResult{Output: actionData.ScriptRecording.Output}
+		if parsed, err := docker.ParseScriptResult([]byte(result.Output)); err == nil {
+			result.Result = parsed
+		}
+		argData = e.dynamicArgs(result)
+	default:
+		argData = e.parseStaticArgs(targetData.Arguments)
+	}
+
+	calls, err := e.packTaskCalls(targetData, argData)
+	if err != nil {
+		return nil, err
+	}
+	inputs := make(map[string][]byte)
+	for _, group := range groupCallsByChain(calls) {
+		input, err := packProxyHubInput(group.calls)
+		if err != nil {
+			return nil, err
+		}
+		inputs[group.chainID] = input
+	}
+	return inputs, nil
+}
+
+// groupCallsByChain groups calls by their chain. Chains keep the order of their
+// first call, and calls keep their order within a chain.
+func groupCallsByChain(calls []packedCall) []chainCalls {
+	var groups []chainCalls
+	position := make(map[string]int)
+	for _, call := range calls {
+		i, ok := position[call.call.TargetChainID]
+		if !ok {
+			i = len(groups)
+			position[call.call.TargetChainID] = i
+			groups = append(groups, chainCalls{chainID: call.call.TargetChainID})
+		}
+		groups[i].calls = append(groups[i].calls, call)
+	}
+	return groups
+}
+
+// packProxyHubInput packs the proxy hub call for the calls of one chain. A single
+// call goes through executeFunction, several through executeFunctionBatch.
+func packProxyHubInput(calls []packedCall) ([]byte, error) {
+	hubABI, err := abi.JSON(strings.NewReader(proxyHubABI))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse execution contract ABI: %v", err)
+	}
+
+	if len(calls) == 1 {
+		return hubABI.Pack("executeFunction", calls[0].target, calls[0].data)
+	}
+
+	targets := make([]ethcommon.Address, len(calls))
+	data := make([][]byte, len(calls))
+	for i, call := range calls {
+		targets[i] = call.target
+		data[i] = call.data
+	}
+	return hubABI.Pack("executeFunctionBatch", targets, data)
+}
+
+// executeTargetCalls sends one transaction per chain and returns a result for every
+// call, indexed by the call's position in the job, and the receipt of every
+// transaction the chains included. Chains are independent: a
+// failure on one does not stop the others. The client and nonce given are for the
+// first chain, the task's primary target.
+func (e *TaskExecutor) executeTargetCalls(calls []packedCall, privateKey *ecdsa.PrivateKey, client *ethclient.Client, nonce uint64, trace targetTrace) ([]types.TargetCallResult, []types.ActionReceipt, uint64, error) {
+	results := make([]types.TargetCallResult, len(calls))
+	for i, call := range calls {
+		results[i] = types.TargetCallResult{
+			Index:                 call.index,
+			TargetChainID:         call.call.TargetChainID,
+			TargetContractAddress: call.call.TargetContractAddress,
+			TargetFunction:        call.call.TargetFunction,
+		}
+	}
+
+	var receipts []types.ActionReceipt
+	var totalGasUsed uint64
+	var lastErr error
+	sent := 0
+	for i, group := range groupCallsByChain(calls) {
+		chainClient, chainNonce := client, nonce
+		if i > 0 {
+			var err error
+			chainClient, chainNonce, err = dialTargetChain(group.chainID, trace)
+			if err != nil {
+				lastErr = err
+				e.recordChainFailure(group, results, err)
+				continue
+			}
+		}
+
+		receipt, txHash, err := e.sendChainCalls(group, privateKey, chainClient, chainNonce, trace)
+		if i > 0 {
+			chainClient.Close()
+		}
+		if err != nil {
+			lastErr = err
+			e.recordChainFailure(group, results, err)
+			continue
+		}
+
+		sent++
+		status := receipt.Status == ethtypes.ReceiptStatusSuccessful
+		for _, call := range group.calls {
+			result := &results[call.index]
+			result.ActionTxHash = txHash
+			result.GasUsed = strconv.FormatUint(receipt.GasUsed, 10)
+			result.Status = status
+			if !status {
+				result.Error = "transaction reverted"
+			}
+		}
+		if evidence, err := proof.ReceiptEvidence(group.chainID, receipt); err != nil {
+			e.logger.Warnf("Receipt of %s on chain %s left out of the proof: %v", txHash, group.chainID, err)
+		} else {
+			receipts = append(receipts, evidence)
+		}
+		totalGasUsed += receipt.GasUsed
+		metrics.TransactionsSentTotal.WithLabelValues(group.chainID, "success").Inc()
+		metrics.GasUsedTotal.WithLabelValues(group.chainID).Add(float64(receipt.GasUsed))
+	}
+
+	if sent == 0 {
+		return results, nil, 0, lastErr
+	}
+	return results, receipts, totalGasUsed, nil
+}
+
+// sendChainCalls submits the proxy hub transaction for the calls on one chain
+func (e *TaskExecutor) sendChainCalls(group chainCalls, privateKey *ecdsa.PrivateKey, client *ethclient.Client, nonce uint64, trace targetTrace) (*ethtypes.Receipt, string, error) {
+	executionContractAddress := utils.GetProxyHubAddress(group.chainID)
+	if executionContractAddress == "" {
+		return nil, "", fmt.Errorf("no proxy hub on chain %s", group.chainID)
+	}
+	// Separate transactions would break the all-or-nothing promise of the calls on a chain
+	if len(group.calls) > 1 && !config.IsProxyHubBatch() {
+		return nil, "", fmt.Errorf("%d calls on chain %s need executeFunctionBatch, which is not enabled", len(group.calls), group.chainID)
+	}
+
+	executionInput, err := packProxyHubInput(group.calls)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to pack execution contract input: %v", err)
+	}
+
+	gasPrice, err := client.SuggestGasPrice(context.Background())
+	if err != nil {
+		return nil, "", err
+	}
+	chainID, err := client.ChainID(context.Background())
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to get chain ID: %v", err)
+	}
+	hub := ethcommon.HexToAddress(executionContractAddress)
+	gasLimit := uint64(gasLimitPerCall * len(group.calls))
+
+	// The dry run only tells the trace why a transaction would revert. It is sent
+	// regardless, as the receipt of a reverted call is evidence the proof commits to.
+	endStep := trace.step(StepSimulation)
+	_, err = client.CallContract(context.Background(), ethereum.CallMsg{
+		From:     crypto.PubkeyToAddress(privateKey.PublicKey),
+		To:       &hub,
+		Gas:      gasLimit,
+		GasPrice: gasPrice,
+		Data:     executionInput,
+	}, nil)
+	endStep("chain "+group.chainID, err)
+
+	return e.submitTransactionWithRetry(
+		client,
+		privateKey,
+		nonce,
+		hub,
+		executionInput,
+		chainID,
+		gasPrice,
+		gasLimit,
+		trace,
+	)
+}
+
+func (e *TaskExecutor) recordChainFailure(group chainCalls, results []types.TargetCallResult, err error) {
+	e.logger.Errorf("Target calls on chain %s failed: %v", group.chainID, err)
+	metrics.TransactionsSentTotal.WithLabelValues(group.chainID, "failed").Inc()
+	for _, call := range group.calls {
+		results[call.index].Error = err.Error()
+	}
+}
+
+// dialTargetChain connects to a chain other than the primary target's and gets the keeper's nonce there
+func dialTargetChain(chainID string, trace targetTrace) (*ethclient.Client, uint64, error) {
+	endStep := trace.step(StepRPCConnect)
+	client, err := ethclient.Dial(utils.GetChainRpcUrl(chainID))
+	endStep("chain "+chainID, err)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to connect to chain %s: %v", chainID, err)
+	}
+	endStep = trace.step(StepNonce)
+	nonce, err := client.PendingNonceAt(context.Background(), ethcommon.HexToAddress(config.GetKeeperAddress()))
+	endStep(fmt.Sprintf("chain %s nonce %d", chainID, nonce), err)
+	if err != nil {
+		client.Close()
+		return nil, 0, fmt.Errorf("failed to get pending nonce on chain %s: %v", chainID, err)
+	}
+	return client, nonce, nil
+}
@@ -0,0 +1,55 @@
+package execution
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	ethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/trigg3rX/triggerx-backend-imua/pkg/types"
+)
+
+func testCall(index int, chainID string, target string) packedCall {
+	return packedCall{
+		index:  index,
+		call:   types.TargetCall{TargetChainID: chainID, TargetContractAddress: target},
+		target: ethcommon.HexToAddress(target),
+		data:   []byte{byte(index)},
+	}
+}
+
+func TestGroupCallsByChain(t *testing.T) {
+	groups := groupCallsByChain([]packedCall{
+		testCall(0, "84532", "0x01"),
+		testCall(1, "11155420", "0x02"),
+		testCall(2, "84532", "0x03"),
+	})
+
+	require.Len(t, groups, 2)
+	assert.Equal(t, "84532", groups[0].chainID)
+	assert.Equal(t, 0, groups[0].calls[0].index)
+	assert.Equal(t, 2, groups[0].calls[1].index)
+	assert.Equal(t, "11155420", groups[1].chainID)
+	assert.Equal(t, 1, groups[1].calls[0].index)
+}
+
+func TestPackProxyHubInput(t *testing.T) {
+	hubABI, err := abi.JSON(strings.NewReader(proxyHubABI))
+	require.NoError(t, err)
+
+	single, err := packProxyHubInput([]packedCall{testCall(0, "84532", "0x01")})
+	require.NoError(t, err)
+	assert.Equal(t, hubABI.Methods["executeFunction"].ID, single[:4])
+
+	batch, err := packProxyHubInput([]packedCall{testCall(0, "84532", "0x01"), testCall(2, "84532", "0x03")})
+	require.NoError(t, err)
+	method := hubABI.Methods["executeFunctionBatch"]
+	assert.Equal(t, method.ID, batch[:4])
+
+	args, err := method.Inputs.Unpack(batch[4:])
+	require.NoError(t, err)
+	assert.Equal(t, []ethcommon.Address{ethcommon.HexToAddress("0x01"), ethcommon.HexToAddress("0x03")}, args[0])
+	assert.Equal(t, [][]byte{{0}, {2}}, args[1])
+}
@@ -0,0 +1,106 @@
+package execution
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/trigg3rX/triggerx-backend-imua/pkg/types"
+)
+
+// Steps of executing a task, as named in its trace
+const (
+	StepSchedulerSignature = "scheduler_signature"
+	StepTriggerValidation  = "trigger_validation"
+	StepRPCConnect         = "rpc_connect"
+	StepNonce              = "nonce"
+	StepScript             = "script"
+	StepSimulation         = "simulation"
+	StepTxSubmit           = "tx_submit"
+	StepTxReplace          = "tx_replace"
+	StepReceipt            = "receipt"
+	StepProof              = "proof"
+	StepIPFSUpload         = "ipfs_upload"
+	StepAggregatorSend     = "aggregator_send"
+)
+
+// Target of the steps of the whole task rather than one of its targets
+const wholeTask = -1
+
+// taskTrace times the steps of executing a task. Targets execute concurrently,
+// so steps are added under a lock. A nil trace records nothing.
+type taskTrace struct {
+	mu    sync.Mutex
+	trace types.ExecutionTrace
+}
+
+func newTaskTrace(taskID int64, traceID string) *taskTrace {
+	return &taskTrace{trace: types.ExecutionTrace{
+		TaskID:    taskID,
+		TraceID:   traceID,
+		StartedAt: time.Now().UTC(),
+	}}
+}
+
+// step starts timing a step. The function returned ends it, with a detail such
+// as a transaction hash, and the error the step failed with, if any.
+func (t *taskTrace) step(target int, name string) func(detail string, err error) {
+	if t == nil {
+		return func(string, error) {}
+	}
+	start := time.Now()
+	return func(detail string, err error) {
+		step := types.ExecutionStep{
+			Name:       name,
+			Target:     target,
+			StartedAt:  start.UTC(),
+			DurationMs: time.Since(start).Milliseconds(),
+			Detail:     detail,
+		}
+		if err != nil {
+			step.Error = err.Error()
+		}
+		t.mu.Lock()
+		t.trace.Steps = append(t.trace.Steps, step)
+		t.mu.Unlock()
+	}
+}
+
+// target is the trace of the steps of one target
+func (t *taskTrace) target(index int) targetTrace {
+	return targetTrace{trace: t, index: index}
+}
+
+// finish ends the trace with the outcome of the task, steps in the order they started
+func (t *taskTrace) finish(success bool, err error) types.ExecutionTrace {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	trace := t.trace
+	trace.Steps = append([]types.ExecutionStep(nil), t.trace.Steps...)
+	sort.SliceStable(trace.Steps, func(i, j int) bool { return trace.Steps[i].StartedAt.Before(trace.Steps[j].StartedAt) })
+	trace.DurationMs = time.Since(trace.StartedAt).Milliseconds()
+	trace.Success = success && err == nil
+	if err != nil {
+		trace.Error = err.Error()
+	}
+	return trace
+}
+
+// validity is the detail of a check's step
+func validity(valid bool) string {
+	if valid {
+		return "valid"
+	}
+	return "invalid"
+}
+
+// targetTrace times the steps of one target of a task
+type targetTrace struct {
+	trace *taskTrace
+	index int
+}
+
+func (t targetTrace) step(name string) func(detail string, err error) {
+	return t.trace.step(t.index, name)
+}
@@ -0,0 +1,84 @@
+package execution
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/trigg3rX/triggerx-backend-imua/pkg/cryptography"
+	"github.com/trigg3rX/triggerx-backend-imua/pkg/types"
+)
+
+func TestTaskTrace(t *testing.T) {
+	trace := newTaskTrace(11, "trace-11")
+	trace.step(wholeTask, StepSchedulerSignature)(validity(true), nil)
+
+	var wg sync.WaitGroup
+	for i := range 3 {
+		wg.Add(1)
+		go func(idx int) {
+			defer wg.Done()
+			target := trace.target(idx)
+			target.step(StepRPCConnect)("chain 17000", nil)
+			if idx == 1 {
+				target.step(StepTxSubmit)("", errors.New("nonce too low"))
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	finished := trace.finish(false, errors.New("nonce too low"))
+	assert.Equal(t, int64(11), finished.TaskID)
+	assert.False(t, finished.Success)
+	assert.Equal(t, "nonce too low", finished.Error)
+	require.Len(t, finished.Steps, 5)
+	assert.Equal(t, StepSchedulerSignature, finished.Steps[0].Name)
+	assert.Equal(t, wholeTask, finished.Steps[0].Target)
+	for i := 1; i < len(finished.Steps); i++ {
+		assert.False(t, finished.Steps[i].StartedAt.Before(finished.Steps[i-1].StartedAt), "steps are in the order they started")
+	}
+
+	// A task that reports no error but did not succeed is not a success
+	assert.False(t, newTaskTrace(12, "").finish(false, nil).Success)
+	assert.True(t, newTaskTrace(13, "").finish(true, nil).Success)
+
+	// Steps of no trace are not recorded
+	var none *taskTrace
+	none.target(0).step(StepNonce)("1", nil)
+}
+
+func TestExecutionTraceSignature(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	address := crypto.PubkeyToAddress(key.PublicKey).Hex()
+
+	trace := newTaskTrace(14, "trace-14")
+	trace.target(0).step(StepSimulation)("chain 17000", errors.New("execution reverted: Not Ready"))
+	signed := trace.finish(false, errors.New("transaction reverted"))
+	signed.PerformerAddress = address
+	signed.Signature, err = cryptography.SignJSONMessage(signed, hex.EncodeToString(crypto.FromECDSA(key)))
+	require.NoError(t, err)
+
+	// The orchestrator verifies the trace as it decodes it from the message
+	data, err := json.Marshal(types.TaskResultData{ExecutionTrace: &signed})
+	require.NoError(t, err)
+	var received types.TaskResultData
+	require.NoError(t, json.Unmarshal(data, &received))
+
+	unsigned := *received.ExecutionTrace
+	unsigned.Signature = ""
+	valid, err := cryptography.VerifySignatureFromJSON(unsigned, received.ExecutionTrace.Signature, address)
+	require.NoError(t, err)
+	assert.True(t, valid)
+
+	unsigned.Steps[0].Error = ""
+	valid, err = cryptography.VerifySignatureFromJSON(unsigned, received.ExecutionTrace.Signature, address)
+	require.NoError(t, err)
+	assert.False(t, valid, "a trace altered after signing does not verify")
+}
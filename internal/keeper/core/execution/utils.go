@@ -10,6 +10,7 @@ import (
 	"strings"
 
 	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/trigg3rX/triggerx-backend-imua/pkg/docker"
 	"github.com/trigg3rX/triggerx-backend-imua/pkg/types"
 )
 
@@ -143,6 +144,20 @@ func (e *TaskExecutor) processArguments(args interface{}, methodInputs []abi.Arg
 			}
 			convertedArgs = append(convertedArgs, convertedArg)
 		}
+	case []docker.Argument:
+		// Typed arguments of a script result, one per parameter
+		if len(argData) != len(methodInputs) {
+			return nil, fmt.Errorf("wrong number of arguments: expected %d, got %d",
+				len(methodInputs), len(argData))
+		}
+
+		for i, inputParam := range methodInputs {
+			convertedArg, err := e.argConverter.convertTypedArgument(argData[i], inputParam.Type)
+			if err != nil {
+				return nil, fmt.Errorf("error converting argument %d: %v", i, err)
+			}
+			convertedArgs = append(convertedArgs, convertedArg)
+		}
 	case []interface{}:
 		// Handle array of mixed types
 		if len(argData) < len(methodInputs) {
@@ -194,6 +209,18 @@ func (e *TaskExecutor) processArguments(args interface{}, methodInputs []abi.Arg
 	return convertedArgs, nil
 }
 
+// dynamicArgs are the arguments a dynamic arguments script gave: typed in its
+// result, or a JSON array it printed if it wrote no result
+func (e *TaskExecutor) dynamicArgs(result *docker.ExecutionResult) interface{} {
+	if result.Result != nil {
+		for _, line := range result.Result.Logs {
+			e.logger.Debugf("Script log: %s", line)
+		}
+		return result.Result.Arguments
+	}
+	return e.parseDynamicArgs(result.Output)
+}
+
 func (e *TaskExecutor) parseDynamicArgs(output string) []interface{} {
 	var argData []interface{}
 
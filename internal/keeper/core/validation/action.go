@@ -12,6 +12,14 @@ import (
 )
 
 func (v *TaskValidator) ValidateAction(targetData *types.TaskTargetData, triggerData *types.TaskTriggerData, actionData *types.PerformerActionData, client *ethclient.Client, traceID string) (bool, error) {
+	if actionData.Skipped {
+		return v.ValidateSkip(targetData, actionData)
+	}
+
+	if actionData.ActionTxHash == "" {
+		return false, fmt.Errorf("action has no transaction of the primary target")
+	}
+
 	v.logger.Infof("txHash: %s", actionData.ActionTxHash)
 	// time.Sleep(10 * time.Second)
 	// Fetch the tx details from the action data
@@ -33,9 +41,7 @@ func (v *TaskValidator) ValidateAction(targetData *types.TaskTargetData, trigger
 		return false, fmt.Errorf("transaction is not successful")
 	}
 
-	// TODO: get the action tx check right
-	// check if the tx was made to correct target contract
-	// fetch the AA contract address and the transaction from there to complete the flow
+	// Who sent the tx, to where and with which calls is checked with its receipt, in ValidateReceipts
 
 	txTimestamp, err := v.getBlockTimestamp(receipt, utils.GetChainRpcUrl(targetData.TargetChainID))
 	if err != nil {
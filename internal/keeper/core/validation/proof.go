@@ -1,15 +1,38 @@
 package validation
 
 import (
-	"crypto/sha256"
-	"encoding/hex"
+	"bytes"
+	"context"
 	"fmt"
+	"math/big"
 
-	"github.com/trigg3rX/triggerx-backend-imua/internal/keeper/config"
+	"github.com/ethereum/go-ethereum/common"
+	ethtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+
+	"github.com/trigg3rX/triggerx-backend-imua/internal/keeper/utils"
 	"github.com/trigg3rX/triggerx-backend-imua/pkg/proof"
 	"github.com/trigg3rX/triggerx-backend-imua/pkg/types"
 )
 
+// receiptSource is the part of a chain client committed receipts are checked against
+type receiptSource interface {
+	TransactionReceipt(ctx context.Context, txHash common.Hash) (*ethtypes.Receipt, error)
+	HeaderByNumber(ctx context.Context, number *big.Int) (*ethtypes.Header, error)
+}
+
+// transactionSource is the part of a chain client committed transactions are fetched from
+type transactionSource interface {
+	TransactionByHash(ctx context.Context, txHash common.Hash) (*ethtypes.Transaction, bool, error)
+}
+
+// callPacker packs the proxy hub input the calls of a task are sent with, by chain
+type callPacker interface {
+	ProxyHubInputs(targetData *types.TaskTargetData, actionData *types.PerformerActionData) (map[string][]byte, error)
+}
+
+// ValidateProof checks every leaf of the proof of task against the data uploaded
+// with it. Leaves are checked on their own, so a mismatch names the evidence at fault.
 func (v *TaskValidator) ValidateProof(ipfsData types.IPFSData, traceID string) (bool, error) {
 	proofData := ipfsData.ProofData
 	if proofData == nil {
@@ -18,70 +41,155 @@ func (v *TaskValidator) ValidateProof(ipfsData types.IPFSData, traceID string) (
 	if proofData.ProofOfTask == "" {
 		return false, fmt.Errorf("proof of task is empty")
 	}
-	if proofData.CertificateHash == "" {
-		return false, fmt.Errorf("certificate hash is empty")
+	if proofData.Version != proof.ProofVersion {
+		return false, fmt.Errorf("unsupported proof version %d", proofData.Version)
 	}
 
-	// Validate TLS certificate by establishing connection to the same host/port
-	// that was used during proof generation
-	tlsConfig := proof.DefaultTLSProofConfig(config.GetTLSProofHost())
-	tlsConfig.TargetPort = config.GetTLSProofPort()
-
-	connState, err := proof.EstablishTLSConnection(tlsConfig)
-	if err != nil {
-		v.logger.Warn("Failed to establish TLS connection for validation", "trace_id", traceID, "error", err)
+	names := proof.LeafNames(ipfsData)
+	if len(proofData.Leaves) != len(names) {
+		return false, fmt.Errorf("proof has %d leaves, expected %d", len(proofData.Leaves), len(names))
 	}
-	if connState == nil {
-		return false, fmt.Errorf("failed to establish TLS connection for validation")
+	for _, name := range names {
+		data, err := proof.LeafData(ipfsData, name)
+		if err != nil {
+			return false, fmt.Errorf("failed to rebuild %s leaf: %w", name, err)
+		}
+		if err := proof.VerifyLeaf(proofData, name, data); err != nil {
+			return false, err
+		}
+	}
+
+	// The keeper's own TLS connection is an optional attestation; the certificates
+	// behind the script's fetches are committed to in the fetches leaf
+	if proofData.CertificateHash != "" {
+		v.logger.Debug("Proof attests a TLS connection", "trace_id", traceID, "certificate_hash", proofData.CertificateHash)
 	}
 
-	// Verify the certificate hash matches what was recorded in the proof
-	if len(connState.PeerCertificates) == 0 {
-		return false, fmt.Errorf("no peer certificates found during validation")
+	v.logger.Info("Proof validation passed", "trace_id", traceID, "task_id", ipfsData.TaskData.TaskID)
+	return true, nil
+}
+
+// ValidateReceipts checks the receipts the action leaf commits to against the
+// chains that included them, and that they cover every transaction reported.
+// Each transaction must be the performer's, to the proxy hub of its chain, with
+// the calls of the task on that chain as input.
+func (v *TaskValidator) ValidateReceipts(ctx context.Context, ipfsData types.IPFSData, traceID string) (bool, error) {
+	actionData := ipfsData.ActionData
+	if err := receiptsCoverTargets(actionData); err != nil {
+		return false, err
+	}
+	if len(actionData.Receipts) == 0 {
+		return true, nil
 	}
 
-	currentCertHash := sha256.Sum256(connState.PeerCertificates[0].Raw)
-	currentCertHashStr := hex.EncodeToString(currentCertHash[:])
+	if v.callPacker == nil {
+		return false, fmt.Errorf("no call packer to check the transactions against")
+	}
+	inputs, err := v.callPacker.ProxyHubInputs(&ipfsData.TaskData.TargetData[0], actionData)
+	if err != nil {
+		return false, fmt.Errorf("failed to pack the calls of the task: %w", err)
+	}
+	performer := common.HexToAddress(ipfsData.TaskData.PerformerData.KeeperAddress)
 
-	if currentCertHashStr != proofData.CertificateHash {
-		v.logger.Warn("Certificate hash mismatch during validation",
-			"trace_id", traceID,
-			"expected", proofData.CertificateHash,
-			"actual", currentCertHashStr)
-		// Certificate might have been renewed, this is not necessarily an error
-		// but should be logged for investigation
+	for _, receipt := range actionData.Receipts {
+		hub := utils.GetProxyHubAddress(receipt.ChainID)
+		if hub == "" {
+			return false, fmt.Errorf("no proxy hub on chain %s", receipt.ChainID)
+		}
+		client, err := ethclient.Dial(utils.GetChainRpcUrl(receipt.ChainID))
+		if err != nil {
+			return false, fmt.Errorf("failed to connect to chain %s: %v", receipt.ChainID, err)
+		}
+		err = checkReceipt(ctx, client, receipt)
+		if err == nil {
+			err = checkTransaction(ctx, client, receipt, performer, common.HexToAddress(hub), inputs[receipt.ChainID])
+		}
+		client.Close()
+		if err != nil {
+			return false, err
+		}
 	}
 
-	// Validate the proof hash by regenerating it
-	return v.validateProofHash(ipfsData, traceID)
+	v.logger.Info("Receipt validation passed", "trace_id", traceID, "receipts", len(actionData.Receipts))
+	return true, nil
 }
 
-func (v *TaskValidator) validateProofHash(ipfsData types.IPFSData, traceID string) (bool, error) {
-	// Create a copy of IPFS data without the proof for hash validation
-	ipfsDataForValidation := types.IPFSData{
-		TaskData:           ipfsData.TaskData,
-		ActionData:         ipfsData.ActionData,
-		ProofData:          &types.ProofData{},
-		PerformerSignature: &types.PerformerSignatureData{},
+// receiptsCoverTargets checks there is a receipt for every transaction the
+// target results name, and none for a skipped action
+func receiptsCoverTargets(actionData *types.PerformerActionData) error {
+	if actionData == nil {
+		return fmt.Errorf("action data is missing")
+	}
+	if actionData.Skipped {
+		if len(actionData.Receipts) > 0 {
+			return fmt.Errorf("skipped action has %d receipts", len(actionData.Receipts))
+		}
+		return nil
+	}
+	if len(actionData.Receipts) == 0 {
+		return fmt.Errorf("action has no receipts")
 	}
-	ipfsDataForValidation.ProofData.TaskID = ipfsData.TaskData.TaskID
-	ipfsDataForValidation.PerformerSignature.TaskID = ipfsData.TaskData.TaskID
-	ipfsDataForValidation.PerformerSignature.PerformerSigningAddress = ipfsData.PerformerSignature.PerformerSigningAddress
 
-	// Regenerate the proof hash
-	dataStr, err := proof.StringifyIPFSData(ipfsDataForValidation)
-	if err != nil {
-		return false, fmt.Errorf("failed to stringify IPFS data for validation: %w", err)
+	committed := make(map[string]bool, len(actionData.Receipts))
+	for _, receipt := range actionData.Receipts {
+		committed[common.HexToHash(receipt.TxHash).Hex()] = true
+	}
+	for _, result := range actionData.TargetResults {
+		if result.ActionTxHash != "" && !committed[common.HexToHash(result.ActionTxHash).Hex()] {
+			return fmt.Errorf("no receipt for transaction %s of target call %d", result.ActionTxHash, result.Index)
+		}
+	}
+	if actionData.ActionTxHash != "" && !committed[common.HexToHash(actionData.ActionTxHash).Hex()] {
+		return fmt.Errorf("no receipt for transaction %s", actionData.ActionTxHash)
 	}
+	return nil
+}
 
-	expectedProofHash := sha256.Sum256([]byte(dataStr))
-	expectedProofHashStr := hex.EncodeToString(expectedProofHash[:])
+// checkReceipt fetches the receipt of a committed transaction and checks it
+// matches, and that its block is still the canonical one at that height
+func checkReceipt(ctx context.Context, chain receiptSource, committed types.ActionReceipt) error {
+	receipt, err := chain.TransactionReceipt(ctx, common.HexToHash(committed.TxHash))
+	if err != nil {
+		return fmt.Errorf("failed to get receipt of %s on chain %s: %v", committed.TxHash, committed.ChainID, err)
+	}
+	actual, err := proof.ReceiptEvidence(committed.ChainID, receipt)
+	if err != nil {
+		return err
+	}
+	if actual != committed {
+		return fmt.Errorf("receipt of %s on chain %s does not match the proof: included in block %d (%s) with status %d, receipt hash %s",
+			committed.TxHash, committed.ChainID, actual.BlockNumber, actual.BlockHash, actual.Status, actual.ReceiptHash)
+	}
 
-	if expectedProofHashStr != ipfsData.ProofData.ProofOfTask {
-		return false, fmt.Errorf("proof hash validation failed: expected %s, got %s",
-			expectedProofHashStr, ipfsData.ProofData.ProofOfTask)
+	header, err := chain.HeaderByNumber(ctx, new(big.Int).SetUint64(committed.BlockNumber))
+	if err != nil {
+		return fmt.Errorf("failed to get block %d on chain %s: %v", committed.BlockNumber, committed.ChainID, err)
+	}
+	if header.Hash().Hex() != committed.BlockHash {
+		return fmt.Errorf("block %d on chain %s is %s, not %s", committed.BlockNumber, committed.ChainID, header.Hash().Hex(), committed.BlockHash)
 	}
+	return nil
+}
 
-	v.logger.Info("Proof validation passed", "trace_id", traceID, "task_id", ipfsData.TaskData.TaskID)
-	return true, nil
+// checkTransaction checks a committed transaction was sent by the performer to
+// the proxy hub, with the input the calls of the task on its chain pack to
+func checkTransaction(ctx context.Context, chain transactionSource, committed types.ActionReceipt, performer common.Address, hub common.Address, input []byte) error {
+	tx, _, err := chain.TransactionByHash(ctx, common.HexToHash(committed.TxHash))
+	if err != nil {
+		return fmt.Errorf("failed to get transaction %s on chain %s: %v", committed.TxHash, committed.ChainID, err)
+	}
+	sender, err := ethtypes.Sender(ethtypes.LatestSignerForChainID(tx.ChainId()), tx)
+	if err != nil {
+		return fmt.Errorf("failed to recover sender of %s on chain %s: %v", committed.TxHash, committed.ChainID, err)
+	}
+	if sender != performer {
+		return fmt.Errorf("transaction %s on chain %s was sent by %s, not the performer %s", committed.TxHash, committed.ChainID, sender.Hex(), performer.Hex())
+	}
+	if tx.To() == nil || *tx.To() != hub {
+		return fmt.Errorf("transaction %s on chain %s is not to the proxy hub %s", committed.TxHash, committed.ChainID, hub.Hex())
+	}
+	if input == nil || !bytes.Equal(tx.Data(), input) {
+		return fmt.Errorf("transaction %s on chain %s does not make the calls of the task", committed.TxHash, committed.ChainID)
+	}
+	return nil
 }
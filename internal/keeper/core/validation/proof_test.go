@@ -1,12 +1,19 @@
 package validation
 
 import (
+	"context"
+	"math/big"
 	"testing"
 
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	ethtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
-	"github.com/trigg3rX/triggerx-backend-imua/internal/keeper/config"
+	"github.com/stretchr/testify/require"
 	"github.com/trigg3rX/triggerx-backend-imua/pkg/logging"
+	"github.com/trigg3rX/triggerx-backend-imua/pkg/proof"
 	"github.com/trigg3rX/triggerx-backend-imua/pkg/types"
 )
 
@@ -59,173 +66,206 @@ func (m *ProofValidatorMockLogger) With(tags ...interface{}) logging.Logger {
 	return args.Get(0).(logging.Logger)
 }
 
+func proofTestData(t *testing.T) types.IPFSData {
+	ipfsData := types.IPFSData{
+		TaskData: &types.SendTaskDataToKeeper{
+			TaskID:      4,
+			TargetData:  []types.TaskTargetData{{TaskID: 4, TargetChainID: "17000"}},
+			TriggerData: []types.TaskTriggerData{{TaskID: 4}},
+		},
+		ActionData: &types.PerformerActionData{
+			TaskID:       4,
+			ActionTxHash: "0x01",
+			Status:       true,
+			Receipts:     []types.ActionReceipt{{ChainID: "17000", TxHash: "0x01"}},
+		},
+		PerformerSignature: &types.PerformerSignatureData{
+			TaskID:                  4,
+			PerformerSigningAddress: "0x0000000000000000000000000000000000000004",
+		},
+	}
+	proofData, err := proof.BuildProof(ipfsData)
+	require.NoError(t, err)
+	ipfsData.ProofData = &proofData
+	return ipfsData
+}
+
 func TestTaskValidator_ValidateProof(t *testing.T) {
 	// Setup
 	mockLogger := new(ProofValidatorMockLogger)
+	mockLogger.On("Info", "Proof validation passed", mock.Anything).Return()
 	validator := &TaskValidator{
 		logger: mockLogger,
 	}
 
 	tests := []struct {
-		name      string
-		ipfsData  types.IPFSData
-		traceID   string
-		wantValid bool
-		wantErr   bool
-		setupMock func()
+		name    string
+		tamper  func(ipfsData *types.IPFSData)
+		wantErr string
 	}{
 		{
-			name: "missing proof data",
-			ipfsData: types.IPFSData{
-				TaskData: &types.SendTaskDataToKeeper{
-					TaskID: 1,
-				},
-			},
-			traceID:   "test-trace-1",
-			wantValid: false,
-			wantErr:   true,
-			setupMock: func() {},
+			name: "valid proof data",
 		},
 		{
-			name: "empty proof of task",
-			ipfsData: types.IPFSData{
-				TaskData: &types.SendTaskDataToKeeper{
-					TaskID: 2,
-				},
-				ProofData: &types.ProofData{},
-			},
-			traceID:   "test-trace-2",
-			wantValid: false,
-			wantErr:   true,
-			setupMock: func() {},
+			name:    "missing proof data",
+			tamper:  func(ipfsData *types.IPFSData) { ipfsData.ProofData = nil },
+			wantErr: "proof data is missing",
+		},
+		{
+			name:    "empty proof of task",
+			tamper:  func(ipfsData *types.IPFSData) { ipfsData.ProofData = &types.ProofData{} },
+			wantErr: "proof of task is empty",
 		},
 		{
-			name: "empty certificate hash",
-			ipfsData: types.IPFSData{
-				TaskData: &types.SendTaskDataToKeeper{
-					TaskID: 3,
-				},
-				ProofData: &types.ProofData{
-					ProofOfTask: "some-proof",
-				},
+			name: "certificate hash proof of the old format",
+			tamper: func(ipfsData *types.IPFSData) {
+				ipfsData.ProofData = &types.ProofData{TaskID: 4, ProofOfTask: "valid-proof", CertificateHash: "valid-cert-hash"}
 			},
-			traceID:   "test-trace-3",
-			wantValid: false,
-			wantErr:   true,
-			setupMock: func() {},
+			wantErr: "unsupported proof version",
 		},
 		{
-			name: "valid proof data",
-			ipfsData: types.IPFSData{
-				TaskData: &types.SendTaskDataToKeeper{
-					TaskID: 4,
-				},
-				ProofData: &types.ProofData{
-					TaskID:          4,
-					ProofOfTask:     "valid-proof",
-					CertificateHash: "valid-cert-hash",
-				},
-				PerformerSignature: &types.PerformerSignatureData{
-					TaskID:                  4,
-					PerformerSigningAddress: config.GetConsensusAddress(),
-				},
+			name:    "action changed after the proof",
+			tamper:  func(ipfsData *types.IPFSData) { ipfsData.ActionData.ActionTxHash = "0x02" },
+			wantErr: "action leaf",
+		},
+		{
+			name:    "trigger changed after the proof",
+			tamper:  func(ipfsData *types.IPFSData) { ipfsData.TaskData.TriggerData[0].EventTxHash = "0x03" },
+			wantErr: "trigger leaf",
+		},
+		{
+			name: "fetches left out of the proof",
+			tamper: func(ipfsData *types.IPFSData) {
+				ipfsData.ActionData.ScriptRecording = &types.ScriptRecording{
+					Exchanges: []types.HTTPExchange{{Method: "GET", URL: "https://api.example.com/price"}},
+				}
 			},
-			traceID:   "test-trace-4",
-			wantValid: false, // Will be false because we're not actually establishing TLS connection
-			wantErr:   true,
-			setupMock: func() {
-				mockLogger.On("Warn", "Failed to establish TLS connection for validation", mock.Anything).Return()
+			wantErr: "proof has 4 leaves, expected 5",
+		},
+		{
+			name: "leaf swapped for another task's",
+			tamper: func(ipfsData *types.IPFSData) {
+				other := proofTestData(t)
+				other.PerformerSignature.PerformerSigningAddress = "0x0000000000000000000000000000000000000005"
+				otherProof, err := proof.BuildProof(other)
+				require.NoError(t, err)
+				ipfsData.PerformerSignature = other.PerformerSignature
+				ipfsData.ProofData.Leaves[3] = otherProof.Leaves[3]
 			},
+			wantErr: "performer leaf is not under the proof of task",
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			tt.setupMock()
-			valid, err := validator.ValidateProof(tt.ipfsData, tt.traceID)
-			if tt.wantErr {
-				assert.Error(t, err)
+			ipfsData := proofTestData(t)
+			if tt.tamper != nil {
+				tt.tamper(&ipfsData)
+			}
+			valid, err := validator.ValidateProof(ipfsData, "test-trace")
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
 				assert.False(t, valid)
 			} else {
 				assert.NoError(t, err)
-				assert.Equal(t, tt.wantValid, valid)
+				assert.True(t, valid)
 			}
-			mockLogger.AssertExpectations(t)
 		})
 	}
 }
 
-func TestTaskValidator_validateProofHash(t *testing.T) {
-	// Setup
-	mockLogger := new(ProofValidatorMockLogger)
-	validator := &TaskValidator{
-		logger: mockLogger,
+func TestReceiptsCoverTargets(t *testing.T) {
+	actionData := &types.PerformerActionData{
+		ActionTxHash: "0x01",
+		TargetResults: []types.TargetCallResult{
+			{Index: 0, ActionTxHash: "0x01"},
+			{Index: 1, ActionTxHash: "0x02"},
+		},
+		Receipts: []types.ActionReceipt{{TxHash: "0x01"}},
 	}
+	assert.ErrorContains(t, receiptsCoverTargets(actionData), "target call 1")
 
-	tests := []struct {
-		name      string
-		ipfsData  types.IPFSData
-		traceID   string
-		wantValid bool
-		wantErr   bool
-		setupMock func()
-	}{
-		{
-			name: "valid proof hash",
-			ipfsData: types.IPFSData{
-				TaskData: &types.SendTaskDataToKeeper{
-					TaskID: 1,
-				},
-				ProofData: &types.ProofData{
-					TaskID:          1,
-					ProofOfTask:     "valid-hash",
-					CertificateHash: "valid-cert-hash",
-				},
-				PerformerSignature: &types.PerformerSignatureData{
-					TaskID:                  1,
-					PerformerSigningAddress: config.GetConsensusAddress(),
-				},
-			},
-			traceID:   "test-trace-1",
-			wantValid: false, // Will be false because we're not actually generating a real hash
-			wantErr:   true,
-			setupMock: func() {},
-		},
-		{
-			name: "missing task data",
-			ipfsData: types.IPFSData{
-				TaskData: &types.SendTaskDataToKeeper{
-					TaskID: 2,
-				},
-				ProofData: &types.ProofData{
-					TaskID:          2,
-					ProofOfTask:     "valid-hash",
-					CertificateHash: "valid-cert-hash",
-				},
-				PerformerSignature: &types.PerformerSignatureData{
-					TaskID:                  2,
-					PerformerSigningAddress: config.GetConsensusAddress(),
-				},
-			},
-			traceID:   "test-trace-2",
-			wantValid: false,
-			wantErr:   true,
-			setupMock: func() {},
-		},
+	actionData.Receipts = append(actionData.Receipts, types.ActionReceipt{TxHash: "0x02"})
+	assert.NoError(t, receiptsCoverTargets(actionData))
+
+	assert.ErrorContains(t, receiptsCoverTargets(&types.PerformerActionData{ActionTxHash: "0x01"}), "no receipts")
+	assert.NoError(t, receiptsCoverTargets(&types.PerformerActionData{Skipped: true}))
+	assert.Error(t, receiptsCoverTargets(&types.PerformerActionData{Skipped: true, Receipts: actionData.Receipts}))
+}
+
+type fakeChain struct {
+	receipt *ethtypes.Receipt
+	header  *ethtypes.Header
+	tx      *ethtypes.Transaction
+}
+
+func (c *fakeChain) TransactionByHash(ctx context.Context, txHash common.Hash) (*ethtypes.Transaction, bool, error) {
+	if c.tx == nil || c.tx.Hash() != txHash {
+		return nil, false, ethereum.NotFound
 	}
+	return c.tx, false, nil
+}
 
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			tt.setupMock()
-			valid, err := validator.validateProofHash(tt.ipfsData, tt.traceID)
-			if tt.wantErr {
-				assert.Error(t, err)
-				assert.False(t, valid)
-			} else {
-				assert.NoError(t, err)
-				assert.Equal(t, tt.wantValid, valid)
-			}
-			mockLogger.AssertExpectations(t)
-		})
+func (c *fakeChain) TransactionReceipt(ctx context.Context, txHash common.Hash) (*ethtypes.Receipt, error) {
+	if c.receipt == nil || c.receipt.TxHash != txHash {
+		return nil, ethereum.NotFound
 	}
+	return c.receipt, nil
+}
+
+func (c *fakeChain) HeaderByNumber(ctx context.Context, number *big.Int) (*ethtypes.Header, error) {
+	return c.header, nil
+}
+
+func TestCheckReceipt(t *testing.T) {
+	header := &ethtypes.Header{Number: big.NewInt(100), Difficulty: big.NewInt(0)}
+	receipt := &ethtypes.Receipt{
+		Type:        ethtypes.DynamicFeeTxType,
+		Status:      ethtypes.ReceiptStatusSuccessful,
+		TxHash:      common.HexToHash("0x01"),
+		BlockHash:   header.Hash(),
+		BlockNumber: header.Number,
+		GasUsed:     21000,
+		Logs:        []*ethtypes.Log{},
+	}
+	chain := &fakeChain{receipt: receipt, header: header}
+	committed, err := proof.ReceiptEvidence("17000", receipt)
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	assert.NoError(t, checkReceipt(ctx, chain, committed))
+
+	forged := committed
+	forged.Status = ethtypes.ReceiptStatusFailed
+	assert.ErrorContains(t, checkReceipt(ctx, chain, forged), "does not match the proof")
+
+	// The block the receipt was in was reorged out
+	chain.header = &ethtypes.Header{Number: big.NewInt(100), Difficulty: big.NewInt(1)}
+	assert.ErrorContains(t, checkReceipt(ctx, chain, committed), "block 100")
+
+	chain.receipt = nil
+	assert.ErrorContains(t, checkReceipt(ctx, chain, committed), "failed to get receipt")
+}
+
+func TestCheckTransaction(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	performer := crypto.PubkeyToAddress(key.PublicKey)
+	hub := common.HexToAddress("0x68605feB94a8FeBe5e1fBEF0A9D3fE6e80cEC126")
+	input := []byte{0x01, 0x02}
+
+	tx, err := ethtypes.SignTx(ethtypes.NewTransaction(0, hub, big.NewInt(0), 300000, big.NewInt(1), input), ethtypes.NewEIP155Signer(big.NewInt(17000)), key)
+	require.NoError(t, err)
+	chain := &fakeChain{tx: tx}
+	committed := types.ActionReceipt{ChainID: "17000", TxHash: tx.Hash().Hex()}
+	ctx := context.Background()
+
+	assert.NoError(t, checkTransaction(ctx, chain, committed, performer, hub, input))
+	assert.ErrorContains(t, checkTransaction(ctx, chain, committed, common.HexToAddress("0x01"), hub, input), "not the performer")
+	assert.ErrorContains(t, checkTransaction(ctx, chain, committed, performer, common.HexToAddress("0x01"), input), "not to the proxy hub")
+	assert.ErrorContains(t, checkTransaction(ctx, chain, committed, performer, hub, []byte{0x01}), "does not make the calls")
+	assert.ErrorContains(t, checkTransaction(ctx, chain, committed, performer, hub, nil), "does not make the calls")
+
+	chain.tx = nil
+	assert.ErrorContains(t, checkTransaction(ctx, chain, committed, performer, hub, input), "failed to get transaction")
 }
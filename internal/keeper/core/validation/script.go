@@ -0,0 +1,74 @@
+package validation
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/trigg3rX/triggerx-backend-imua/pkg/docker"
+	"github.com/trigg3rX/triggerx-backend-imua/pkg/types"
+)
+
+// scriptReplayer replays recorded executions of dynamic arguments scripts
+type scriptReplayer interface {
+	ReplayScript(ctx context.Context, recording *types.ScriptRecording, noOfAttesters int) (*docker.ExecutionResult, error)
+}
+
+// ValidateDynamicArguments replays the performer's execution of the dynamic
+// arguments script from its recording, and checks the replay gives the same
+// output, byte for byte
+func (v *TaskValidator) ValidateDynamicArguments(ctx context.Context, targetData *types.TaskTargetData, actionData *types.PerformerActionData, traceID string) (bool, error) {
+	switch targetData.TaskDefinitionID {
+	case 2, 4, 6:
+	default:
+		return true, nil
+	}
+
+	if actionData == nil || actionData.ScriptRecording == nil {
+		return false, fmt.Errorf("no recording of the dynamic arguments script")
+	}
+	recording := actionData.ScriptRecording
+	if recording.ScriptURL != targetData.DynamicArgumentsScriptUrl {
+		return false, fmt.Errorf("recording is of script %s, task runs %s", recording.ScriptURL, targetData.DynamicArgumentsScriptUrl)
+	}
+	if v.codeExecutor == nil {
+		return false, fmt.Errorf("no code executor to replay the dynamic arguments script")
+	}
+
+	result, err := v.codeExecutor.ReplayScript(ctx, recording, 1)
+	if err != nil {
+		return false, fmt.Errorf("failed to replay dynamic arguments script: %w", err)
+	}
+	if !result.Success {
+		return false, fmt.Errorf("replay of dynamic arguments script failed: %v", result.Error)
+	}
+	if result.Output != recording.Output {
+		return false, fmt.Errorf("replayed output of dynamic arguments script differs from the recorded output")
+	}
+	return true, nil
+}
+
+// ValidateSkip checks a task that sent no transaction was skipped by its dynamic
+// arguments script. That the script really asked to skip is shown by replaying
+// it, in ValidateDynamicArguments.
+func (v *TaskValidator) ValidateSkip(targetData *types.TaskTargetData, actionData *types.PerformerActionData) (bool, error) {
+	switch targetData.TaskDefinitionID {
+	case 2, 4, 6:
+	default:
+		return false, fmt.Errorf("task definition %d has no script that can skip it", targetData.TaskDefinitionID)
+	}
+	if actionData.ActionTxHash != "" || len(actionData.TargetResults) > 0 {
+		return false, fmt.Errorf("skipped task has a transaction")
+	}
+	if actionData.ScriptRecording == nil {
+		return false, fmt.Errorf("no recording of the dynamic arguments script")
+	}
+
+	result, err := docker.ParseScriptResult([]byte(actionData.ScriptRecording.Output))
+	if err != nil {
+		return false, fmt.Errorf("recorded output of dynamic arguments script is not a result: %w", err)
+	}
+	if !result.Skip {
+		return false, fmt.Errorf("dynamic arguments script did not skip the task")
+	}
+	return true, nil
+}
@@ -0,0 +1,121 @@
+package validation
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/trigg3rX/triggerx-backend-imua/pkg/docker"
+	"github.com/trigg3rX/triggerx-backend-imua/pkg/types"
+)
+
+type fakeReplayer struct {
+	result *docker.ExecutionResult
+	err    error
+}
+
+func (f *fakeReplayer) ReplayScript(ctx context.Context, recording *types.ScriptRecording, noOfAttesters int) (*docker.ExecutionResult, error) {
+	return f.result, f.err
+}
+
+func TestTaskValidator_ValidateDynamicArguments(t *testing.T) {
+	const scriptURL = "https://ipfs.io/ipfs/bafyscript"
+	recorded := &types.PerformerActionData{
+		ScriptRecording: &types.ScriptRecording{ScriptURL: scriptURL, Runtime: "go", Output: "[42]\n"},
+	}
+
+	tests := []struct {
+		name       string
+		definition int
+		actionData *types.PerformerActionData
+		replayer   scriptReplayer
+		wantValid  bool
+	}{
+		{
+			name:       "static arguments are not replayed",
+			definition: 1,
+			actionData: &types.PerformerActionData{},
+			wantValid:  true,
+		},
+		{
+			name:       "same output",
+			definition: 2,
+			actionData: recorded,
+			replayer:   &fakeReplayer{result: &docker.ExecutionResult{Success: true, Output: "[42]\n"}},
+			wantValid:  true,
+		},
+		{
+			name:       "output differs by a byte",
+			definition: 4,
+			actionData: recorded,
+			replayer:   &fakeReplayer{result: &docker.ExecutionResult{Success: true, Output: "[42]"}},
+		},
+		{
+			name:       "replay diverged",
+			definition: 6,
+			actionData: recorded,
+			replayer:   &fakeReplayer{result: &docker.ExecutionResult{Error: errors.New("request is not in the recording")}},
+		},
+		{
+			name:       "no recording",
+			definition: 2,
+			actionData: &types.PerformerActionData{},
+			replayer:   &fakeReplayer{},
+		},
+		{
+			name:       "recording of another script",
+			definition: 2,
+			actionData: &types.PerformerActionData{
+				ScriptRecording: &types.ScriptRecording{ScriptURL: "https://ipfs.io/ipfs/bafyother", Output: "[42]\n"},
+			},
+			replayer: &fakeReplayer{result: &docker.ExecutionResult{Success: true, Output: "[42]\n"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			validator := &TaskValidator{codeExecutor: tt.replayer}
+			targetData := &types.TaskTargetData{TaskDefinitionID: tt.definition, DynamicArgumentsScriptUrl: scriptURL}
+
+			valid, err := validator.ValidateDynamicArguments(context.Background(), targetData, tt.actionData, "trace")
+			assert.Equal(t, tt.wantValid, valid)
+			if tt.wantValid {
+				assert.NoError(t, err)
+			} else {
+				assert.Error(t, err)
+			}
+		})
+	}
+}
+
+func TestTaskValidator_ValidateSkip(t *testing.T) {
+	skip := &types.ScriptRecording{Output: `{"version": 1, "skip": true, "reason": "rewards below gas"}`}
+	act := &types.ScriptRecording{Output: `{"version": 1, "arguments": [{"type": "bool", "value": true}]}`}
+
+	tests := []struct {
+		name       string
+		definition int
+		actionData *types.PerformerActionData
+		wantValid  bool
+	}{
+		{"script skipped", 2, &types.PerformerActionData{Skipped: true, ScriptRecording: skip}, true},
+		{"static task cannot skip", 1, &types.PerformerActionData{Skipped: true, ScriptRecording: skip}, false},
+		{"skip with a transaction", 4, &types.PerformerActionData{Skipped: true, ActionTxHash: "0x01", ScriptRecording: skip}, false},
+		{"script asked to act", 6, &types.PerformerActionData{Skipped: true, ScriptRecording: act}, false},
+		{"no recording", 2, &types.PerformerActionData{Skipped: true}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			validator := &TaskValidator{}
+			valid, err := validator.ValidateSkip(&types.TaskTargetData{TaskDefinitionID: tt.definition}, tt.actionData)
+			assert.Equal(t, tt.wantValid, valid)
+			if tt.wantValid {
+				assert.NoError(t, err)
+			} else {
+				assert.Error(t, err)
+			}
+		})
+	}
+}
@@ -9,6 +9,7 @@ import (
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/ethclient"
 	"github.com/trigg3rX/triggerx-backend-imua/internal/keeper/utils"
+	"github.com/trigg3rX/triggerx-backend-imua/pkg/timeseries"
 	"github.com/trigg3rX/triggerx-backend-imua/pkg/types"
 )
 
@@ -129,6 +130,12 @@ func (v *TaskValidator) IsValidConditionBasedTrigger(triggerData *types.TaskTrig
 		return false, errors.New("expiration time is before trigger timestamp")
 	}
 	// v.logger.Infof("trigger data: %+v", triggerData)
+
+	// windowed conditions are recomputed from the samples the worker recorded
+	if timeseries.IsWindowedCondition(triggerData.ConditionType) {
+		return v.isValidWindowedCondition(triggerData)
+	}
+
 	v.logger.Infof("value: %v | upper limit: %v | lower limit: %v", triggerData.ConditionSatisfiedValue, triggerData.ConditionUpperLimit, triggerData.ConditionLowerLimit)
 
 	// check if the condition was satisfied by the value
@@ -159,3 +166,34 @@ func (v *TaskValidator) IsValidConditionBasedTrigger(triggerData *types.TaskTrig
 
 	return false, nil
 }
+
+func (v *TaskValidator) isValidWindowedCondition(triggerData *types.TaskTriggerData) (bool, error) {
+	if len(triggerData.ConditionSamples) < 2 {
+		return false, fmt.Errorf("windowed condition %s requires at least 2 samples, got %d", triggerData.ConditionType, len(triggerData.ConditionSamples))
+	}
+
+	// the trigger must have happened on the latest sample, not on a stale window
+	latest := triggerData.ConditionSamples[0].Timestamp
+	for _, sample := range triggerData.ConditionSamples {
+		if sample.Timestamp.After(latest) {
+			latest = sample.Timestamp
+		}
+	}
+	if latest.After(triggerData.CurrentTriggerTimestamp.Add(timeTolerance)) {
+		return false, fmt.Errorf("latest sample at %v is after the trigger timestamp %v", latest, triggerData.CurrentTriggerTimestamp)
+	}
+
+	window := time.Duration(triggerData.ConditionWindowSeconds) * time.Second
+	satisfied, err := timeseries.Evaluate(
+		triggerData.ConditionType,
+		triggerData.ConditionSamples,
+		window,
+		triggerData.ConditionLowerLimit,
+		triggerData.ConditionUpperLimit,
+	)
+	if err != nil {
+		return false, err
+	}
+	v.logger.Infof("windowed condition %s over %v with %d samples: %v", triggerData.ConditionType, window, len(triggerData.ConditionSamples), satisfied)
+	return satisfied, nil
+}
@@ -2,31 +2,44 @@ package validation
 
 import (
 	"context"
+	"fmt"
+	"strings"
 
 	"github.com/ethereum/go-ethereum/ethclient"
 	"github.com/trigg3rX/triggerx-backend-imua/internal/keeper/utils"
 	"github.com/trigg3rX/triggerx-backend-imua/pkg/client/aggregator"
 	"github.com/trigg3rX/triggerx-backend-imua/pkg/docker"
 	"github.com/trigg3rX/triggerx-backend-imua/pkg/logging"
+	"github.com/trigg3rX/triggerx-backend-imua/pkg/proof"
 	"github.com/trigg3rX/triggerx-backend-imua/pkg/types"
 )
 
 type TaskValidator struct {
 	alchemyAPIKey    string
 	etherscanAPIKey  string
-	codeExecutor     *docker.CodeExecutor
+	codeExecutor     scriptReplayer
+	callPacker       callPacker
 	aggregatorClient *aggregator.AggregatorClient
 	logger           logging.Logger
 }
 
 func NewTaskValidator(alchemyAPIKey string, etherscanAPIKey string, codeExecutor *docker.CodeExecutor, aggregatorClient *aggregator.AggregatorClient, logger logging.Logger) *TaskValidator {
-	return &TaskValidator{
+	validator := &TaskValidator{
 		alchemyAPIKey:    alchemyAPIKey,
 		etherscanAPIKey:  etherscanAPIKey,
-		codeExecutor:     codeExecutor,
 		aggregatorClient: aggregatorClient,
 		logger:           logger,
 	}
+	if codeExecutor != nil {
+		validator.codeExecutor = codeExecutor
+	}
+	return validator
+}
+
+// SetCallPacker sets what packs the calls of a task, to check the input of its
+// transactions against. The task executor does, and is created after the validator.
+func (v *TaskValidator) SetCallPacker(packer callPacker) {
+	v.callPacker = packer
 }
 
 func (v *TaskValidator) ValidateTask(ctx context.Context, ipfsData types.IPFSData, traceID string) (bool, error) {
@@ -62,6 +75,13 @@ func (v *TaskValidator) ValidateTask(ctx context.Context, ipfsData types.IPFSDat
 	}
 	v.logger.Info("Action validation passed", "task_id", ipfsData.TaskData.TaskID, "trace_id", traceID)
 
+	// check the dynamic arguments replay to the output the performer used
+	isDynamicArgumentsTrue, err := v.ValidateDynamicArguments(ctx, &ipfsData.TaskData.TargetData[0], ipfsData.ActionData, traceID)
+	if !isDynamicArgumentsTrue {
+		v.logger.Error("Dynamic arguments validation failed", "task_id", ipfsData.TaskData.TaskID, "trace_id", traceID, "error", err)
+		return false, err
+	}
+
 	// validate the proof data
 	isProofTrue, err := v.ValidateProof(ipfsData, traceID)
 	if !isProofTrue {
@@ -70,6 +90,13 @@ func (v *TaskValidator) ValidateTask(ctx context.Context, ipfsData types.IPFSDat
 	}
 	v.logger.Info("Proof validation passed", "task_id", ipfsData.TaskData.TaskID, "trace_id", traceID)
 
+	// check the committed receipts against the chains
+	isReceiptsTrue, err := v.ValidateReceipts(ctx, ipfsData, traceID)
+	if !isReceiptsTrue {
+		v.logger.Error("Receipt validation failed", "task_id", ipfsData.TaskData.TaskID, "trace_id", traceID, "error", err)
+		return false, err
+	}
+
 	// check if the performer signature is valid
 	isPerformerSignatureTrue, err := v.ValidatePerformerSignature(ipfsData, traceID)
 	if !isPerformerSignatureTrue {
@@ -81,6 +108,29 @@ func (v *TaskValidator) ValidateTask(ctx context.Context, ipfsData types.IPFSDat
 	return true, nil
 }
 
+// ValidateProofs validates every proof of an upload, of any schema version, and
+// that together they are the proof of task the performer broadcast
+func (v *TaskValidator) ValidateProofs(ctx context.Context, proofs []types.IPFSData, proofOfTask string, traceID string) (bool, error) {
+	if len(proofs) == 0 {
+		return false, fmt.Errorf("upload has no proofs")
+	}
+	root, err := proof.BatchRoot(proofs)
+	if err != nil {
+		return false, err
+	}
+	if !strings.EqualFold(root, proofOfTask) {
+		return false, fmt.Errorf("proofs commit to %s, the proof of task is %s", root, proofOfTask)
+	}
+
+	for _, ipfsData := range proofs {
+		isTaskTrue, err := v.ValidateTask(ctx, ipfsData, traceID)
+		if !isTaskTrue {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
 // ValidateTarget validates a target
 func (v *TaskValidator) ValidateTarget(targetData *types.TaskTargetData, traceID string) (bool, error) {
 	// TODO: Implement target validation
@@ -34,6 +34,14 @@ var (
 		Help:      "Total tasks completed",
 	}, []string{"type"})
 
+	// Tasks whose dynamic arguments script decided not to act
+	TasksSkippedTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: "triggerx",
+		Subsystem: "keeper",
+		Name:      "tasks_skipped_total",
+		Help:      "Total tasks skipped by their dynamic arguments script",
+	})
+
 	// Time taken for task completion, type: executed, validated
 	TaskDurationSeconds = promauto.NewHistogramVec(prometheus.HistogramOpts{
 		Namespace: "triggerx",
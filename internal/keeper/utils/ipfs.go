@@ -1,110 +1,91 @@
 package utils
 
 import (
-	"bytes"
-	"encoding/json"
+	"context"
 	"fmt"
-	"io"
-	"mime/multipart"
-	"net/http"
+	"sync"
 
 	"github.com/trigg3rX/triggerx-backend-imua/internal/keeper/config"
 	"github.com/trigg3rX/triggerx-backend-imua/internal/keeper/metrics"
+	"github.com/trigg3rX/triggerx-backend-imua/pkg/ipfs"
 	"github.com/trigg3rX/triggerx-backend-imua/pkg/types"
 )
 
-func UploadToIPFS(filename string, data []byte) (string, error) {
-	metrics.IPFSUploadSizeBytes.Add(float64(len(data)))
-
-	url := "https://uploads.pinata.cloud/v3/files"
-
-	body := &bytes.Buffer{}
-	writer := multipart.NewWriter(body)
+var (
+	storeMu     sync.Mutex
+	store       ipfs.Store
+	storeConfig ipfs.Config
+	writer      *ipfs.PayloadWriter // Remembers the ABIs uploaded to the store
+)
 
-	// Add the "network" field and set it to "public"
-	if err := writer.WriteField("network", "public"); err != nil {
-		return "", fmt.Errorf("failed to write network field: %v", err)
+// IPFSStore is the store of the configured backend. Pinata credentials only come
+// with the health check-in, so the store is made when first used, and again if
+// they change.
+func IPFSStore() (ipfs.Store, error) {
+	cfg := ipfs.Config{
+		Backend:     ipfs.Backend(config.GetIPFSBackend()),
+		GatewayHost: config.GetIPFSGateway(),
+		APIURL:      config.GetIPFSAPIURL(),
+		Token:       config.GetIPFSAPIToken(),
+		Dir:         config.GetIPFSStoreDir(),
 	}
-
-	part, err := writer.CreateFormFile("file", filename)
-	if err != nil {
-		return "", fmt.Errorf("failed to create form file: %v", err)
-	}
-	if _, err := part.Write(data); err != nil {
-		return "", fmt.Errorf("failed to write data to form: %v", err)
-	}
-	if err := writer.Close(); err != nil {
-		return "", fmt.Errorf("failed to close writer: %v", err)
+	if cfg.Backend == ipfs.BackendPinata || cfg.Backend == "" {
+		cfg.Token = config.GetPinataJWT()
 	}
 
-	req, err := http.NewRequest("POST", url, body)
-	if err != nil {
-		return "", fmt.Errorf("failed to create request: %v", err)
+	storeMu.Lock()
+	defer storeMu.Unlock()
+	if store != nil && cfg == storeConfig {
+		return store, nil
 	}
-
-	req.Header.Set("Authorization", "Bearer "+config.GetPinataJWT())
-	req.Header.Set("Content-Type", writer.FormDataContentType())
-
-	client := &http.Client{}
-	resp, err := client.Do(req)
+	s, err := ipfs.NewStore(cfg)
 	if err != nil {
-		return "", fmt.Errorf("failed to send request: %v", err)
+		return nil, fmt.Errorf("failed to create IPFS store: %w", err)
 	}
-	defer func() {
-		if err := resp.Body.Close(); err != nil {
-			fmt.Printf("Warning: failed to close response body: %v\n", err)
-		}
-	}()
+	store, storeConfig, writer = s, cfg, ipfs.NewPayloadWriter(s)
+	return store, nil
+}
 
-	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("failed to upload to IPFS: status code %d", resp.StatusCode)
+func UploadToIPFS(filename string, data []byte) (string, error) {
+	if filename == "" || len(data) == 0 {
+		return "", fmt.Errorf("nothing to upload")
 	}
+	metrics.IPFSUploadSizeBytes.Add(float64(len(data)))
 
-	respBody, err := io.ReadAll(resp.Body)
+	s, err := IPFSStore()
 	if err != nil {
-		return "", fmt.Errorf("failed to read response body: %v", err)
+		return "", err
 	}
+	return s.Put(context.Background(), filename, data)
+}
 
-	var ipfsResponse struct {
-		Data struct {
-			CID string `json:"cid"`
-		} `json:"data"`
+// UploadProofs uploads the proofs of a task as one compressed envelope
+func UploadProofs(filename string, proofs []types.IPFSData) (string, error) {
+	if _, err := IPFSStore(); err != nil {
+		return "", err
 	}
+	storeMu.Lock()
+	w := writer
+	storeMu.Unlock()
 
-	if err := json.Unmarshal([]byte(respBody), &ipfsResponse); err != nil {
-		return string(respBody), fmt.Errorf("failed to unmarshal IPFS response: %v", err)
+	data, err := w.Encode(context.Background(), proofs)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode proofs: %w", err)
 	}
-
-	cid := ipfsResponse.Data.CID
-
-	return cid, nil
+	return UploadToIPFS(filename, data)
 }
 
-func FetchIPFSContent(cid string) (types.IPFSData, error) {
-	ipfsUrl := "https://" + config.GetIpfsHost() + "/ipfs/" + cid
-	resp, err := http.Get(ipfsUrl)
+// FetchProofs fetches the proofs uploaded under a CID, of any schema version
+func FetchProofs(cid string) ([]types.IPFSData, error) {
+	s, err := IPFSStore()
 	if err != nil {
-		return types.IPFSData{}, fmt.Errorf("failed to fetch IPFS content: %v", err)
+		return nil, err
 	}
-	defer func() {
-		_ = resp.Body.Close()
-	}()
-
-	if resp.StatusCode != http.StatusOK {
-		return types.IPFSData{}, fmt.Errorf("failed to fetch IPFS content: status code %d", resp.StatusCode)
-	}
-
-	body, err := io.ReadAll(resp.Body)
+	body, err := s.Get(context.Background(), cid)
 	if err != nil {
-		return types.IPFSData{}, fmt.Errorf("failed to read response body: %v", err)
+		return nil, fmt.Errorf("failed to fetch IPFS content: %v", err)
 	}
-
-	var ipfsData types.IPFSData
-	if err := json.Unmarshal(body, &ipfsData); err != nil {
-		return types.IPFSData{}, fmt.Errorf("failed to unmarshal IPFS data: %v", err)
-	}
-
 	metrics.IPFSDownloadSizeBytes.Add(float64(len(body)))
 
-	return ipfsData, nil
+	return ipfs.DecodePayload(context.Background(), s, body)
 }
@@ -52,7 +52,7 @@ func TestUploadToIPFS_ErrorCases(t *testing.T) {
 	}
 }
 
-func TestFetchIPFSContent_Success(t *testing.T) {
+func TestFetchProofs_Success(t *testing.T) {
 	// Set IPFS_HOST env var if needed by config.GetIpfsHost
 	if err := os.Setenv("IPFS_HOST", "localhost"); err != nil {
 		t.Fatalf("failed to set IPFS_HOST: %v", err)
@@ -75,22 +75,22 @@ func TestFetchIPFSContent_Success(t *testing.T) {
 	}))
 	defer server.Close()
 
-	// We cannot change the URL in FetchIPFSContent, so this test will only check for network errors (unless the function is refactored)
-	_, err := FetchIPFSContent("testcid")
+	// We cannot change the URL in FetchProofs, so this test will only check for network errors (unless the function is refactored)
+	_, err := FetchProofs("testcid")
 	if err == nil {
-		t.Skip("Cannot test FetchIPFSContent fully without code modification to inject URL")
+		t.Skip("Cannot test FetchProofs fully without code modification to inject URL")
 	}
 }
 
-func TestFetchIPFSContent_NotFound(t *testing.T) {
+func TestFetchProofs_NotFound(t *testing.T) {
 	// This will always hit the real endpoint, so we only check for error on invalid CID
-	_, err := FetchIPFSContent("testcid")
+	_, err := FetchProofs("testcid")
 	if err == nil {
 		t.Error("expected error for empty CID, got nil")
 	}
 }
 
-func TestFetchIPFSContent_InvalidJSON(t *testing.T) {
+func TestFetchProofs_InvalidJSON(t *testing.T) {
 	// This will always hit the real endpoint, so we only check for error on invalid CID
 	// (Cannot test invalid JSON without code modification)
 }
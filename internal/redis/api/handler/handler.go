@@ -1,6 +1,7 @@
 package handler
 
 import (
+	"errors"
 	"net/http"
 	"time"
 
@@ -9,6 +10,7 @@ import (
 	"github.com/trigg3rX/triggerx-backend-imua/internal/redis/metrics"
 	"github.com/trigg3rX/triggerx-backend-imua/internal/redis/streams/jobs"
 	"github.com/trigg3rX/triggerx-backend-imua/internal/redis/streams/tasks"
+	"github.com/trigg3rX/triggerx-backend-imua/pkg/ipfs"
 	"github.com/trigg3rX/triggerx-backend-imua/pkg/logging"
 )
 
@@ -18,15 +20,19 @@ type handler struct {
 	taskStreamMgr    *tasks.TaskStreamManager
 	jobStreamMgr     *jobs.JobStreamManager
 	metricsCollector *metrics.Collector
+	ipfsStore        ipfs.Store
+	pinManager       *ipfs.PinManager
 }
 
 // NewHandler creates a new instance of Handler
-func NewHandler(logger logging.Logger, taskStreamMgr *tasks.TaskStreamManager, jobStreamMgr *jobs.JobStreamManager, metricsCollector *metrics.Collector) *handler {
+func NewHandler(logger logging.Logger, taskStreamMgr *tasks.TaskStreamManager, jobStreamMgr *jobs.JobStreamManager, metricsCollector *metrics.Collector, ipfsStore ipfs.Store, pinManager *ipfs.PinManager) *handler {
 	return &handler{
 		logger:           logger,
 		taskStreamMgr:    taskStreamMgr,
 		jobStreamMgr:     jobStreamMgr,
 		metricsCollector: metricsCollector,
+		ipfsStore:        ipfsStore,
+		pinManager:       pinManager,
 	}
 }
 
@@ -44,6 +50,9 @@ func (h *handler) HandleRoot(c *gin.Context) {
 			"POST /task/validate - Task validation",
 			"POST /p2p/message - P2P message handling",
 			"GET /streams/info - Stream information",
+			"GET /ipfs/pins/report - Bytes kept pinned per job and user (admin token)",
+			"POST /ipfs/pins/dispute - Hold the proofs of a challenged task (admin token)",
+			"POST /ipfs/pins/resolve - Release the proofs of a resolved task (admin token)",
 		},
 	})
 }
@@ -113,7 +122,12 @@ func (h *handler) SubmitTaskFromScheduler(c *gin.Context) {
 			"scheduler_id", request.SchedulerID,
 			"error", err)
 
-		c.JSON(http.StatusInternalServerError, gin.H{
+		// The jobs were paused, so retrying the task will not help
+		status := http.StatusInternalServerError
+		if errors.Is(err, tasks.ErrInsufficientBudget) {
+			status = http.StatusPaymentRequired
+		}
+		c.JSON(status, gin.H{
 			"error":   "Failed to process task",
 			"task_id": request.SendTaskDataToKeeper.TaskID,
 			"details": err.Error(),
@@ -1,240 +1,105 @@
 package handler
 
 import (
-	"encoding/json"
+	"context"
+	"errors"
 	"fmt"
-	"io"
 	"net/http"
-	"time"
 
-	"github.com/trigg3rX/triggerx-backend-imua/internal/registrar/config"
-	"github.com/trigg3rX/triggerx-backend-imua/pkg/logging"
+	"github.com/gin-gonic/gin"
+	"github.com/trigg3rX/triggerx-backend-imua/pkg/ipfs"
 )
 
-// Pinata v3 API structures
-type PinataFile struct {
-	ID        string            `json:"id"`
-	Name      string            `json:"name"`
-	CID       string            `json:"cid"`
-	Size      int64             `json:"size"`
-	MimeType  string            `json:"mime_type"`
-	GroupID   string            `json:"group_id,omitempty"`
-	Keyvalues map[string]string `json:"keyvalues,omitempty"`
-	CreatedAt time.Time         `json:"created_at"`
+type PinTaskRequest struct {
+	TaskID int64 `json:"task_id" binding:"required"`
 }
 
-type PinataListResponse struct {
-	Data struct {
-		Files         []PinataFile `json:"files"`
-		NextPageToken string       `json:"next_page_token,omitempty"`
-	} `json:"data"`
-}
-
-type PinataDeleteResponse struct {
-	Data interface{} `json:"data"`
-}
-
-// Helper function to make authenticated requests to Pinata
-func makePinataRequest(method, url string, body io.Reader, logger logging.Logger) (*http.Response, error) {
-	req, err := http.NewRequest(method, url, body)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+// trackUpload keeps a validated proof upload pinned until its tasks can no
+// longer be challenged
+func (h *handler) trackUpload(ctx context.Context, cid string, upload ipfs.Upload, traceID string) {
+	if h.pinManager == nil || len(upload.Proofs) == 0 {
+		return
 	}
 
-	req.Header.Set("Authorization", "Bearer "+config.GetPinataJWT())
-	req.Header.Set("Content-Type", "application/json")
-
-	resp, err := http.DefaultClient.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("failed to send request: %w", err)
-	}
-
-	return resp, nil
-}
-
-// Find file ID by CID using Pinata v3 API
-func findPinataFileIDByCID(cid string, logger logging.Logger) (string, error) {
-	network := config.GetPinataHost() // Use configurable network from config
-	url := fmt.Sprintf("https://api.pinata.cloud/v3/files/%s?cid=%s&limit=1", network, cid)
-
-	resp, err := makePinataRequest("GET", url, nil, logger)
-	if err != nil {
-		return "", fmt.Errorf("failed to search for CID %s: %w", cid, err)
-	}
-	defer func() {
-		if err := resp.Body.Close(); err != nil {
-			logger.Debugf("failed to close response body: %v", err)
+	first := upload.Proofs[0]
+	task := ipfs.PinTask{CID: cid, Size: upload.Size, Blobs: upload.Blobs}
+	if first.TaskData != nil {
+		task.TaskID = first.TaskData.TaskID
+		if len(first.TaskData.TargetData) > 0 {
+			task.JobID = first.TaskData.TargetData[0].JobID
 		}
-	}()
-
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return "", fmt.Errorf("failed to search for CID %s: status %d, body: %s",
-			cid, resp.StatusCode, string(body))
 	}
-
-	var listResp PinataListResponse
-	if err := json.NewDecoder(resp.Body).Decode(&listResp); err != nil {
-		return "", fmt.Errorf("failed to decode search response for CID %s: %w", cid, err)
-	}
-
-	if len(listResp.Data.Files) == 0 {
-		return "", fmt.Errorf("no file found with CID %s", cid)
+	if first.ActionData != nil {
+		switch {
+		case first.ActionData.Skipped:
+			task.TaskStatus = "skipped"
+		case first.ActionData.Status:
+			task.TaskStatus = "completed"
+		default:
+			task.TaskStatus = "failed"
+		}
 	}
 
-	return listResp.Data.Files[0].ID, nil
-}
-
-// Delete file by ID using Pinata v3 API
-func deletePinataFileByID(fileID string, logger logging.Logger) error {
-	network := config.GetPinataHost() // Use configurable network from config
-	url := fmt.Sprintf("https://api.pinata.cloud/v3/files/%s/%s", network, fileID)
-
-	resp, err := makePinataRequest("DELETE", url, nil, logger)
-	if err != nil {
-		return fmt.Errorf("failed to delete file %s: %w", fileID, err)
-	}
-	defer func() {
-		if err := resp.Body.Close(); err != nil {
-			logger.Debugf("failed to close response body: %v", err)
+	if task.JobID != 0 {
+		userID, err := h.taskStreamMgr.JobUser(task.JobID)
+		if err != nil {
+			// The proof is held all the same, only not counted against a user
+			h.logger.Warn("Failed to get user of proof of task", "trace_id", traceID, "task_id", task.TaskID, "error", err)
 		}
-	}()
-
-	body, _ := io.ReadAll(resp.Body)
-
-	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusAccepted {
-		return fmt.Errorf("failed to delete file %s: status %d, body: %s",
-			fileID, resp.StatusCode, string(body))
+		task.UserID = userID
 	}
 
-	var deleteResp PinataDeleteResponse
-	if err := json.Unmarshal(body, &deleteResp); err != nil {
-		// If we can't parse the response but got a success status, that's still OK
-		logger.Debugf("Could not parse delete response for file %s, but status was %d", fileID, resp.StatusCode)
+	if err := h.pinManager.Track(ctx, task); err != nil {
+		h.logger.Error("Failed to track proof of task", "trace_id", traceID, "task_id", task.TaskID, "cid", cid, "error", err)
 	}
-
-	logger.Infof("Successfully deleted file %s from Pinata", fileID)
-	return nil
 }
 
-// Delete file by CID (finds ID first, then deletes)
-func DeletePinataCID(cid string, logger logging.Logger) error {
-	fileID, err := findPinataFileIDByCID(cid, logger)
+// GetPinReport reports the bytes kept pinned per job and user
+func (h *handler) GetPinReport(c *gin.Context) {
+	if h.pinManager == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Pinning manager is not enabled"})
+		return
+	}
+	report, err := h.pinManager.Report(c.Request.Context())
 	if err != nil {
-		return fmt.Errorf("failed to find file ID for CID %s: %w", cid, err)
+		h.logger.Error("Failed to build pin report", "trace_id", getTraceID(c), "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to build pin report"})
+		return
 	}
-
-	return deletePinataFileByID(fileID, logger)
+	c.JSON(http.StatusOK, report)
 }
 
-// List all files from Pinata v3 API
-func listPinataFiles(logger logging.Logger) ([]PinataFile, error) {
-	network := config.GetPinataHost() // Use configurable network from config
-	url := fmt.Sprintf("https://api.pinata.cloud/v3/files/%s?limit=1000", network)
-
-	var allFiles []PinataFile
-	nextPageToken := ""
-
-	for {
-		requestURL := url
-		if nextPageToken != "" {
-			requestURL = fmt.Sprintf("%s&pageToken=%s", url, nextPageToken)
-		}
-
-		resp, err := makePinataRequest("GET", requestURL, nil, logger)
-		if err != nil {
-			return nil, fmt.Errorf("failed to list files: %w", err)
-		}
-
-		if resp.StatusCode != http.StatusOK {
-			body, _ := io.ReadAll(resp.Body)
-			if err := resp.Body.Close(); err != nil {
-				logger.Debugf("failed to close response body: %v", err)
-			}
-			return nil, fmt.Errorf("failed to list files: status %d, body: %s",
-				resp.StatusCode, string(body))
-		}
-
-		var listResp PinataListResponse
-		if err := json.NewDecoder(resp.Body).Decode(&listResp); err != nil {
-			if err := resp.Body.Close(); err != nil {
-				logger.Debugf("failed to close response body: %v", err)
-			}
-			return nil, fmt.Errorf("failed to decode list response: %w", err)
-		}
-		if err := resp.Body.Close(); err != nil {
-			logger.Debugf("failed to close response body: %v", err)
-		}
-
-		allFiles = append(allFiles, listResp.Data.Files...)
-
-		// Check if there are more pages
-		if listResp.Data.NextPageToken == "" {
-			break
-		}
-		nextPageToken = listResp.Data.NextPageToken
-	}
-
-	return allFiles, nil
+// DisputeTask holds the proofs of a challenged task until the dispute is resolved
+func (h *handler) DisputeTask(c *gin.Context) {
+	h.setPinTaskStatus(c, "disputed", h.pinManager.Dispute)
 }
 
-// Schedule a 24h delayed deletion for a CID
-func scheduleCIDDeletion(cid string, logger logging.Logger) {
-	go func() {
-		logger.Infof("Scheduled deletion for CID %s in 24h", cid)
-		time.Sleep(24 * time.Hour)
-
-		if err := DeletePinataCID(cid, logger); err != nil {
-			logger.Errorf("Failed to delete CID %s after 24h: %v", cid, err)
-		} else {
-			logger.Infof("Successfully deleted CID %s after 24h delay", cid)
-		}
-	}()
+// ResolveTask releases the proofs of a task after the dispute period
+func (h *handler) ResolveTask(c *gin.Context) {
+	h.setPinTaskStatus(c, "resolved", h.pinManager.Resolve)
 }
 
-// Weekly cleanup: delete files older than 1 day every Sunday
-func StartWeeklyPinataCleanup(logger logging.Logger) {
-	go func() {
-		for {
-			now := time.Now().UTC()
-			// Calculate next Sunday 00:05 UTC
-			daysUntilSunday := (7 - int(now.Weekday())) % 7
-			if daysUntilSunday == 0 && now.Hour() >= 0 && now.Minute() >= 5 {
-				daysUntilSunday = 7
-			}
-			nextSunday := time.Date(now.Year(), now.Month(), now.Day(), 0, 5, 0, 0, time.UTC).AddDate(0, 0, daysUntilSunday)
-			wait := nextSunday.Sub(now)
-
-			logger.Infof("Weekly Pinata cleanup scheduled for: %v (in %v)", nextSunday, wait)
-			time.Sleep(wait)
-
-			logger.Info("Starting weekly Pinata cleanup: deleting files older than 1 day...")
-			files, err := listPinataFiles(logger)
-			if err != nil {
-				logger.Errorf("Failed to list Pinata files: %v", err)
-				continue
-			}
-
-			cutoff := time.Now().Add(-24 * time.Hour)
-			deletedCount := 0
-
-			for _, file := range files {
-				if file.CreatedAt.Before(cutoff) {
-					logger.Infof("Deleting old file %s (CID: %s) created at %s",
-						file.ID, file.CID, file.CreatedAt)
-
-					if err := deletePinataFileByID(file.ID, logger); err != nil {
-						logger.Errorf("Failed to delete old file %s: %v", file.ID, err)
-					} else {
-						deletedCount++
-					}
-
-					// Add small delay between deletions to avoid rate limiting
-					time.Sleep(100 * time.Millisecond)
-				}
-			}
+func (h *handler) setPinTaskStatus(c *gin.Context, status string, update func(context.Context, int64) (int, error)) {
+	if h.pinManager == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Pinning manager is not enabled"})
+		return
+	}
+	var request PinTaskRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Invalid request: %v", err)})
+		return
+	}
 
-			logger.Infof("Weekly cleanup completed. Deleted %d old files.", deletedCount)
+	updated, err := update(c.Request.Context(), request.TaskID)
+	if err != nil {
+		if errors.Is(err, ipfs.ErrNotPinned) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
 		}
-	}()
+		h.logger.Error("Failed to update pins of task", "trace_id", getTraceID(c), "task_id", request.TaskID, "status", status, "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update pins of task"})
+		return
+	}
+	h.logger.Info("Updated pins of task", "trace_id", getTraceID(c), "task_id", request.TaskID, "status", status, "pins", updated)
+	c.JSON(http.StatusOK, gin.H{"task_id": request.TaskID, "status": status, "pins": updated})
 }
@@ -7,7 +7,6 @@ import (
 	"net/http"
 
 	"github.com/gin-gonic/gin"
-	"github.com/trigg3rX/triggerx-backend-imua/internal/redis/config"
 	"github.com/trigg3rX/triggerx-backend-imua/pkg/ipfs"
 	"github.com/trigg3rX/triggerx-backend-imua/pkg/types"
 )
@@ -58,6 +57,18 @@ func (h *handler) HandleP2PMessage(c *gin.Context) {
 
 	decodedDataString := string(decodedData)
 
+	// Performers report the result of a task with their trace of executing it
+	var resultData types.TaskResultData
+	if err := json.Unmarshal(decodedData, &resultData); err == nil && resultData.ExecutionTrace != nil {
+		if err := h.taskStreamMgr.ReportTaskResult(resultData.ExecutionTrace); err != nil {
+			h.logger.Error("[HandleP2PMessage] Failed to take task result", "trace_id", traceID, "task_id", resultData.ExecutionTrace.TaskID, "error", err)
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to take task result"})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"success": "Task result received"})
+		return
+	}
+
 	var requestData types.SendTaskDataToKeeper
 	if err := json.Unmarshal([]byte(decodedDataString), &requestData); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
@@ -99,7 +110,7 @@ func (h *handler) HandleValidateRequest(c *gin.Context) {
 	decodedData = string(dataBytes)
 	h.logger.Info("[HandleValidateRequest] Decoded Data CID", "trace_id", traceID, "cid", decodedData)
 
-	ipfsData, err := ipfs.FetchIPFSContent(config.GetPinataHost(), decodedData)
+	upload, err := ipfs.FetchUpload(c.Request.Context(), h.ipfsStore, decodedData)
 	if err != nil {
 		h.logger.Error("[HandleValidateRequest] Failed to fetch IPFS content", "trace_id", traceID, "error", err)
 		c.JSON(http.StatusInternalServerError, ValidationResponse{
@@ -112,7 +123,11 @@ func (h *handler) HandleValidateRequest(c *gin.Context) {
 
 	h.logger.Info("[HandleValidateRequest] Updating task stream and database ...", "trace_id", traceID)
 
-	h.taskStreamMgr.UpdateDatabase(ipfsData)
+	// A batched upload holds the proof of every target of the task
+	for _, ipfsData := range upload.Proofs {
+		h.taskStreamMgr.UpdateDatabase(ipfsData)
+	}
+	h.trackUpload(c.Request.Context(), decodedData, upload, traceID)
 
 	h.logger.Info("[HandleValidateRequest] Task validation completed", "trace_id", traceID)
 	c.JSON(http.StatusOK, ValidationResponse{
@@ -1,6 +1,9 @@
 package api
 
 import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -68,6 +71,19 @@ func TraceMiddleware() gin.HandlerFunc {
 	}
 }
 
+// AdminTokenMiddleware lets through requests bearing the admin token. With no
+// token configured, every request is refused.
+func AdminTokenMiddleware(token string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		given, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if token == "" || !ok || subtle.ConstantTimeCompare([]byte(given), []byte(token)) != 1 {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+			return
+		}
+		c.Next()
+	}
+}
+
 // LoggerMiddleware creates a gin middleware for logging requests
 func LoggerMiddleware(logger logging.Logger) gin.HandlerFunc {
 	return func(c *gin.Context) {
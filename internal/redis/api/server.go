@@ -13,6 +13,7 @@ import (
 	"github.com/trigg3rX/triggerx-backend-imua/internal/redis/metrics"
 	"github.com/trigg3rX/triggerx-backend-imua/internal/redis/streams/jobs"
 	"github.com/trigg3rX/triggerx-backend-imua/internal/redis/streams/tasks"
+	"github.com/trigg3rX/triggerx-backend-imua/pkg/ipfs"
 	"github.com/trigg3rX/triggerx-backend-imua/pkg/logging"
 	gootel "go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
@@ -26,6 +27,7 @@ type Server struct {
 	router     *gin.Engine
 	httpServer *http.Server
 	logger     logging.Logger
+	adminToken string
 }
 
 // Config holds the server configuration
@@ -34,6 +36,7 @@ type Config struct {
 	ReadTimeout    time.Duration
 	WriteTimeout   time.Duration
 	MaxHeaderBytes int
+	AdminToken     string // Bearer token of the proof pinning routes, refused if empty
 }
 
 // Dependencies holds the server dependencies
@@ -42,6 +45,8 @@ type Dependencies struct {
 	TaskStreamMgr    *tasks.TaskStreamManager
 	JobStreamMgr     *jobs.JobStreamManager
 	MetricsCollector *metrics.Collector
+	IPFSStore        ipfs.Store
+	PinManager       *ipfs.PinManager
 }
 
 // NewServer creates a new API server
@@ -67,8 +72,9 @@ func NewServer(cfg Config, deps Dependencies) *Server {
 
 	// Create server instance
 	srv := &Server{
-		router: router,
-		logger: deps.Logger,
+		router:     router,
+		logger:     deps.Logger,
+		adminToken: cfg.AdminToken,
 		httpServer: &http.Server{
 			Addr:           fmt.Sprintf(":%s", cfg.Port),
 			Handler:        router,
@@ -114,7 +120,7 @@ func (s *Server) setupMiddleware() {
 // setupRoutes sets up the routes for the server
 func (s *Server) setupRoutes(deps Dependencies) {
 	// Create handlers
-	redisHandler := handler.NewHandler(deps.Logger, deps.TaskStreamMgr, deps.JobStreamMgr, deps.MetricsCollector)
+	redisHandler := handler.NewHandler(deps.Logger, deps.TaskStreamMgr, deps.JobStreamMgr, deps.MetricsCollector, deps.IPFSStore, deps.PinManager)
 
 	// Redis service routes
 	s.router.GET("/", redisHandler.HandleRoot)
@@ -130,6 +136,12 @@ func (s *Server) setupRoutes(deps Dependencies) {
 	// P2P message handling (similar to keeper)
 	s.router.POST("/task/validate", redisHandler.HandleValidateRequest)
 	s.router.POST("/p2p/message", redisHandler.HandleP2PMessage)
+
+	// Proof pinning routes, for operators only
+	pins := s.router.Group("/ipfs/pins", AdminTokenMiddleware(s.adminToken))
+	pins.GET("/report", redisHandler.GetPinReport)
+	pins.POST("/dispute", redisHandler.DisputeTask)
+	pins.POST("/resolve", redisHandler.ResolveTask)
 }
 
 // InitTracer sets up OpenTelemetry tracing with OTLP exporter for Tempo
@@ -18,6 +18,8 @@ type Config struct {
 
 	// DBServer RPC URL
 	dbServerRPCUrl string
+	// Token the service authenticates to the dbserver with
+	dbServerServiceToken string
 	// Health RPC URL
 	healthRPCUrl string
 	// Aggregator RPC URL
@@ -33,6 +35,22 @@ type Config struct {
 
 	// Pinata Host
 	pinataHost string
+	pinataJWT  string
+
+	// IPFS storage backend, Pinata by default
+	ipfsBackend  string
+	ipfsAPIURL   string
+	ipfsAPIToken string
+	ipfsStoreDir string
+	ipfsGateway  string
+
+	// Proofs of tasks are kept pinned until they can no longer be challenged
+	ipfsChallengeWindow  time.Duration
+	ipfsDisputePeriod    time.Duration
+	ipfsPinSweepInterval time.Duration
+	ipfsArchiveDir       string
+	// Bearer token of the routes that hold and release proofs, refused if empty
+	ipfsPinsAdminToken string
 
 	// Fallback: Local Redis settings (optional)
 	localAddr     string
@@ -65,31 +83,43 @@ func Init() error {
 		return fmt.Errorf("error loading .env file: %w", err)
 	}
 	cfg = Config{
-		devMode:             env.GetEnvBool("DEV_MODE", false),
-		redisRPCPort:        env.GetEnvString("REDIS_RPC_PORT", "9003"),
-		healthRPCUrl:        env.GetEnvString("HEALTH_RPC_URL", "http://localhost:9004"),
-		dbServerRPCUrl:      env.GetEnvString("DBSERVER_RPC_URL", "http://localhost:9002"),
-		aggregatorRPCUrl:    env.GetEnvString("AGGREGATOR_RPC_URL", "http://localhost:9001"),
-		redisSigningKey:     env.GetEnvString("REDIS_SIGNING_KEY", ""),
-		redisSigningAddress: env.GetEnvString("REDIS_SIGNING_ADDRESS", ""),
-		upstashURL:          env.GetEnvString("UPSTASH_REDIS_URL", ""),
-		upstashToken:        env.GetEnvString("UPSTASH_REDIS_REST_TOKEN", ""),
-		localAddr:           env.GetEnvString("REDIS_ADDR", "localhost:6379"),
-		localPassword:       env.GetEnvString("REDIS_PASSWORD", ""),
-		db:                  0,
-		poolSize:            env.GetEnvInt("REDIS_POOL_SIZE", 10),
-		minIdleConns:        env.GetEnvInt("REDIS_MIN_IDLE_CONNS", 2),
-		maxRetries:          env.GetEnvInt("REDIS_MAX_RETRIES", 3),
-		dialTimeout:         env.GetEnvDuration("REDIS_DIAL_TIMEOUT", 5*time.Second),
-		readTimeout:         env.GetEnvDuration("REDIS_READ_TIMEOUT", 3*time.Second),
-		writeTimeout:        env.GetEnvDuration("REDIS_WRITE_TIMEOUT", 3*time.Second),
-		poolTimeout:         env.GetEnvDuration("REDIS_POOL_TIMEOUT", 4*time.Second),
-		streamMaxLen:        env.GetEnvInt("REDIS_STREAM_MAX_LEN", 10000),
-		jobStreamTTL:        env.GetEnvDuration("REDIS_JOB_STREAM_TTL", 120*time.Hour),
-		taskStreamTTL:       env.GetEnvDuration("REDIS_TASK_STREAM_TTL", 1*time.Hour),
-		cacheTTL:            env.GetEnvDuration("REDIS_CACHE_TTL", 24*time.Hour),
-		cleanupInterval:     env.GetEnvDuration("REDIS_CLEANUP_INTERVAL", 10*time.Minute),
-		pinataHost:          env.GetEnvString("PINATA_HOST", ""),
+		devMode:              env.GetEnvBool("DEV_MODE", false),
+		redisRPCPort:         env.GetEnvString("REDIS_RPC_PORT", "9003"),
+		healthRPCUrl:         env.GetEnvString("HEALTH_RPC_URL", "http://localhost:9004"),
+		dbServerRPCUrl:       env.GetEnvString("DBSERVER_RPC_URL", "http://localhost:9002"),
+		dbServerServiceToken: env.GetEnvString("DBSERVER_SERVICE_TOKEN", ""),
+		aggregatorRPCUrl:     env.GetEnvString("AGGREGATOR_RPC_URL", "http://localhost:9001"),
+		redisSigningKey:      env.GetEnvString("REDIS_SIGNING_KEY", ""),
+		redisSigningAddress:  env.GetEnvString("REDIS_SIGNING_ADDRESS", ""),
+		upstashURL:           env.GetEnvString("UPSTASH_REDIS_URL", ""),
+		upstashToken:         env.GetEnvString("UPSTASH_REDIS_REST_TOKEN", ""),
+		localAddr:            env.GetEnvString("REDIS_ADDR", "localhost:6379"),
+		localPassword:        env.GetEnvString("REDIS_PASSWORD", ""),
+		db:                   0,
+		poolSize:             env.GetEnvInt("REDIS_POOL_SIZE", 10),
+		minIdleConns:         env.GetEnvInt("REDIS_MIN_IDLE_CONNS", 2),
+		maxRetries:           env.GetEnvInt("REDIS_MAX_RETRIES", 3),
+		dialTimeout:          env.GetEnvDuration("REDIS_DIAL_TIMEOUT", 5*time.Second),
+		readTimeout:          env.GetEnvDuration("REDIS_READ_TIMEOUT", 3*time.Second),
+		writeTimeout:         env.GetEnvDuration("REDIS_WRITE_TIMEOUT", 3*time.Second),
+		poolTimeout:          env.GetEnvDuration("REDIS_POOL_TIMEOUT", 4*time.Second),
+		streamMaxLen:         env.GetEnvInt("REDIS_STREAM_MAX_LEN", 10000),
+		jobStreamTTL:         env.GetEnvDuration("REDIS_JOB_STREAM_TTL", 120*time.Hour),
+		taskStreamTTL:        env.GetEnvDuration("REDIS_TASK_STREAM_TTL", 1*time.Hour),
+		cacheTTL:             env.GetEnvDuration("REDIS_CACHE_TTL", 24*time.Hour),
+		cleanupInterval:      env.GetEnvDuration("REDIS_CLEANUP_INTERVAL", 10*time.Minute),
+		pinataHost:           env.GetEnvString("PINATA_HOST", ""),
+		pinataJWT:            env.GetEnvString("PINATA_JWT", ""),
+		ipfsBackend:          env.GetEnvString("IPFS_BACKEND", "pinata"),
+		ipfsAPIURL:           env.GetEnvString("IPFS_API_URL", ""),
+		ipfsAPIToken:         env.GetEnvString("IPFS_API_TOKEN", ""),
+		ipfsStoreDir:         env.GetEnvString("IPFS_STORE_DIR", ""),
+		ipfsGateway:          env.GetEnvString("IPFS_GATEWAY", ""),
+		ipfsChallengeWindow:  env.GetEnvDuration("IPFS_CHALLENGE_WINDOW", 24*time.Hour),
+		ipfsDisputePeriod:    env.GetEnvDuration("IPFS_DISPUTE_PERIOD", 72*time.Hour),
+		ipfsPinSweepInterval: env.GetEnvDuration("IPFS_PIN_SWEEP_INTERVAL", 10*time.Minute),
+		ipfsArchiveDir:       env.GetEnvString("IPFS_ARCHIVE_DIR", ""),
+		ipfsPinsAdminToken:   env.GetEnvString("IPFS_PINS_ADMIN_TOKEN", ""),
 	}
 
 	if !cfg.devMode {
@@ -128,6 +158,55 @@ func GetPinataHost() string {
 	return cfg.pinataHost
 }
 
+func GetPinataJWT() string {
+	return cfg.pinataJWT
+}
+
+func GetIPFSBackend() string {
+	return cfg.ipfsBackend
+}
+
+func GetIPFSAPIURL() string {
+	return cfg.ipfsAPIURL
+}
+
+func GetIPFSAPIToken() string {
+	return cfg.ipfsAPIToken
+}
+
+func GetIPFSStoreDir() string {
+	return cfg.ipfsStoreDir
+}
+
+// GetIPFSGateway is the gateway content is fetched from, the Pinata host if none is set
+func GetIPFSGateway() string {
+	if cfg.ipfsGateway != "" {
+		return cfg.ipfsGateway
+	}
+	return cfg.pinataHost
+}
+
+func GetIPFSChallengeWindow() time.Duration {
+	return cfg.ipfsChallengeWindow
+}
+
+func GetIPFSDisputePeriod() time.Duration {
+	return cfg.ipfsDisputePeriod
+}
+
+func GetIPFSPinSweepInterval() time.Duration {
+	return cfg.ipfsPinSweepInterval
+}
+
+func GetIPFSPinsAdminToken() string {
+	return cfg.ipfsPinsAdminToken
+}
+
+// GetIPFSArchiveDir is where proofs are archived before they are unpinned, none if empty
+func GetIPFSArchiveDir() string {
+	return cfg.ipfsArchiveDir
+}
+
 func GetHealthRPCUrl() string {
 	return cfg.healthRPCUrl
 }
@@ -140,6 +219,10 @@ func GetDBServerRPCUrl() string {
 	return cfg.dbServerRPCUrl
 }
 
+func GetDBServerServiceToken() string {
+	return cfg.dbServerServiceToken
+}
+
 func GetAggregatorRPCUrl() string {
 	return cfg.aggregatorRPCUrl
 }
@@ -165,6 +165,21 @@ var (
 		Help:      "Tasks permanently failed and moved to failed stream",
 	})
 
+	// Execution Budget
+	TaskBudgetReservationsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "triggerx",
+		Subsystem: "redis",
+		Name:      "task_budget_reservations_total",
+		Help:      "Task fee reservations against user balances (status=reserved/insufficient/error)",
+	}, []string{"status"})
+
+	JobsPausedForBudgetTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: "triggerx",
+		Subsystem: "redis",
+		Name:      "jobs_paused_for_budget_total",
+		Help:      "Jobs paused because their user could not pay for the next task",
+	})
+
 	TaskReadyToProcessingTotal = promauto.NewCounter(prometheus.CounterOpts{
 		Namespace: "triggerx",
 		Subsystem: "redis",
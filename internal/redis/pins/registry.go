@@ -0,0 +1,144 @@
+package pins
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	redisClient "github.com/trigg3rX/triggerx-backend-imua/pkg/client/redis"
+	"github.com/trigg3rX/triggerx-backend-imua/pkg/ipfs"
+)
+
+const (
+	pinRecordKey   = "ipfs:pin:%s"       // JSON of the pin record of a CID
+	pinTaskKey     = "ipfs:pins:task:%d" // Set of the CIDs a task owns
+	pinReleaseKey  = "ipfs:pins:release" // CIDs held, scored by when they may be released
+	pinAllKey      = "ipfs:pins"         // Set of every CID tracked
+	operationLimit = 5 * time.Second
+)
+
+// Registry keeps the pin records of the pinning manager in Redis
+type Registry struct {
+	client redisClient.RedisClientInterface
+}
+
+var _ ipfs.PinRegistry = (*Registry)(nil)
+
+func NewRegistry(client redisClient.RedisClientInterface) *Registry {
+	return &Registry{client: client}
+}
+
+// releaseScore is when a record may be released, never for those not held
+func releaseScore(record ipfs.PinRecord) float64 {
+	if record.Status != ipfs.PinHeld {
+		return math.Inf(1)
+	}
+	return float64(record.ReleaseAfter.Unix())
+}
+
+func (r *Registry) Save(ctx context.Context, record ipfs.PinRecord) error {
+	ctx, cancel := context.WithTimeout(ctx, operationLimit)
+	defer cancel()
+
+	data, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("failed to marshal pin record: %w", err)
+	}
+	_, err = r.client.Client().TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Set(ctx, fmt.Sprintf(pinRecordKey, record.CID), data, 0)
+		pipe.ZAdd(ctx, pinReleaseKey, redis.Z{Score: releaseScore(record), Member: record.CID})
+		pipe.SAdd(ctx, fmt.Sprintf(pinTaskKey, record.TaskID), record.CID)
+		pipe.SAdd(ctx, pinAllKey, record.CID)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to save pin record of %s: %w", record.CID, err)
+	}
+	return nil
+}
+
+func (r *Registry) Get(ctx context.Context, cid string) (*ipfs.PinRecord, error) {
+	ctx, cancel := context.WithTimeout(ctx, operationLimit)
+	defer cancel()
+
+	data, err := r.client.Client().Get(ctx, fmt.Sprintf(pinRecordKey, cid)).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pin record of %s: %w", cid, err)
+	}
+	var record ipfs.PinRecord
+	if err := json.Unmarshal(data, &record); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal pin record of %s: %w", cid, err)
+	}
+	return &record, nil
+}
+
+func (r *Registry) Delete(ctx context.Context, cid string) error {
+	record, err := r.Get(ctx, cid)
+	if err != nil || record == nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, operationLimit)
+	defer cancel()
+	_, err = r.client.Client().TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Del(ctx, fmt.Sprintf(pinRecordKey, cid))
+		pipe.ZRem(ctx, pinReleaseKey, cid)
+		pipe.SRem(ctx, fmt.Sprintf(pinTaskKey, record.TaskID), cid)
+		pipe.SRem(ctx, pinAllKey, cid)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete pin record of %s: %w", cid, err)
+	}
+	return nil
+}
+
+func (r *Registry) Due(ctx context.Context, at time.Time) ([]ipfs.PinRecord, error) {
+	cids, err := r.client.Client().ZRangeByScore(ctx, pinReleaseKey, &redis.ZRangeBy{
+		Min: "-inf",
+		Max: strconv.FormatInt(at.Unix(), 10),
+	}).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read pins due: %w", err)
+	}
+	return r.getAll(ctx, cids)
+}
+
+func (r *Registry) ByTask(ctx context.Context, taskID int64) ([]ipfs.PinRecord, error) {
+	cids, err := r.client.Client().SMembers(ctx, fmt.Sprintf(pinTaskKey, taskID)).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read pins of task %d: %w", taskID, err)
+	}
+	return r.getAll(ctx, cids)
+}
+
+func (r *Registry) All(ctx context.Context) ([]ipfs.PinRecord, error) {
+	cids, err := r.client.Client().SMembers(ctx, pinAllKey).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read pins: %w", err)
+	}
+	return r.getAll(ctx, cids)
+}
+
+func (r *Registry) getAll(ctx context.Context, cids []string) ([]ipfs.PinRecord, error) {
+	records := make([]ipfs.PinRecord, 0, len(cids))
+	for _, cid := range cids {
+		record, err := r.Get(ctx, cid)
+		if err != nil {
+			return nil, err
+		}
+		// Records deleted since the index was read are skipped
+		if record != nil {
+			records = append(records, *record)
+		}
+	}
+	return records, nil
+}
@@ -0,0 +1,182 @@
+package tasks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/trigg3rX/triggerx-backend-imua/internal/redis/metrics"
+	"github.com/trigg3rX/triggerx-backend-imua/pkg/types"
+)
+
+// ErrInsufficientBudget is returned for a task whose users cannot pay its estimated fee
+var ErrInsufficientBudget = errors.New("insufficient balance for task fee")
+
+// Hash of a user's fee reservations: task ID -> "<fee>:<expires at>"
+const budgetReservationsKey = "budget:reservations:%d"
+
+// reserveBudgetScript reserves a task's fee if the user's balance, less the fees
+// already reserved for their other tasks, covers it. Expired reservations are
+// dropped on the way. Returns whether the fee was reserved, and what is left.
+//
+// KEYS[1] reservations hash
+// ARGV[1] task ID, ARGV[2] fee, ARGV[3] balance, ARGV[4] now, ARGV[5] expires at, ARGV[6] hash TTL
+var reserveBudgetScript = redis.NewScript(`
+local reserved = 0
+local entries = redis.call('HGETALL', KEYS[1])
+for i = 1, #entries, 2 do
+	local fee, expiresAt = string.match(entries[i + 1], '^([^:]+):([^:]+)$')
+	if fee == nil or tonumber(expiresAt) <= tonumber(ARGV[4]) then
+		redis.call('HDEL', KEYS[1], entries[i])
+	elseif entries[i] ~= ARGV[1] then
+		reserved = reserved + tonumber(fee)
+	end
+end
+
+local available = tonumber(ARGV[3]) - reserved - tonumber(ARGV[2])
+if available < 0 then
+	return {0, tostring(available + tonumber(ARGV[2]))}
+end
+redis.call('HSET', KEYS[1], ARGV[1], ARGV[2] .. ':' .. ARGV[5])
+redis.call('EXPIRE', KEYS[1], ARGV[6])
+return {1, tostring(available)}
+`)
+
+// reserveTaskBudget holds the estimated fee of every target of a task against its
+// user's balance until the task is settled, so that tasks dispatched together
+// cannot spend more than the user has. Targets whose user cannot pay are dropped
+// from the task and their jobs paused. ErrInsufficientBudget is returned if no
+// target is left.
+func (tsm *TaskStreamManager) reserveTaskBudget(task *TaskStreamData) error {
+	sendTaskData := &task.SendTaskDataToKeeper
+	funded := sendTaskData.TargetData[:0]
+	var fundedTriggers []types.TaskTriggerData
+
+	for i, target := range sendTaskData.TargetData {
+		reservation, err := tsm.reserveTargetBudget(target)
+		if errors.Is(err, ErrInsufficientBudget) {
+			tsm.logger.Warn("Dropping task of job with insufficient balance",
+				"task_id", target.TaskID,
+				"job_id", target.JobID,
+				"error", err)
+			tsm.pauseUnfundedJob(target.JobID)
+			continue
+		}
+		if err != nil {
+			tsm.releaseTaskBudget(task)
+			return err
+		}
+
+		task.BudgetReservations = append(task.BudgetReservations, reservation)
+		funded = append(funded, target)
+		if i < len(sendTaskData.TriggerData) {
+			fundedTriggers = append(fundedTriggers, sendTaskData.TriggerData[i])
+		}
+	}
+
+	if len(funded) == 0 {
+		return fmt.Errorf("%w: no job of task %d can be paid for", ErrInsufficientBudget, sendTaskData.TaskID)
+	}
+	sendTaskData.TargetData = funded
+	sendTaskData.TriggerData = fundedTriggers
+	task.JobID = funded[0].JobID
+
+	// A batch is known by its first task
+	if sendTaskData.TaskID != funded[0].TaskID {
+		sendTaskData.TaskID = funded[0].TaskID
+		if sendTaskData.SchedulerSignature != nil {
+			sendTaskData.SchedulerSignature.TaskID = funded[0].TaskID
+		}
+	}
+	return nil
+}
+
+func (tsm *TaskStreamManager) reserveTargetBudget(target types.TaskTargetData) (BudgetReservation, error) {
+	budget, err := tsm.dbClient.GetJobBudget(target.JobID)
+	if err != nil {
+		metrics.TaskBudgetReservationsTotal.WithLabelValues("error").Inc()
+		return BudgetReservation{}, fmt.Errorf("failed to get budget of job %d: %w", target.JobID, err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	now := time.Now()
+	result, err := reserveBudgetScript.Run(ctx, tsm.client.Client(),
+		[]string{fmt.Sprintf(budgetReservationsKey, budget.UserID)},
+		target.TaskID,
+		strconv.FormatFloat(budget.EstimatedFee, 'f', -1, 64),
+		strconv.FormatFloat(budget.Balance, 'f', -1, 64),
+		now.Unix(),
+		now.Add(BudgetReservationTTL).Unix(),
+		int64(BudgetReservationTTL/time.Second),
+	).Slice()
+	if err != nil {
+		metrics.TaskBudgetReservationsTotal.WithLabelValues("error").Inc()
+		return BudgetReservation{}, fmt.Errorf("failed to reserve fee of task %d: %w", target.TaskID, err)
+	}
+
+	available, _ := strconv.ParseFloat(fmt.Sprint(result[1]), 64)
+	if reserved, _ := result[0].(int64); reserved != 1 {
+		metrics.TaskBudgetReservationsTotal.WithLabelValues("insufficient").Inc()
+		return BudgetReservation{}, fmt.Errorf("%w: job %d needs %f TG, user %d has %f TG available",
+			ErrInsufficientBudget, target.JobID, budget.EstimatedFee, budget.UserID, available)
+	}
+	metrics.TaskBudgetReservationsTotal.WithLabelValues("reserved").Inc()
+
+	if available < budget.EstimatedFee*LowBudgetTaskThreshold {
+		tsm.logger.Warn("User balance is running low",
+			"user_id", budget.UserID,
+			"job_id", target.JobID,
+			"available", available,
+			"estimated_fee", budget.EstimatedFee)
+	}
+
+	return BudgetReservation{
+		TaskID: target.TaskID,
+		JobID:  target.JobID,
+		UserID: budget.UserID,
+		Fee:    budget.EstimatedFee,
+	}, nil
+}
+
+// releaseTaskBudget drops the fee reservations of a task that was settled, or
+// will never execute
+func (tsm *TaskStreamManager) releaseTaskBudget(task *TaskStreamData) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	for _, reservation := range task.BudgetReservations {
+		key := fmt.Sprintf(budgetReservationsKey, reservation.UserID)
+		if err := tsm.client.Client().HDel(ctx, key, strconv.FormatInt(reservation.TaskID, 10)).Err(); err != nil {
+			tsm.logger.Error("Failed to release task fee reservation",
+				"task_id", reservation.TaskID,
+				"user_id", reservation.UserID,
+				"error", err)
+		}
+	}
+	task.BudgetReservations = nil
+}
+
+// JobUser returns the user a job belongs to, whose balance pays for its tasks
+func (tsm *TaskStreamManager) JobUser(jobID int64) (int64, error) {
+	budget, err := tsm.dbClient.GetJobBudget(jobID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get user of job %d: %w", jobID, err)
+	}
+	return budget.UserID, nil
+}
+
+// pauseUnfundedJob pauses a job whose user cannot pay for its next task. The
+// user resumes it once they have topped up their balance.
+func (tsm *TaskStreamManager) pauseUnfundedJob(jobID int64) {
+	if err := tsm.dbClient.PauseJob(jobID); err != nil {
+		tsm.logger.Error("Failed to pause job with insufficient balance", "job_id", jobID, "error", err)
+		return
+	}
+	metrics.JobsPausedForBudgetTotal.Inc()
+	tsm.logger.Warn("Paused job with insufficient balance", "job_id", jobID)
+}
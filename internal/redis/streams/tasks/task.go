@@ -12,6 +12,7 @@ import (
 	"github.com/trigg3rX/triggerx-backend-imua/pkg/client/aggregator"
 	"github.com/trigg3rX/triggerx-backend-imua/pkg/client/dbserver"
 	redisClient "github.com/trigg3rX/triggerx-backend-imua/pkg/client/redis"
+	"github.com/trigg3rX/triggerx-backend-imua/pkg/cryptography"
 	"github.com/trigg3rX/triggerx-backend-imua/pkg/logging"
 	"github.com/trigg3rX/triggerx-backend-imua/pkg/types"
 )
@@ -48,6 +49,7 @@ func NewTaskStreamManager(logger logging.Logger, client redisClient.RedisClientI
 		metrics.ServiceStatus.WithLabelValues("task_stream_manager").Set(0)
 		return nil, fmt.Errorf("failed to create dbserver client: %w", err)
 	}
+	dbserverClient.SetServiceToken(config.GetDBServerServiceToken())
 
 	tsm := &TaskStreamManager{
 		client:         client,
@@ -107,16 +109,26 @@ func (tsm *TaskStreamManager) ReceiveTaskFromScheduler(request *SchedulerTaskReq
 
 	// Create task stream data
 	taskStreamData := TaskStreamData{
-		JobID:                request.SendTaskDataToKeeper.TaskID, // Use TaskID as JobID for simple cases
+		JobID:                request.SendTaskDataToKeeper.TargetData[0].JobID,
 		TaskDefinitionID:     request.SendTaskDataToKeeper.TargetData[0].TaskDefinitionID,
 		CreatedAt:            time.Now(),
 		RetryCount:           0,
 		SendTaskDataToKeeper: request.SendTaskDataToKeeper,
 	}
 
+	// Keepers are not handed work the users cannot pay for
+	if err := tsm.reserveTaskBudget(&taskStreamData); err != nil {
+		tsm.logger.Error("Failed to reserve task fee",
+			"task_id", request.SendTaskDataToKeeper.TaskID,
+			"source", request.Source,
+			"error", err)
+		return nil, fmt.Errorf("failed to reserve task fee: %w", err)
+	}
+
 	// Add to ready stream for processing
 	performerData, err := tsm.AddTaskToReadyStream(taskStreamData)
 	if err != nil {
+		tsm.releaseTaskBudget(&taskStreamData)
 		tsm.logger.Error("Failed to add task to ready stream",
 			"task_id", request.SendTaskDataToKeeper.TaskID,
 			"source", request.Source,
@@ -298,7 +310,7 @@ func (tsm *TaskStreamManager) MarkTaskCompleted(taskID int64, performerData type
 		"performer_id", performerData.KeeperID)
 
 	// Find and move task from processing to completed
-	task, err := tsm.findTaskInProcessing(taskID)
+	task, messageID, err := tsm.findTaskInProcessing(taskID)
 	if err != nil {
 		return fmt.Errorf("failed to find task in processing: %w", err)
 	}
@@ -311,14 +323,54 @@ func (tsm *TaskStreamManager) MarkTaskCompleted(taskID int64, performerData type
 		return fmt.Errorf("failed to add to completed stream: %w", err)
 	}
 
-	// Remove from processing stream (acknowledge)
-	// Note: In a real implementation, we'd need to track the processing message ID
+	tsm.removeFromProcessing(taskID, messageID)
 	tsm.logger.Info("Task marked as completed successfully", "task_id", taskID)
 	metrics.TasksAddedToStreamTotal.WithLabelValues("completed", "success").Inc()
 
 	return nil
 }
 
+// ReportTaskResult takes a performer's signed trace of executing a task. A failed
+// task is retried, or moved to the failed stream, with the trace attached; one
+// that succeeded is completed once its proof is validated.
+func (tsm *TaskStreamManager) ReportTaskResult(trace *types.ExecutionTrace) error {
+	task, messageID, err := tsm.findTaskInProcessing(trace.TaskID)
+	if err != nil {
+		return fmt.Errorf("failed to find task in processing: %w", err)
+	}
+
+	// Only the performer the task was sent to can report on it
+	performer := task.SendTaskDataToKeeper.PerformerData.KeeperAddress
+	unsigned := *trace
+	unsigned.Signature = ""
+	valid, err := cryptography.VerifySignatureFromJSON(unsigned, trace.Signature, performer)
+	if err != nil {
+		return fmt.Errorf("failed to verify execution trace of task %d: %w", trace.TaskID, err)
+	}
+	if !valid {
+		return fmt.Errorf("execution trace of task %d is not signed by its performer %s", trace.TaskID, performer)
+	}
+
+	task.ExecutionTrace = trace
+	if trace.Success {
+		tsm.logger.Info("Performer reported task executed",
+			"task_id", trace.TaskID,
+			"trace_id", trace.TraceID,
+			"duration_ms", trace.DurationMs)
+		return nil
+	}
+
+	tsm.logger.Warn("Performer reported task failed",
+		"task_id", trace.TaskID,
+		"trace_id", trace.TraceID,
+		"error", trace.Error)
+	if err := tsm.moveTaskToFailed(*task, fmt.Sprintf("performer: %s", trace.Error)); err != nil {
+		return err
+	}
+	tsm.removeFromProcessing(trace.TaskID, messageID)
+	return nil
+}
+
 // moveTaskToFailed moves a task to the failed stream or retry stream
 func (tsm *TaskStreamManager) moveTaskToFailed(task TaskStreamData, errorMsg string) error {
 	task.LastError = errorMsg
@@ -347,6 +399,8 @@ func (tsm *TaskStreamManager) moveTaskToFailed(task TaskStreamData, errorMsg str
 		if err != nil {
 			return fmt.Errorf("failed to add to failed stream: %w", err)
 		}
+		tsm.releaseTaskBudget(&task)
+		tsm.reportTaskFailed(&task, errorMsg)
 
 		tsm.logger.Error("Task permanently failed",
 			"task_id", task.SendTaskDataToKeeper.TaskID,
@@ -359,6 +413,19 @@ func (tsm *TaskStreamManager) moveTaskToFailed(task TaskStreamData, errorMsg str
 	return nil
 }
 
+// reportTaskFailed tells the database server that the tasks of each job in a task
+// failed for good, so that the jobs chained after them fail rather than wait
+func (tsm *TaskStreamManager) reportTaskFailed(task *TaskStreamData, errorMsg string) {
+	for _, target := range task.SendTaskDataToKeeper.TargetData {
+		if err := tsm.dbClient.FailTaskExecution(target.TaskID, errorMsg); err != nil {
+			tsm.logger.Error("Failed to report failed task to database server",
+				"task_id", target.TaskID,
+				"job_id", target.JobID,
+				"error", err)
+		}
+	}
+}
+
 // checkProcessingTimeouts checks for tasks that have been processing too long
 func (tsm *TaskStreamManager) checkProcessingTimeouts() {
 	tsm.logger.Debug("Checking for processing timeouts")
@@ -454,20 +521,58 @@ func (tsm *TaskStreamManager) processRetryTasks() {
 	}
 }
 
-// findTaskInProcessing finds a specific task in the processing stream
-func (tsm *TaskStreamManager) findTaskInProcessing(taskID int64) (*TaskStreamData, error) {
-	tasks, _, err := tsm.readTasksFromStreamWithIDs(TasksProcessingStream, "task-finder", "finder", 100)
+// findTaskInProcessing finds a task in the processing stream, and the ID of its
+// entry. The stream is read by range rather than by a consumer group, so that
+// looking up one task does not consume the entries of others.
+func (tsm *TaskStreamManager) findTaskInProcessing(taskID int64) (*TaskStreamData, string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// The latest entry of the task is the one of its current attempt
+	messages, err := tsm.client.Client().XRevRange(ctx, TasksProcessingStream, "+", "-").Result()
 	if err != nil {
-		return nil, err
+		return nil, "", fmt.Errorf("failed to read processing stream: %w", err)
 	}
 
-	for _, task := range tasks {
+	for _, message := range messages {
+		taskJSON, exists := message.Values["task"].(string)
+		if !exists {
+			continue
+		}
+		var task TaskStreamData
+		if err := json.Unmarshal([]byte(taskJSON), &task); err != nil {
+			tsm.logger.Error("Failed to unmarshal task data",
+				"stream", TasksProcessingStream,
+				"message_id", message.ID,
+				"error", err)
+			continue
+		}
 		if task.SendTaskDataToKeeper.TaskID == taskID {
-			return &task, nil
+			return &task, message.ID, nil
 		}
 	}
 
-	return nil, fmt.Errorf("task %d not found in processing stream", taskID)
+	return nil, "", fmt.Errorf("task %d not found in processing stream", taskID)
+}
+
+// removeFromProcessing drops the entry of a task that left the processing stream,
+// so that the timeout checker does not move it again
+func (tsm *TaskStreamManager) removeFromProcessing(taskID int64, messageID string) {
+	if err := tsm.AckTaskProcessed(TasksProcessingStream, "timeout-checker", messageID); err != nil {
+		tsm.logger.Warn("Failed to acknowledge task in processing stream",
+			"task_id", taskID,
+			"message_id", messageID,
+			"error", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := tsm.client.Client().XDel(ctx, TasksProcessingStream, messageID).Err(); err != nil {
+		tsm.logger.Warn("Failed to remove task from processing stream",
+			"task_id", taskID,
+			"message_id", messageID,
+			"error", err)
+	}
 }
 
 // RegisterConsumerGroup registers a consumer group for a stream
@@ -100,6 +100,8 @@ func (tsm *TaskStreamManager) AddTaskToRetryStream(task *TaskStreamData, retryRe
 			return err
 		}
 
+		tsm.releaseTaskBudget(task)
+
 		// Notify scheduler about task failure
 		// tsm.notifySchedulerTaskComplete(task, false)
 
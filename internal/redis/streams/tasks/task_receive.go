@@ -40,6 +40,11 @@ func (tsm *TaskStreamManager) UpdateDatabase(ipfsData types.IPFSData) {
 		ExecutionTxHash: ipfsData.ActionData.ActionTxHash,
 		ProofOfTask: ipfsData.ProofData.ProofOfTask,
 		TaskOpXCost: ipfsData.ActionData.TotalFee,
+		IsSuccessful: ipfsData.ActionData.Status || ipfsData.ActionData.Skipped,
+		IsSkipped: ipfsData.ActionData.Skipped,
+	}
+	if len(ipfsData.TaskData.TargetData) > 0 {
+		updateTaskExecutionData.DynamicArgumentsScriptUrl = ipfsData.TaskData.TargetData[0].DynamicArgumentsScriptUrl
 	}
 	tsm.logger.Infof("UpdateTaskExecutionDataRequest: %+v", updateTaskExecutionData)
 
@@ -49,6 +54,8 @@ func (tsm *TaskStreamManager) UpdateDatabase(ipfsData types.IPFSData) {
 	}
 
 	if success {
+		// The actual cost is now charged to the user, the estimate is no longer held
+		tsm.releaseTaskBudget(taskStreamData)
 		tsm.logger.Info("Task execution data updated successfully")
 	} else {
 		tsm.logger.Error("Failed to update task execution data")
@@ -23,6 +23,10 @@ const (
 	// Retry Configuration
 	MaxRetryAttempts = 3
 	RetryBackoffBase = 5 * time.Second
+
+	// Budget Configuration
+	BudgetReservationTTL   = 2 * time.Hour // Reservations of tasks that never settle are dropped after this
+	LowBudgetTaskThreshold = 10            // Warn when the balance covers fewer tasks than this
 )
 
 // TaskStreamData represents task information for Redis-managed task streams
@@ -36,6 +40,9 @@ type TaskStreamData struct {
 	LastAttemptAt *time.Time `json:"last_attempt_at,omitempty"`
 	ScheduledFor  *time.Time `json:"scheduled_for,omitempty"`
 
+	// Fees held against user balances until the tasks are settled
+	BudgetReservations []BudgetReservation `json:"budget_reservations,omitempty"`
+
 	// Core task data from schedulers
 	SendTaskDataToKeeper types.SendTaskDataToKeeper `json:"send_task_data_to_keeper"`
 
@@ -43,6 +50,17 @@ type TaskStreamData struct {
 	ProcessingStartedAt *time.Time `json:"processing_started_at,omitempty"`
 	CompletedAt         *time.Time `json:"completed_at,omitempty"`
 	LastError           string     `json:"last_error,omitempty"`
+
+	// The performer's signed account of its last attempt at the task
+	ExecutionTrace *types.ExecutionTrace `json:"execution_trace,omitempty"`
+}
+
+// BudgetReservation is the estimated fee of one task, held against its user's balance
+type BudgetReservation struct {
+	TaskID int64   `json:"task_id"`
+	JobID  int64   `json:"job_id"`
+	UserID int64   `json:"user_id"`
+	Fee    float64 `json:"fee"`
 }
 
 // TaskProcessingTimeout represents a task that has timed out in processing
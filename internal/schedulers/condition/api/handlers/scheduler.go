@@ -73,12 +73,57 @@ func (h *SchedulerHandler) ScheduleJob(c *gin.Context) {
 	c.JSON(http.StatusOK, response)
 }
 
-// UnscheduleJob unschedules a condition-based job
+// UpdateJob swaps an edited condition-based job into its running worker
+func (h *SchedulerHandler) UpdateJob(c *gin.Context) {
+	traceID := getTraceID(c)
+	h.logger.Info("[UpdateJob] trace_id=" + traceID + " - Updating job")
+
+	var jobData types.ScheduleConditionJobData
+	if err := c.ShouldBindJSON(&jobData); err != nil {
+		h.logger.Error("Invalid request payload", "error", err)
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":    "error",
+			"message":   "Invalid request payload",
+			"error":     err.Error(),
+			"timestamp": time.Now().UTC(),
+		})
+		return
+	}
+
+	if err := h.scheduler.UpdateJob(&jobData); err != nil {
+		h.logger.Error("Failed to update condition job", "job_id", jobData.JobID, "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":    "error",
+			"message":   "Failed to update condition job",
+			"error":     err.Error(),
+			"timestamp": time.Now().UTC(),
+		})
+		return
+	}
+
+	h.logger.Info("Condition job updated successfully", "job_id", jobData.JobID)
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":    "success",
+		"message":   "Condition job updated successfully",
+		"job_id":    jobData.JobID,
+		"timestamp": time.Now().UTC(),
+	})
+}
+
+// UnscheduleJob unschedules a condition-based job. The job ID comes from the
+// path, or from the body as the dbserver sends it to /job/pause.
 func (h *SchedulerHandler) UnscheduleJob(c *gin.Context) {
 	traceID := getTraceID(c)
 	h.logger.Info("[UnscheduleJob] trace_id=" + traceID + " - Unscheduling job")
 
 	jobIDStr := c.Param("job_id")
+	if jobIDStr == "" {
+		var jobData types.ScheduleConditionJobData
+		if err := c.ShouldBindJSON(&jobData); err == nil {
+			jobIDStr = strconv.FormatInt(jobData.JobID, 10)
+		}
+	}
 	jobID, err := strconv.ParseInt(jobIDStr, 10, 64)
 	if err != nil {
 		h.logger.Error("Invalid job ID", "job_id", jobIDStr, "error", err)
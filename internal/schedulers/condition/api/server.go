@@ -103,6 +103,7 @@ func (s *Server) setupRoutes(deps Dependencies) {
 		// Job management endpoints
 		api.POST("/job/schedule", schedulerHandler.ScheduleJob)
 		api.POST("/job/pause", schedulerHandler.UnscheduleJob)
+		api.POST("/job/update", schedulerHandler.UpdateJob)
 		api.GET("/job/stats/:job_id", schedulerHandler.GetJobStats)
 
 		api.PUT("/job/task/:job_id/:task_id", schedulerHandler.UpdateJobsTask)
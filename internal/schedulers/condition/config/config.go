@@ -2,10 +2,13 @@ package config
 
 import (
 	"fmt"
+	"os"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/joho/godotenv"
 
+	redisClient "github.com/trigg3rX/triggerx-backend-imua/pkg/client/redis"
 	"github.com/trigg3rX/triggerx-backend-imua/pkg/env"
 )
 
@@ -25,11 +28,27 @@ type Config struct {
 	// Scheduler ID for consumer groups
 	conditionSchedulerID int
 
+	// Name of this instance among those sharing the job catalog
+	instanceID string
+
 	// Maximum number of workers
 	maxWorkers int
 
 	// API Keys for Alchemy
 	alchemyAPIKey string
+
+	// Per-host rate limit for shared value source polling
+	sourceHostRate  float64
+	sourceHostBurst int
+
+	// Heartbeat interval of scheduler instances sharing the job catalog
+	shardHeartbeatSeconds int
+
+	// Redis for condition sample windows (optional)
+	upstashURL    string
+	upstashToken  string
+	localAddr     string
+	localPassword string
 }
 
 var cfg Config
@@ -51,8 +70,19 @@ func Init() error {
 		aggregatorRPCURL:          env.GetEnvString("AGGREGATOR_RPC_URL", "http://localhost:9001"),
 		redisRPCUrl:               env.GetEnvString("REDIS_RPC_URL", "http://localhost:9003"),
 		conditionSchedulerID:      env.GetEnvInt("CONDITION_SCHEDULER_ID", 5678),
+		instanceID:                env.GetEnvString("CONDITION_SCHEDULER_INSTANCE_ID", ""),
 		maxWorkers:                env.GetEnvInt("CONDITION_SCHEDULER_MAX_WORKERS", 100),
 		alchemyAPIKey:             env.GetEnvString("ALCHEMY_API_KEY", ""),
+		sourceHostRate:            env.GetEnvFloat("CONDITION_SOURCE_HOST_RATE", 5),
+		sourceHostBurst:           env.GetEnvInt("CONDITION_SOURCE_HOST_BURST", 5),
+		shardHeartbeatSeconds:     env.GetEnvInt("CONDITION_SCHEDULER_HEARTBEAT_SECONDS", 5),
+		upstashURL:                env.GetEnvString("UPSTASH_REDIS_URL", ""),
+		upstashToken:              env.GetEnvString("UPSTASH_REDIS_REST_TOKEN", ""),
+		localAddr:                 env.GetEnvString("REDIS_ADDR", ""),
+		localPassword:             env.GetEnvString("REDIS_PASSWORD", ""),
+	}
+	if cfg.instanceID == "" {
+		cfg.instanceID = defaultInstanceID()
 	}
 	if err := validateConfig(); err != nil {
 		return fmt.Errorf("invalid configuration: %w", err)
@@ -76,6 +106,9 @@ func validateConfig() error {
 	if !env.IsValidURL(cfg.redisRPCUrl) {
 		return fmt.Errorf("invalid Redis API URL: %s", cfg.redisRPCUrl)
 	}
+	if cfg.sourceHostRate <= 0 {
+		return fmt.Errorf("invalid condition source host rate: %g", cfg.sourceHostRate)
+	}
 	return nil
 }
 
@@ -113,6 +146,65 @@ func GetSchedulerID() int {
 	return cfg.conditionSchedulerID
 }
 
+// GetInstanceID returns the name this instance holds job leases under
+func GetInstanceID() string {
+	return cfg.instanceID
+}
+
+// defaultInstanceID tells apart instances started in the same second, on one
+// host or on several
+func defaultInstanceID() string {
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "unknown"
+	}
+	return fmt.Sprintf("condition-scheduler-%s-%d", hostname, os.Getpid())
+}
+
+// GetSourceHostRate returns the number of value source requests per second allowed per host
+func GetSourceHostRate() float64 {
+	return cfg.sourceHostRate
+}
+
+// GetSourceHostBurst returns the burst of value source requests allowed per host
+func GetSourceHostBurst() int {
+	return cfg.sourceHostBurst
+}
+
+// GetShardHeartbeatInterval returns how often an instance renews its membership and job leases
+func GetShardHeartbeatInterval() time.Duration {
+	return time.Duration(cfg.shardHeartbeatSeconds) * time.Second
+}
+
+// IsRedisEnabled returns whether a Redis instance is configured for sample storage
+func IsRedisEnabled() bool {
+	return cfg.upstashURL != "" || cfg.localAddr != ""
+}
+
+// GetRedisConfig returns the Redis client configuration, preferring Upstash
+func GetRedisConfig() redisClient.RedisConfig {
+	return redisClient.RedisConfig{
+		IsUpstash: cfg.upstashURL != "",
+		UpstashConfig: redisClient.UpstashConfig{
+			URL:   cfg.upstashURL,
+			Token: cfg.upstashToken,
+		},
+		LocalRedisConfig: redisClient.LocalRedisConfig{
+			Addr:     cfg.localAddr,
+			Password: cfg.localPassword,
+		},
+		ConnectionSettings: redisClient.ConnectionSettings{
+			PoolSize:     10,
+			MinIdleConns: 2,
+			MaxRetries:   3,
+			DialTimeout:  5 * time.Second,
+			ReadTimeout:  3 * time.Second,
+			WriteTimeout: 3 * time.Second,
+			PoolTimeout:  4 * time.Second,
+		},
+	}
+}
+
 // GetChainRPCUrlsTest returns local/test chain RPC URLs
 func GetChainRPCUrlsTest() map[string]string {
 	local := "http://127.0.0.1:8545"
@@ -144,3 +236,26 @@ func GetChainRPCUrls() map[string]string {
 		"11155111": fmt.Sprintf("https://eth-sepolia.g.alchemy.com/v2/%s", cfg.alchemyAPIKey),  // Ethereum Sepolia
 	}
 }
+
+// GetChainWSUrls returns chain WebSocket URLs for event log subscriptions.
+// Chains without an entry are served by HTTP polling only.
+func GetChainWSUrls() map[string]string {
+	if isTestEnv() {
+		local := "ws://127.0.0.1:8545"
+		return map[string]string{
+			"11155420": local,
+			"84532":    local,
+			"11155111": local,
+		}
+	}
+
+	if cfg.alchemyAPIKey == "" {
+		return map[string]string{}
+	}
+
+	return map[string]string{
+		"11155420": fmt.Sprintf("wss://opt-sepolia.g.alchemy.com/v2/%s", cfg.alchemyAPIKey),  // OP Sepolia
+		"84532":    fmt.Sprintf("wss://base-sepolia.g.alchemy.com/v2/%s", cfg.alchemyAPIKey), // Base Sepolia
+		"11155111": fmt.Sprintf("wss://eth-sepolia.g.alchemy.com/v2/%s", cfg.alchemyAPIKey),  // Ethereum Sepolia
+	}
+}
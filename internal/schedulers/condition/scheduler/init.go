@@ -11,6 +11,7 @@ import (
 
 	"github.com/trigg3rX/triggerx-backend-imua/internal/schedulers/condition/config"
 	"github.com/trigg3rX/triggerx-backend-imua/internal/schedulers/condition/metrics"
+	"github.com/trigg3rX/triggerx-backend-imua/internal/schedulers/condition/scheduler/sharding"
 	"github.com/trigg3rX/triggerx-backend-imua/internal/schedulers/condition/scheduler/worker"
 	"github.com/trigg3rX/triggerx-backend-imua/pkg/client/dbserver"
 	"github.com/trigg3rX/triggerx-backend-imua/pkg/logging"
@@ -35,6 +36,10 @@ type ConditionBasedScheduler struct {
 	metrics                 *metrics.Collector
 	maxWorkers              int
 	schedulerID             int
+	sampleStore             worker.SampleStore // Persists windowed condition samples, nil when Redis is not configured
+	sources                 *worker.SourceMultiplexer // Shared value source polling for condition workers
+	logs                    *worker.LogMultiplexer    // Shared log subscriptions for event workers
+	coordinator             *sharding.Coordinator     // Distributes jobs across instances, nil when Redis is not configured
 }
 
 // NewConditionBasedScheduler creates a new instance of ConditionBasedScheduler
@@ -67,17 +72,27 @@ func NewConditionBasedScheduler(managerID string, logger logging.Logger, dbClien
 		schedulerID:             config.GetSchedulerID(),
 	}
 
+	scheduler.sources = worker.NewSourceMultiplexer(ctx, logger, worker.SourceMultiplexerConfig{
+		PollInterval: worker.ConditionPollInterval,
+		HostRate:     config.GetSourceHostRate(),
+		HostBurst:    config.GetSourceHostBurst(),
+	})
+
 	// Initialize chain clients for event workers
 	if err := scheduler.initChainClients(); err != nil {
 		cancel()
 		return nil, fmt.Errorf("failed to initialize chain clients: %w", err)
 	}
 
+	scheduler.initLogMultiplexer()
+
 	if err := scheduler.initRetryClient(); err != nil {
 		cancel()
 		return nil, fmt.Errorf("failed to initialize retry client: %w", err)
 	}
 
+	scheduler.initRedis(managerID)
+
 	// Start metrics collection
 	scheduler.metrics.Start()
 
@@ -86,6 +101,9 @@ func NewConditionBasedScheduler(managerID string, logger logging.Logger, dbClien
 		"scheduler_id", scheduler.schedulerID,
 		"redis_api_url", scheduler.redisAPIURL,
 		"connected_chains", len(scheduler.chainClients),
+		"log_streams", len(config.GetChainWSUrls()),
+		"sample_store", scheduler.sampleStore != nil,
+		"sharding", scheduler.coordinator != nil,
 	)
 
 	return scheduler, nil
@@ -96,6 +114,11 @@ func (s *ConditionBasedScheduler) Start(ctx context.Context) {
 	s.logger.Info("Condition-based scheduler ready for job scheduling",
 		"scheduler_id", s.schedulerID,)
 
+	// Run the jobs this instance owns in the shared catalog
+	if s.coordinator != nil {
+		go s.coordinator.Run(s.ctx)
+	}
+
 	// Keep the service alive
 	<-ctx.Done()
 	s.logger.Info("Scheduler context cancelled, stopping all workers")
@@ -115,6 +138,11 @@ func (s *ConditionBasedScheduler) Stop() {
 
 	connectedChains := len(s.chainClients)
 
+	// Hand owned jobs back before the workers go away
+	if s.coordinator != nil {
+		s.coordinator.Leave()
+	}
+
 	s.cancel()
 
 	// Stop all workers
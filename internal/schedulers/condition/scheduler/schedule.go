@@ -14,6 +14,7 @@ import (
 	"github.com/trigg3rX/triggerx-backend-imua/internal/schedulers/condition/metrics"
 	"github.com/trigg3rX/triggerx-backend-imua/internal/schedulers/condition/scheduler/worker"
 	"github.com/trigg3rX/triggerx-backend-imua/pkg/retry"
+	"github.com/trigg3rX/triggerx-backend-imua/pkg/timeseries"
 	"github.com/trigg3rX/triggerx-backend-imua/pkg/types"
 )
 
@@ -35,8 +36,73 @@ type RedisAPIResponse struct {
 	Details   string              `json:"details,omitempty"`
 }
 
-// ScheduleJob creates and starts a new condition worker for monitoring
+// ScheduleJob schedules a job. With sharding enabled the job is added to the
+// shared catalog and started by whichever instance owns it.
 func (s *ConditionBasedScheduler) ScheduleJob(jobData *types.ScheduleConditionJobData) error {
+	if s.coordinator == nil {
+		return s.startJob(jobData)
+	}
+
+	if err := s.validateJob(jobData); err != nil {
+		return err
+	}
+	if err := s.coordinator.Publish(s.ctx, jobData); err != nil {
+		metrics.TrackCriticalError("job_catalog_publish_failed")
+		return fmt.Errorf("failed to publish job %d: %w", jobData.JobID, err)
+	}
+
+	s.logger.Info("Job published to scheduler catalog", "job_id", jobData.JobID)
+	return nil
+}
+
+// validateJob checks a job before it is started or published
+func (s *ConditionBasedScheduler) validateJob(jobData *types.ScheduleConditionJobData) error {
+	switch jobData.TaskDefinitionID {
+	case 3, 4: // Event-based jobs
+		// Check if chain client is available
+		if _, exists := s.chainClients[jobData.EventWorkerData.TriggerChainID]; !exists {
+			metrics.TrackCriticalError("chain_client_not_found")
+			return fmt.Errorf("chain client not found for chain %s", jobData.EventWorkerData.TriggerChainID)
+		}
+
+		// Validate contract address
+		if !common.IsHexAddress(jobData.EventWorkerData.TriggerContractAddress) {
+			metrics.TrackCriticalError("invalid_contract_address")
+			return fmt.Errorf("invalid contract address: %s", jobData.EventWorkerData.TriggerContractAddress)
+		}
+
+	case 5, 6: // Condition-based jobs
+		// Validate condition type
+		if !isValidConditionType(jobData.ConditionWorkerData.ConditionType) {
+			metrics.TrackCriticalError("invalid_condition_type")
+			return fmt.Errorf("unsupported condition type: %s", jobData.ConditionWorkerData.ConditionType)
+		}
+
+		// Windowed condition types need a window to evaluate over
+		if timeseries.IsWindowedCondition(jobData.ConditionWorkerData.ConditionType) && jobData.ConditionWorkerData.WindowSeconds <= 0 {
+			metrics.TrackCriticalError("invalid_condition_window")
+			return fmt.Errorf("condition type %s requires a positive window_seconds", jobData.ConditionWorkerData.ConditionType)
+		}
+
+		// Validate value source type
+		if !isValidSourceType(jobData.ConditionWorkerData.ValueSourceType) {
+			metrics.TrackCriticalError("invalid_source_type")
+			return fmt.Errorf("unsupported value source type: %s", jobData.ConditionWorkerData.ValueSourceType)
+		}
+
+	default:
+		return fmt.Errorf("unsupported task definition id: %d", jobData.TaskDefinitionID)
+	}
+
+	return nil
+}
+
+// startJob creates and starts a new worker on this instance
+func (s *ConditionBasedScheduler) startJob(jobData *types.ScheduleConditionJobData) error {
+	if err := s.validateJob(jobData); err != nil {
+		return err
+	}
+
 	s.workersMutex.Lock()
 	defer s.workersMutex.Unlock()
 
@@ -73,18 +139,6 @@ func (s *ConditionBasedScheduler) scheduleConditionJob(jobData *types.ScheduleCo
 		return fmt.Errorf("job %d is already scheduled", jobData.JobID)
 	}
 
-	// Validate condition type
-	if !isValidConditionType(jobData.ConditionWorkerData.ConditionType) {
-		metrics.TrackCriticalError("invalid_condition_type")
-		return fmt.Errorf("unsupported condition type: %s", jobData.ConditionWorkerData.ConditionType)
-	}
-
-	// Validate value source type
-	if !isValidSourceType(jobData.ConditionWorkerData.ValueSourceType) {
-		metrics.TrackCriticalError("invalid_source_type")
-		return fmt.Errorf("unsupported value source type: %s", jobData.ConditionWorkerData.ValueSourceType)
-	}
-
 	// Create condition worker with Redis callback
 	conditionWorker, err := s.createConditionWorker(&jobData.ConditionWorkerData, s.HTTPClient)
 	if err != nil {
@@ -127,18 +181,6 @@ func (s *ConditionBasedScheduler) scheduleEventJob(jobData *types.ScheduleCondit
 		return fmt.Errorf("job %d is already scheduled", jobData.JobID)
 	}
 
-	// Check if chain client is available
-	if _, exists := s.chainClients[jobData.EventWorkerData.TriggerChainID]; !exists {
-		metrics.TrackCriticalError("chain_client_not_found")
-		return fmt.Errorf("chain client not found for chain %s", jobData.EventWorkerData.TriggerChainID)
-	}
-
-	// Validate contract address
-	if !common.IsHexAddress(jobData.EventWorkerData.TriggerContractAddress) {
-		metrics.TrackCriticalError("invalid_contract_address")
-		return fmt.Errorf("invalid contract address: %s", jobData.EventWorkerData.TriggerContractAddress)
-	}
-
 	// Create event worker with Redis callback
 	eventWorker, err := s.createEventWorker(&jobData.EventWorkerData, s.chainClients[jobData.EventWorkerData.TriggerChainID])
 	if err != nil {
@@ -184,6 +226,8 @@ func (s *ConditionBasedScheduler) createConditionWorker(conditionWorkerData *typ
 		IsActive:            false,
 		LastCheckTimestamp:  time.Now(),
 		TriggerCallback:     s.handleTriggerNotification,
+		SampleStore:         s.sampleStore,
+		Sources:             s.sources,
 	}
 
 	return worker, nil
@@ -208,6 +252,7 @@ func (s *ConditionBasedScheduler) createEventWorker(eventWorkerData *types.Event
 		Cancel:          cancel,
 		LastBlock:       currentBlock,
 		IsActive:        false,
+		Logs:            s.logs,
 		TriggerCallback: s.handleTriggerNotification,
 	}
 
@@ -253,6 +298,13 @@ func (s *ConditionBasedScheduler) handleTriggerNotification(notification *worker
 			"duration", duration,
 		)
 		metrics.TrackActionExecution(fmt.Sprintf("%d", notification.JobID), duration)
+
+		// A triggered non-recurring job is done; keep other instances from restarting it
+		if s.coordinator != nil && !isRecurring(jobData) {
+			if err := s.coordinator.Remove(s.ctx, notification.JobID); err != nil {
+				s.logger.Warn("Failed to remove completed job from scheduler catalog", "job_id", notification.JobID, "error", err)
+			}
+		}
 	} else {
 		s.logger.Error("Failed to submit triggered task to Redis API",
 			"job_id", notification.JobID,
@@ -283,6 +335,7 @@ func (s *ConditionBasedScheduler) submitTriggeredTaskToRedis(jobData *types.Sche
 		ArgType:                   jobData.TaskTargetData.ArgType,
 		Arguments:                 jobData.TaskTargetData.Arguments,
 		DynamicArgumentsScriptUrl: jobData.TaskTargetData.DynamicArgumentsScriptUrl,
+		ExtraTargets:              jobData.TaskTargetData.ExtraTargets,
 	}
 
 	// Create trigger data based on job type
@@ -324,12 +377,14 @@ func (s *ConditionBasedScheduler) createTriggerDataFromNotification(jobData *typ
 
 	switch jobData.TaskDefinitionID {
 	case 5, 6: // Condition-based
-		baseTriggerData.ConditionSatisfiedValue = int(notification.TriggerValue)
+		baseTriggerData.ConditionSatisfiedValue = notification.TriggerValue
 		baseTriggerData.ConditionType = jobData.ConditionWorkerData.ConditionType
 		baseTriggerData.ConditionSourceType = jobData.ConditionWorkerData.ValueSourceType
 		baseTriggerData.ConditionSourceUrl = jobData.ConditionWorkerData.ValueSourceUrl
-		baseTriggerData.ConditionUpperLimit = int(jobData.ConditionWorkerData.UpperLimit)
-		baseTriggerData.ConditionLowerLimit = int(jobData.ConditionWorkerData.LowerLimit)
+		baseTriggerData.ConditionUpperLimit = jobData.ConditionWorkerData.UpperLimit
+		baseTriggerData.ConditionLowerLimit = jobData.ConditionWorkerData.LowerLimit
+		baseTriggerData.ConditionWindowSeconds = jobData.ConditionWorkerData.WindowSeconds
+		baseTriggerData.ConditionSamples = notification.Samples
 
 	case 3, 4: // Event-based
 		baseTriggerData.EventTxHash = notification.TriggerTxHash
@@ -419,8 +474,118 @@ func (s *ConditionBasedScheduler) submitTaskToRedisAPI(request SchedulerTaskRequ
 	return true, nil
 }
 
-// UnscheduleJob stops and removes a condition worker
+// UpdateJob applies an edited job to the worker running it. With sharding
+// enabled the catalog entry is replaced and the owning instance applies it.
+func (s *ConditionBasedScheduler) UpdateJob(jobData *types.ScheduleConditionJobData) error {
+	if s.coordinator == nil {
+		return s.updateJob(jobData)
+	}
+	return s.ScheduleJob(jobData)
+}
+
+// updateJob swaps an edited job into its running worker on this instance. If only
+// the target changed the worker keeps running and triggers with the new job data
+// from then on. If the trigger or condition changed the worker is replaced; the
+// old one keeps running until the new one is ready. A job not running here is started.
+func (s *ConditionBasedScheduler) updateJob(jobData *types.ScheduleConditionJobData) error {
+	if err := s.validateJob(jobData); err != nil {
+		return err
+	}
+
+	s.workersMutex.Lock()
+	current, exists := s.jobDataStore[jobData.JobID]
+	if !exists {
+		s.workersMutex.Unlock()
+		return s.startJob(jobData)
+	}
+	if sameWorkerData(current, jobData) {
+		s.jobDataStore[jobData.JobID] = jobData
+		s.workersMutex.Unlock()
+		s.logger.Info("Job target updated", "job_id", jobData.JobID)
+		return nil
+	}
+	s.workersMutex.Unlock()
+
+	// The replacement is built without the lock, the event worker asks the
+	// chain for its starting block, and swapped in only if the job was not
+	// stopped or edited again meanwhile
+	switch jobData.TaskDefinitionID {
+	case 3, 4:
+		eventWorker, err := s.createEventWorker(&jobData.EventWorkerData, s.chainClients[jobData.EventWorkerData.TriggerChainID])
+		if err != nil {
+			metrics.TrackCriticalError("worker_creation_failed")
+			return fmt.Errorf("failed to create event worker: %w", err)
+		}
+		s.workersMutex.Lock()
+		defer s.workersMutex.Unlock()
+		if s.jobDataStore[jobData.JobID] != current {
+			eventWorker.Cancel()
+			return fmt.Errorf("job %d changed while its worker was replaced", jobData.JobID)
+		}
+		if previous, ok := s.eventWorkers[jobData.JobID]; ok {
+			previous.Stop()
+		}
+		s.eventWorkers[jobData.JobID] = eventWorker
+		s.jobDataStore[jobData.JobID] = jobData
+		go eventWorker.Start()
+
+	case 5, 6:
+		conditionWorker, err := s.createConditionWorker(&jobData.ConditionWorkerData, s.HTTPClient)
+		if err != nil {
+			metrics.TrackCriticalError("worker_creation_failed")
+			return fmt.Errorf("failed to create condition worker: %w", err)
+		}
+		s.workersMutex.Lock()
+		defer s.workersMutex.Unlock()
+		if s.jobDataStore[jobData.JobID] != current {
+			conditionWorker.Cancel()
+			return fmt.Errorf("job %d changed while its worker was replaced", jobData.JobID)
+		}
+		if previous, ok := s.conditionWorkers[jobData.JobID]; ok {
+			previous.Stop()
+		}
+		s.conditionWorkers[jobData.JobID] = conditionWorker
+		s.jobDataStore[jobData.JobID] = jobData
+		go conditionWorker.Start()
+	}
+
+	s.logger.Info("Job worker replaced with edited definition", "job_id", jobData.JobID)
+	return nil
+}
+
+// UnscheduleJob stops and removes a job. With sharding enabled the job is
+// removed from the shared catalog and stopped by whichever instance runs it.
 func (s *ConditionBasedScheduler) UnscheduleJob(jobID int64) error {
+	if s.coordinator == nil {
+		if err := s.stopJob(jobID); err != nil {
+			return err
+		}
+		s.deleteSamples(jobID)
+		return nil
+	}
+
+	if err := s.coordinator.Remove(s.ctx, jobID); err != nil {
+		metrics.TrackCriticalError("job_catalog_remove_failed")
+		return fmt.Errorf("failed to remove job %d: %w", jobID, err)
+	}
+	s.deleteSamples(jobID)
+
+	s.logger.Info("Job removed from scheduler catalog", "job_id", jobID)
+	return nil
+}
+
+// deleteSamples drops the persisted samples of a windowed condition job
+func (s *ConditionBasedScheduler) deleteSamples(jobID int64) {
+	if s.sampleStore == nil {
+		return
+	}
+	if err := s.sampleStore.Delete(s.ctx, jobID); err != nil {
+		s.logger.Warn("Failed to delete condition samples", "job_id", jobID, "error", err)
+	}
+}
+
+// stopJob stops and removes a worker on this instance
+func (s *ConditionBasedScheduler) stopJob(jobID int64) error {
 	s.workersMutex.Lock()
 	defer s.workersMutex.Unlock()
 
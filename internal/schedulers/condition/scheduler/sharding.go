@@ -0,0 +1,30 @@
+package scheduler
+
+import "github.com/trigg3rX/triggerx-backend-imua/pkg/types"
+
+// shardJobHandler runs the jobs the coordinator assigns to this instance
+type shardJobHandler struct {
+	s *ConditionBasedScheduler
+}
+
+// StartJob starts a claimed job locally
+func (h shardJobHandler) StartJob(jobData *types.ScheduleConditionJobData) error {
+	return h.s.startJob(jobData)
+}
+
+// StopJob stops a job that was released; its samples stay for the next owner
+func (h shardJobHandler) StopJob(jobID int64) error {
+	return h.s.stopJob(jobID)
+}
+
+// UpdateJob applies an edited job that is running locally
+func (h shardJobHandler) UpdateJob(jobData *types.ScheduleConditionJobData) error {
+	return h.s.updateJob(jobData)
+}
+
+func (s *ConditionBasedScheduler) shardingStats() map[string]interface{} {
+	if s.coordinator == nil {
+		return nil
+	}
+	return s.coordinator.Stats()
+}
@@ -0,0 +1,310 @@
+package sharding
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/trigg3rX/triggerx-backend-imua/pkg/logging"
+	"github.com/trigg3rX/triggerx-backend-imua/pkg/types"
+)
+
+// JobHandler runs jobs on the local instance
+type JobHandler interface {
+	StartJob(jobData *types.ScheduleConditionJobData) error
+	StopJob(jobID int64) error
+	// UpdateJob applies an edited catalog entry to a job already running locally
+	UpdateJob(jobData *types.ScheduleConditionJobData) error
+}
+
+// Config configures heartbeats and leases
+type Config struct {
+	HeartbeatInterval time.Duration // How often membership and leases are renewed
+	MemberTTL         time.Duration // Instances silent for longer are considered dead
+	LeaseTTL          time.Duration // Lease lifetime, must outlive a few heartbeats
+	VirtualNodes      int
+}
+
+// Coordinator distributes the job catalog across scheduler instances. Each job
+// is owned by one instance on a consistent hash ring of live members, and run
+// only while that instance holds the job's lease. When an instance dies its
+// heartbeats stop, the ring shrinks, and its leases expire for the new owners
+// to claim.
+type Coordinator struct {
+	instanceID string
+	store      Store
+	handler    JobHandler
+	logger     logging.Logger
+	config     Config
+
+	mu      sync.Mutex
+	owned   map[int64]string // Catalog entry each owned job was started or last updated from
+	members []string
+	stopped bool
+	trigger chan struct{}
+}
+
+// NewCoordinator creates a coordinator for one scheduler instance
+func NewCoordinator(instanceID string, store Store, handler JobHandler, logger logging.Logger, config Config) *Coordinator {
+	if config.HeartbeatInterval <= 0 {
+		config.HeartbeatInterval = 5 * time.Second
+	}
+	if config.MemberTTL <= 0 {
+		config.MemberTTL = 3 * config.HeartbeatInterval
+	}
+	if config.LeaseTTL <= 0 {
+		config.LeaseTTL = 3 * config.HeartbeatInterval
+	}
+
+	return &Coordinator{
+		instanceID: instanceID,
+		store:      store,
+		handler:    handler,
+		logger:     logger,
+		config:     config,
+		owned:      make(map[int64]string),
+		trigger:    make(chan struct{}, 1),
+	}
+}
+
+// InstanceID returns the ID this instance registers under
+func (c *Coordinator) InstanceID() string {
+	return c.instanceID
+}
+
+// Run reconciles on every heartbeat and catalog change until ctx is done
+func (c *Coordinator) Run(ctx context.Context) {
+	ticker := time.NewTicker(c.config.HeartbeatInterval)
+	defer ticker.Stop()
+	changes := c.store.Changes(ctx)
+
+	c.Reconcile(ctx)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-changes:
+		case <-c.trigger:
+		}
+		c.Reconcile(ctx)
+	}
+}
+
+// Publish adds a job to the shared catalog; its owner picks it up
+func (c *Coordinator) Publish(ctx context.Context, jobData *types.ScheduleConditionJobData) error {
+	data, err := json.Marshal(jobData)
+	if err != nil {
+		return fmt.Errorf("failed to marshal job %d: %w", jobData.JobID, err)
+	}
+	if err := c.store.PutJob(ctx, jobData.JobID, data); err != nil {
+		return err
+	}
+	c.Trigger()
+	return nil
+}
+
+// Remove deletes a job from the shared catalog; its owner stops it
+func (c *Coordinator) Remove(ctx context.Context, jobID int64) error {
+	if err := c.store.RemoveJob(ctx, jobID); err != nil {
+		return err
+	}
+	c.Trigger()
+	return nil
+}
+
+// Trigger requests a reconcile without waiting for the next heartbeat
+func (c *Coordinator) Trigger() {
+	select {
+	case c.trigger <- struct{}{}:
+	default:
+	}
+}
+
+// Reconcile renews membership, stops jobs this instance no longer owns and
+// claims the ones it now owns
+func (c *Coordinator) Reconcile(ctx context.Context) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.stopped {
+		return
+	}
+	if err := c.store.Heartbeat(ctx, c.instanceID); err != nil {
+		c.logger.Warn("Failed to send scheduler heartbeat", "instance_id", c.instanceID, "error", err)
+		return
+	}
+	members, err := c.store.Members(ctx, c.config.MemberTTL)
+	if err != nil {
+		c.logger.Warn("Failed to list scheduler members", "instance_id", c.instanceID, "error", err)
+		return
+	}
+	sort.Strings(members)
+	if !equalMembers(members, c.members) {
+		c.logger.Info("Scheduler membership changed, rebalancing jobs",
+			"instance_id", c.instanceID,
+			"members", members,
+		)
+		c.members = members
+	}
+	ring := NewRing(members, c.config.VirtualNodes)
+
+	catalog, err := c.store.Jobs(ctx)
+	if err != nil {
+		c.logger.Warn("Failed to load job catalog", "instance_id", c.instanceID, "error", err)
+		return
+	}
+
+	// Stop jobs that were removed, moved to another instance, or whose lease was lost
+	for jobID, started := range c.owned {
+		data, exists := catalog[jobID]
+		if !exists {
+			c.stop(ctx, jobID, "removed from catalog")
+			continue
+		}
+		if ring.Owner(jobID) != c.instanceID {
+			c.stop(ctx, jobID, "moved to another instance")
+			continue
+		}
+		renewed, err := c.store.Renew(ctx, jobID, c.instanceID, c.config.LeaseTTL)
+		if err != nil {
+			// Keep running through a Redis blip rather than churn every job
+			c.logger.Warn("Failed to renew job lease", "job_id", jobID, "error", err)
+			continue
+		}
+		if !renewed {
+			c.stop(ctx, jobID, "lease lost")
+			continue
+		}
+		if string(data) != started {
+			c.update(jobID, data)
+		}
+	}
+
+	// Claim jobs this instance now owns
+	for jobID, data := range catalog {
+		if _, running := c.owned[jobID]; running || ring.Owner(jobID) != c.instanceID {
+			continue
+		}
+
+		var jobData types.ScheduleConditionJobData
+		if err := json.Unmarshal(data, &jobData); err != nil {
+			c.logger.Error("Invalid job in catalog", "job_id", jobID, "error", err)
+			continue
+		}
+		if isExpired(&jobData) {
+			if err := c.store.RemoveJob(ctx, jobID); err != nil {
+				c.logger.Warn("Failed to remove expired job from catalog", "job_id", jobID, "error", err)
+			}
+			continue
+		}
+
+		claimed, err := c.store.Claim(ctx, jobID, c.instanceID, c.config.LeaseTTL)
+		if err != nil {
+			c.logger.Warn("Failed to claim job", "job_id", jobID, "error", err)
+			continue
+		}
+		if !claimed {
+			// The previous owner has not released it yet, or its lease has not expired
+			continue
+		}
+
+		if err := c.handler.StartJob(&jobData); err != nil {
+			c.logger.Error("Failed to start claimed job", "job_id", jobID, "error", err)
+			if err := c.store.Release(ctx, jobID, c.instanceID); err != nil {
+				c.logger.Warn("Failed to release job lease", "job_id", jobID, "error", err)
+			}
+			continue
+		}
+		c.owned[jobID] = string(data)
+		c.logger.Info("Claimed job", "job_id", jobID, "instance_id", c.instanceID)
+	}
+}
+
+// Stats returns the membership and the jobs owned by this instance
+func (c *Coordinator) Stats() map[string]interface{} {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return map[string]interface{}{
+		"instance_id": c.instanceID,
+		"members":     append([]string(nil), c.members...),
+		"owned_jobs":  len(c.owned),
+	}
+}
+
+// update applies an edited catalog entry to an owned job. Caller holds c.mu.
+func (c *Coordinator) update(jobID int64, data []byte) {
+	var jobData types.ScheduleConditionJobData
+	if err := json.Unmarshal(data, &jobData); err != nil {
+		c.logger.Error("Invalid job in catalog", "job_id", jobID, "error", err)
+		return
+	}
+	if err := c.handler.UpdateJob(&jobData); err != nil {
+		// Retried on the next reconcile, the job keeps running as it was
+		c.logger.Error("Failed to apply edited job", "job_id", jobID, "error", err)
+		return
+	}
+	c.owned[jobID] = string(data)
+	c.logger.Info("Applied edited job", "job_id", jobID, "instance_id", c.instanceID)
+}
+
+// stop stops a local job and releases its lease. Caller holds c.mu.
+func (c *Coordinator) stop(ctx context.Context, jobID int64, reason string) {
+	if err := c.handler.StopJob(jobID); err != nil {
+		c.logger.Warn("Failed to stop job", "job_id", jobID, "error", err)
+	}
+	if err := c.store.Release(ctx, jobID, c.instanceID); err != nil {
+		c.logger.Warn("Failed to release job lease", "job_id", jobID, "error", err)
+	}
+	delete(c.owned, jobID)
+	c.logger.Info("Released job", "job_id", jobID, "instance_id", c.instanceID, "reason", reason)
+}
+
+// Leave stops the owned jobs, releases their leases and deregisters, so other
+// instances take over at once. The coordinator claims nothing afterwards.
+func (c *Coordinator) Leave() {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.stopped {
+		return
+	}
+	c.stopped = true
+
+	for jobID := range c.owned {
+		c.stop(ctx, jobID, "instance shutting down")
+	}
+	if err := c.store.Leave(ctx, c.instanceID); err != nil {
+		c.logger.Warn("Failed to leave scheduler members", "instance_id", c.instanceID, "error", err)
+	}
+}
+
+func isExpired(jobData *types.ScheduleConditionJobData) bool {
+	var expiration time.Time
+	switch jobData.TaskDefinitionID {
+	case 3, 4:
+		expiration = jobData.EventWorkerData.ExpirationTime
+	case 5, 6:
+		expiration = jobData.ConditionWorkerData.ExpirationTime
+	}
+	return !expiration.IsZero() && time.Now().After(expiration)
+}
+
+func equalMembers(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
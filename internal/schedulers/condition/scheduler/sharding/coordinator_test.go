@@ -0,0 +1,299 @@
+package sharding
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/trigg3rX/triggerx-backend-imua/pkg/logging"
+	"github.com/trigg3rX/triggerx-backend-imua/pkg/types"
+)
+
+type MockLogger struct{}
+
+func (l *MockLogger) Debug(msg string, tags ...any)               {}
+func (l *MockLogger) Info(msg string, tags ...any)                {}
+func (l *MockLogger) Warn(msg string, tags ...any)                {}
+func (l *MockLogger) Error(msg string, tags ...any)               {}
+func (l *MockLogger) Fatal(msg string, tags ...any)               {}
+func (l *MockLogger) Debugf(template string, args ...interface{}) {}
+func (l *MockLogger) Infof(template string, args ...interface{})  {}
+func (l *MockLogger) Warnf(template string, args ...interface{})  {}
+func (l *MockLogger) Errorf(template string, args ...interface{}) {}
+func (l *MockLogger) Fatalf(template string, args ...interface{}) {}
+func (l *MockLogger) With(tags ...any) logging.Logger             { return l }
+
+// memoryStore is an in-memory Store with a controllable clock
+type memoryStore struct {
+	mu      sync.Mutex
+	now     time.Time
+	members map[string]time.Time
+	jobs    map[int64][]byte
+	leases  map[int64]memoryLease
+}
+
+type memoryLease struct {
+	owner   string
+	expires time.Time
+}
+
+func newMemoryStore() *memoryStore {
+	return &memoryStore{
+		now:     time.Now(),
+		members: make(map[string]time.Time),
+		jobs:    make(map[int64][]byte),
+		leases:  make(map[int64]memoryLease),
+	}
+}
+
+func (s *memoryStore) advance(d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.now = s.now.Add(d)
+}
+
+func (s *memoryStore) Heartbeat(ctx context.Context, instanceID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.members[instanceID] = s.now
+	return nil
+}
+
+func (s *memoryStore) Members(ctx context.Context, ttl time.Duration) ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var members []string
+	for member, seen := range s.members {
+		if s.now.Sub(seen) > ttl {
+			delete(s.members, member)
+			continue
+		}
+		members = append(members, member)
+	}
+	return members, nil
+}
+
+func (s *memoryStore) Leave(ctx context.Context, instanceID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.members, instanceID)
+	return nil
+}
+
+func (s *memoryStore) Jobs(ctx context.Context) (map[int64][]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	jobs := make(map[int64][]byte, len(s.jobs))
+	for id, data := range s.jobs {
+		jobs[id] = data
+	}
+	return jobs, nil
+}
+
+func (s *memoryStore) PutJob(ctx context.Context, jobID int64, data []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.jobs[jobID] = data
+	return nil
+}
+
+func (s *memoryStore) RemoveJob(ctx context.Context, jobID int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.jobs, jobID)
+	return nil
+}
+
+func (s *memoryStore) Claim(ctx context.Context, jobID int64, owner string, ttl time.Duration) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if lease, held := s.leases[jobID]; held && s.now.Before(lease.expires) {
+		return false, nil
+	}
+	s.leases[jobID] = memoryLease{owner: owner, expires: s.now.Add(ttl)}
+	return true, nil
+}
+
+func (s *memoryStore) Renew(ctx context.Context, jobID int64, owner string, ttl time.Duration) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	lease, held := s.leases[jobID]
+	if !held || lease.owner != owner || !s.now.Before(lease.expires) {
+		return false, nil
+	}
+	s.leases[jobID] = memoryLease{owner: owner, expires: s.now.Add(ttl)}
+	return true, nil
+}
+
+func (s *memoryStore) Release(ctx context.Context, jobID int64, owner string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if lease, held := s.leases[jobID]; held && lease.owner == owner {
+		delete(s.leases, jobID)
+	}
+	return nil
+}
+
+func (s *memoryStore) Changes(ctx context.Context) <-chan struct{} {
+	return make(chan struct{})
+}
+
+// fakeHandler records which jobs are running locally
+type fakeHandler struct {
+	mu      sync.Mutex
+	running map[int64]struct{}
+	updated map[int64]*types.ScheduleConditionJobData
+}
+
+func newFakeHandler() *fakeHandler {
+	return &fakeHandler{
+		running: make(map[int64]struct{}),
+		updated: make(map[int64]*types.ScheduleConditionJobData),
+	}
+}
+
+func (h *fakeHandler) StartJob(jobData *types.ScheduleConditionJobData) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.running[jobData.JobID] = struct{}{}
+	return nil
+}
+
+func (h *fakeHandler) StopJob(jobID int64) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	delete(h.running, jobID)
+	return nil
+}
+
+func (h *fakeHandler) UpdateJob(jobData *types.ScheduleConditionJobData) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.updated[jobData.JobID] = jobData
+	return nil
+}
+
+func (h *fakeHandler) jobs() map[int64]struct{} {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	jobs := make(map[int64]struct{}, len(h.running))
+	for id := range h.running {
+		jobs[id] = struct{}{}
+	}
+	return jobs
+}
+
+func putTestJobs(t *testing.T, store *memoryStore, count int) {
+	for jobID := int64(1); jobID <= int64(count); jobID++ {
+		data, err := json.Marshal(&types.ScheduleConditionJobData{JobID: jobID, TaskDefinitionID: 5})
+		require.NoError(t, err)
+		require.NoError(t, store.PutJob(context.Background(), jobID, data))
+	}
+}
+
+func TestCoordinatorSplitsJobsAndFailsOver(t *testing.T) {
+	ctx := context.Background()
+	store := newMemoryStore()
+	putTestJobs(t, store, 50)
+
+	config := Config{HeartbeatInterval: time.Second}
+	handlerA, handlerB := newFakeHandler(), newFakeHandler()
+	a := NewCoordinator("scheduler-a", store, handlerA, &MockLogger{}, config)
+	b := NewCoordinator("scheduler-b", store, handlerB, &MockLogger{}, config)
+
+	// The first round registers both members; the second settles ownership
+	for i := 0; i < 2; i++ {
+		a.Reconcile(ctx)
+		b.Reconcile(ctx)
+	}
+	// Jobs a claimed before b joined are released, then claimed by b
+	b.Reconcile(ctx)
+
+	jobsA, jobsB := handlerA.jobs(), handlerB.jobs()
+	assert.NotEmpty(t, jobsA)
+	assert.NotEmpty(t, jobsB)
+	assert.Len(t, jobsA, 50-len(jobsB))
+	for jobID := range jobsA {
+		_, overlap := jobsB[jobID]
+		assert.False(t, overlap, "job %d runs on both instances", jobID)
+	}
+
+	// b dies without leaving: its heartbeat and leases expire, and a takes over
+	store.advance(5 * time.Second)
+	a.Reconcile(ctx)
+	assert.Len(t, handlerA.jobs(), 50)
+}
+
+func TestCoordinatorLeaveHandsJobsOver(t *testing.T) {
+	ctx := context.Background()
+	store := newMemoryStore()
+	putTestJobs(t, store, 20)
+
+	config := Config{HeartbeatInterval: time.Second}
+	handlerA, handlerB := newFakeHandler(), newFakeHandler()
+	a := NewCoordinator("scheduler-a", store, handlerA, &MockLogger{}, config)
+	b := NewCoordinator("scheduler-b", store, handlerB, &MockLogger{}, config)
+	for i := 0; i < 2; i++ {
+		a.Reconcile(ctx)
+		b.Reconcile(ctx)
+	}
+
+	b.Leave()
+	assert.Empty(t, handlerB.jobs())
+
+	a.Reconcile(ctx)
+	assert.Len(t, handlerA.jobs(), 20)
+
+	// A stopped coordinator never claims again
+	b.Reconcile(ctx)
+	assert.Empty(t, handlerB.jobs())
+}
+
+func TestCoordinatorRemoveStopsJob(t *testing.T) {
+	ctx := context.Background()
+	store := newMemoryStore()
+	putTestJobs(t, store, 3)
+
+	handler := newFakeHandler()
+	c := NewCoordinator("scheduler-a", store, handler, &MockLogger{}, Config{HeartbeatInterval: time.Second})
+	c.Reconcile(ctx)
+	require.Len(t, handler.jobs(), 3)
+
+	require.NoError(t, c.Remove(ctx, 2))
+	c.Reconcile(ctx)
+
+	jobs := handler.jobs()
+	assert.Len(t, jobs, 2)
+	assert.NotContains(t, jobs, int64(2))
+	assert.Equal(t, 2, c.Stats()["owned_jobs"])
+}
+
+func TestCoordinatorAppliesEditedJob(t *testing.T) {
+	ctx := context.Background()
+	store := newMemoryStore()
+	putTestJobs(t, store, 2)
+
+	handler := newFakeHandler()
+	c := NewCoordinator("scheduler-a", store, handler, &MockLogger{}, Config{HeartbeatInterval: time.Second})
+	c.Reconcile(ctx)
+	require.Len(t, handler.jobs(), 2)
+
+	// An unchanged catalog entry is left alone
+	c.Reconcile(ctx)
+	assert.Empty(t, handler.updated)
+
+	edited := &types.ScheduleConditionJobData{JobID: 2, TaskDefinitionID: 5}
+	edited.TaskTargetData.TargetFunction = "rebalance"
+	require.NoError(t, c.Publish(ctx, edited))
+	c.Reconcile(ctx)
+
+	require.Contains(t, handler.updated, int64(2))
+	assert.Equal(t, "rebalance", handler.updated[2].TaskTargetData.TargetFunction)
+	assert.NotContains(t, handler.updated, int64(1))
+	assert.Len(t, handler.jobs(), 2)
+}
@@ -0,0 +1,67 @@
+package sharding
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"sort"
+	"strconv"
+)
+
+// DefaultVirtualNodes spreads each instance over the ring so jobs move evenly on
+// rebalance, and keeps every instance's share within a few percent of fair
+const DefaultVirtualNodes = 256
+
+// Ring is a consistent hash ring of scheduler instances
+type Ring struct {
+	hashes []uint64
+	owners map[uint64]string
+}
+
+// NewRing places every member on the ring with the given number of virtual nodes
+func NewRing(members []string, virtualNodes int) *Ring {
+	if virtualNodes <= 0 {
+		virtualNodes = DefaultVirtualNodes
+	}
+
+	ring := &Ring{owners: make(map[uint64]string, len(members)*virtualNodes)}
+	for _, member := range members {
+		for i := 0; i < virtualNodes; i++ {
+			hash := hashKey(member + "#" + strconv.Itoa(i))
+			existing, exists := ring.owners[hash]
+			if !exists {
+				ring.hashes = append(ring.hashes, hash)
+				ring.owners[hash] = member
+				continue
+			}
+			// On the unlikely collision the smaller ID wins, so every instance agrees
+			if member < existing {
+				ring.owners[hash] = member
+			}
+		}
+	}
+	sort.Slice(ring.hashes, func(i, j int) bool { return ring.hashes[i] < ring.hashes[j] })
+
+	return ring
+}
+
+// Owner returns the instance responsible for a job, or "" for an empty ring
+func (r *Ring) Owner(jobID int64) string {
+	if len(r.hashes) == 0 {
+		return ""
+	}
+
+	hash := hashKey(strconv.FormatInt(jobID, 10))
+	idx := sort.Search(len(r.hashes), func(i int) bool { return r.hashes[i] >= hash })
+	if idx == len(r.hashes) {
+		idx = 0
+	}
+	return r.owners[r.hashes[idx]]
+}
+
+// hashKey places a key on the ring. Member IDs and job IDs differ in a few
+// trailing characters, which a cheaper hash leaves clustered, so it takes the
+// first 8 bytes of the key's SHA-256.
+func hashKey(key string) uint64 {
+	sum := sha256.Sum256([]byte(key))
+	return binary.BigEndian.Uint64(sum[:8])
+}
@@ -0,0 +1,53 @@
+package sharding
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRingOwner(t *testing.T) {
+	assert.Equal(t, "", NewRing(nil, 0).Owner(1))
+
+	members := []string{"scheduler-a", "scheduler-b", "scheduler-c"}
+	ring := NewRing(members, 0)
+	reordered := NewRing([]string{"scheduler-c", "scheduler-a", "scheduler-b"}, 0)
+
+	for jobID := int64(1); jobID <= 3000; jobID++ {
+		assert.Equal(t, ring.Owner(jobID), reordered.Owner(jobID), "member order must not matter")
+	}
+}
+
+func TestRingBalance(t *testing.T) {
+	memberSets := [][]string{
+		{"scheduler-a", "scheduler-b", "scheduler-c"},
+		{"condition-scheduler-host-a-101", "condition-scheduler-host-b-102"},
+		{"condition-scheduler-host-a-101", "condition-scheduler-host-b-102", "condition-scheduler-host-c-103"},
+		{"condition-scheduler-node-1-7", "condition-scheduler-node-2-7", "condition-scheduler-node-3-7", "condition-scheduler-node-4-7"},
+	}
+	const jobs = 30000
+
+	for _, members := range memberSets {
+		ring := NewRing(members, 0)
+		counts := make(map[string]int)
+		for jobID := int64(1); jobID <= jobs; jobID++ {
+			counts[ring.Owner(jobID)]++
+		}
+
+		fair := float64(jobs) / float64(len(members))
+		for _, member := range members {
+			assert.InDelta(t, fair, float64(counts[member]), fair*0.15, "%s should own its fair share of %v", member, members)
+		}
+	}
+}
+
+func TestRingRebalanceMovesOnlyDeadMemberJobs(t *testing.T) {
+	before := NewRing([]string{"scheduler-a", "scheduler-b", "scheduler-c"}, 0)
+	after := NewRing([]string{"scheduler-a", "scheduler-c"}, 0)
+
+	for jobID := int64(1); jobID <= 1000; jobID++ {
+		if owner := before.Owner(jobID); owner != "scheduler-b" {
+			assert.Equal(t, owner, after.Owner(jobID))
+		}
+	}
+}
@@ -0,0 +1,195 @@
+package sharding
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	goredis "github.com/redis/go-redis/v9"
+
+	"github.com/trigg3rX/triggerx-backend-imua/pkg/types"
+)
+
+const (
+	membersKey     = "condition:schedulers"
+	leaseKeyPrefix = "condition:lease:"
+)
+
+// renewLeaseScript extends a lease only if it is still held by the caller
+const renewLeaseScript = `
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("PEXPIRE", KEYS[1], ARGV[2])
+end
+return 0
+`
+
+// releaseLeaseScript deletes a lease only if it is still held by the caller
+const releaseLeaseScript = `
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0
+`
+
+// Store is the state shared by all condition scheduler instances
+type Store interface {
+	// Heartbeat marks the instance alive, and Members lists the instances seen within ttl
+	Heartbeat(ctx context.Context, instanceID string) error
+	Members(ctx context.Context, ttl time.Duration) ([]string, error)
+	Leave(ctx context.Context, instanceID string) error
+
+	// Jobs returns the catalog of scheduled jobs, keyed by job ID
+	Jobs(ctx context.Context) (map[int64][]byte, error)
+	PutJob(ctx context.Context, jobID int64, data []byte) error
+	RemoveJob(ctx context.Context, jobID int64) error
+
+	// Claim, Renew and Release manage the lease that lets one instance run a job
+	Claim(ctx context.Context, jobID int64, owner string, ttl time.Duration) (bool, error)
+	Renew(ctx context.Context, jobID int64, owner string, ttl time.Duration) (bool, error)
+	Release(ctx context.Context, jobID int64, owner string) error
+
+	// Changes delivers a signal whenever another process changes the catalog
+	Changes(ctx context.Context) <-chan struct{}
+}
+
+// RedisStore keeps members in a sorted set scored by last heartbeat, the job
+// catalog in a hash, and one expiring key per lease
+type RedisStore struct {
+	client *goredis.Client
+}
+
+// NewRedisStore creates a store backed by the given Redis client
+func NewRedisStore(client *goredis.Client) *RedisStore {
+	return &RedisStore{client: client}
+}
+
+func leaseKey(jobID int64) string {
+	return leaseKeyPrefix + strconv.FormatInt(jobID, 10)
+}
+
+// Heartbeat records the current time for the instance
+func (s *RedisStore) Heartbeat(ctx context.Context, instanceID string) error {
+	if err := s.client.ZAdd(ctx, membersKey, goredis.Z{
+		Score:  float64(time.Now().UnixMilli()),
+		Member: instanceID,
+	}).Err(); err != nil {
+		return fmt.Errorf("failed to record heartbeat: %w", err)
+	}
+	return nil
+}
+
+// Members drops instances that missed their heartbeats and returns the rest
+func (s *RedisStore) Members(ctx context.Context, ttl time.Duration) ([]string, error) {
+	cutoff := time.Now().Add(-ttl).UnixMilli()
+	if err := s.client.ZRemRangeByScore(ctx, membersKey, "-inf", "("+strconv.FormatInt(cutoff, 10)).Err(); err != nil {
+		return nil, fmt.Errorf("failed to prune scheduler members: %w", err)
+	}
+	members, err := s.client.ZRange(ctx, membersKey, 0, -1).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list scheduler members: %w", err)
+	}
+	return members, nil
+}
+
+// Leave removes the instance so its jobs move immediately
+func (s *RedisStore) Leave(ctx context.Context, instanceID string) error {
+	if err := s.client.ZRem(ctx, membersKey, instanceID).Err(); err != nil {
+		return fmt.Errorf("failed to leave scheduler members: %w", err)
+	}
+	return s.notify(ctx)
+}
+
+// Jobs returns the raw catalog entries
+func (s *RedisStore) Jobs(ctx context.Context) (map[int64][]byte, error) {
+	entries, err := s.client.HGetAll(ctx, types.ConditionJobCatalogKey).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load job catalog: %w", err)
+	}
+
+	jobs := make(map[int64][]byte, len(entries))
+	for field, value := range entries {
+		jobID, err := strconv.ParseInt(field, 10, 64)
+		if err != nil {
+			continue
+		}
+		jobs[jobID] = []byte(value)
+	}
+	return jobs, nil
+}
+
+// PutJob adds or replaces a catalog entry and notifies the other instances
+func (s *RedisStore) PutJob(ctx context.Context, jobID int64, data []byte) error {
+	if err := s.client.HSet(ctx, types.ConditionJobCatalogKey, strconv.FormatInt(jobID, 10), data).Err(); err != nil {
+		return fmt.Errorf("failed to add job %d to catalog: %w", jobID, err)
+	}
+	return s.notify(ctx)
+}
+
+// RemoveJob deletes a catalog entry and notifies the other instances
+func (s *RedisStore) RemoveJob(ctx context.Context, jobID int64) error {
+	if err := s.client.HDel(ctx, types.ConditionJobCatalogKey, strconv.FormatInt(jobID, 10)).Err(); err != nil {
+		return fmt.Errorf("failed to remove job %d from catalog: %w", jobID, err)
+	}
+	return s.notify(ctx)
+}
+
+// Claim takes the lease if nobody holds it
+func (s *RedisStore) Claim(ctx context.Context, jobID int64, owner string, ttl time.Duration) (bool, error) {
+	ok, err := s.client.SetNX(ctx, leaseKey(jobID), owner, ttl).Result()
+	if err != nil {
+		return false, fmt.Errorf("failed to claim job %d: %w", jobID, err)
+	}
+	return ok, nil
+}
+
+// Renew extends the lease if the owner still holds it
+func (s *RedisStore) Renew(ctx context.Context, jobID int64, owner string, ttl time.Duration) (bool, error) {
+	result, err := s.client.Eval(ctx, renewLeaseScript, []string{leaseKey(jobID)}, owner, ttl.Milliseconds()).Int64()
+	if err != nil {
+		return false, fmt.Errorf("failed to renew lease for job %d: %w", jobID, err)
+	}
+	return result == 1, nil
+}
+
+// Release gives the lease up if the owner still holds it
+func (s *RedisStore) Release(ctx context.Context, jobID int64, owner string) error {
+	if err := s.client.Eval(ctx, releaseLeaseScript, []string{leaseKey(jobID)}, owner).Err(); err != nil {
+		return fmt.Errorf("failed to release lease for job %d: %w", jobID, err)
+	}
+	return nil
+}
+
+// Changes subscribes to catalog change notifications
+func (s *RedisStore) Changes(ctx context.Context) <-chan struct{} {
+	changes := make(chan struct{}, 1)
+	pubsub := s.client.Subscribe(ctx, types.ConditionJobCatalogChannel)
+
+	go func() {
+		defer func() { _ = pubsub.Close() }()
+		messages := pubsub.Channel()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case _, ok := <-messages:
+				if !ok {
+					return
+				}
+				select {
+				case changes <- struct{}{}:
+				default:
+				}
+			}
+		}
+	}()
+
+	return changes
+}
+
+func (s *RedisStore) notify(ctx context.Context) error {
+	if err := s.client.Publish(ctx, types.ConditionJobCatalogChannel, "changed").Err(); err != nil {
+		return fmt.Errorf("failed to publish catalog change: %w", err)
+	}
+	return nil
+}
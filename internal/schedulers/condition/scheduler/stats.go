@@ -96,8 +96,15 @@ func (s *ConditionBasedScheduler) GetStats() map[string]interface{} {
 		"chain_info": map[string]interface{}{
 			"connected_chains": connectedChains,
 			"chain_ids":        chainIDs,
+			"log_streams":      s.logs.Stats(),
 		},
 
+		// Subscribers per shared value source
+		"shared_sources": s.sources.Stats(),
+
+		// Instances sharing the job catalog, nil when sharding is disabled
+		"sharding": s.shardingStats(),
+
 		"condition_workers": conditionWorkerDetails,
 		"event_workers":     eventWorkerDetails,
 
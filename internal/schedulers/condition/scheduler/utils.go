@@ -1,12 +1,20 @@
 package scheduler
 
 import (
+	"bytes"
+	"encoding/json"
 	"fmt"
+	"time"
 
 	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/trigg3rX/triggerx-backend-imua/internal/registrar/events/websocket"
 	"github.com/trigg3rX/triggerx-backend-imua/internal/schedulers/condition/config"
+	"github.com/trigg3rX/triggerx-backend-imua/internal/schedulers/condition/scheduler/sharding"
 	"github.com/trigg3rX/triggerx-backend-imua/internal/schedulers/condition/scheduler/worker"
+	redisClient "github.com/trigg3rX/triggerx-backend-imua/pkg/client/redis"
 	"github.com/trigg3rX/triggerx-backend-imua/pkg/retry"
+	"github.com/trigg3rX/triggerx-backend-imua/pkg/timeseries"
+	"github.com/trigg3rX/triggerx-backend-imua/pkg/types"
 )
 
 // Helper functions
@@ -20,7 +28,7 @@ func isValidConditionType(conditionType string) bool {
 			return true
 		}
 	}
-	return false
+	return timeseries.IsWindowedCondition(conditionType)
 }
 
 func isValidSourceType(sourceType string) bool {
@@ -33,6 +41,35 @@ func isValidSourceType(sourceType string) bool {
 	return false
 }
 
+// initRedis connects to Redis for windowed condition samples and sharding.
+// Without it, workers keep samples in memory only and this instance runs every job.
+func (s *ConditionBasedScheduler) initRedis(instanceID string) {
+	if !config.IsRedisEnabled() {
+		s.logger.Warn("Redis not configured, windowed condition samples will not survive restarts and sharding is disabled")
+		return
+	}
+
+	client, err := redisClient.NewRedisClient(s.logger, config.GetRedisConfig())
+	if err != nil {
+		s.logger.Warn("Failed to connect to Redis for condition samples and sharding", "error", err)
+		return
+	}
+	s.sampleStore = worker.NewRedisSampleStore(client.Client())
+	s.coordinator = sharding.NewCoordinator(instanceID, sharding.NewRedisStore(client.Client()), shardJobHandler{s}, s.logger, sharding.Config{
+		HeartbeatInterval: config.GetShardHeartbeatInterval(),
+	})
+}
+
+// isRecurring returns whether a job keeps running after it triggers
+func isRecurring(jobData *types.ScheduleConditionJobData) bool {
+	switch jobData.TaskDefinitionID {
+	case 3, 4:
+		return jobData.EventWorkerData.Recurring
+	default:
+		return jobData.ConditionWorkerData.Recurring
+	}
+}
+
 func (s *ConditionBasedScheduler) initRetryClient() error {
 	retryConfig := retry.DefaultHTTPRetryConfig()
 	var err error
@@ -71,4 +108,48 @@ func (s *ConditionBasedScheduler) initChainClients() error {
 	}
 
 	return nil
-}
\ No newline at end of file
+}
+
+// initLogMultiplexer shares WebSocket log subscriptions between event workers,
+// using the chain clients for HTTP polling and gap backfill
+func (s *ConditionBasedScheduler) initLogMultiplexer() {
+	httpClients := make(map[string]worker.ChainLogReader, len(s.chainClients))
+	for chainID, client := range s.chainClients {
+		httpClients[chainID] = client
+	}
+
+	s.logs = worker.NewLogMultiplexer(s.ctx, s.logger, httpClients, config.GetChainWSUrls(), worker.LogMultiplexerConfig{
+		PollInterval: worker.EventPollInterval,
+		Reconnect: websocket.ReconnectConfig{
+			MaxRetries:    10,
+			BaseDelay:     2 * time.Second,
+			MaxDelay:      time.Minute,
+			BackoffFactor: 2.0,
+			Jitter:        true,
+		},
+	})
+}
+
+// sameWorkerData reports whether two versions of a job watch for the same trigger
+func sameWorkerData(a, b *types.ScheduleConditionJobData) bool {
+	if a.TaskDefinitionID != b.TaskDefinitionID {
+		return false
+	}
+	var before, after interface{}
+	switch a.TaskDefinitionID {
+	case 3, 4:
+		before, after = a.EventWorkerData, b.EventWorkerData
+	default:
+		before, after = a.ConditionWorkerData, b.ConditionWorkerData
+	}
+	// Compared as JSON, as both usually arrive that way and times lose their monotonic reading
+	beforeJSON, err := json.Marshal(before)
+	if err != nil {
+		return false
+	}
+	afterJSON, err := json.Marshal(after)
+	if err != nil {
+		return false
+	}
+	return bytes.Equal(beforeJSON, afterJSON)
+}
@@ -24,6 +24,12 @@ type ConditionWorker struct {
 	LastValue       float64
 	LastCheckTimestamp time.Time
 	ConditionMet    int64 // Count of consecutive condition met checks
+	Armed           bool      // Whether the next satisfied check may trigger
+	LastTriggeredAt time.Time // Last time the worker notified the scheduler
+	Samples         []types.ConditionSample // Value buffer for windowed condition types
+	SampleStore     SampleStore             // Optional persistence for Samples
+	Sources         *SourceMultiplexer      // Shared source poller, nil to poll the source directly
+	Subscription    *SourceSubscription
 	TriggerCallback WorkerTriggerCallback // Callback to notify scheduler when condition is satisfied
 }
 
@@ -33,6 +39,8 @@ func (w *ConditionWorker) Start() {
 
 	w.Mutex.Lock()
 	w.IsActive = true
+	// Edge-triggered jobs must first see the value outside the range
+	w.Armed = !w.ConditionWorkerData.EdgeTriggered
 	w.Mutex.Unlock()
 
 	// Track worker start
@@ -44,8 +52,23 @@ func (w *ConditionWorker) Start() {
 		"value_source", w.ConditionWorkerData.ValueSourceUrl,
 		"upper_limit", w.ConditionWorkerData.UpperLimit,
 		"lower_limit", w.ConditionWorkerData.LowerLimit,
+		"consecutive_hits", w.ConditionWorkerData.ConsecutiveHits,
+		"cooldown_seconds", w.ConditionWorkerData.CooldownSeconds,
+		"edge_triggered", w.ConditionWorkerData.EdgeTriggered,
+		"rearm_percent", w.ConditionWorkerData.RearmPercent,
+		"window_seconds", w.ConditionWorkerData.WindowSeconds,
 	)
 
+	w.loadSamples()
+
+	// Values come from the shared source multiplexer when available
+	if w.Sources != nil {
+		w.Subscription = w.Sources.Subscribe(w.ConditionWorkerData.ValueSourceType, w.ConditionWorkerData.ValueSourceUrl)
+		defer w.Sources.Unsubscribe(w.Subscription)
+		w.runWithSubscription(startTime)
+		return
+	}
+
 	ticker := time.NewTicker(ConditionPollInterval)
 	defer ticker.Stop()
 
@@ -72,6 +95,28 @@ func (w *ConditionWorker) Start() {
 	}
 }
 
+// runWithSubscription checks the condition on every value the multiplexer delivers
+func (w *ConditionWorker) runWithSubscription(startTime time.Time) {
+	for {
+		select {
+		case <-w.Ctx.Done():
+			w.Logger.Info("Condition worker stopped",
+				"job_id", w.ConditionWorkerData.JobID,
+				"runtime", time.Since(startTime),
+				"last_value", w.LastValue,
+				"condition_met_count", w.ConditionMet,
+			)
+			metrics.JobsCompleted.WithLabelValues("success").Inc()
+			return
+		case value := <-w.Subscription.C:
+			if err := w.processSourceValue(value); err != nil {
+				w.Logger.Error("Error checking condition", "job_id", w.ConditionWorkerData.JobID, "error", err)
+				metrics.JobsCompleted.WithLabelValues("failed").Inc()
+			}
+		}
+	}
+}
+
 // Stop gracefully stops the condition worker
 func (w *ConditionWorker) Stop() {
 	w.Mutex.Lock()
@@ -24,6 +24,8 @@ type EventWorker struct {
 	Mutex           sync.RWMutex
 	LastBlock       uint64
 	LastBlockTimestamp time.Time
+	Logs            *LogMultiplexer  // Shared log subscriptions, nil to poll the chain directly
+	LogSubscription *LogSubscription
 	TriggerCallback WorkerTriggerCallback // Callback to notify scheduler when event is detected
 }
 
@@ -59,6 +61,21 @@ func (w *EventWorker) Start() {
 	contractAddr := common.HexToAddress(w.EventWorkerData.TriggerContractAddress)
 	eventSig := crypto.Keccak256Hash([]byte(w.EventWorkerData.TriggerEvent))
 
+	// Logs come from the shared WebSocket subscription when available
+	if w.Logs != nil {
+		sub, err := w.Logs.Subscribe(w.EventWorkerData.TriggerChainID, contractAddr, eventSig)
+		if err == nil {
+			w.LogSubscription = sub
+			defer w.Logs.Unsubscribe(w.LogSubscription)
+			w.runWithLogSubscription(startTime)
+			return
+		}
+		w.Logger.Warn("Failed to subscribe to shared logs, polling the chain directly",
+			"job_id", w.EventWorkerData.JobID,
+			"error", err,
+		)
+	}
+
 	ticker := time.NewTicker(EventPollInterval)
 	defer ticker.Stop()
 
@@ -76,13 +93,7 @@ func (w *EventWorker) Start() {
 			metrics.JobsCompleted.WithLabelValues("success").Inc()
 			return
 		case <-ticker.C:
-			// Check if job has expired
-			if time.Now().After(w.EventWorkerData.ExpirationTime) {
-				w.Logger.Info("Job has expired, stopping worker",
-					"job_id", w.EventWorkerData.JobID,
-					"expiration_time", w.EventWorkerData.ExpirationTime,
-				)
-				go w.Stop() // Stop in a goroutine to avoid deadlock
+			if w.stopIfExpired() {
 				return
 			}
 
@@ -94,6 +105,59 @@ func (w *EventWorker) Start() {
 	}
 }
 
+// runWithLogSubscription processes every log the multiplexer delivers
+func (w *EventWorker) runWithLogSubscription(startTime time.Time) {
+	ticker := time.NewTicker(EventPollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-w.Ctx.Done():
+			w.Logger.Info("Event worker stopped",
+				"job_id", w.EventWorkerData.JobID,
+				"runtime", time.Since(startTime),
+				"final_block", w.LastBlock,
+			)
+			metrics.JobsCompleted.WithLabelValues("success").Inc()
+			return
+		case log := <-w.LogSubscription.Logs:
+			// A non-recurring job may have been stopped by an earlier log
+			if w.Ctx.Err() != nil {
+				continue
+			}
+			if log.BlockNumber > w.LastBlock {
+				w.LastBlock = log.BlockNumber
+			}
+			if err := w.processEvent(log); err != nil {
+				w.Logger.Error("Failed to process event",
+					"job_id", w.EventWorkerData.JobID,
+					"tx_hash", log.TxHash.Hex(),
+					"block", log.BlockNumber,
+					"error", err,
+				)
+				metrics.TrackCriticalError("event_processing_failed")
+			}
+		case <-ticker.C:
+			if w.stopIfExpired() {
+				return
+			}
+		}
+	}
+}
+
+// stopIfExpired stops the worker once the job has passed its expiration time
+func (w *EventWorker) stopIfExpired() bool {
+	if !time.Now().After(w.EventWorkerData.ExpirationTime) {
+		return false
+	}
+	w.Logger.Info("Job has expired, stopping worker",
+		"job_id", w.EventWorkerData.JobID,
+		"expiration_time", w.EventWorkerData.ExpirationTime,
+	)
+	go w.Stop() // Stop in a goroutine to avoid deadlock
+	return true
+}
+
 // Stop gracefully stops the event worker
 func (w *EventWorker) Stop() {
 	w.Mutex.Lock()
@@ -0,0 +1,543 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	ethtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+
+	"github.com/trigg3rX/triggerx-backend-imua/internal/registrar/events/websocket"
+	"github.com/trigg3rX/triggerx-backend-imua/internal/schedulers/condition/metrics"
+	"github.com/trigg3rX/triggerx-backend-imua/pkg/logging"
+)
+
+const (
+	// Blocks of processed log IDs kept to drop duplicates between stream and backfill
+	logDedupDepth = 128
+	// Buffered logs per event worker before delivery blocks
+	logSubscriptionBuffer = 256
+)
+
+// ChainLogReader is the HTTP side of a chain, used for polling and gap backfill
+type ChainLogReader interface {
+	ethereum.BlockNumberReader
+	FilterLogs(ctx context.Context, query ethereum.FilterQuery) ([]ethtypes.Log, error)
+}
+
+// ChainLogStreamer is the WebSocket side of a chain, used for eth_subscribe("logs")
+type ChainLogStreamer interface {
+	SubscribeFilterLogs(ctx context.Context, query ethereum.FilterQuery, ch chan<- ethtypes.Log) (ethereum.Subscription, error)
+	Close()
+}
+
+// LogMultiplexerConfig configures streaming, fallback polling and reconnects
+type LogMultiplexerConfig struct {
+	PollInterval       time.Duration // HTTP polling interval while a chain is not streaming
+	CheckpointInterval time.Duration // How often the head is recorded while streaming
+	ConfirmationLag    uint64        // Blocks behind the head assumed fully streamed at a checkpoint
+	Reconnect          websocket.ReconnectConfig
+}
+
+// LogSubscription receives the logs of one shared contract/topic group
+type LogSubscription struct {
+	Key  string
+	Logs chan ethtypes.Log
+
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// LogMultiplexer shares one WebSocket connection per chain between event workers,
+// and one eth_subscribe("logs") between workers watching the same contract and topic.
+// While a chain is disconnected its groups are polled over HTTP, and the blocks
+// missed during the outage are backfilled once the stream is back.
+type LogMultiplexer struct {
+	ctx         context.Context
+	logger      logging.Logger
+	config      LogMultiplexerConfig
+	httpClients map[string]ChainLogReader
+	wsURLs      map[string]string
+	dial        func(ctx context.Context, url string) (ChainLogStreamer, error)
+
+	mu     sync.Mutex
+	chains map[string]*chainLogStream
+}
+
+// chainLogStream is the shared connection of one chain
+type chainLogStream struct {
+	mux        *LogMultiplexer
+	chainID    string
+	wsURL      string
+	httpClient ChainLogReader
+	reconnect  *websocket.ReconnectManager
+
+	mu        sync.Mutex
+	wsClient  ChainLogStreamer
+	streaming bool
+	groups    map[string]*logGroup
+}
+
+// logGroup is a single subscription shared by workers with the same filter
+type logGroup struct {
+	key         string
+	query       ethereum.FilterQuery
+	sub         ethereum.Subscription // nil while the chain is polled
+	lastBlock   uint64                // Highest block whose logs were all delivered
+	seen        map[logID]uint64      // Delivered logs -> block number
+	subscribers map[*LogSubscription]struct{}
+}
+
+type logID struct {
+	txHash common.Hash
+	index  uint
+}
+
+// NewLogMultiplexer creates a multiplexer bound to the scheduler context.
+// Chains without a WebSocket URL are served by HTTP polling only.
+func NewLogMultiplexer(ctx context.Context, logger logging.Logger, httpClients map[string]ChainLogReader, wsURLs map[string]string, config LogMultiplexerConfig) *LogMultiplexer {
+	if config.PollInterval <= 0 {
+		config.PollInterval = EventPollInterval
+	}
+	if config.CheckpointInterval <= 0 {
+		config.CheckpointInterval = 30 * time.Second
+	}
+	if config.ConfirmationLag == 0 {
+		config.ConfirmationLag = 12
+	}
+
+	return &LogMultiplexer{
+		ctx:         ctx,
+		logger:      logger,
+		config:      config,
+		httpClients: httpClients,
+		wsURLs:      wsURLs,
+		dial: func(ctx context.Context, url string) (ChainLogStreamer, error) {
+			return ethclient.DialContext(ctx, url)
+		},
+		chains: make(map[string]*chainLogStream),
+	}
+}
+
+// LogGroupKey builds the key under which identical log filters are shared
+func LogGroupKey(chainID string, contractAddr common.Address, eventSig common.Hash) string {
+	return strings.ToLower(fmt.Sprintf("%s|%s|%s", chainID, contractAddr.Hex(), eventSig.Hex()))
+}
+
+// Subscribe registers a worker for a contract/topic, creating the group if needed.
+// Logs are delivered from the current head onwards.
+func (m *LogMultiplexer) Subscribe(chainID string, contractAddr common.Address, eventSig common.Hash) (*LogSubscription, error) {
+	chain, err := m.chain(chainID)
+	if err != nil {
+		return nil, err
+	}
+
+	key := LogGroupKey(chainID, contractAddr, eventSig)
+	sub := &LogSubscription{
+		Key:  key,
+		Logs: make(chan ethtypes.Log, logSubscriptionBuffer),
+		done: make(chan struct{}),
+	}
+
+	head, err := chain.httpClient.BlockNumber(m.ctx)
+	if err != nil {
+		metrics.TrackCriticalError("rpc_block_number_failed")
+		return nil, fmt.Errorf("failed to get current block number: %w", err)
+	}
+
+	chain.mu.Lock()
+	group, exists := chain.groups[key]
+	if !exists {
+		group = &logGroup{
+			key: key,
+			query: ethereum.FilterQuery{
+				Addresses: []common.Address{contractAddr},
+				Topics:    [][]common.Hash{{eventSig}},
+			},
+			lastBlock:   head,
+			seen:        make(map[logID]uint64),
+			subscribers: make(map[*LogSubscription]struct{}),
+		}
+		chain.groups[key] = group
+		if chain.streaming {
+			err = chain.subscribeGroup(group)
+		}
+		m.logger.Info("Created shared log subscription", "group", key, "streaming", chain.streaming)
+	}
+	group.subscribers[sub] = struct{}{}
+	subscribers := len(group.subscribers)
+	chain.mu.Unlock()
+
+	if err != nil {
+		chain.handleDisconnect(err)
+	}
+
+	m.logger.Debug("Subscribed to shared log subscription", "group", key, "subscribers", subscribers)
+	return sub, nil
+}
+
+// Unsubscribe removes a worker, dropping the group once nobody listens.
+// It is safe to call more than once.
+func (m *LogMultiplexer) Unsubscribe(sub *LogSubscription) {
+	if sub == nil {
+		return
+	}
+	sub.closeOnce.Do(func() { close(sub.done) })
+
+	chainID := strings.SplitN(sub.Key, "|", 2)[0]
+	m.mu.Lock()
+	chain, exists := m.chains[chainID]
+	m.mu.Unlock()
+	if !exists {
+		return
+	}
+
+	chain.mu.Lock()
+	defer chain.mu.Unlock()
+
+	group, exists := chain.groups[sub.Key]
+	if !exists {
+		return
+	}
+	delete(group.subscribers, sub)
+
+	if len(group.subscribers) == 0 {
+		if group.sub != nil {
+			group.sub.Unsubscribe()
+			group.sub = nil
+		}
+		delete(chain.groups, sub.Key)
+		m.logger.Info("Removed shared log subscription", "group", sub.Key)
+	}
+}
+
+// Stats returns the connection state and subscription counts per chain
+func (m *LogMultiplexer) Stats() map[string]interface{} {
+	m.mu.Lock()
+	chains := make([]*chainLogStream, 0, len(m.chains))
+	for _, chain := range m.chains {
+		chains = append(chains, chain)
+	}
+	m.mu.Unlock()
+
+	stats := make(map[string]interface{}, len(chains))
+	for _, chain := range chains {
+		chain.mu.Lock()
+		subscribers := 0
+		for _, group := range chain.groups {
+			subscribers += len(group.subscribers)
+		}
+		stats[chain.chainID] = map[string]interface{}{
+			"websocket":   chain.wsURL != "",
+			"streaming":   chain.streaming,
+			"groups":      len(chain.groups),
+			"subscribers": subscribers,
+		}
+		chain.mu.Unlock()
+	}
+	return stats
+}
+
+// chain returns the shared connection of a chain, starting it on first use
+func (m *LogMultiplexer) chain(chainID string) (*chainLogStream, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if chain, exists := m.chains[chainID]; exists {
+		return chain, nil
+	}
+
+	httpClient, exists := m.httpClients[chainID]
+	if !exists {
+		return nil, fmt.Errorf("chain client not found for chain %s", chainID)
+	}
+
+	chain := &chainLogStream{
+		mux:        m,
+		chainID:    chainID,
+		wsURL:      m.wsURLs[chainID],
+		httpClient: httpClient,
+		groups:     make(map[string]*logGroup),
+	}
+	m.chains[chainID] = chain
+
+	go chain.run()
+	if chain.wsURL != "" {
+		chain.reconnect = websocket.NewReconnectManagerWithConfig(chain.wsURL, m.config.Reconnect, m.logger)
+		go chain.reconnect.Start(m.ctx, chain.connect)
+	}
+
+	return chain, nil
+}
+
+// run polls the chain while it is not streaming, and records checkpoints while it is
+func (c *chainLogStream) run() {
+	pollTicker := time.NewTicker(c.mux.config.PollInterval)
+	defer pollTicker.Stop()
+	checkpointTicker := time.NewTicker(c.mux.config.CheckpointInterval)
+	defer checkpointTicker.Stop()
+
+	for {
+		select {
+		case <-c.mux.ctx.Done():
+			c.close()
+			return
+		case <-pollTicker.C:
+			if !c.isStreaming() {
+				c.poll()
+			}
+		case <-checkpointTicker.C:
+			if c.isStreaming() {
+				c.checkpoint()
+				continue
+			}
+			// Keep trying to get back to streaming after the reconnect budget ran out
+			if c.reconnect != nil && !c.reconnect.IsRunning() {
+				go c.reconnect.Start(c.mux.ctx, c.connect)
+			}
+		}
+	}
+}
+
+// connect dials the WebSocket, subscribes every group, then backfills the gap
+func (c *chainLogStream) connect() error {
+	ctx, cancel := context.WithTimeout(c.mux.ctx, 10*time.Second)
+	defer cancel()
+
+	wsClient, err := c.mux.dial(ctx, c.wsURL)
+	if err != nil {
+		metrics.TrackChainConnection(c.chainID, "websocket_failed")
+		return fmt.Errorf("failed to connect to chain %s WebSocket: %w", c.chainID, err)
+	}
+
+	c.mu.Lock()
+	if c.wsClient != nil {
+		c.wsClient.Close()
+	}
+	c.wsClient = wsClient
+	for _, group := range c.groups {
+		if err := c.subscribeGroup(group); err != nil {
+			c.unsubscribeAll()
+			c.wsClient.Close()
+			c.wsClient = nil
+			c.mu.Unlock()
+			return err
+		}
+	}
+	c.streaming = true
+	groups := len(c.groups)
+	c.mu.Unlock()
+
+	// Logs emitted while disconnected arrive through the backfill, new ones
+	// through the subscriptions that are already open
+	c.poll()
+
+	metrics.TrackChainConnection(c.chainID, "websocket_connected")
+	c.mux.logger.Info("Streaming event logs over WebSocket", "chain_id", c.chainID, "groups", groups)
+	return nil
+}
+
+// subscribeGroup opens the eth_subscribe("logs") of a group. Caller holds c.mu.
+func (c *chainLogStream) subscribeGroup(group *logGroup) error {
+	logs := make(chan ethtypes.Log, logSubscriptionBuffer)
+	sub, err := c.wsClient.SubscribeFilterLogs(c.mux.ctx, group.query, logs)
+	if err != nil {
+		return fmt.Errorf("failed to subscribe to logs for %s: %w", group.key, err)
+	}
+	group.sub = sub
+	go c.stream(group, sub, logs)
+	return nil
+}
+
+// stream forwards the logs of one subscription until it ends
+func (c *chainLogStream) stream(group *logGroup, sub ethereum.Subscription, logs chan ethtypes.Log) {
+	for {
+		select {
+		case <-c.mux.ctx.Done():
+			return
+		case log := <-logs:
+			// Earlier blocks are complete once a later block shows up
+			var complete uint64
+			if log.BlockNumber > 0 {
+				complete = log.BlockNumber - 1
+			}
+			c.deliver(group, []ethtypes.Log{log}, complete)
+		case err, ok := <-sub.Err():
+			if !ok || err == nil {
+				return // Unsubscribed
+			}
+			// Errors of a subscription that was already replaced are stale
+			c.mu.Lock()
+			current := group.sub == sub
+			c.mu.Unlock()
+			if current {
+				c.handleDisconnect(err)
+			}
+			return
+		}
+	}
+}
+
+// handleDisconnect switches the chain to HTTP polling and starts reconnecting
+func (c *chainLogStream) handleDisconnect(err error) {
+	c.mu.Lock()
+	if !c.streaming {
+		c.mu.Unlock()
+		return
+	}
+	c.streaming = false
+	c.unsubscribeAll()
+	if c.wsClient != nil {
+		c.wsClient.Close()
+		c.wsClient = nil
+	}
+	c.mu.Unlock()
+
+	metrics.TrackConnectionFailure(c.chainID)
+	c.mux.logger.Warn("Event log stream disconnected, falling back to HTTP polling",
+		"chain_id", c.chainID,
+		"error", err,
+	)
+
+	if c.reconnect != nil {
+		go c.reconnect.Start(c.mux.ctx, c.connect)
+	}
+}
+
+// unsubscribeAll closes every group subscription. Caller holds c.mu.
+func (c *chainLogStream) unsubscribeAll() {
+	for _, group := range c.groups {
+		if group.sub != nil {
+			group.sub.Unsubscribe()
+			group.sub = nil
+		}
+	}
+}
+
+// poll fetches the logs of every group from its last complete block to the head
+func (c *chainLogStream) poll() {
+	head, err := c.httpClient.BlockNumber(c.mux.ctx)
+	if err != nil {
+		metrics.TrackCriticalError("rpc_block_number_failed")
+		c.mux.logger.Error("Failed to get current block number", "chain_id", c.chainID, "error", err)
+		return
+	}
+
+	c.mu.Lock()
+	groups := make([]*logGroup, 0, len(c.groups))
+	fromBlocks := make([]uint64, 0, len(c.groups))
+	for _, group := range c.groups {
+		groups = append(groups, group)
+		fromBlocks = append(fromBlocks, group.lastBlock+1)
+	}
+	c.mu.Unlock()
+
+	for i, group := range groups {
+		if fromBlocks[i] > head {
+			continue
+		}
+
+		query := group.query
+		query.FromBlock = new(big.Int).SetUint64(fromBlocks[i])
+		query.ToBlock = new(big.Int).SetUint64(head)
+
+		logs, err := c.httpClient.FilterLogs(c.mux.ctx, query)
+		if err != nil {
+			metrics.TrackCriticalError("rpc_filter_logs_failed")
+			c.mux.logger.Error("Failed to filter logs",
+				"group", group.key,
+				"from_block", fromBlocks[i],
+				"to_block", head,
+				"error", err,
+			)
+			continue
+		}
+		c.deliver(group, logs, head)
+	}
+}
+
+// checkpoint advances every group to a block the stream has surely covered,
+// so a later backfill does not start from the last block that had a log
+func (c *chainLogStream) checkpoint() {
+	head, err := c.httpClient.BlockNumber(c.mux.ctx)
+	if err != nil || head <= c.mux.config.ConfirmationLag {
+		return
+	}
+	complete := head - c.mux.config.ConfirmationLag
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, group := range c.groups {
+		if complete > group.lastBlock {
+			group.lastBlock = complete
+		}
+	}
+}
+
+// deliver fans new logs out to the group, skipping duplicates and removed logs,
+// and marks every block up to complete as delivered
+func (c *chainLogStream) deliver(group *logGroup, logs []ethtypes.Log, complete uint64) {
+	c.mu.Lock()
+	fresh := make([]ethtypes.Log, 0, len(logs))
+	for _, log := range logs {
+		if log.Removed {
+			c.mux.logger.Debug("Skipping removed log", "group", group.key, "tx_hash", log.TxHash.Hex(), "block", log.BlockNumber)
+			continue
+		}
+		id := logID{txHash: log.TxHash, index: log.Index}
+		if _, seen := group.seen[id]; seen {
+			continue
+		}
+		group.seen[id] = log.BlockNumber
+		fresh = append(fresh, log)
+	}
+	if complete > group.lastBlock {
+		group.lastBlock = complete
+	}
+	for id, block := range group.seen {
+		if block+logDedupDepth < group.lastBlock {
+			delete(group.seen, id)
+		}
+	}
+	subscribers := make([]*LogSubscription, 0, len(group.subscribers))
+	for sub := range group.subscribers {
+		subscribers = append(subscribers, sub)
+	}
+	c.mu.Unlock()
+
+	for _, log := range fresh {
+		for _, sub := range subscribers {
+			select {
+			case sub.Logs <- log:
+			case <-sub.done:
+			case <-c.mux.ctx.Done():
+				return
+			}
+		}
+	}
+}
+
+func (c *chainLogStream) isStreaming() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.streaming
+}
+
+// close drops every subscription and the WebSocket connection
+func (c *chainLogStream) close() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.streaming = false
+	c.unsubscribeAll()
+	if c.wsClient != nil {
+		c.wsClient.Close()
+		c.wsClient = nil
+	}
+}
@@ -0,0 +1,190 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	ethtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/trigg3rX/triggerx-backend-imua/internal/registrar/events/websocket"
+)
+
+type fakeLogReader struct {
+	mu   sync.Mutex
+	head uint64
+	logs []ethtypes.Log
+}
+
+func (r *fakeLogReader) BlockNumber(ctx context.Context) (uint64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.head, nil
+}
+
+func (r *fakeLogReader) FilterLogs(ctx context.Context, query ethereum.FilterQuery) ([]ethtypes.Log, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var logs []ethtypes.Log
+	for _, log := range r.logs {
+		if log.BlockNumber >= query.FromBlock.Uint64() && log.BlockNumber <= query.ToBlock.Uint64() {
+			logs = append(logs, log)
+		}
+	}
+	return logs, nil
+}
+
+func (r *fakeLogReader) emit(log ethtypes.Log) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.logs = append(r.logs, log)
+	if log.BlockNumber > r.head {
+		r.head = log.BlockNumber
+	}
+}
+
+type fakeLogSubscription struct {
+	logs chan<- ethtypes.Log
+	errc chan error
+	once sync.Once
+}
+
+func (s *fakeLogSubscription) Unsubscribe()      { s.once.Do(func() { close(s.errc) }) }
+func (s *fakeLogSubscription) Err() <-chan error { return s.errc }
+
+type fakeLogStreamer struct {
+	mu   sync.Mutex
+	subs []*fakeLogSubscription
+}
+
+func (f *fakeLogStreamer) SubscribeFilterLogs(ctx context.Context, query ethereum.FilterQuery, ch chan<- ethtypes.Log) (ethereum.Subscription, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	sub := &fakeLogSubscription{logs: ch, errc: make(chan error, 1)}
+	f.subs = append(f.subs, sub)
+	return sub, nil
+}
+
+func (f *fakeLogStreamer) Close() {}
+
+func (f *fakeLogStreamer) subscriptions() []*fakeLogSubscription {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]*fakeLogSubscription(nil), f.subs...)
+}
+
+func newTestLogMultiplexer(ctx context.Context, reader *fakeLogReader, streamer *fakeLogStreamer) *LogMultiplexer {
+	mux := NewLogMultiplexer(ctx, &MockLogger{}, map[string]ChainLogReader{"84532": reader}, map[string]string{"84532": "ws://node"}, LogMultiplexerConfig{
+		PollInterval:       10 * time.Millisecond,
+		CheckpointInterval: time.Hour,
+		Reconnect:          websocket.ReconnectConfig{BaseDelay: 10 * time.Millisecond, MaxDelay: 10 * time.Millisecond},
+	})
+	mux.dial = func(ctx context.Context, url string) (ChainLogStreamer, error) {
+		return streamer, nil
+	}
+	return mux
+}
+
+func isStreaming(mux *LogMultiplexer) bool {
+	stats, ok := mux.Stats()["84532"].(map[string]interface{})
+	return ok && stats["streaming"] == true
+}
+
+func receiveLog(t *testing.T, sub *LogSubscription) ethtypes.Log {
+	t.Helper()
+	select {
+	case log := <-sub.Logs:
+		return log
+	case <-time.After(time.Second):
+		t.Fatal("no log delivered")
+		return ethtypes.Log{}
+	}
+}
+
+func assertNoLog(t *testing.T, sub *LogSubscription) {
+	t.Helper()
+	select {
+	case log := <-sub.Logs:
+		t.Fatalf("unexpected log in block %d", log.BlockNumber)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestLogMultiplexerSharesSubscriptions(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	reader := &fakeLogReader{head: 100}
+	streamer := &fakeLogStreamer{}
+	mux := newTestLogMultiplexer(ctx, reader, streamer)
+
+	contract := common.HexToAddress("0x1000000000000000000000000000000000000001")
+	topic := common.HexToHash("0xaa")
+
+	first, err := mux.Subscribe("84532", contract, topic)
+	require.NoError(t, err)
+	second, err := mux.Subscribe("84532", contract, topic)
+	require.NoError(t, err)
+	assert.Equal(t, first.Key, second.Key)
+
+	require.Eventually(t, func() bool { return isStreaming(mux) }, time.Second, 5*time.Millisecond)
+	require.Len(t, streamer.subscriptions(), 1)
+
+	log := ethtypes.Log{Address: contract, Topics: []common.Hash{topic}, BlockNumber: 101, TxHash: common.HexToHash("0x01")}
+	streamer.subscriptions()[0].logs <- log
+	streamer.subscriptions()[0].logs <- log // Same log twice is delivered once
+
+	assert.Equal(t, log.TxHash, receiveLog(t, first).TxHash)
+	assert.Equal(t, log.TxHash, receiveLog(t, second).TxHash)
+	assertNoLog(t, first)
+
+	mux.Unsubscribe(first)
+	mux.Unsubscribe(first)
+	assert.Equal(t, 1, mux.Stats()["84532"].(map[string]interface{})["groups"])
+	mux.Unsubscribe(second)
+	assert.Equal(t, 0, mux.Stats()["84532"].(map[string]interface{})["groups"])
+
+	_, err = mux.Subscribe("1", contract, topic)
+	assert.Error(t, err)
+}
+
+func TestLogMultiplexerBackfillsAfterDisconnect(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	reader := &fakeLogReader{head: 100}
+	streamer := &fakeLogStreamer{}
+	mux := newTestLogMultiplexer(ctx, reader, streamer)
+
+	contract := common.HexToAddress("0x1000000000000000000000000000000000000001")
+	topic := common.HexToHash("0xaa")
+
+	sub, err := mux.Subscribe("84532", contract, topic)
+	require.NoError(t, err)
+	require.Eventually(t, func() bool { return isStreaming(mux) }, time.Second, 5*time.Millisecond)
+
+	// Drop the stream; a log emitted meanwhile must still arrive
+	streamer.subscriptions()[0].errc <- errors.New("connection reset")
+	missed := ethtypes.Log{Address: contract, Topics: []common.Hash{topic}, BlockNumber: 105, TxHash: common.HexToHash("0x02")}
+	reader.emit(missed)
+
+	got := receiveLog(t, sub)
+	assert.Equal(t, missed.TxHash, got.TxHash)
+	assert.Equal(t, uint64(105), got.BlockNumber)
+
+	// Once reconnected the log replayed by the node is not delivered again
+	require.Eventually(t, func() bool {
+		return isStreaming(mux) && len(streamer.subscriptions()) == 2
+	}, time.Second, 5*time.Millisecond)
+	streamer.subscriptions()[1].logs <- missed
+	assertNoLog(t, sub)
+
+	mux.Unsubscribe(sub)
+}
@@ -11,6 +11,8 @@ import (
 	"time"
 
 	"github.com/trigg3rX/triggerx-backend-imua/internal/schedulers/condition/metrics"
+	"github.com/trigg3rX/triggerx-backend-imua/pkg/timeseries"
+	"github.com/trigg3rX/triggerx-backend-imua/pkg/types"
 )
 
 // checkCondition fetches the current value and checks if condition is satisfied
@@ -27,6 +29,27 @@ func (w *ConditionWorker) checkCondition() error {
 		return fmt.Errorf("failed to fetch value: %w", err)
 	}
 
+	return w.processValue(currentValue, startTime)
+}
+
+// processSourceValue checks the condition against a value pushed by the source multiplexer
+func (w *ConditionWorker) processSourceValue(sourceValue SourceValue) error {
+	startTime := time.Now()
+
+	metrics.TrackConditionBySource(w.ConditionWorkerData.ValueSourceType)
+
+	if sourceValue.Err != nil {
+		metrics.TrackValueParsingError(w.ConditionWorkerData.ValueSourceType)
+		return fmt.Errorf("failed to fetch value: %w", sourceValue.Err)
+	}
+
+	return w.processValue(sourceValue.Value, startTime)
+}
+
+// processValue evaluates the condition for a fetched value and notifies the scheduler when it triggers
+func (w *ConditionWorker) processValue(currentValue float64, startTime time.Time) error {
+	var err error
+
 	w.LastValue = currentValue
 	w.LastCheckTimestamp = time.Now()
 
@@ -41,7 +64,12 @@ func (w *ConditionWorker) checkCondition() error {
 	}
 
 	// Check if condition is satisfied
-	satisfied, err := w.evaluateCondition(currentValue)
+	var satisfied bool
+	if timeseries.IsWindowedCondition(w.ConditionWorkerData.ConditionType) {
+		satisfied, err = w.evaluateWindowedCondition(currentValue, w.LastCheckTimestamp)
+	} else {
+		satisfied, err = w.evaluateCondition(currentValue)
+	}
 	if err != nil {
 		conditionContext["status"] = "evaluation_error"
 		conditionContext["error"] = err.Error()
@@ -64,6 +92,18 @@ func (w *ConditionWorker) checkCondition() error {
 		conditionContext["status"] = "satisfied"
 		conditionContext["consecutive_checks"] = w.ConditionMet
 
+		// Apply consecutive hits, cooldown and re-arm policy before notifying
+		if fire, reason := w.shouldTrigger(time.Now()); !fire {
+			conditionContext["action_status"] = "suppressed"
+			w.Logger.Debug("Condition satisfied, trigger suppressed",
+				"job_id", w.ConditionWorkerData.JobID,
+				"current_value", currentValue,
+				"consecutive_checks", w.ConditionMet,
+				"reason", reason,
+			)
+			return nil
+		}
+
 		w.Logger.Info("Condition satisfied",
 			"job_id", w.ConditionWorkerData.JobID,
 			"current_value", currentValue,
@@ -80,6 +120,9 @@ func (w *ConditionWorker) checkCondition() error {
 				TriggerValue:    currentValue,
 				TriggeredAt:     time.Now(),
 			}
+			if timeseries.IsWindowedCondition(w.ConditionWorkerData.ConditionType) {
+				notification.Samples = append([]types.ConditionSample(nil), w.Samples...)
+			}
 
 			if err := w.TriggerCallback(notification); err != nil {
 				w.Logger.Error("Failed to notify scheduler about trigger",
@@ -88,6 +131,7 @@ func (w *ConditionWorker) checkCondition() error {
 				)
 				metrics.TrackCriticalError("trigger_notification_failed")
 			} else {
+				w.markTriggered(time.Now())
 				w.Logger.Info("Successfully notified scheduler about trigger",
 					"job_id", w.ConditionWorkerData.JobID,
 					"trigger_value", currentValue,
@@ -111,6 +155,7 @@ func (w *ConditionWorker) checkCondition() error {
 		conditionContext["action_status"] = "triggered"
 	} else {
 		w.ConditionMet = 0
+		w.rearmIfOutside(currentValue)
 		conditionContext["status"] = "not_satisfied"
 
 		w.Logger.Debug("Condition not satisfied",
@@ -122,7 +167,8 @@ func (w *ConditionWorker) checkCondition() error {
 	return nil
 }
 
-// fetchValueWithCache retrieves the current value with caching support
+// fetchValueWithCache retrieves the current value directly from the source.
+// Workers sharing a source through the SourceMultiplexer do not call it.
 func (w *ConditionWorker) fetchValueWithCache() (float64, error) {
 	// Fetch fresh value
 	currentValue, err := w.fetchValue()
@@ -195,6 +241,17 @@ func (w *ConditionWorker) fetchFromAPI() (float64, error) {
 		return 0, fmt.Errorf("failed to read response body: %w", err)
 	}
 
+	value, err := parseValueResponse(body)
+	if err != nil {
+		metrics.TrackInvalidValue(w.ConditionWorkerData.ValueSourceUrl)
+		metrics.TrackValueParsingError(w.ConditionWorkerData.ValueSourceType)
+		return 0, err
+	}
+	return value, nil
+}
+
+// parseValueResponse extracts a numeric value from a source response body
+func parseValueResponse(body []byte) (float64, error) {
 	// Try to parse response as ValueResponse struct
 	var valueResp ValueResponse
 	if err := json.Unmarshal(body, &valueResp); err == nil {
@@ -233,8 +290,6 @@ func (w *ConditionWorker) fetchFromAPI() (float64, error) {
 		}
 	}
 
-	metrics.TrackInvalidValue(w.ConditionWorkerData.ValueSourceUrl)
-	metrics.TrackValueParsingError(w.ConditionWorkerData.ValueSourceType)
 	return 0, fmt.Errorf("could not extract numeric value from response: %s", string(body))
 }
 
@@ -0,0 +1,74 @@
+package worker
+
+import (
+	"time"
+
+	"github.com/trigg3rX/triggerx-backend-imua/pkg/timeseries"
+	"github.com/trigg3rX/triggerx-backend-imua/pkg/types"
+)
+
+// windowDuration returns the configured window of a windowed condition
+func (w *ConditionWorker) windowDuration() time.Duration {
+	return time.Duration(w.ConditionWorkerData.WindowSeconds) * time.Second
+}
+
+// sampleRetention keeps two windows of samples, so crossing types can compare
+// the indicator at the latest sample with the one before it
+func (w *ConditionWorker) sampleRetention() time.Duration {
+	return 2 * w.windowDuration()
+}
+
+// loadSamples restores the sample buffer from the store on worker start
+func (w *ConditionWorker) loadSamples() {
+	if w.SampleStore == nil || !timeseries.IsWindowedCondition(w.ConditionWorkerData.ConditionType) {
+		return
+	}
+
+	samples, err := w.SampleStore.Load(w.Ctx, w.ConditionWorkerData.JobID, time.Now().Add(-w.sampleRetention()))
+	if err != nil {
+		w.Logger.Warn("Failed to load condition samples, starting with empty window",
+			"job_id", w.ConditionWorkerData.JobID,
+			"error", err,
+		)
+		return
+	}
+	w.Samples = samples
+	w.Logger.Info("Restored condition samples",
+		"job_id", w.ConditionWorkerData.JobID,
+		"samples", len(samples),
+	)
+}
+
+// recordSample appends a value to the in-memory buffer and the store
+func (w *ConditionWorker) recordSample(value float64, at time.Time) {
+	sample := types.ConditionSample{Timestamp: at, Value: value}
+	w.Samples = append(w.Samples, sample)
+
+	cutoff := at.Add(-w.sampleRetention())
+	trim := 0
+	for trim < len(w.Samples) && w.Samples[trim].Timestamp.Before(cutoff) {
+		trim++
+	}
+	w.Samples = w.Samples[trim:]
+
+	if w.SampleStore != nil {
+		if err := w.SampleStore.Append(w.Ctx, w.ConditionWorkerData.JobID, sample, w.sampleRetention()); err != nil {
+			w.Logger.Warn("Failed to persist condition sample",
+				"job_id", w.ConditionWorkerData.JobID,
+				"error", err,
+			)
+		}
+	}
+}
+
+// evaluateWindowedCondition evaluates the condition over the recorded samples
+func (w *ConditionWorker) evaluateWindowedCondition(currentValue float64, at time.Time) (bool, error) {
+	w.recordSample(currentValue, at)
+	return timeseries.Evaluate(
+		w.ConditionWorkerData.ConditionType,
+		w.Samples,
+		w.windowDuration(),
+		w.ConditionWorkerData.LowerLimit,
+		w.ConditionWorkerData.UpperLimit,
+	)
+}
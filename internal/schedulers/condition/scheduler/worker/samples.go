@@ -0,0 +1,82 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	goredis "github.com/redis/go-redis/v9"
+	"github.com/trigg3rX/triggerx-backend-imua/pkg/types"
+)
+
+const sampleKeyPrefix = "condition:samples:"
+
+// SampleStore persists value samples of windowed condition workers, so the
+// buffer survives scheduler restarts
+type SampleStore interface {
+	Append(ctx context.Context, jobID int64, sample types.ConditionSample, retention time.Duration) error
+	Load(ctx context.Context, jobID int64, since time.Time) ([]types.ConditionSample, error)
+	Delete(ctx context.Context, jobID int64) error
+}
+
+// RedisSampleStore keeps samples in a sorted set per job, scored by unix milliseconds
+type RedisSampleStore struct {
+	client *goredis.Client
+}
+
+// NewRedisSampleStore creates a sample store backed by the given Redis client
+func NewRedisSampleStore(client *goredis.Client) *RedisSampleStore {
+	return &RedisSampleStore{client: client}
+}
+
+func sampleKey(jobID int64) string {
+	return sampleKeyPrefix + strconv.FormatInt(jobID, 10)
+}
+
+// Append adds a sample and drops the ones older than retention
+func (s *RedisSampleStore) Append(ctx context.Context, jobID int64, sample types.ConditionSample, retention time.Duration) error {
+	member, err := json.Marshal(sample)
+	if err != nil {
+		return fmt.Errorf("failed to marshal sample: %w", err)
+	}
+
+	key := sampleKey(jobID)
+	cutoff := sample.Timestamp.Add(-retention).UnixMilli()
+
+	pipe := s.client.TxPipeline()
+	pipe.ZAdd(ctx, key, goredis.Z{Score: float64(sample.Timestamp.UnixMilli()), Member: member})
+	pipe.ZRemRangeByScore(ctx, key, "-inf", "("+strconv.FormatInt(cutoff, 10))
+	pipe.Expire(ctx, key, retention)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to append sample for job %d: %w", jobID, err)
+	}
+	return nil
+}
+
+// Load returns the samples recorded since the given time, oldest first
+func (s *RedisSampleStore) Load(ctx context.Context, jobID int64, since time.Time) ([]types.ConditionSample, error) {
+	members, err := s.client.ZRangeByScore(ctx, sampleKey(jobID), &goredis.ZRangeBy{
+		Min: strconv.FormatInt(since.UnixMilli(), 10),
+		Max: "+inf",
+	}).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load samples for job %d: %w", jobID, err)
+	}
+
+	samples := make([]types.ConditionSample, 0, len(members))
+	for _, member := range members {
+		var sample types.ConditionSample
+		if err := json.Unmarshal([]byte(member), &sample); err != nil {
+			continue
+		}
+		samples = append(samples, sample)
+	}
+	return samples, nil
+}
+
+// Delete removes all samples of a job
+func (s *RedisSampleStore) Delete(ctx context.Context, jobID int64) error {
+	return s.client.Del(ctx, sampleKey(jobID)).Err()
+}
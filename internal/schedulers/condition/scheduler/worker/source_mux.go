@@ -0,0 +1,391 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/trigg3rX/triggerx-backend-imua/internal/schedulers/condition/metrics"
+	"github.com/trigg3rX/triggerx-backend-imua/pkg/logging"
+)
+
+// SourceValue is a value fetched from a shared source, delivered to every subscriber
+type SourceValue struct {
+	Value     float64
+	FetchedAt time.Time
+	Err       error
+}
+
+// SourceSubscription receives the values of one shared source
+type SourceSubscription struct {
+	Key string
+	C   chan SourceValue
+}
+
+// SourceMultiplexerConfig configures polling and per-host rate limits
+type SourceMultiplexerConfig struct {
+	PollInterval   time.Duration
+	RequestTimeout time.Duration
+	HostRate       float64 // Requests per second allowed per host
+	HostBurst      int
+}
+
+// SourceMultiplexer polls each distinct value source once per interval and fans
+// the value out to all condition workers subscribed to it
+type SourceMultiplexer struct {
+	ctx        context.Context
+	logger     logging.Logger
+	config     SourceMultiplexerConfig
+	httpClient *http.Client
+
+	mu      sync.Mutex
+	sources map[string]*sharedSource
+	hosts   map[string]*hostLimiter
+}
+
+// sharedSource is a single poller for one normalized source key
+type sharedSource struct {
+	key         string
+	sourceType  string
+	url         string
+	host        string
+	cancel      context.CancelFunc
+	subscribers map[*SourceSubscription]struct{}
+}
+
+// hostLimiter is a token bucket per host, also honouring Retry-After
+type hostLimiter struct {
+	mu           sync.Mutex
+	tokens       float64
+	lastRefill   time.Time
+	blockedUntil time.Time
+}
+
+// NewSourceMultiplexer creates a multiplexer bound to the scheduler context
+func NewSourceMultiplexer(ctx context.Context, logger logging.Logger, config SourceMultiplexerConfig) *SourceMultiplexer {
+	if config.PollInterval <= 0 {
+		config.PollInterval = ConditionPollInterval
+	}
+	if config.RequestTimeout <= 0 {
+		config.RequestTimeout = 3 * time.Second
+	}
+	if config.HostRate <= 0 {
+		config.HostRate = 5
+	}
+	if config.HostBurst <= 0 {
+		config.HostBurst = int(config.HostRate)
+		if config.HostBurst < 1 {
+			config.HostBurst = 1
+		}
+	}
+
+	return &SourceMultiplexer{
+		ctx:        ctx,
+		logger:     logger,
+		config:     config,
+		httpClient: &http.Client{Timeout: config.RequestTimeout},
+		sources:    make(map[string]*sharedSource),
+		hosts:      make(map[string]*hostLimiter),
+	}
+}
+
+// NormalizeSourceKey builds the key under which identical sources are shared.
+// Scheme and host are lower-cased, default ports and fragments dropped, and
+// query parameters sorted.
+func NormalizeSourceKey(sourceType, rawURL string) string {
+	sourceType = strings.ToLower(strings.TrimSpace(sourceType))
+	rawURL = strings.TrimSpace(rawURL)
+	if sourceType == SourceTypeStatic {
+		return sourceType + "|" + rawURL
+	}
+
+	parsed, err := url.Parse(rawURL)
+	if err != nil || parsed.Host == "" {
+		return sourceType + "|" + rawURL
+	}
+
+	parsed.Scheme = strings.ToLower(parsed.Scheme)
+	host := strings.ToLower(parsed.Hostname())
+	port := parsed.Port()
+	if (parsed.Scheme == "https" && port == "443") || (parsed.Scheme == "http" && port == "80") {
+		port = ""
+	}
+	if port != "" {
+		host = host + ":" + port
+	}
+	parsed.Host = host
+	parsed.Fragment = ""
+	parsed.RawQuery = parsed.Query().Encode()
+	if parsed.Path != "/" {
+		parsed.Path = strings.TrimSuffix(parsed.Path, "/")
+	}
+
+	return sourceType + "|" + parsed.String()
+}
+
+// Subscribe registers a worker for a source, starting its poller if needed
+func (m *SourceMultiplexer) Subscribe(sourceType, sourceURL string) *SourceSubscription {
+	key := NormalizeSourceKey(sourceType, sourceURL)
+	sub := &SourceSubscription{Key: key, C: make(chan SourceValue, 1)}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	source, exists := m.sources[key]
+	if !exists {
+		ctx, cancel := context.WithCancel(m.ctx)
+		source = &sharedSource{
+			key:         key,
+			sourceType:  strings.ToLower(sourceType),
+			url:         sourceURL,
+			host:        sourceHost(sourceURL),
+			cancel:      cancel,
+			subscribers: make(map[*SourceSubscription]struct{}),
+		}
+		m.sources[key] = source
+		go m.poll(ctx, source)
+
+		m.logger.Info("Started shared value source", "source", key)
+	}
+	source.subscribers[sub] = struct{}{}
+
+	m.logger.Debug("Subscribed to shared value source", "source", key, "subscribers", len(source.subscribers))
+	return sub
+}
+
+// Unsubscribe removes a worker, stopping the poller once nobody listens.
+// It is safe to call more than once.
+func (m *SourceMultiplexer) Unsubscribe(sub *SourceSubscription) {
+	if sub == nil {
+		return
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	source, exists := m.sources[sub.Key]
+	if !exists {
+		return
+	}
+	if _, subscribed := source.subscribers[sub]; !subscribed {
+		return
+	}
+	delete(source.subscribers, sub)
+
+	if len(source.subscribers) == 0 {
+		source.cancel()
+		delete(m.sources, sub.Key)
+		m.logger.Info("Stopped shared value source", "source", sub.Key)
+	}
+}
+
+// Stats returns the number of subscribers per shared source
+func (m *SourceMultiplexer) Stats() map[string]int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stats := make(map[string]int, len(m.sources))
+	for key, source := range m.sources {
+		stats[key] = len(source.subscribers)
+	}
+	return stats
+}
+
+// poll fetches the source once per interval and fans the value out
+func (m *SourceMultiplexer) poll(ctx context.Context, source *sharedSource) {
+	ticker := time.NewTicker(m.config.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if source.sourceType != SourceTypeStatic && !m.limiter(source.host).allow(time.Now(), m.config.HostRate, m.config.HostBurst) {
+				m.logger.Debug("Skipping source poll, host rate limited", "source", source.key, "host", source.host)
+				continue
+			}
+
+			value, err := m.fetch(ctx, source)
+			m.broadcast(source, SourceValue{Value: value, FetchedAt: time.Now(), Err: err})
+		}
+	}
+}
+
+// broadcast delivers a value to every subscriber, replacing any value the
+// subscriber has not consumed yet
+func (m *SourceMultiplexer) broadcast(source *sharedSource, value SourceValue) {
+	m.mu.Lock()
+	subscribers := make([]*SourceSubscription, 0, len(source.subscribers))
+	for sub := range source.subscribers {
+		subscribers = append(subscribers, sub)
+	}
+	m.mu.Unlock()
+
+	for _, sub := range subscribers {
+		select {
+		case sub.C <- value:
+		default:
+			select {
+			case <-sub.C:
+			default:
+			}
+			select {
+			case sub.C <- value:
+			default:
+			}
+		}
+	}
+}
+
+// fetch retrieves the current value of a source
+func (m *SourceMultiplexer) fetch(ctx context.Context, source *sharedSource) (float64, error) {
+	switch source.sourceType {
+	case SourceTypeStatic:
+		value, err := strconv.ParseFloat(source.url, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid static value: %s", source.url)
+		}
+		return value, nil
+	case SourceTypeAPI, SourceTypeOracle:
+		return m.fetchHTTP(ctx, source)
+	default:
+		return 0, fmt.Errorf("unsupported value source type: %s", source.sourceType)
+	}
+}
+
+// fetchHTTP performs a single request, without retries, so Retry-After is respected
+func (m *SourceMultiplexer) fetchHTTP(ctx context.Context, source *sharedSource) (float64, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", source.url, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := m.httpClient.Do(req)
+	if err != nil {
+		metrics.TrackHTTPRequest("GET", source.url, "error")
+		metrics.TrackHTTPClientConnectionError()
+		if isTimeoutError(err) {
+			metrics.TrackTimeout("http_api_request")
+		}
+		return 0, fmt.Errorf("HTTP request failed: %w", err)
+	}
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			m.logger.Errorf("Error closing response body: %v", err)
+		}
+	}()
+
+	statusCode := strconv.Itoa(resp.StatusCode)
+	metrics.TrackHTTPRequest("GET", source.url, statusCode)
+	metrics.TrackAPIResponse(source.url, statusCode)
+
+	if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode == http.StatusServiceUnavailable {
+		if wait, ok := parseRetryAfter(resp.Header.Get("Retry-After"), time.Now()); ok {
+			m.limiter(source.host).block(time.Now().Add(wait))
+			m.logger.Warn("Value source asked to back off",
+				"source", source.key,
+				"host", source.host,
+				"retry_after", wait,
+			)
+		}
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("HTTP request failed with status: %s", resp.Status)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		metrics.TrackHTTPRequest("GET", source.url, "read_error")
+		return 0, fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	value, err := parseValueResponse(body)
+	if err != nil {
+		metrics.TrackInvalidValue(source.url)
+		metrics.TrackValueParsingError(source.sourceType)
+		return 0, err
+	}
+	return value, nil
+}
+
+// limiter returns the rate limiter for a host
+func (m *SourceMultiplexer) limiter(host string) *hostLimiter {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	limiter, exists := m.hosts[host]
+	if !exists {
+		limiter = &hostLimiter{tokens: float64(m.config.HostBurst), lastRefill: time.Now()}
+		m.hosts[host] = limiter
+	}
+	return limiter
+}
+
+// allow takes a token if the host is not blocked and has capacity
+func (l *hostLimiter) allow(now time.Time, rate float64, burst int) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if now.Before(l.blockedUntil) {
+		return false
+	}
+
+	l.tokens += now.Sub(l.lastRefill).Seconds() * rate
+	if l.tokens > float64(burst) {
+		l.tokens = float64(burst)
+	}
+	l.lastRefill = now
+
+	if l.tokens < 1 {
+		return false
+	}
+	l.tokens--
+	return true
+}
+
+// block stops requests to the host until the given time
+func (l *hostLimiter) block(until time.Time) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if until.After(l.blockedUntil) {
+		l.blockedUntil = until
+	}
+}
+
+// parseRetryAfter supports both delay-seconds and HTTP-date values
+func parseRetryAfter(header string, now time.Time) (time.Duration, bool) {
+	header = strings.TrimSpace(header)
+	if header == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(header); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+	if at, err := http.ParseTime(header); err == nil {
+		if at.Before(now) {
+			return 0, true
+		}
+		return at.Sub(now), true
+	}
+	return 0, false
+}
+
+func sourceHost(rawURL string) string {
+	parsed, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil {
+		return rawURL
+	}
+	return strings.ToLower(parsed.Host)
+}
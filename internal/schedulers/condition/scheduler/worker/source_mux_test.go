@@ -0,0 +1,120 @@
+package worker
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalizeSourceKey(t *testing.T) {
+	assert.Equal(t,
+		NormalizeSourceKey("api", "https://API.example.com:443/price/?b=2&a=1#frag"),
+		NormalizeSourceKey("API", "https://api.example.com/price?a=1&b=2"),
+	)
+	assert.NotEqual(t,
+		NormalizeSourceKey("api", "https://api.example.com/price?a=1"),
+		NormalizeSourceKey("api", "https://api.example.com/price?a=2"),
+	)
+	assert.Equal(t, "static|42", NormalizeSourceKey("static", " 42 "))
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2025, time.June, 2, 17, 0, 0, 0, time.UTC)
+
+	wait, ok := parseRetryAfter("30", now)
+	assert.True(t, ok)
+	assert.Equal(t, 30*time.Second, wait)
+
+	wait, ok = parseRetryAfter(now.Add(2*time.Minute).Format(http.TimeFormat), now)
+	assert.True(t, ok)
+	assert.Equal(t, 2*time.Minute, wait)
+
+	_, ok = parseRetryAfter("soon", now)
+	assert.False(t, ok)
+}
+
+func TestSourceMultiplexerSharesPolling(t *testing.T) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		_, _ = w.Write([]byte(`{"price": 42.5}`))
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	mux := NewSourceMultiplexer(ctx, &MockLogger{}, SourceMultiplexerConfig{
+		PollInterval: 20 * time.Millisecond,
+		HostRate:     1000,
+	})
+
+	first := mux.Subscribe(SourceTypeAPI, server.URL+"/price?a=1&b=2")
+	second := mux.Subscribe(SourceTypeAPI, server.URL+"/price?b=2&a=1")
+	assert.Equal(t, map[string]int{first.Key: 2}, mux.Stats())
+
+	for _, sub := range []*SourceSubscription{first, second} {
+		select {
+		case value := <-sub.C:
+			assert.NoError(t, value.Err)
+			assert.Equal(t, 42.5, value.Value)
+		case <-time.After(time.Second):
+			t.Fatal("no value delivered")
+		}
+	}
+	assert.LessOrEqual(t, atomic.LoadInt32(&requests), int32(2))
+
+	mux.Unsubscribe(first)
+	mux.Unsubscribe(first)
+	assert.Equal(t, map[string]int{second.Key: 1}, mux.Stats())
+	mux.Unsubscribe(second)
+	assert.Empty(t, mux.Stats())
+}
+
+func TestSourceMultiplexerRespectsRetryAfter(t *testing.T) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.Header().Set("Retry-After", "60")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	mux := NewSourceMultiplexer(ctx, &MockLogger{}, SourceMultiplexerConfig{
+		PollInterval: 10 * time.Millisecond,
+		HostRate:     1000,
+	})
+	sub := mux.Subscribe(SourceTypeAPI, server.URL)
+
+	select {
+	case value := <-sub.C:
+		assert.Error(t, value.Err)
+	case <-time.After(time.Second):
+		t.Fatal("no value delivered")
+	}
+
+	time.Sleep(100 * time.Millisecond)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&requests))
+}
+
+func TestHostLimiter(t *testing.T) {
+	now := time.Date(2025, time.June, 2, 17, 0, 0, 0, time.UTC)
+	limiter := &hostLimiter{tokens: 2, lastRefill: now}
+
+	assert.True(t, limiter.allow(now, 1, 2))
+	assert.True(t, limiter.allow(now, 1, 2))
+	assert.False(t, limiter.allow(now, 1, 2))
+	assert.True(t, limiter.allow(now.Add(time.Second), 1, 2))
+
+	limiter.block(now.Add(time.Minute))
+	assert.False(t, limiter.allow(now.Add(10*time.Second), 1, 2))
+	assert.True(t, limiter.allow(now.Add(2*time.Minute), 1, 2))
+}
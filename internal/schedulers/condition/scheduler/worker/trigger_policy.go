@@ -0,0 +1,92 @@
+package worker
+
+import (
+	"fmt"
+	"time"
+)
+
+// shouldTrigger applies the job's trigger policy to a satisfied check.
+// It returns false with a reason when the trigger must be suppressed.
+func (w *ConditionWorker) shouldTrigger(now time.Time) (bool, string) {
+	data := w.ConditionWorkerData
+
+	if !w.Armed {
+		return false, "waiting_for_rearm"
+	}
+
+	requiredHits := int64(data.ConsecutiveHits)
+	if requiredHits < 1 {
+		requiredHits = 1
+	}
+	if w.ConditionMet < requiredHits {
+		return false, fmt.Sprintf("consecutive_hits_%d_of_%d", w.ConditionMet, requiredHits)
+	}
+
+	if data.CooldownSeconds > 0 && !w.LastTriggeredAt.IsZero() {
+		cooldown := time.Duration(data.CooldownSeconds) * time.Second
+		if now.Sub(w.LastTriggeredAt) < cooldown {
+			return false, "cooldown"
+		}
+	}
+
+	return true, ""
+}
+
+// markTriggered records a trigger and disarms the worker when the job is
+// edge-triggered or uses re-arm hysteresis
+func (w *ConditionWorker) markTriggered(now time.Time) {
+	w.LastTriggeredAt = now
+	w.ConditionMet = 0
+	if w.ConditionWorkerData.EdgeTriggered || w.ConditionWorkerData.RearmPercent > 0 {
+		w.Armed = false
+	}
+}
+
+// rearmIfOutside re-arms a disarmed worker once the value has left the range,
+// and moved at least RearmPercent beyond the limits when hysteresis is set
+func (w *ConditionWorker) rearmIfOutside(currentValue float64) {
+	if w.Armed {
+		return
+	}
+	if w.isOutsideRearmBand(currentValue) {
+		w.Armed = true
+		w.Logger.Debug("Condition worker re-armed",
+			"job_id", w.ConditionWorkerData.JobID,
+			"current_value", currentValue,
+			"rearm_percent", w.ConditionWorkerData.RearmPercent,
+		)
+	}
+}
+
+// isOutsideRearmBand checks the value against the limits widened by RearmPercent.
+// It is only called for values that already fail the condition.
+func (w *ConditionWorker) isOutsideRearmBand(currentValue float64) bool {
+	data := w.ConditionWorkerData
+	if data.RearmPercent <= 0 {
+		return true
+	}
+	margin := data.RearmPercent / 100
+
+	lower := data.LowerLimit - abs(data.LowerLimit)*margin
+	upper := data.UpperLimit + abs(data.UpperLimit)*margin
+
+	switch data.ConditionType {
+	case ConditionGreaterThan, ConditionGreaterEqual:
+		return currentValue < lower
+	case ConditionLessThan, ConditionLessEqual:
+		return currentValue > upper
+	case ConditionBetween:
+		return currentValue < lower || currentValue > upper
+	case ConditionEquals:
+		return abs(currentValue-data.LowerLimit) > abs(data.LowerLimit)*margin
+	default:
+		return true
+	}
+}
+
+func abs(v float64) float64 {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
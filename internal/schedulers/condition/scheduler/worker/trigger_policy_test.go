@@ -0,0 +1,115 @@
+package worker
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/trigg3rX/triggerx-backend-imua/pkg/logging"
+	"github.com/trigg3rX/triggerx-backend-imua/pkg/types"
+)
+
+type MockLogger struct{}
+
+func (l *MockLogger) Debug(msg string, tags ...any)               {}
+func (l *MockLogger) Info(msg string, tags ...any)                {}
+func (l *MockLogger) Warn(msg string, tags ...any)                {}
+func (l *MockLogger) Error(msg string, tags ...any)               {}
+func (l *MockLogger) Fatal(msg string, tags ...any)               {}
+func (l *MockLogger) Debugf(template string, args ...interface{}) {}
+func (l *MockLogger) Infof(template string, args ...interface{})  {}
+func (l *MockLogger) Warnf(template string, args ...interface{})  {}
+func (l *MockLogger) Errorf(template string, args ...interface{}) {}
+func (l *MockLogger) Fatalf(template string, args ...interface{}) {}
+func (l *MockLogger) With(tags ...any) logging.Logger             { return l }
+
+func newTestConditionWorker(data *types.ConditionWorkerData) *ConditionWorker {
+	return &ConditionWorker{
+		ConditionWorkerData: data,
+		Logger:              &MockLogger{},
+		Armed:               !data.EdgeTriggered,
+	}
+}
+
+// simulate feeds values through the same policy steps as checkCondition and
+// returns the indexes of the values that triggered
+func simulate(w *ConditionWorker, values []float64, step time.Duration) []int {
+	var fired []int
+	now := time.Date(2025, time.June, 2, 17, 0, 0, 0, time.UTC)
+	for i, v := range values {
+		satisfied, _ := w.evaluateCondition(v)
+		if satisfied {
+			w.ConditionMet++
+			if ok, _ := w.shouldTrigger(now); ok {
+				fired = append(fired, i)
+				w.markTriggered(now)
+			}
+		} else {
+			w.ConditionMet = 0
+			w.rearmIfOutside(v)
+		}
+		now = now.Add(step)
+	}
+	return fired
+}
+
+func TestTriggerPolicy(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     types.ConditionWorkerData
+		values   []float64
+		step     time.Duration
+		expected []int
+	}{
+		{
+			name:     "default fires on every satisfied check",
+			data:     types.ConditionWorkerData{ConditionType: ConditionGreaterThan, LowerLimit: 100},
+			values:   []float64{101, 102, 99, 103},
+			step:     time.Second,
+			expected: []int{0, 1, 3},
+		},
+		{
+			name:     "consecutive hits",
+			data:     types.ConditionWorkerData{ConditionType: ConditionGreaterThan, LowerLimit: 100, ConsecutiveHits: 3},
+			values:   []float64{101, 102, 99, 101, 102, 103, 104},
+			step:     time.Second,
+			expected: []int{5},
+		},
+		{
+			name:     "cooldown",
+			data:     types.ConditionWorkerData{ConditionType: ConditionGreaterThan, LowerLimit: 100, CooldownSeconds: 3},
+			values:   []float64{101, 101, 101, 101, 101},
+			step:     time.Second,
+			expected: []int{0, 3},
+		},
+		{
+			name:     "edge triggered ignores initial in-range value",
+			data:     types.ConditionWorkerData{ConditionType: ConditionGreaterThan, LowerLimit: 100, EdgeTriggered: true},
+			values:   []float64{101, 102, 99, 101, 102, 99, 101},
+			step:     time.Second,
+			expected: []int{3, 6},
+		},
+		{
+			name:     "rearm hysteresis",
+			data:     types.ConditionWorkerData{ConditionType: ConditionGreaterThan, LowerLimit: 100, RearmPercent: 1},
+			values:   []float64{101, 99.5, 101, 98.9, 101},
+			step:     time.Second,
+			expected: []int{0, 4},
+		},
+		{
+			name:     "between rearms above upper band",
+			data:     types.ConditionWorkerData{ConditionType: ConditionBetween, LowerLimit: 100, UpperLimit: 200, RearmPercent: 5},
+			values:   []float64{150, 205, 150, 211, 150},
+			step:     time.Second,
+			expected: []int{0, 4},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := tt.data
+			w := newTestConditionWorker(&data)
+			assert.Equal(t, tt.expected, simulate(w, tt.values, tt.step))
+		})
+	}
+}
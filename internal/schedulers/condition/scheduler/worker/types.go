@@ -1,6 +1,10 @@
 package worker
 
-import "time"
+import (
+	"time"
+
+	"github.com/trigg3rX/triggerx-backend-imua/pkg/types"
+)
 
 const (
 	PerformerLockTTL         = 15 * time.Minute // Lock duration for condition monitoring
@@ -47,6 +51,8 @@ type TriggerNotification struct {
 	TriggerTxHash   string    `json:"trigger_tx_hash"`
 	TriggerValue    float64   `json:"trigger_value"`
 	TriggeredAt     time.Time `json:"triggered_at"`
+	// Samples evaluated by windowed condition types, for validators to recompute
+	Samples []types.ConditionSample `json:"samples,omitempty"`
 }
 
 // WorkerTriggerCallback is the interface that workers use to notify the scheduler
@@ -18,6 +18,8 @@ type Config struct {
 
 	// Database RPC URL
 	dbServerURL string
+	// Token the scheduler authenticates to the database server with
+	dbServerServiceToken string
 	// Aggregator RPC URL
 	aggregatorRPCUrl string
 	// Redis API URL
@@ -33,6 +35,12 @@ type Config struct {
 	performerLockTTL    time.Duration
 	taskCacheTTL        time.Duration
 	duplicateTaskWindow time.Duration
+	claimLeaseTTL       time.Duration
+
+	// Timing wheel dispatch
+	wheelTick          time.Duration
+	dispatchLead       time.Duration
+	scriptDispatchLead time.Duration
 }
 
 var cfg Config
@@ -46,6 +54,7 @@ func Init() error {
 		timeSchedulerRPCPort: env.GetEnvString("TIME_SCHEDULER_RPC_PORT", "9005"),
 		redisRPCUrl:          env.GetEnvString("REDIS_RPC_URL", "http://localhost:9003"),
 		dbServerURL:          env.GetEnvString("DBSERVER_RPC_URL", "http://localhost:9002"),
+		dbServerServiceToken: env.GetEnvString("DBSERVER_SERVICE_TOKEN", ""),
 		aggregatorRPCUrl:     env.GetEnvString("AGGREGATOR_RPC_URL", "http://localhost:9001"),
 		pollingInterval:      env.GetEnvDuration("TIME_SCHEDULER_POLLING_INTERVAL", 30*time.Second),
 		pollingLookAhead:     env.GetEnvDuration("TIME_SCHEDULER_POLLING_LOOKAHEAD", 40*time.Minute),
@@ -53,6 +62,10 @@ func Init() error {
 		performerLockTTL:     env.GetEnvDuration("TIME_SCHEDULER_PERFORMER_LOCK_TTL", 31*time.Second),
 		taskCacheTTL:         env.GetEnvDuration("TIME_SCHEDULER_TASK_CACHE_TTL", 1*time.Minute),
 		duplicateTaskWindow:  env.GetEnvDuration("TIME_SCHEDULER_DUPLICATE_TASK_WINDOW", 1*time.Minute),
+		claimLeaseTTL:        env.GetEnvDuration("TIME_SCHEDULER_CLAIM_LEASE_TTL", 2*time.Minute),
+		wheelTick:            env.GetEnvDuration("TIME_SCHEDULER_WHEEL_TICK", 100*time.Millisecond),
+		dispatchLead:         env.GetEnvDuration("TIME_SCHEDULER_DISPATCH_LEAD", 2*time.Second),
+		scriptDispatchLead:   env.GetEnvDuration("TIME_SCHEDULER_SCRIPT_DISPATCH_LEAD", 10*time.Second),
 	}
 	if err := validateConfig(); err != nil {
 		return fmt.Errorf("invalid configuration: %w", err)
@@ -70,12 +83,24 @@ func validateConfig() error {
 	if !env.IsValidURL(cfg.dbServerURL) {
 		return fmt.Errorf("invalid database server URL: %s", cfg.dbServerURL)
 	}
+	if env.IsEmpty(cfg.dbServerServiceToken) {
+		return fmt.Errorf("DBSERVER_SERVICE_TOKEN is required to claim time jobs")
+	}
 	if !env.IsValidURL(cfg.aggregatorRPCUrl) {
 		return fmt.Errorf("invalid aggregator RPC URL: %s", cfg.aggregatorRPCUrl)
 	}
 	if !env.IsValidURL(cfg.redisRPCUrl) {
 		return fmt.Errorf("invalid Redis API URL: %s", cfg.redisRPCUrl)
 	}
+	if cfg.claimLeaseTTL < time.Second {
+		return fmt.Errorf("invalid claim lease TTL: %s", cfg.claimLeaseTTL)
+	}
+	if cfg.wheelTick <= 0 {
+		return fmt.Errorf("invalid timing wheel tick: %s", cfg.wheelTick)
+	}
+	if cfg.dispatchLead < 0 || cfg.scriptDispatchLead < 0 {
+		return fmt.Errorf("invalid dispatch lead: %s, %s", cfg.dispatchLead, cfg.scriptDispatchLead)
+	}
 	return nil
 }
 
@@ -91,6 +116,10 @@ func GetDBServerURL() string {
 	return cfg.dbServerURL
 }
 
+func GetDBServerServiceToken() string {
+	return cfg.dbServerServiceToken
+}
+
 func GetAggregatorRPCUrl() string {
 	return cfg.aggregatorRPCUrl
 }
@@ -126,3 +155,19 @@ func GetTaskCacheTTL() time.Duration {
 func GetDuplicateTaskWindow() time.Duration {
 	return cfg.duplicateTaskWindow
 }
+
+func GetClaimLeaseTTL() time.Duration {
+	return cfg.claimLeaseTTL
+}
+
+func GetWheelTick() time.Duration {
+	return cfg.wheelTick
+}
+
+func GetDispatchLead() time.Duration {
+	return cfg.dispatchLead
+}
+
+func GetScriptDispatchLead() time.Duration {
+	return cfg.scriptDispatchLead
+}
@@ -132,6 +132,11 @@ func TrackTaskExpired() {
 	TasksExpiredTotal.Inc()
 }
 
+// TrackTaskMisfired tracks late tasks by the misfire policy applied to them
+func TrackTaskMisfired(policy string) {
+	TasksMisfiredTotal.WithLabelValues(policy).Inc()
+}
+
 // UpdateTasksPerMinute updates the tasks per minute metric
 func UpdateTasksPerMinute(count float64) {
 	TasksPerMinute.Set(count)
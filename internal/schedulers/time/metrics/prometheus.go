@@ -116,6 +116,22 @@ var (
 		Help:      "Tasks that expired before execution",
 	})
 
+	// Tasks found later than their max lateness, by misfire policy
+	TasksMisfiredTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "triggerx",
+		Subsystem: "time_scheduler",
+		Name:      "tasks_misfired_total",
+		Help:      "Tasks found later than their max lateness",
+	}, []string{"policy"})
+
+	// Claimed tasks waiting for dispatch
+	TasksHeld = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: "triggerx",
+		Subsystem: "time_scheduler",
+		Name:      "tasks_held",
+		Help:      "Claimed tasks waiting in the timing wheel for dispatch",
+	})
+
 	// Task batch size
 	TaskBatchSize = promauto.NewGauge(prometheus.GaugeOpts{
 		Namespace: "triggerx",
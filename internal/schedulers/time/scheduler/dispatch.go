@@ -0,0 +1,166 @@
+package scheduler
+
+import (
+	"context"
+	"time"
+
+	"github.com/trigg3rX/triggerx-backend-imua/internal/schedulers/time/metrics"
+	"github.com/trigg3rX/triggerx-backend-imua/pkg/types"
+)
+
+// timingWheelSize is the number of slots per wheel level, one minute at the default tick
+const timingWheelSize = 600
+
+// holdTasks puts claimed tasks in the timing wheel until their dispatch time
+// and returns the ones that are already due. A job this scheduler already holds
+// was claimed again to renew its lease; the held task takes the fresh lease and
+// job data, so edits apply, and moves in the wheel if it was rescheduled.
+func (s *TimeBasedScheduler) holdTasks(tasks []types.ScheduleTimeTaskData) []types.ScheduleTimeTaskData {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var due []types.ScheduleTimeTaskData
+	for i := range tasks {
+		task := tasks[i]
+		jobID := task.TaskTargetData.JobID
+
+		if held, ok := s.activeTasks[jobID]; ok {
+			rescheduled := !held.NextExecutionTimestamp.Equal(task.NextExecutionTimestamp)
+			*held = task
+			if !rescheduled {
+				continue
+			}
+			// The old wheel entry no longer matches the task and is skipped when it comes due
+			delete(s.activeTasks, jobID)
+		}
+		if _, ok := s.inFlightTasks[jobID]; ok {
+			continue
+		}
+
+		entry := &wheelEntry{
+			jobID:      jobID,
+			dispatchAt: task.NextExecutionTimestamp.Add(-s.dispatchLeadFor(&task)),
+		}
+		if s.wheel.add(entry) {
+			s.activeTasks[jobID] = &task
+			continue
+		}
+		s.inFlightTasks[jobID] = struct{}{}
+		due = append(due, task)
+	}
+
+	metrics.TasksHeld.Set(float64(len(s.activeTasks)))
+	return due
+}
+
+// runTimingWheel advances the timing wheel every tick and dispatches the tasks that came due
+func (s *TimeBasedScheduler) runTimingWheel(ctx context.Context) {
+	ticker := time.NewTicker(s.wheelTick)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.ctx.Done():
+			return
+		case now := <-ticker.C:
+			if due := s.takeDueTasks(now); len(due) > 0 {
+				go s.dispatchTasks(due)
+			}
+		}
+	}
+}
+
+// takeDueTasks moves the tasks whose dispatch time has come out of the wheel
+func (s *TimeBasedScheduler) takeDueTasks(now time.Time) []types.ScheduleTimeTaskData {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entries := s.wheel.advance(now, nil)
+	due := make([]types.ScheduleTimeTaskData, 0, len(entries))
+	for _, entry := range entries {
+		task, ok := s.activeTasks[entry.jobID]
+		if !ok || !entry.dispatchAt.Equal(task.NextExecutionTimestamp.Add(-s.dispatchLeadFor(task))) {
+			continue
+		}
+		delete(s.activeTasks, entry.jobID)
+		s.inFlightTasks[entry.jobID] = struct{}{}
+		due = append(due, *task)
+	}
+
+	metrics.TasksHeld.Set(float64(len(s.activeTasks)))
+	return due
+}
+
+// dispatchTasks submits due tasks in batches. Once a batch has been acknowledged
+// or released its jobs may be claimed and held again.
+func (s *TimeBasedScheduler) dispatchTasks(tasks []types.ScheduleTimeTaskData) {
+	for i := 0; i < len(tasks); i += s.taskBatchSize {
+		end := i + s.taskBatchSize
+		if end > len(tasks) {
+			end = len(tasks)
+		}
+
+		batch := tasks[i:end]
+		s.processBatch(batch)
+
+		s.mu.Lock()
+		for _, task := range batch {
+			delete(s.inFlightTasks, task.TaskTargetData.JobID)
+		}
+		s.mu.Unlock()
+	}
+}
+
+// dropUnclaimedTasks stops holding jobs that a claim no longer returned. A held
+// job is due within the claim look-ahead and leased to this scheduler, so it is
+// only missing once it was paused, cancelled or deleted.
+func (s *TimeBasedScheduler) dropUnclaimedTasks(tasks []types.ScheduleTimeTaskData) []int64 {
+	claimed := make(map[int64]struct{}, len(tasks))
+	for i := range tasks {
+		claimed[tasks[i].TaskTargetData.JobID] = struct{}{}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var dropped []int64
+	for jobID := range s.activeTasks {
+		if _, ok := claimed[jobID]; !ok {
+			delete(s.activeTasks, jobID)
+			dropped = append(dropped, jobID)
+		}
+	}
+
+	metrics.TasksHeld.Set(float64(len(s.activeTasks)))
+	return dropped
+}
+
+// releaseHeldTasks hands back every job still waiting in the wheel, so another
+// scheduler can claim it without waiting for the lease to expire
+func (s *TimeBasedScheduler) releaseHeldTasks() int {
+	s.mu.Lock()
+	jobIDs := make([]int64, 0, len(s.activeTasks))
+	for jobID := range s.activeTasks {
+		jobIDs = append(jobIDs, jobID)
+	}
+	s.activeTasks = make(map[int64]*types.ScheduleTimeTaskData)
+	s.wheel = newTimingWheel(s.wheelTick, timingWheelSize, time.Now())
+	s.mu.Unlock()
+
+	metrics.TasksHeld.Set(0)
+	if len(jobIDs) > 0 {
+		s.releaseTasks(jobIDs)
+	}
+	return len(jobIDs)
+}
+
+// dispatchLeadFor returns how long before its execution time a task is handed
+// to the keepers. Tasks with a dynamic arguments script need time to run it.
+func (s *TimeBasedScheduler) dispatchLeadFor(task *types.ScheduleTimeTaskData) time.Duration {
+	if task.TaskDefinitionID == 2 {
+		return s.scriptDispatchLead
+	}
+	return s.dispatchLead
+}
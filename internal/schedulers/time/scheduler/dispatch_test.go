@@ -0,0 +1,113 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/trigg3rX/triggerx-backend-imua/pkg/types"
+)
+
+func newTestWheelScheduler(now time.Time) *TimeBasedScheduler {
+	return &TimeBasedScheduler{
+		wheel:              newTimingWheel(100*time.Millisecond, timingWheelSize, now),
+		activeTasks:        make(map[int64]*types.ScheduleTimeTaskData),
+		inFlightTasks:      make(map[int64]struct{}),
+		wheelTick:          100 * time.Millisecond,
+		dispatchLead:       2 * time.Second,
+		scriptDispatchLead: 10 * time.Second,
+	}
+}
+
+func timeTask(jobID int64, taskDefinitionID int, next time.Time) types.ScheduleTimeTaskData {
+	return types.ScheduleTimeTaskData{
+		TaskID:                 jobID * 10,
+		TaskDefinitionID:       taskDefinitionID,
+		NextExecutionTimestamp: next,
+		TaskTargetData:         types.TaskTargetData{JobID: jobID},
+	}
+}
+
+func TestHoldTasksDispatchesAtLeadTime(t *testing.T) {
+	now := time.Now()
+	s := newTestWheelScheduler(now)
+
+	due := s.holdTasks([]types.ScheduleTimeTaskData{
+		timeTask(1, 1, now.Add(time.Second)),
+		timeTask(2, 1, now.Add(30*time.Second)),
+		timeTask(3, 2, now.Add(30*time.Second)),
+	})
+	// Within the dispatch lead already, submitted straight away
+	assert.Len(t, due, 1)
+	assert.Equal(t, int64(1), due[0].TaskTargetData.JobID)
+	assert.Len(t, s.activeTasks, 2)
+
+	// The script task leaves 10s before its execution, the plain one 2s before
+	due = s.takeDueTasks(now.Add(20 * time.Second))
+	assert.Len(t, due, 1)
+	assert.Equal(t, int64(3), due[0].TaskTargetData.JobID)
+
+	due = s.takeDueTasks(now.Add(28 * time.Second))
+	assert.Len(t, due, 1)
+	assert.Equal(t, int64(2), due[0].TaskTargetData.JobID)
+	assert.Empty(t, s.activeTasks)
+}
+
+func TestHoldTasksRenewsLeaseOfHeldJob(t *testing.T) {
+	now := time.Now()
+	s := newTestWheelScheduler(now)
+
+	task := timeTask(1, 1, now.Add(time.Minute))
+	task.LeaseExpiresAt = now.Add(2 * time.Minute)
+	assert.Empty(t, s.holdTasks([]types.ScheduleTimeTaskData{task}))
+
+	task.LeaseExpiresAt = now.Add(3 * time.Minute)
+	assert.Empty(t, s.holdTasks([]types.ScheduleTimeTaskData{task}))
+	assert.Len(t, s.activeTasks, 1)
+	assert.Equal(t, task.LeaseExpiresAt, s.activeTasks[1].LeaseExpiresAt)
+
+	// A job being submitted is not held a second time
+	s.inFlightTasks[2] = struct{}{}
+	assert.Empty(t, s.holdTasks([]types.ScheduleTimeTaskData{timeTask(2, 1, now)}))
+	assert.Len(t, s.activeTasks, 1)
+}
+
+func TestHoldTasksAppliesEditsAndReschedules(t *testing.T) {
+	now := time.Now()
+	s := newTestWheelScheduler(now)
+
+	task := timeTask(1, 1, now.Add(30*time.Second))
+	assert.Empty(t, s.holdTasks([]types.ScheduleTimeTaskData{task}))
+
+	// The target was edited while the job was held
+	task.TaskTargetData.TargetFunction = "rebalance"
+	assert.Empty(t, s.holdTasks([]types.ScheduleTimeTaskData{task}))
+	assert.Equal(t, "rebalance", s.activeTasks[1].TaskTargetData.TargetFunction)
+
+	// Resumed with a later execution, the old dispatch time no longer applies
+	task.NextExecutionTimestamp = now.Add(50 * time.Second)
+	assert.Empty(t, s.holdTasks([]types.ScheduleTimeTaskData{task}))
+	assert.Empty(t, s.takeDueTasks(now.Add(29*time.Second)))
+
+	due := s.takeDueTasks(now.Add(48 * time.Second))
+	assert.Len(t, due, 1)
+	assert.Equal(t, "rebalance", due[0].TaskTargetData.TargetFunction)
+}
+
+func TestDropUnclaimedTasks(t *testing.T) {
+	now := time.Now()
+	s := newTestWheelScheduler(now)
+
+	assert.Empty(t, s.holdTasks([]types.ScheduleTimeTaskData{
+		timeTask(1, 1, now.Add(30*time.Second)),
+		timeTask(2, 1, now.Add(30*time.Second)),
+	}))
+
+	// Job 2 was paused, so the next claim does not return it
+	dropped := s.dropUnclaimedTasks([]types.ScheduleTimeTaskData{timeTask(1, 1, now.Add(30*time.Second))})
+	assert.Equal(t, []int64{2}, dropped)
+
+	due := s.takeDueTasks(now.Add(28 * time.Second))
+	assert.Len(t, due, 1)
+	assert.Equal(t, int64(1), due[0].TaskTargetData.JobID)
+}
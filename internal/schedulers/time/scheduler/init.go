@@ -3,6 +3,7 @@ package scheduler
 import (
 	"context"
 	"net/http"
+	"sync"
 	"time"
 
 	"github.com/trigg3rX/triggerx-backend-imua/internal/schedulers/time/config"
@@ -16,18 +17,26 @@ type TimeBasedScheduler struct {
 	ctx                 context.Context
 	cancel              context.CancelFunc
 	logger              logging.Logger
-	activeTasks         map[int64]*types.ScheduleTimeTaskData
+	mu                  sync.Mutex
+	wheel               *timingWheel
+	activeTasks         map[int64]*types.ScheduleTimeTaskData // Claimed tasks waiting in the wheel, by job ID
+	inFlightTasks       map[int64]struct{}                    // Jobs being submitted, until acked or released
 	dbClient            *dbserver.DBServerClient
 	httpClient          *http.Client
 	redisAPIURL         string
 	metrics             *metrics.Collector
 	schedulerID         int
+	instanceID          string // Owner of the leases on claimed jobs
 	pollingInterval     time.Duration
 	pollingLookAhead    time.Duration
 	taskBatchSize       int
 	performerLockTTL    time.Duration
 	taskCacheTTL        time.Duration
 	duplicateTaskWindow time.Duration
+	claimLeaseTTL       time.Duration
+	wheelTick           time.Duration
+	dispatchLead        time.Duration
+	scriptDispatchLead  time.Duration
 }
 
 // NewTimeBasedScheduler creates a new instance of TimeBasedScheduler
@@ -48,18 +57,25 @@ func NewTimeBasedScheduler(managerID string, logger logging.Logger, dbClient *db
 		ctx:                 ctx,
 		cancel:              cancel,
 		logger:              logger,
+		wheel:               newTimingWheel(config.GetWheelTick(), timingWheelSize, time.Now()),
 		activeTasks:         make(map[int64]*types.ScheduleTimeTaskData),
+		inFlightTasks:       make(map[int64]struct{}),
 		dbClient:            dbClient,
 		httpClient:          httpClient,
 		redisAPIURL:         config.GetRedisRPCUrl(),
 		metrics:             metrics.NewCollector(),
 		schedulerID:         config.GetSchedulerID(),
+		instanceID:          managerID,
 		pollingInterval:     config.GetPollingInterval(),
 		pollingLookAhead:    config.GetPollingLookAhead(),
 		taskBatchSize:       config.GetTaskBatchSize(),
 		performerLockTTL:    config.GetPerformerLockTTL(),
 		taskCacheTTL:        config.GetTaskCacheTTL(),
 		duplicateTaskWindow: config.GetDuplicateTaskWindow(),
+		claimLeaseTTL:       config.GetClaimLeaseTTL(),
+		wheelTick:           config.GetWheelTick(),
+		dispatchLead:        config.GetDispatchLead(),
+		scriptDispatchLead:  config.GetScriptDispatchLead(),
 	}
 
 	// Start metrics collection
@@ -67,6 +83,7 @@ func NewTimeBasedScheduler(managerID string, logger logging.Logger, dbClient *db
 
 	scheduler.logger.Info("Time-based scheduler initialized",
 		"scheduler_id", scheduler.schedulerID,
+		"instance_id", scheduler.instanceID,
 		"redis_api_url", scheduler.redisAPIURL,
 		"polling_interval", scheduler.pollingInterval,
 		"polling_look_ahead", scheduler.pollingLookAhead,
@@ -74,15 +91,22 @@ func NewTimeBasedScheduler(managerID string, logger logging.Logger, dbClient *db
 		"performer_lock_ttl", scheduler.performerLockTTL,
 		"task_cache_ttl", scheduler.taskCacheTTL,
 		"duplicate_task_window", scheduler.duplicateTaskWindow,
+		"claim_lease_ttl", scheduler.claimLeaseTTL,
+		"wheel_tick", scheduler.wheelTick,
+		"dispatch_lead", scheduler.dispatchLead,
+		"script_dispatch_lead", scheduler.scriptDispatchLead,
 	)
 
 	return scheduler, nil
 }
 
-// Start begins the scheduler's main polling and execution loop
+// Start begins the scheduler's main polling loop. Claimed tasks are held in
+// the timing wheel and dispatched shortly before their execution time.
 func (s *TimeBasedScheduler) Start(ctx context.Context) {
 	s.logger.Info("Starting time-based scheduler", "scheduler_id", s.schedulerID)
 
+	go s.runTimingWheel(ctx)
+
 	ticker := time.NewTicker(s.pollingInterval)
 	defer ticker.Stop()
 	// Poll and schedule tasks immediately on startup
@@ -107,11 +131,11 @@ func (s *TimeBasedScheduler) Stop() {
 	startTime := time.Now()
 	s.logger.Info("Stopping time-based scheduler")
 
-	// Capture statistics before shutdown
-	activeTasksCount := len(s.activeTasks)
-
 	s.cancel()
 
+	// Jobs still waiting for dispatch go back to the pool
+	activeTasksCount := s.releaseHeldTasks()
+
 	duration := time.Since(startTime)
 
 	s.logger.Info("Time-based scheduler stopped",
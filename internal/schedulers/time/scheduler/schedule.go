@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"github.com/trigg3rX/triggerx-backend-imua/internal/schedulers/time/metrics"
+	"github.com/trigg3rX/triggerx-backend-imua/pkg/parser"
 	"github.com/trigg3rX/triggerx-backend-imua/pkg/types"
 )
 
@@ -29,15 +30,20 @@ type RedisAPIResponse struct {
 	Details   string              `json:"details,omitempty"`
 }
 
-// pollAndScheduleTasks fetches tasks from database and schedules them for execution
+// pollAndScheduleTasks claims upcoming tasks from database and schedules them for dispatch
 func (s *TimeBasedScheduler) pollAndScheduleTasks() {
-	tasks, err := s.dbClient.GetTimeBasedTasks()
+	tasks, err := s.dbClient.ClaimTimeBasedTasks(s.instanceID, s.claimLeaseTTL)
 	if err != nil {
-		s.logger.Errorf("Failed to fetch time-based tasks: %v", err)
+		s.logger.Errorf("Failed to claim time-based tasks: %v", err)
 		metrics.TrackDBConnectionError()
 		return
 	}
 
+	if dropped := s.dropUnclaimedTasks(tasks); len(dropped) > 0 {
+		s.logger.Infof("Dropped %d held tasks whose jobs are no longer active", len(dropped))
+		s.releaseTasks(dropped)
+	}
+
 	if len(tasks) == 0 {
 		return
 	}
@@ -46,14 +52,9 @@ func (s *TimeBasedScheduler) pollAndScheduleTasks() {
 	metrics.TasksScheduled.Set(float64(len(tasks)))
 	metrics.TaskBatchSize.Set(float64(s.taskBatchSize))
 
-	for i := 0; i < len(tasks); i += s.taskBatchSize {
-		end := i + s.taskBatchSize
-		if end > len(tasks) {
-			end = len(tasks)
-		}
-
-		batch := tasks[i:end]
-		s.processBatch(batch)
+	// Tasks not yet due wait in the timing wheel
+	if due := s.holdTasks(tasks); len(due) > 0 {
+		s.dispatchTasks(due)
 	}
 }
 
@@ -64,12 +65,33 @@ func (s *TimeBasedScheduler) processBatch(tasks []types.ScheduleTimeTaskData) {
 	var targetDataList []types.TaskTargetData
 	var triggerDataList []types.TaskTriggerData
 	var validTaskIDs []int64
+	var validJobIDs []int64
+	// Jobs acknowledged without submitting: expired, or dropped by their misfire policy
+	var droppedJobIDs []int64
+	var misfiredTaskIDs []int64
 
 	for _, task := range tasks {
 		// Check if ExpirationTime of the job has passed or not
 		if task.ExpirationTime.Before(time.Now()) {
 			s.logger.Infof("Task ID %d has expired, skipping execution", task.TaskID)
 			metrics.TrackTaskExpired()
+			// Acknowledging completes the job, so it is not claimed again
+			droppedJobIDs = append(droppedJobIDs, task.TaskTargetData.JobID)
+			continue
+		}
+
+		triggerTimestamp, submit := s.resolveMisfire(&task)
+		if !submit {
+			droppedJobIDs = append(droppedJobIDs, task.TaskTargetData.JobID)
+			if misfirePolicy(&task) == types.MisfireFail {
+				misfiredTaskIDs = append(misfiredTaskIDs, task.TaskID)
+			}
+			continue
+		}
+
+		// Another scheduler may already have reclaimed the job
+		if !task.LeaseExpiresAt.IsZero() && task.LeaseExpiresAt.Before(time.Now()) {
+			s.logger.Warnf("Lease on job %d expired before submission, skipping task %d", task.TaskTargetData.JobID, task.TaskID)
 			continue
 		}
 
@@ -88,13 +110,15 @@ func (s *TimeBasedScheduler) processBatch(tasks []types.ScheduleTimeTaskData) {
 			ArgType:                   task.TaskTargetData.ArgType,
 			Arguments:                 task.TaskTargetData.Arguments,
 			DynamicArgumentsScriptUrl: task.TaskTargetData.DynamicArgumentsScriptUrl,
+			ExtraTargets:              task.TaskTargetData.ExtraTargets,
 		}
 		triggerData := types.TaskTriggerData{
 			TaskID:                  task.TaskID,
 			TaskDefinitionID:        task.TaskDefinitionID,
 			ExpirationTime:          task.ExpirationTime,
 			CurrentTriggerTimestamp: task.LastExecutedAt,
-			NextTriggerTimestamp:    task.NextExecutionTimestamp,
+			NextTriggerTimestamp:    triggerTimestamp,
+			MaxLatenessSeconds:      int64(parser.MaxLateness(task.MaxLatenessSeconds) / time.Second),
 			TimeScheduleType:        task.ScheduleType,
 			TimeCronExpression:      task.CronExpression,
 			TimeSpecificSchedule:    task.SpecificSchedule,
@@ -104,6 +128,11 @@ func (s *TimeBasedScheduler) processBatch(tasks []types.ScheduleTimeTaskData) {
 		targetDataList = append(targetDataList, targetData)
 		triggerDataList = append(triggerDataList, triggerData)
 		validTaskIDs = append(validTaskIDs, task.TaskID)
+		validJobIDs = append(validJobIDs, task.TaskTargetData.JobID)
+	}
+
+	if len(droppedJobIDs) > 0 {
+		s.ackTasks(droppedJobIDs, misfiredTaskIDs)
 	}
 
 	// If no valid tasks, return early
@@ -145,9 +174,69 @@ func (s *TimeBasedScheduler) processBatch(tasks []types.ScheduleTimeTaskData) {
 		s.logger.Infof("Batch processing completed successfully: %d tasks submitted", len(validTaskIDs))
 		metrics.TrackTaskCompletion(true, time.Since(time.Now()))
 		metrics.TrackTaskBroadcast("redis_submitted")
+		s.ackTasks(validJobIDs, nil)
 	} else {
 		s.logger.Errorf("Batch processing failed: %d tasks", len(validTaskIDs))
 		metrics.TrackTaskBroadcast("failed")
+		s.releaseTasks(validJobIDs)
+	}
+}
+
+// resolveMisfire applies the job's misfire policy to a task later than its max
+// lateness. It returns when the keeper should execute the task, and false if the
+// task must not be submitted at all.
+func (s *TimeBasedScheduler) resolveMisfire(task *types.ScheduleTimeTaskData) (time.Time, bool) {
+	now := time.Now()
+	if !parser.IsMisfired(task.NextExecutionTimestamp, now, parser.MaxLateness(task.MaxLatenessSeconds)) {
+		return task.NextExecutionTimestamp, true
+	}
+
+	policy := misfirePolicy(task)
+	metrics.TrackTaskMisfired(policy)
+
+	switch policy {
+	case types.MisfireSkip:
+		s.logger.Warn("Skipping misfired task",
+			"job_id", task.TaskTargetData.JobID,
+			"task_id", task.TaskID,
+			"scheduled_at", task.NextExecutionTimestamp)
+		return time.Time{}, false
+	case types.MisfireFail:
+		s.logger.Error("Task misfired, marking it as failed",
+			"job_id", task.TaskTargetData.JobID,
+			"task_id", task.TaskID,
+			"scheduled_at", task.NextExecutionTimestamp)
+		return time.Time{}, false
+	default:
+		// Fire now; the dbserver decides on ack which other missed executions still run
+		s.logger.Warn("Firing misfired task now",
+			"job_id", task.TaskTargetData.JobID,
+			"task_id", task.TaskID,
+			"scheduled_at", task.NextExecutionTimestamp,
+			"policy", policy)
+		return now, true
+	}
+}
+
+// ackTasks advances the schedule of submitted jobs. A job that cannot be
+// acknowledged keeps its lease and is claimed again once the lease expires.
+func (s *TimeBasedScheduler) ackTasks(jobIDs []int64, misfiredTaskIDs []int64) {
+	response, err := s.dbClient.AckTimeBasedTasks(s.instanceID, jobIDs, misfiredTaskIDs)
+	if err != nil {
+		s.logger.Error("Failed to acknowledge time-based tasks", "job_ids", jobIDs, "error", err)
+		metrics.TrackDBConnectionError()
+		return
+	}
+	if len(response.Lost) > 0 {
+		s.logger.Warn("Leases lost before acknowledgement, jobs may execute again", "job_ids", response.Lost)
+	}
+}
+
+// releaseTasks hands jobs back, so the next poll retries them without waiting for the lease to expire
+func (s *TimeBasedScheduler) releaseTasks(jobIDs []int64) {
+	if _, err := s.dbClient.ReleaseTimeBasedTasks(s.instanceID, jobIDs); err != nil {
+		s.logger.Error("Failed to release time-based tasks", "job_ids", jobIDs, "error", err)
+		metrics.TrackDBConnectionError()
 	}
 }
 
@@ -233,3 +322,10 @@ func (s *TimeBasedScheduler) submitBatchToRedis(request SchedulerTaskRequest, pr
 
 	return true
 }
+
+func misfirePolicy(task *types.ScheduleTimeTaskData) string {
+	if task.MisfirePolicy == "" {
+		return types.DefaultMisfirePolicy
+	}
+	return task.MisfirePolicy
+}
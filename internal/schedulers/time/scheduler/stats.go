@@ -7,14 +7,25 @@ func (s *TimeBasedScheduler) GetStats() map[string]interface{} {
 	// Get task performance stats
 	totalTasks, successfulTasks, avgTime := metrics.GetTaskStats()
 
+	s.mu.Lock()
+	activeTasks := len(s.activeTasks)
+	inFlightTasks := len(s.inFlightTasks)
+	s.mu.Unlock()
+
 	return map[string]interface{}{
 		"scheduler_id": s.schedulerID,
-		"active_tasks":              len(s.activeTasks),
+		"active_tasks":              activeTasks,
+		"in_flight_tasks":           inFlightTasks,
 		"performer_lock_ttl":        s.performerLockTTL,
 		"task_cache_ttl":            s.taskCacheTTL,
 		"duplicate_task_window":     s.duplicateTaskWindow,
 		"polling_interval":          s.pollingInterval,
 		"polling_look_ahead":        s.pollingLookAhead,
+		"instance_id":               s.instanceID,
+		"claim_lease_ttl":           s.claimLeaseTTL,
+		"wheel_tick":                s.wheelTick,
+		"dispatch_lead":             s.dispatchLead,
+		"script_dispatch_lead":      s.scriptDispatchLead,
 
 		// Performance metrics
 		"task_stats": map[string]interface{}{
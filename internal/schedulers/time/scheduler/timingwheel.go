@@ -0,0 +1,84 @@
+package scheduler
+
+import "time"
+
+// wheelEntry is a claimed job waiting in the timing wheel until its dispatch time
+type wheelEntry struct {
+	jobID      int64
+	dispatchAt time.Time
+}
+
+// timingWheel is a hierarchical timing wheel. Each wheel holds entries due
+// within size ticks; later entries go to an overflow wheel whose tick is the
+// whole span of this one, and cascade down as their time comes closer.
+// It is not safe for concurrent use.
+type timingWheel struct {
+	tick     time.Duration
+	size     int64
+	interval time.Duration
+	current  time.Time
+	buckets  [][]*wheelEntry
+	overflow *timingWheel
+}
+
+func newTimingWheel(tick time.Duration, size int64, start time.Time) *timingWheel {
+	return &timingWheel{
+		tick:     tick,
+		size:     size,
+		interval: tick * time.Duration(size),
+		current:  start.Truncate(tick),
+		buckets:  make([][]*wheelEntry, size),
+	}
+}
+
+// add places the entry in this wheel or an overflow wheel. It returns false
+// if the entry is already due, in which case the caller dispatches it.
+func (w *timingWheel) add(entry *wheelEntry) bool {
+	switch {
+	case entry.dispatchAt.Before(w.current.Add(w.tick)):
+		return false
+	case entry.dispatchAt.Before(w.current.Add(w.interval)):
+		idx := w.index(entry.dispatchAt)
+		w.buckets[idx] = append(w.buckets[idx], entry)
+		return true
+	default:
+		if w.overflow == nil {
+			w.overflow = newTimingWheel(w.interval, w.size, w.current)
+		}
+		return w.overflow.add(entry)
+	}
+}
+
+// advance moves the wheel forward to now, tick by tick, and appends the
+// entries that became due to due
+func (w *timingWheel) advance(now time.Time, due []*wheelEntry) []*wheelEntry {
+	for !w.current.Add(w.tick).After(now) {
+		w.current = w.current.Add(w.tick)
+
+		// Entries of the overflow wheel that are now within reach move down first
+		if w.overflow != nil {
+			for _, entry := range w.overflow.advance(w.current, nil) {
+				due = w.insert(entry, due)
+			}
+		}
+
+		idx := w.index(w.current)
+		bucket := w.buckets[idx]
+		w.buckets[idx] = nil
+		for _, entry := range bucket {
+			due = w.insert(entry, due)
+		}
+	}
+	return due
+}
+
+func (w *timingWheel) insert(entry *wheelEntry, due []*wheelEntry) []*wheelEntry {
+	if !w.add(entry) {
+		due = append(due, entry)
+	}
+	return due
+}
+
+func (w *timingWheel) index(t time.Time) int64 {
+	return (t.UnixNano() / int64(w.tick)) % w.size
+}
@@ -0,0 +1,53 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func dueJobIDs(entries []*wheelEntry) []int64 {
+	ids := make([]int64, 0, len(entries))
+	for _, entry := range entries {
+		ids = append(ids, entry.jobID)
+	}
+	return ids
+}
+
+func TestTimingWheelDispatchesOnTime(t *testing.T) {
+	start := time.Date(2025, time.June, 2, 17, 0, 0, 0, time.UTC)
+	wheel := newTimingWheel(100*time.Millisecond, 10, start)
+
+	assert.True(t, wheel.add(&wheelEntry{jobID: 1, dispatchAt: start.Add(250 * time.Millisecond)}))
+	assert.True(t, wheel.add(&wheelEntry{jobID: 2, dispatchAt: start.Add(900 * time.Millisecond)}))
+
+	assert.Empty(t, wheel.advance(start.Add(199*time.Millisecond), nil))
+	assert.Equal(t, []int64{1}, dueJobIDs(wheel.advance(start.Add(250*time.Millisecond), nil)))
+	assert.Empty(t, wheel.advance(start.Add(899*time.Millisecond), nil))
+	assert.Equal(t, []int64{2}, dueJobIDs(wheel.advance(start.Add(950*time.Millisecond), nil)))
+}
+
+func TestTimingWheelCascadesFromOverflow(t *testing.T) {
+	start := time.Date(2025, time.June, 2, 17, 0, 0, 0, time.UTC)
+	wheel := newTimingWheel(time.Second, 10, start)
+
+	// Beyond the 10s span of the first wheel, two levels up
+	assert.True(t, wheel.add(&wheelEntry{jobID: 1, dispatchAt: start.Add(35 * time.Second)}))
+	assert.True(t, wheel.add(&wheelEntry{jobID: 2, dispatchAt: start.Add(150 * time.Second)}))
+	assert.NotNil(t, wheel.overflow)
+	assert.NotNil(t, wheel.overflow.overflow)
+
+	assert.Empty(t, wheel.advance(start.Add(34*time.Second), nil))
+	assert.Equal(t, []int64{1}, dueJobIDs(wheel.advance(start.Add(35*time.Second), nil)))
+	assert.Empty(t, wheel.advance(start.Add(149*time.Second), nil))
+	assert.Equal(t, []int64{2}, dueJobIDs(wheel.advance(start.Add(151*time.Second), nil)))
+}
+
+func TestTimingWheelRejectsDueEntries(t *testing.T) {
+	start := time.Date(2025, time.June, 2, 17, 0, 0, 0, time.UTC)
+	wheel := newTimingWheel(time.Second, 10, start)
+
+	assert.False(t, wheel.add(&wheelEntry{jobID: 1, dispatchAt: start.Add(-time.Minute)}))
+	assert.False(t, wheel.add(&wheelEntry{jobID: 2, dispatchAt: start.Add(500 * time.Millisecond)}))
+}
@@ -3,6 +3,7 @@ package aggregator
 import (
 	"context"
 	"encoding/hex"
+	"encoding/json"
 	"fmt"
 
 	"github.com/trigg3rX/triggerx-backend-imua/pkg/types"
@@ -32,3 +33,29 @@ func (c *AggregatorClient) SendTaskToPerformer(ctx context.Context, taskData *ty
 		"result", result)
 	return true, nil
 }
+
+// SendTaskResult reports the result of a task to the orchestrator, which picks
+// it up from the custom messages the aggregator broadcasts
+func (c *AggregatorClient) SendTaskResult(ctx context.Context, result *types.TaskResultData, taskDefinitionID int) (bool, error) {
+	if result == nil || result.ExecutionTrace == nil {
+		return false, fmt.Errorf("task result has no execution trace")
+	}
+	data, err := json.Marshal(result)
+	if err != nil {
+		return false, fmt.Errorf("%w: %v", ErrMarshalFailed, err)
+	}
+
+	params := CallParams{
+		Data:             "0x" + hex.EncodeToString(data),
+		TaskDefinitionID: taskDefinitionID,
+	}
+
+	var response interface{}
+	if err := c.executeWithRetry(ctx, "sendCustomMessage", &response, params); err != nil {
+		c.logger.Error("Failed to send task result", "error", err)
+		return false, fmt.Errorf("failed to send task result: %w", err)
+	}
+
+	c.logger.Debug("Task result sent", "TaskID", result.ExecutionTrace.TaskID)
+	return true, nil
+}
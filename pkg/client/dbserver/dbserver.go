@@ -16,6 +16,7 @@ type DBServerClient struct {
 	logger            logging.Logger
 	dbserverUrl       string
 	httpClient        *retry.HTTPClient
+	serviceToken      string
 }
 
 // NewDBServerClient creates a new instance of DBServerClient
@@ -39,6 +40,19 @@ func NewDBServerClient(logger logging.Logger, dbserverUrl string) (*DBServerClie
 	}, nil
 }
 
+// SetServiceToken sets the token the client authenticates with on the routes
+// of the dbserver only the other services may call
+func (c *DBServerClient) SetServiceToken(token string) {
+	c.serviceToken = token
+}
+
+// authorize adds the service token to a request
+func (c *DBServerClient) authorize(req *http.Request) {
+	if c.serviceToken != "" {
+		req.Header.Set("Authorization", "Bearer "+c.serviceToken)
+	}
+}
+
 // HealthCheck checks if the database server is healthy
 func (c *DBServerClient) HealthCheck() error {
 	url := fmt.Sprintf("%s/api/health", c.dbserverUrl)
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
 
 	"github.com/trigg3rX/triggerx-backend-imua/pkg/types"
@@ -29,3 +30,82 @@ func (c *DBServerClient) UpdateTaskExecutionData(taskExecutionData types.UpdateT
 
 	return true, nil
 }
+
+// FailTaskExecution reports a task that failed for good, so that the jobs chained
+// after its job fail too
+func (c *DBServerClient) FailTaskExecution(taskID int64, reason string) error {
+	url := fmt.Sprintf("%s/api/tasks/failed/%d", c.dbserverUrl, taskID)
+
+	jsonPayload, err := json.Marshal(types.FailTaskExecutionRequest{TaskID: taskID, Error: reason})
+	if err != nil {
+		return fmt.Errorf("failed to marshal task failure: %v", err)
+	}
+
+	req, err := http.NewRequest("PUT", url, bytes.NewBuffer(jsonPayload))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %v", err)
+	}
+	c.authorize(req)
+
+	resp, err := c.httpClient.DoWithRetry(req)
+	if err != nil {
+		return fmt.Errorf("failed to fail task %d: %v", taskID, err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to fail task %d: dbserver returned status %d", taskID, resp.StatusCode)
+	}
+	return nil
+}
+
+// GetJobBudget gets the balance left to the job's user and the expected fee of its next task
+func (c *DBServerClient) GetJobBudget(jobID int64) (types.JobBudgetData, error) {
+	url := fmt.Sprintf("%s/api/jobs/%d/budget", c.dbserverUrl, jobID)
+
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return types.JobBudgetData{}, fmt.Errorf("failed to create request: %v", err)
+	}
+
+	resp, err := c.httpClient.DoWithRetry(req)
+	if err != nil {
+		return types.JobBudgetData{}, fmt.Errorf("failed to get job budget: %v", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return types.JobBudgetData{}, fmt.Errorf("failed to read response body: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return types.JobBudgetData{}, fmt.Errorf("dbserver returned status %d: %s", resp.StatusCode, string(body))
+	}
+
+	var budget types.JobBudgetData
+	if err := json.Unmarshal(body, &budget); err != nil {
+		return types.JobBudgetData{}, fmt.Errorf("failed to unmarshal response body: %v", err)
+	}
+	return budget, nil
+}
+
+// PauseJob pauses a job until its user resumes it
+func (c *DBServerClient) PauseJob(jobID int64) error {
+	url := fmt.Sprintf("%s/api/jobs/%d/pause", c.dbserverUrl, jobID)
+
+	req, err := http.NewRequest("PUT", url, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %v", err)
+	}
+
+	resp, err := c.httpClient.DoWithRetry(req)
+	if err != nil {
+		return fmt.Errorf("failed to pause job %d: %v", jobID, err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to pause job %d: dbserver returned status %d", jobID, resp.StatusCode)
+	}
+	return nil
+}
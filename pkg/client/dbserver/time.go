@@ -1,40 +1,97 @@
 package dbserver
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
+	"time"
 
 	"github.com/trigg3rX/triggerx-backend-imua/pkg/types"
 )
 
-// GetTimeBasedTasks fetches tasks that need to be executed in the next window
-func (c *DBServerClient) GetTimeBasedTasks() ([]types.ScheduleTimeTaskData, error) {
-	url := fmt.Sprintf("%s/api/jobs/time", c.dbserverUrl)
+// ClaimTimeBasedTasks leases the tasks that need to be executed in the next window to this scheduler
+func (c *DBServerClient) ClaimTimeBasedTasks(schedulerID string, leaseTTL time.Duration) ([]types.ScheduleTimeTaskData, error) {
+	url := fmt.Sprintf("%s/api/jobs/time/claim", c.dbserverUrl)
 
-	req, err := http.NewRequest("GET", url, nil)
+	body, err := c.postTimeJobs(url, types.ClaimTimeJobsRequest{
+		SchedulerID:  schedulerID,
+		LeaseSeconds: int64(leaseTTL / time.Second),
+	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to fetch time-based tasks: %v", err)
+		return nil, fmt.Errorf("failed to claim time-based tasks: %v", err)
 	}
 
-	resp, err := c.httpClient.DoWithRetry(req)
+	var tasks []types.ScheduleTimeTaskData
+	err = json.Unmarshal(body, &tasks)
 	if err != nil {
-		return nil, fmt.Errorf("failed to fetch time-based tasks: %v", err)
+		return nil, fmt.Errorf("failed to unmarshal response body: %v", err)
 	}
 
-	body, err := io.ReadAll(resp.Body)
+	c.logger.Debugf("Claimed %d time-based tasks", len(tasks))
+	return tasks, nil
+}
+
+// AckTimeBasedTasks advances the schedule of jobs whose tasks were handed to the keepers,
+// or dropped by their misfire policy. Tasks in misfiredTaskIDs are marked misfired.
+func (c *DBServerClient) AckTimeBasedTasks(schedulerID string, jobIDs []int64, misfiredTaskIDs []int64) (types.TimeJobLeaseResponse, error) {
+	url := fmt.Sprintf("%s/api/jobs/time/ack", c.dbserverUrl)
+	return c.updateTimeJobLeases(url, types.TimeJobLeaseRequest{
+		SchedulerID:     schedulerID,
+		JobIDs:          jobIDs,
+		MisfiredTaskIDs: misfiredTaskIDs,
+	})
+}
+
+// ReleaseTimeBasedTasks hands claimed jobs back so they are claimed again on the next poll
+func (c *DBServerClient) ReleaseTimeBasedTasks(schedulerID string, jobIDs []int64) (types.TimeJobLeaseResponse, error) {
+	url := fmt.Sprintf("%s/api/jobs/time/release", c.dbserverUrl)
+	return c.updateTimeJobLeases(url, types.TimeJobLeaseRequest{
+		SchedulerID: schedulerID,
+		JobIDs:      jobIDs,
+	})
+}
+
+func (c *DBServerClient) updateTimeJobLeases(url string, request types.TimeJobLeaseRequest) (types.TimeJobLeaseResponse, error) {
+	body, err := c.postTimeJobs(url, request)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read response body: %v", err)
+		return types.TimeJobLeaseResponse{}, fmt.Errorf("failed to update time job leases: %v", err)
 	}
-	defer func() { _ = resp.Body.Close() }()
 
-	var tasks []types.ScheduleTimeTaskData
-	err = json.Unmarshal(body, &tasks)
+	var response types.TimeJobLeaseResponse
+	err = json.Unmarshal(body, &response)
 	if err != nil {
-		return nil, fmt.Errorf("failed to unmarshal response body: %v", err)
+		return types.TimeJobLeaseResponse{}, fmt.Errorf("failed to unmarshal response body: %v", err)
 	}
+	return response, nil
+}
 
-	c.logger.Debugf("Fetched %d time-based tasks", len(tasks))
-	return tasks, nil
+func (c *DBServerClient) postTimeJobs(url string, payload interface{}) ([]byte, error) {
+	jsonPayload, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %v", err)
+	}
+
+	req, err := http.NewRequest("POST", url, bytes.NewBuffer(jsonPayload))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	c.authorize(req)
+
+	resp, err := c.httpClient.DoWithRetry(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("dbserver returned status %d: %s", resp.StatusCode, string(body))
+	}
+	return body, nil
 }
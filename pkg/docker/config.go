@@ -1,13 +1,21 @@
 package docker
 
-import "github.com/docker/go-units"
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/docker/go-units"
+)
 
 type DockerConfig struct {
-	Image          string
 	TimeoutSeconds int
 	AutoCleanup    bool
 	MemoryLimit    string
 	CPULimit       float64
+	Sandbox        SandboxConfig
 }
 
 type FeeConfig struct {
@@ -17,19 +25,42 @@ type FeeConfig struct {
 	OverheadCost          float64
 }
 
+// PoolConfig sizes the pool of warm containers scripts are run in. A pool of
+// size 0 runs every script in a fresh container.
+type PoolConfig struct {
+	Size        int        // Containers kept running
+	MaxUses     int        // Executions before a container is replaced
+	CacheDir    string     // Host directory for the module, build and binary caches
+	WarmModules []string   // Go modules fetched into the module cache on start
+	Languages   []Language // Runtimes with a pool, scripts of others run in fresh containers
+}
+
+// IPFSConfig sets where scripts are fetched from. Scripts are named by CID and
+// checked against it, so any gateway will do, and the next is tried when one
+// fails or serves the wrong content.
+type IPFSConfig struct {
+	Gateways       []string // Base URLs, like https://ipfs.io
+	TimeoutSeconds int      // Per request to a gateway
+	MaxScriptBytes int64
+	CacheDir       string // Host directory verified blocks of scripts are kept in, empty to not keep them
+}
+
 type ExecutorConfig struct {
-	Docker DockerConfig
-	Fees   FeeConfig
+	Docker   DockerConfig
+	Fees     FeeConfig
+	Pool     PoolConfig
+	IPFS     IPFSConfig
+	Runtimes map[Language]Runtime
 }
 
 func DefaultConfig() ExecutorConfig {
 	return ExecutorConfig{
 		Docker: DockerConfig{
-			Image:          "golang:latest",
 			TimeoutSeconds: 600,
 			AutoCleanup:    true,
 			MemoryLimit:    "1024m",
 			CPULimit:       1.0,
+			Sandbox:        DefaultSandboxConfig(),
 		},
 		Fees: FeeConfig{
 			PricePerTG:            0.0001,
@@ -37,6 +68,55 @@ func DefaultConfig() ExecutorConfig {
 			TransactionSimulation: 1.0,
 			OverheadCost:          0.1,
 		},
+		IPFS:     DefaultIPFSConfig(),
+		Runtimes: DefaultRuntimes(),
+	}
+}
+
+// PinRuntimes pins the images of the runtimes to digests, given as a comma
+// separated list of language=digest, like go=sha256:...
+func (c *ExecutorConfig) PinRuntimes(digests string) error {
+	if c.Runtimes == nil {
+		c.Runtimes = DefaultRuntimes()
+	}
+	for _, item := range strings.Split(digests, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		language, digest, ok := strings.Cut(item, "=")
+		runtime, known := c.Runtimes[Language(strings.TrimSpace(language))]
+		if !ok || !known {
+			return fmt.Errorf("invalid runtime digest %q, expected a known language=sha256:digest", item)
+		}
+		digest = strings.TrimSpace(digest)
+		if !digestPattern.MatchString(digest) {
+			return fmt.Errorf("invalid digest %q for the %s runtime", digest, runtime.Language)
+		}
+		runtime.Digest = digest
+		c.Runtimes[runtime.Language] = runtime
+	}
+	return nil
+}
+
+var digestPattern = regexp.MustCompile(`^sha256:[0-9a-f]{64}$`)
+
+func DefaultIPFSConfig() IPFSConfig {
+	return IPFSConfig{
+		Gateways:       []string{"https://ipfs.io", "https://dweb.link", "https://w3s.link"},
+		TimeoutSeconds: 15,
+		MaxScriptBytes: MaxScriptBytes,
+		CacheDir:       filepath.Join("data", "scripts"),
+	}
+}
+
+func DefaultPoolConfig() PoolConfig {
+	return PoolConfig{
+		Size:        4,
+		MaxUses:     100,
+		CacheDir:    filepath.Join(os.TempDir(), "triggerx-script-cache"),
+		WarmModules: []string{"github.com/ethereum/go-ethereum@latest"},
+		Languages:   []Language{LanguageGo},
 	}
 }
 
@@ -2,71 +2,471 @@ package docker
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"os"
 	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/ipfs/go-cid"
+	"google.golang.org/protobuf/encoding/protowire"
 
 	"github.com/trigg3rX/triggerx-backend-imua/pkg/logging"
-	"github.com/trigg3rX/triggerx-backend-imua/pkg/retry"
 )
 
+// Blocks larger than this are not IPFS blocks, whatever the gateway says
+const maxBlockBytes = 2 << 20
+
+// Scripts spread over more blocks than this are refused
+const maxScriptBlocks = 1024
+
+// Downloader fetches scripts by CID from IPFS gateways. It asks gateways for
+// raw blocks and hashes each one against its CID, so a gateway can fail a
+// download but not change a script, and performers and attesters run the same
+// code.
 type Downloader struct {
-	client *retry.HTTPClient
+	config IPFSConfig
+	client *http.Client
+	logger logging.Logger
+	next   atomic.Uint32 // Gateway the next block is asked from first
 }
 
-func NewDownloader(logger logging.Logger) (*Downloader, error) {
-	httpClient, err := retry.NewHTTPClient(retry.DefaultHTTPRetryConfig(), logger)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create HTTP client: %w", err)
+func NewDownloader(cfg IPFSConfig, logger logging.Logger) (*Downloader, error) {
+	if len(cfg.Gateways) == 0 {
+		return nil, errors.New("no IPFS gateways configured")
+	}
+	defaults := DefaultIPFSConfig()
+	if cfg.TimeoutSeconds <= 0 {
+		cfg.TimeoutSeconds = defaults.TimeoutSeconds
+	}
+	if cfg.MaxScriptBytes <= 0 {
+		cfg.MaxScriptBytes = defaults.MaxScriptBytes
+	}
+	if cfg.CacheDir != "" {
+		// Private to the keeper, whatever it was created with before
+		if err := os.MkdirAll(cfg.CacheDir, 0700); err != nil {
+			return nil, fmt.Errorf("failed to create script cache: %w", err)
+		}
+		if err := os.Chmod(cfg.CacheDir, 0700); err != nil {
+			return nil, fmt.Errorf("failed to create script cache: %w", err)
+		}
 	}
 
 	return &Downloader{
-		client: httpClient,
+		config: cfg,
+		client: &http.Client{},
+		logger: logger,
 	}, nil
 }
 
-func (d *Downloader) DownloadFile(ctx context.Context, url string, logger logging.Logger) (string, error) {
-	tmpDir, err := os.MkdirTemp("", "ipfs-code")
+// DownloadScript fetches the script a URL names by CID: a gateway URL
+// (https://<gateway>/ipfs/<cid>[/path]), an ipfs://<cid>[/path] URL or a bare
+// CID. Only the CID and path are used, never the gateway in the URL.
+func (d *Downloader) DownloadScript(ctx context.Context, url string) ([]byte, error) {
+	root, path, err := parseScriptURL(url)
 	if err != nil {
-		return "", fmt.Errorf("failed to create temp directory: %w", err)
+		return nil, err
 	}
 
-	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	target, err := d.resolvePath(ctx, root, path)
 	if err != nil {
-		return "", fmt.Errorf("failed to create request: %w", err)
+		return nil, err
+	}
+	file := &scriptFile{}
+	if err := d.readFile(ctx, target, file); err != nil {
+		return nil, err
 	}
+	return file.content, nil
+}
 
-	resp, err := d.client.DoWithRetry(req)
+// resolvePath follows the names of path through UnixFS directories from root
+func (d *Downloader) resolvePath(ctx context.Context, root cid.Cid, path []string) (cid.Cid, error) {
+	current := root
+	for _, name := range path {
+		block, err := d.fetchBlock(ctx, current)
+		if err != nil {
+			return cid.Undef, err
+		}
+		if current.Type() != cid.DagProtobuf {
+			return cid.Undef, fmt.Errorf("%s is not a directory", current)
+		}
+		node, err := decodeDagNode(block)
+		if err != nil {
+			return cid.Undef, fmt.Errorf("invalid block %s: %w", current, err)
+		}
+		if node.Type != unixfsDirectory {
+			return cid.Undef, fmt.Errorf("%s is not a directory", current)
+		}
+		found := false
+		for _, link := range node.Links {
+			if link.Name == name {
+				current, found = link.Cid, true
+				break
+			}
+		}
+		if !found {
+			return cid.Undef, fmt.Errorf("%s has no entry %q", current, name)
+		}
+	}
+	return current, nil
+}
+
+// scriptFile is a script being read from its blocks
+type scriptFile struct {
+	content []byte
+	blocks  int
+}
+
+// readFile appends the content of the UnixFS file at c to file, fetching and
+// verifying its blocks in order
+func (d *Downloader) readFile(ctx context.Context, c cid.Cid, file *scriptFile) error {
+	if file.blocks++; file.blocks > maxScriptBlocks {
+		return fmt.Errorf("script has more than %d blocks", maxScriptBlocks)
+	}
+	block, err := d.fetchBlock(ctx, c)
 	if err != nil {
-		return "", fmt.Errorf("failed to download file: %w", err)
+		return err
+	}
+
+	switch c.Type() {
+	case cid.Raw:
+		return d.appendContent(file, block)
+	case cid.DagProtobuf:
+	default:
+		return fmt.Errorf("unsupported codec %#x for %s", c.Type(), c)
+	}
+
+	node, err := decodeDagNode(block)
+	if err != nil {
+		return fmt.Errorf("invalid block %s: %w", c, err)
+	}
+	if node.Type != unixfsFile && node.Type != unixfsRaw {
+		return fmt.Errorf("%s is not a file", c)
 	}
-	defer func() {
-		if err := resp.Body.Close(); err != nil {
-			logger.Error("Error closing response body", "error", err)
+	if err := d.appendContent(file, node.Data); err != nil {
+		return err
+	}
+	for _, link := range node.Links {
+		if err := d.readFile(ctx, link.Cid, file); err != nil {
+			return err
 		}
-	}()
+	}
+	return nil
+}
+
+func (d *Downloader) appendContent(file *scriptFile, data []byte) error {
+	if int64(len(file.content)+len(data)) > d.config.MaxScriptBytes {
+		return fmt.Errorf("script is larger than %d bytes", d.config.MaxScriptBytes)
+	}
+	file.content = append(file.content, data...)
+	return nil
+}
+
+// fetchBlock returns the raw block of c from the cache, or else from the first
+// gateway asked in turn that serves one hashing to it. Cached blocks are hashed
+// again on every read, so a changed cache file is fetched anew, not run.
+func (d *Downloader) fetchBlock(ctx context.Context, c cid.Cid) ([]byte, error) {
+	if block, ok := d.cachedBlock(c); ok {
+		return block, nil
+	}
+
+	gateways := d.config.Gateways
+	start := int(d.next.Add(1)-1) % len(gateways)
+
+	var errs []error
+	for i := range gateways {
+		gateway := gateways[(start+i)%len(gateways)]
+		block, err := d.fetchBlockFrom(ctx, gateway, c)
+		if err == nil {
+			d.cacheBlock(c, block)
+			return block, nil
+		}
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		d.logger.Warnf("failed to fetch block %s from %s: %v", c, gateway, err)
+		errs = append(errs, fmt.Errorf("%s: %w", gateway, err))
+	}
+	return nil, fmt.Errorf("failed to fetch block %s: %w", c, errors.Join(errs...))
+}
+
+func (d *Downloader) fetchBlockFrom(ctx context.Context, gateway string, c cid.Cid) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(d.config.TimeoutSeconds)*time.Second)
+	defer cancel()
+
+	url := strings.TrimSuffix(gateway, "/") + "/ipfs/" + c.String() + "?format=raw"
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Accept", "application/vnd.ipld.raw")
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
 
 	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+
+	block, err := io.ReadAll(io.LimitReader(resp.Body, maxBlockBytes+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read block: %w", err)
+	}
+	if len(block) > maxBlockBytes {
+		return nil, fmt.Errorf("block is larger than %d bytes", maxBlockBytes)
+	}
+
+	if err := verifyBlock(c, block); err != nil {
+		return nil, err
+	}
+	return block, nil
+}
+
+// verifyBlock checks a block hashes to its CID
+func verifyBlock(c cid.Cid, block []byte) error {
+	sum, err := c.Prefix().Sum(block)
+	if err != nil {
+		return fmt.Errorf("failed to hash block: %w", err)
 	}
+	if !sum.Equals(c) {
+		return fmt.Errorf("block hashes to %s", sum)
+	}
+	return nil
+}
 
-	filePath := filepath.Join(tmpDir, "code.go")
-	out, err := os.Create(filePath)
+// cachedBlock reads the block of c from the cache, if it is there and still
+// hashes to c
+func (d *Downloader) cachedBlock(c cid.Cid) ([]byte, bool) {
+	if d.config.CacheDir == "" {
+		return nil, false
+	}
+	path := filepath.Join(d.config.CacheDir, c.String())
+	block, err := os.ReadFile(path)
+	if err != nil || len(block) > maxBlockBytes {
+		return nil, false
+	}
+	if err := verifyBlock(c, block); err != nil {
+		d.logger.Warnf("dropping cached block %s: %v", c, err)
+		_ = os.Remove(path)
+		return nil, false
+	}
+	return block, true
+}
+
+func (d *Downloader) cacheBlock(c cid.Cid, block []byte) {
+	if d.config.CacheDir == "" {
+		return
+	}
+	if err := writeCacheFile(filepath.Join(d.config.CacheDir, c.String()), block); err != nil {
+		d.logger.Warnf("failed to cache block %s: %v", c, err)
+	}
+}
+
+// writeCacheFile writes through a temporary file, so readers never see part of
+// a block
+func writeCacheFile(path string, content []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".script-*")
 	if err != nil {
-		return "", fmt.Errorf("failed to create local file: %w", err)
+		return err
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+	if _, err := tmp.Write(content); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// parseScriptURL splits a script URL into the CID it names and the path below it
+func parseScriptURL(url string) (cid.Cid, []string, error) {
+	url = strings.TrimSpace(url)
+	var rest string
+	switch {
+	case strings.HasPrefix(url, "ipfs://"):
+		rest = strings.TrimPrefix(url, "ipfs://")
+	case strings.Contains(url, "/ipfs/"):
+		rest = url[strings.LastIndex(url, "/ipfs/")+len("/ipfs/"):]
+	case !strings.Contains(url, "/"):
+		rest = url
+	default:
+		return cid.Undef, nil, fmt.Errorf("script URL %s has no IPFS CID", url)
+	}
+	if i := strings.IndexAny(rest, "?#"); i >= 0 {
+		rest = rest[:i]
+	}
+
+	parts := strings.Split(strings.Trim(rest, "/"), "/")
+	root, err := cid.Decode(parts[0])
+	if err != nil {
+		return cid.Undef, nil, fmt.Errorf("script URL %s has no valid IPFS CID: %w", url, err)
+	}
+	var path []string
+	for _, part := range parts[1:] {
+		if part == "" || part == "." || part == ".." {
+			return cid.Undef, nil, fmt.Errorf("invalid path in script URL %s", url)
+		}
+		path = append(path, part)
+	}
+	return root, path, nil
+}
+
+// ScriptCID is the IPFS CID in a script URL, or the URL itself if it has none
+func ScriptCID(url string) string {
+	url = strings.TrimSpace(url)
+	if rest, ok := strings.CutPrefix(url, "ipfs://"); ok {
+		if j := strings.IndexAny(rest, "/?#"); j >= 0 {
+			rest = rest[:j]
+		}
+		if rest != "" {
+			return rest
+		}
 	}
-	defer func() {
-		if err := out.Close(); err != nil {
-			logger.Error("Error closing output file", "error", err)
+	if i := strings.LastIndex(url, "/ipfs/"); i >= 0 {
+		cid := url[i+len("/ipfs/"):]
+		if j := strings.IndexAny(cid, "/?#"); j >= 0 {
+			cid = cid[:j]
 		}
-	}()
+		if cid != "" {
+			return cid
+		}
+	}
+	return url
+}
+
+// UnixFS node types
+const (
+	unixfsRaw       = 0
+	unixfsDirectory = 1
+	unixfsFile      = 2
+)
+
+type dagLink struct {
+	Cid  cid.Cid
+	Name string
+}
 
-	_, err = io.Copy(out, resp.Body)
+// dagNode is a dag-pb block with its UnixFS data decoded
+type dagNode struct {
+	Type  uint64
+	Data  []byte
+	Links []dagLink
+}
+
+// decodeDagNode decodes the dag-pb protobuf of a block, and the UnixFS message
+// in its data:
+//
+//	PBNode { bytes Data = 1; repeated PBLink Links = 2; }
+//	PBLink { bytes Hash = 1; string Name = 2; uint64 Tsize = 3; }
+//	Data   { DataType Type = 1; bytes Data = 2; ... }
+func decodeDagNode(block []byte) (*dagNode, error) {
+	node := &dagNode{}
+	var unixfs []byte
+	err := walkProtobuf(block, func(num protowire.Number, typ protowire.Type, value []byte) error {
+		if typ != protowire.BytesType {
+			return nil
+		}
+		switch num {
+		case 1:
+			unixfs = value
+		case 2:
+			link, err := decodeDagLink(value)
+			if err != nil {
+				return err
+			}
+			node.Links = append(node.Links, link)
+		}
+		return nil
+	})
 	if err != nil {
-		return "", fmt.Errorf("failed to save file: %w", err)
+		return nil, err
+	}
+	if unixfs == nil {
+		return nil, errors.New("block has no UnixFS data")
 	}
 
-	return filePath, nil
+	hasType := false
+	err = walkProtobuf(unixfs, func(num protowire.Number, typ protowire.Type, value []byte) error {
+		switch {
+		case num == 1 && typ == protowire.VarintType:
+			node.Type, _ = protowire.ConsumeVarint(value)
+			hasType = true
+		case num == 2 && typ == protowire.BytesType:
+			node.Data = value
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("invalid UnixFS data: %w", err)
+	}
+	if !hasType {
+		return nil, errors.New("UnixFS data has no type")
+	}
+	return node, nil
+}
+
+func decodeDagLink(data []byte) (dagLink, error) {
+	var link dagLink
+	err := walkProtobuf(data, func(num protowire.Number, typ protowire.Type, value []byte) error {
+		if typ != protowire.BytesType {
+			return nil
+		}
+		switch num {
+		case 1:
+			c, err := cid.Cast(value)
+			if err != nil {
+				return fmt.Errorf("invalid link: %w", err)
+			}
+			link.Cid = c
+		case 2:
+			link.Name = string(value)
+		}
+		return nil
+	})
+	if err == nil && !link.Cid.Defined() {
+		err = errors.New("link has no hash")
+	}
+	return link, err
+}
+
+// walkProtobuf calls field with each field of a protobuf message. Varints are
+// passed still encoded, length-delimited fields without their length.
+func walkProtobuf(data []byte, field func(num protowire.Number, typ protowire.Type, value []byte) error) error {
+	for len(data) > 0 {
+		num, typ, n := protowire.ConsumeTag(data)
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		data = data[n:]
+
+		value := data
+		switch typ {
+		case protowire.BytesType:
+			var m int
+			value, m = protowire.ConsumeBytes(data)
+			n = m
+		default:
+			n = protowire.ConsumeFieldValue(num, typ, data)
+			if n >= 0 {
+				value = data[:n]
+			}
+		}
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		if err := field(num, typ, value); err != nil {
+			return err
+		}
+		data = data[n:]
+	}
+	return nil
 }
@@ -0,0 +1,238 @@
+package docker
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/ipfs/go-cid"
+	mh "github.com/multiformats/go-multihash"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// fakeGateway serves raw blocks by CID, as an IPFS gateway does for ?format=raw
+type fakeGateway struct {
+	*httptest.Server
+	mu     sync.Mutex
+	blocks map[string][]byte
+	hits   int
+}
+
+func newFakeGateway(t *testing.T, blocks map[string][]byte) *fakeGateway {
+	g := &fakeGateway{blocks: blocks}
+	g.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		g.mu.Lock()
+		defer g.mu.Unlock()
+		g.hits++
+		block, ok := g.blocks[strings.TrimPrefix(r.URL.Path, "/ipfs/")]
+		if !ok || r.URL.Query().Get("format") != "raw" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write(block)
+	}))
+	t.Cleanup(g.Close)
+	return g
+}
+
+func rawBlock(blocks map[string][]byte, data string) cid.Cid {
+	c, _ := cid.NewPrefixV1(cid.Raw, mh.SHA2_256).Sum([]byte(data))
+	blocks[c.String()] = []byte(data)
+	return c
+}
+
+// dagBlock adds a dag-pb block with UnixFS data of the given type
+func dagBlock(blocks map[string][]byte, unixfsType uint64, data string, links map[string]cid.Cid, order ...string) cid.Cid {
+	var unixfs []byte
+	unixfs = protowire.AppendTag(unixfs, 1, protowire.VarintType)
+	unixfs = protowire.AppendVarint(unixfs, unixfsType)
+	if data != "" {
+		unixfs = protowire.AppendTag(unixfs, 2, protowire.BytesType)
+		unixfs = protowire.AppendString(unixfs, data)
+	}
+
+	var block []byte
+	// Links come first in the canonical encoding
+	for _, name := range order {
+		var link []byte
+		link = protowire.AppendTag(link, 1, protowire.BytesType)
+		link = protowire.AppendBytes(link, links[name].Bytes())
+		link = protowire.AppendTag(link, 2, protowire.BytesType)
+		link = protowire.AppendString(link, name)
+		block = protowire.AppendTag(block, 2, protowire.BytesType)
+		block = protowire.AppendBytes(block, link)
+	}
+	block = protowire.AppendTag(block, 1, protowire.BytesType)
+	block = protowire.AppendBytes(block, unixfs)
+
+	c, _ := cid.NewPrefixV0(mh.SHA2_256).Sum(block)
+	blocks[c.String()] = block
+	return c
+}
+
+func newTestDownloader(t *testing.T, gateways ...string) *Downloader {
+	cfg := DefaultIPFSConfig()
+	cfg.Gateways = gateways
+	cfg.CacheDir = ""
+	downloader, err := NewDownloader(cfg, &MockLogger{})
+	require.NoError(t, err)
+	return downloader
+}
+
+func TestDownloadScriptVerifiesContent(t *testing.T) {
+	blocks := map[string][]byte{}
+	script := rawBlock(blocks, "print(42)")
+
+	tampered := map[string][]byte{script.String(): []byte("print(666)")}
+	bad := newFakeGateway(t, tampered)
+	good := newFakeGateway(t, blocks)
+
+	// The gateway in the URL is never asked, and a gateway serving other content
+	// is passed over
+	downloader := newTestDownloader(t, bad.URL, good.URL)
+	content, err := downloader.DownloadScript(context.Background(), "https://attacker.example/ipfs/"+script.String())
+	require.NoError(t, err)
+	assert.Equal(t, "print(42)", string(content))
+	assert.Equal(t, 1, bad.hits)
+
+	// With only bad gateways the download fails
+	downloader = newTestDownloader(t, bad.URL)
+	_, err = downloader.DownloadScript(context.Background(), "ipfs://"+script.String())
+	assert.ErrorContains(t, err, "hashes to")
+}
+
+func TestDownloadScriptRotatesGateways(t *testing.T) {
+	blocks := map[string][]byte{}
+	script := rawBlock(blocks, "print(42)")
+	first, second := newFakeGateway(t, blocks), newFakeGateway(t, blocks)
+
+	downloader := newTestDownloader(t, first.URL, second.URL)
+	for i := 0; i < 4; i++ {
+		_, err := downloader.DownloadScript(context.Background(), script.String())
+		require.NoError(t, err)
+	}
+	assert.Equal(t, 2, first.hits)
+	assert.Equal(t, 2, second.hits)
+}
+
+func TestDownloadScriptReadsUnixFSDirectories(t *testing.T) {
+	blocks := map[string][]byte{}
+	b, c := rawBlock(blocks, "b"), rawBlock(blocks, "c")
+	file := dagBlock(blocks, unixfsFile, "a", map[string]cid.Cid{"": b, " ": c}, "", " ")
+	dir := dagBlock(blocks, unixfsDirectory, "", map[string]cid.Cid{"price.ts": file, "README": b}, "README", "price.ts")
+	gateway := newFakeGateway(t, blocks)
+
+	downloader := newTestDownloader(t, gateway.URL)
+	content, err := downloader.DownloadScript(context.Background(), "https://ipfs.io/ipfs/"+dir.String()+"/price.ts")
+	require.NoError(t, err)
+	assert.Equal(t, "abc", string(content))
+
+	_, err = downloader.DownloadScript(context.Background(), "https://ipfs.io/ipfs/"+dir.String()+"/missing.ts")
+	assert.ErrorContains(t, err, "no entry")
+	_, err = downloader.DownloadScript(context.Background(), "https://ipfs.io/ipfs/"+dir.String())
+	assert.ErrorContains(t, err, "not a file")
+}
+
+func TestDownloadScriptEnforcesMaxSize(t *testing.T) {
+	blocks := map[string][]byte{}
+	b, c := rawBlock(blocks, "bbbb"), rawBlock(blocks, "cccc")
+	file := dagBlock(blocks, unixfsFile, "", map[string]cid.Cid{"b": b, "c": c}, "b", "c")
+	gateway := newFakeGateway(t, blocks)
+
+	cfg := DefaultIPFSConfig()
+	cfg.Gateways = []string{gateway.URL}
+	cfg.CacheDir = ""
+	cfg.MaxScriptBytes = 6
+	downloader, err := NewDownloader(cfg, &MockLogger{})
+	require.NoError(t, err)
+
+	_, err = downloader.DownloadScript(context.Background(), file.String())
+	assert.ErrorContains(t, err, "larger than 6 bytes")
+}
+
+func TestDownloadScriptCachesByCID(t *testing.T) {
+	blocks := map[string][]byte{}
+	script := rawBlock(blocks, "print(42)")
+	gateway := newFakeGateway(t, blocks)
+
+	cfg := DefaultIPFSConfig()
+	cfg.Gateways = []string{gateway.URL}
+	cfg.CacheDir = t.TempDir()
+	downloader, err := NewDownloader(cfg, &MockLogger{})
+	require.NoError(t, err)
+
+	_, err = downloader.DownloadScript(context.Background(), script.String())
+	require.NoError(t, err)
+	gateway.Close()
+
+	// Served from the cache, under any URL naming the same CID
+	content, err := downloader.DownloadScript(context.Background(), "https://dweb.link/ipfs/"+script.String())
+	require.NoError(t, err)
+	assert.Equal(t, "print(42)", string(content))
+	assert.FileExists(t, cfg.CacheDir+"/"+script.String())
+
+	info, err := os.Stat(cfg.CacheDir)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0700), info.Mode().Perm())
+}
+
+func TestDownloadScriptReverifiesCache(t *testing.T) {
+	blocks := map[string][]byte{}
+	script := rawBlock(blocks, "print(42)")
+	gateway := newFakeGateway(t, blocks)
+
+	cfg := DefaultIPFSConfig()
+	cfg.Gateways = []string{gateway.URL}
+	cfg.CacheDir = t.TempDir()
+	downloader, err := NewDownloader(cfg, &MockLogger{})
+	require.NoError(t, err)
+
+	cached := filepath.Join(cfg.CacheDir, script.String())
+	require.NoError(t, os.WriteFile(cached, []byte("print(666)"), 0600))
+
+	// A changed cache file is fetched anew
+	content, err := downloader.DownloadScript(context.Background(), script.String())
+	require.NoError(t, err)
+	assert.Equal(t, "print(42)", string(content))
+
+	require.NoError(t, os.WriteFile(cached, []byte("print(666)"), 0600))
+	gateway.Close()
+	_, err = downloader.DownloadScript(context.Background(), script.String())
+	assert.Error(t, err)
+}
+
+func TestParseScriptURL(t *testing.T) {
+	blocks := map[string][]byte{}
+	script := rawBlock(blocks, "print(42)")
+
+	for _, url := range []string{
+		script.String(),
+		"ipfs://" + script.String(),
+		"https://ipfs.io/ipfs/" + script.String() + "?filename=code.go",
+	} {
+		root, path, err := parseScriptURL(url)
+		require.NoError(t, err, url)
+		assert.Equal(t, script, root, url)
+		assert.Empty(t, path, url)
+	}
+
+	_, path, err := parseScriptURL("ipfs://" + script.String() + "/src/price.ts")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"src", "price.ts"}, path)
+
+	for _, url := range []string{
+		"https://example.com/code.go",
+		"https://ipfs.io/ipfs/bafynotacid",
+		"ipfs://" + script.String() + "/../etc/passwd",
+	} {
+		_, _, err := parseScriptURL(url)
+		assert.Error(t, err, url)
+	}
+}
@@ -0,0 +1,690 @@
+package docker
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/trigg3rX/triggerx-backend-imua/pkg/logging"
+	"github.com/trigg3rX/triggerx-backend-imua/pkg/proof"
+	"github.com/trigg3rX/triggerx-backend-imua/pkg/types"
+)
+
+// Bodies larger than this are not recorded. The script gets a 502 in place of
+// such a response, in the recording and in its replays alike.
+const maxRecordedBodyBytes = 4 << 20
+
+// Where the certificate of the proxy's CA is mounted in script containers
+const (
+	egressCADir  = "/etc/triggerx/certs"
+	egressCAFile = egressCADir + "/egress-ca.pem"
+)
+
+// Upstream connections are refused to addresses in these ranges, on top of the
+// loopback, private, link-local and unspecified ones, so that scripts cannot
+// reach the keeper's host, its network or cloud metadata services
+var blockedNetworks = mustParseCIDRs("100.64.0.0/10", "192.0.0.0/24", "198.18.0.0/15", "240.0.0.0/4", "64:ff9b::/96")
+
+// Hop-by-hop headers, and those the proxy sets itself, which are not recorded
+var unrecordedHeaders = []string{
+	"Connection", "Keep-Alive", "Proxy-Authenticate", "Proxy-Authorization", "Proxy-Connection",
+	"Te", "Trailer", "Transfer-Encoding", "Upgrade", "Content-Length",
+}
+
+// NetworkRequest is an outbound request a script made through the egress proxy
+type NetworkRequest struct {
+	Time    time.Time `json:"time"`
+	Method  string    `json:"method"`
+	Host    string    `json:"host"`
+	URL     string    `json:"url,omitempty"` // Not known for passthrough hosts, which are tunnelled
+	Allowed bool      `json:"allowed"`
+}
+
+// EgressProxy is the only way out of the sandbox network. It is an HTTP proxy
+// that lets scripts reach the allowed hosts, and logs every request they make.
+// Each execution authenticates with its own token, so requests are logged
+// against the execution that made them. Scripts whose HTTP client ignores the
+// HTTP(S)_PROXY variables have no network at all.
+//
+// Only the allowed and passthrough hosts are let through, and never to an
+// address on the keeper's host or its private network, whatever a host
+// resolves to.
+//
+// HTTPS is terminated at the proxy with certificates of its own CA, which script
+// containers trust, so that every exchange can be recorded and replayed. Only
+// passthrough hosts, which serve content verified by other means such as go.sum,
+// are tunnelled.
+type EgressProxy struct {
+	allowed     []string // Hosts, or *.domain patterns
+	passthrough []string
+	allowLocal  bool // Lets upstream connections reach internal addresses, for tests
+	listener    net.Listener
+	server      *http.Server
+	transport   *http.Transport
+	logger      logging.Logger
+
+	ca      *x509.Certificate
+	caKey   *ecdsa.PrivateKey
+	certsMu sync.Mutex
+	certs   map[string]*tls.Certificate // Host -> certificate issued for it
+
+	mu       sync.Mutex
+	sessions map[string]*EgressSession
+}
+
+// EgressSession collects the requests of one execution. It records the
+// exchanges the script makes, or, when replaying, answers the script from a
+// recording without touching the network.
+type EgressSession struct {
+	proxy     *EgressProxy
+	token     string
+	requests  []NetworkRequest
+	exchanges []types.HTTPExchange // Recorded, or the recording being replayed
+	replaying bool
+	replayed  []bool   // Exchanges of the recording already served
+	unmatched []string // Requests of a replay the recording has no response for
+}
+
+// NewEgressProxy starts a proxy listening on addr, enforcing policy
+func NewEgressProxy(addr string, policy NetworkPolicy, logger logging.Logger) (*EgressProxy, error) {
+	ca, caKey, err := newEgressCA()
+	if err != nil {
+		return nil, err
+	}
+
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen for egress proxy: %w", err)
+	}
+
+	p := &EgressProxy{
+		allowed:     policy.AllowedHosts,
+		passthrough: policy.PassthroughHosts,
+		listener:    listener,
+		logger:      logger,
+		ca:          ca,
+		caKey:       caKey,
+		certs:       make(map[string]*tls.Certificate),
+		sessions:    make(map[string]*EgressSession),
+	}
+	p.transport = &http.Transport{
+		Proxy:                 nil,
+		DialContext:           p.dial,
+		ResponseHeaderTimeout: 30 * time.Second,
+	}
+	p.server = &http.Server{Handler: p, ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		if err := p.server.Serve(listener); err != nil && err != http.ErrServerClosed {
+			logger.Errorf("egress proxy stopped: %v", err)
+		}
+	}()
+	return p, nil
+}
+
+// Addr is the address the proxy listens on
+func (p *EgressProxy) Addr() string {
+	return p.listener.Addr().String()
+}
+
+// CACertificate is the PEM certificate of the CA the proxy issues HTTPS
+// certificates from
+func (p *EgressProxy) CACertificate() []byte {
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: p.ca.Raw})
+}
+
+func (p *EgressProxy) Close() error {
+	return p.server.Close()
+}
+
+// Session starts recording the requests of an execution
+func (p *EgressProxy) Session() *EgressSession {
+	return p.newSession(nil, false)
+}
+
+// ReplaySession starts an execution that is answered from exchanges, in place
+// of the network
+func (p *EgressProxy) ReplaySession(exchanges []types.HTTPExchange) *EgressSession {
+	return p.newSession(exchanges, true)
+}
+
+func (p *EgressProxy) newSession(exchanges []types.HTTPExchange, replaying bool) *EgressSession {
+	token := make([]byte, 16)
+	_, _ = rand.Read(token)
+	session := &EgressSession{
+		proxy:     p,
+		token:     hex.EncodeToString(token),
+		exchanges: exchanges,
+		replaying: replaying,
+		replayed:  make([]bool, len(exchanges)),
+	}
+
+	p.mu.Lock()
+	p.sessions[session.token] = session
+	p.mu.Unlock()
+	return session
+}
+
+// Env points a script's HTTP clients at the proxy with the session's token, and
+// has them trust the proxy's CA
+func (s *EgressSession) Env(proxyHost string) []string {
+	if s == nil {
+		return nil
+	}
+	proxyURL := fmt.Sprintf("http://script:%s@%s", s.token, proxyHost)
+	return []string{
+		"HTTP_PROXY=" + proxyURL,
+		"HTTPS_PROXY=" + proxyURL,
+		"http_proxy=" + proxyURL,
+		"https_proxy=" + proxyURL,
+		// Go and OpenSSL still load the system roots from /etc/ssl/certs
+		"SSL_CERT_FILE=" + egressCAFile,
+		"NODE_EXTRA_CA_CERTS=" + egressCAFile,
+		"REQUESTS_CA_BUNDLE=" + egressCAFile,
+	}
+}
+
+// Close ends the session, and returns the requests made in it
+func (s *EgressSession) Close() []NetworkRequest {
+	if s == nil {
+		return nil
+	}
+	s.proxy.mu.Lock()
+	defer s.proxy.mu.Unlock()
+	delete(s.proxy.sessions, s.token)
+	return s.requests
+}
+
+// Exchanges are the exchanges recorded in the session, in the order the script
+// made them
+func (s *EgressSession) Exchanges() []types.HTTPExchange {
+	if s == nil {
+		return nil
+	}
+	s.proxy.mu.Lock()
+	defer s.proxy.mu.Unlock()
+	return s.exchanges
+}
+
+// Err reports the requests of a replay that were not in the recording
+func (s *EgressSession) Err() error {
+	if s == nil {
+		return nil
+	}
+	s.proxy.mu.Lock()
+	defer s.proxy.mu.Unlock()
+	if len(s.unmatched) == 0 {
+		return nil
+	}
+	return fmt.Errorf("%d requests are not in the recording: %s", len(s.unmatched), strings.Join(s.unmatched, ", "))
+}
+
+func (p *EgressProxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	session := p.authenticate(r)
+	if session == nil {
+		w.Header().Set("Proxy-Authenticate", `Basic realm="triggerx"`)
+		http.Error(w, "unknown execution", http.StatusProxyAuthRequired)
+		return
+	}
+
+	host := r.Host
+	if r.Method != http.MethodConnect && r.URL.Host != "" {
+		host = r.URL.Host
+	}
+	request := NetworkRequest{
+		Time:    time.Now().UTC(),
+		Method:  r.Method,
+		Host:    host,
+		Allowed: p.isAllowed(host),
+	}
+	if r.Method != http.MethodConnect {
+		request.URL = r.URL.String()
+	}
+
+	if !request.Allowed {
+		p.logRequest(session, request)
+		http.Error(w, "host is not allowed", http.StatusForbidden)
+		return
+	}
+
+	switch {
+	case r.Method == http.MethodConnect && p.isPassthrough(host):
+		p.logRequest(session, request)
+		p.tunnel(w, host)
+	case r.Method == http.MethodConnect:
+		// Each request inside the tunnel is logged on its own
+		p.intercept(w, session, host)
+	case p.isPassthrough(host):
+		p.logRequest(session, request)
+		p.forward(w, r)
+	default:
+		writeResponse(w, p.exchange(session, r))
+	}
+}
+
+func (p *EgressProxy) logRequest(session *EgressSession, request NetworkRequest) {
+	p.mu.Lock()
+	session.requests = append(session.requests, request)
+	p.mu.Unlock()
+	p.logger.Infof("script egress: %s %s allowed=%v", request.Method, request.Host, request.Allowed)
+}
+
+func (p *EgressProxy) authenticate(r *http.Request) *EgressSession {
+	auth := r.Header.Get("Proxy-Authorization")
+	encoded, ok := strings.CutPrefix(auth, "Basic ")
+	if !ok {
+		return nil
+	}
+	decoded, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil
+	}
+	_, token, _ := strings.Cut(string(decoded), ":")
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.sessions[token]
+}
+
+// isAllowed reports whether scripts may reach host. Passthrough hosts are
+// allowed, any other host only if it is on the allow list.
+func (p *EgressProxy) isAllowed(host string) bool {
+	return matchesHost(p.allowed, host) || matchesHost(p.passthrough, host)
+}
+
+func (p *EgressProxy) isPassthrough(host string) bool {
+	return matchesHost(p.passthrough, host)
+}
+
+// matchesHost reports whether host, with or without its port, is one of the
+// hosts or *.domain patterns
+func matchesHost(patterns []string, host string) bool {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	host = strings.ToLower(host)
+	for _, pattern := range patterns {
+		pattern = strings.ToLower(pattern)
+		if suffix, ok := strings.CutPrefix(pattern, "*."); ok {
+			if strings.HasSuffix(host, "."+suffix) {
+				return true
+			}
+		} else if host == pattern {
+			return true
+		}
+	}
+	return false
+}
+
+// dial connects upstream to one of the addresses host resolves to, once all of
+// them are found to be public. The address dialled is the one checked, so a
+// host cannot resolve to another address in between.
+func (p *EgressProxy) dial(ctx context.Context, network string, addr string) (net.Conn, error) {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, err
+	}
+	ips, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+	if len(ips) == 0 {
+		return nil, fmt.Errorf("no addresses found for %s", host)
+	}
+	for _, ip := range ips {
+		if !p.allowLocal && isInternalIP(ip.IP) {
+			return nil, fmt.Errorf("%s resolves to internal address %s", host, ip.IP)
+		}
+	}
+
+	dialer := &net.Dialer{Timeout: 10 * time.Second}
+	var lastErr error
+	for _, ip := range ips {
+		conn, err := dialer.DialContext(ctx, network, net.JoinHostPort(ip.IP.String(), port))
+		if err == nil {
+			return conn, nil
+		}
+		lastErr = err
+	}
+	return nil, lastErr
+}
+
+// isInternalIP reports whether ip is on the keeper's host or a network it is
+// not meant to be reached on
+func isInternalIP(ip net.IP) bool {
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() || ip.IsUnspecified() {
+		return true
+	}
+	for _, network := range blockedNetworks {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+func mustParseCIDRs(cidrs ...string) []*net.IPNet {
+	networks := make([]*net.IPNet, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			panic(err)
+		}
+		networks = append(networks, network)
+	}
+	return networks
+}
+
+func (p *EgressProxy) tunnel(w http.ResponseWriter, host string) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	upstream, err := p.dial(ctx, "tcp", host)
+	cancel()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+
+	hijacker, ok := w.(http.Hijacker)
+	if !ok {
+		_ = upstream.Close()
+		http.Error(w, "tunnelling not supported", http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	client, _, err := hijacker.Hijack()
+	if err != nil {
+		_ = upstream.Close()
+		return
+	}
+
+	go func() {
+		_, _ = io.Copy(upstream, client)
+		_ = upstream.Close()
+	}()
+	_, _ = io.Copy(client, upstream)
+	_ = client.Close()
+}
+
+// intercept terminates the TLS of a CONNECT to host, and answers the requests
+// made inside it as if they were plain HTTP
+func (p *EgressProxy) intercept(w http.ResponseWriter, session *EgressSession, host string) {
+	hijacker, ok := w.(http.Hijacker)
+	if !ok {
+		http.Error(w, "tunnelling not supported", http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	client, _, err := hijacker.Hijack()
+	if err != nil {
+		return
+	}
+
+	hostname, port, err := net.SplitHostPort(host)
+	if err != nil {
+		hostname, port = host, "443"
+	}
+	conn := tls.Server(client, &tls.Config{
+		GetCertificate: func(hello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+			if hello.ServerName != "" {
+				return p.certificate(hello.ServerName)
+			}
+			return p.certificate(hostname)
+		},
+	})
+	defer func() { _ = conn.Close() }()
+	if err := conn.Handshake(); err != nil {
+		p.logger.Warnf("script egress: TLS handshake for %s failed: %v", host, err)
+		return
+	}
+
+	reader := bufio.NewReader(conn)
+	for {
+		r, err := http.ReadRequest(reader)
+		if err != nil {
+			return
+		}
+		r.URL.Scheme = "https"
+		r.URL.Host = hostname
+		if port != "443" {
+			r.URL.Host = host
+		}
+		resp := p.exchange(session, r)
+		if err := resp.Write(conn); err != nil || r.Close {
+			return
+		}
+	}
+}
+
+// exchange answers a request of a script, from the network while recording or
+// from the recording while replaying
+func (p *EgressProxy) exchange(session *EgressSession, r *http.Request) *http.Response {
+	p.logRequest(session, NetworkRequest{
+		Time:    time.Now().UTC(),
+		Method:  r.Method,
+		Host:    r.URL.Host,
+		URL:     r.URL.String(),
+		Allowed: true,
+	})
+
+	var requestBody []byte
+	if r.Body != nil {
+		body, err := io.ReadAll(io.LimitReader(r.Body, maxRecordedBodyBytes+1))
+		if err != nil {
+			return errorResponse(r, http.StatusBadGateway, "failed to read request body")
+		}
+		requestBody = body
+	}
+	if len(requestBody) > maxRecordedBodyBytes {
+		return errorResponse(r, http.StatusRequestEntityTooLarge, "request body is too large")
+	}
+
+	if session.replaying {
+		exchange, ok := p.replay(session, r.Method, r.URL.String(), requestBody)
+		if !ok {
+			return errorResponse(r, http.StatusBadGateway, "request is not in the recording")
+		}
+		return recordedResponse(r, exchange)
+	}
+
+	exchange := p.roundTrip(r, requestBody)
+	p.mu.Lock()
+	session.exchanges = append(session.exchanges, exchange)
+	p.mu.Unlock()
+	return recordedResponse(r, exchange)
+}
+
+// roundTrip makes a script's request upstream
+func (p *EgressProxy) roundTrip(r *http.Request, body []byte) types.HTTPExchange {
+	exchange := types.HTTPExchange{Method: r.Method, URL: r.URL.String(), RequestBody: body}
+	failed := func(message string) types.HTTPExchange {
+		exchange.StatusCode = http.StatusBadGateway
+		exchange.Header = map[string][]string{"Content-Type": {"text/plain; charset=utf-8"}}
+		exchange.ResponseBody = []byte(message)
+		return exchange
+	}
+
+	outbound, err := http.NewRequestWithContext(r.Context(), r.Method, r.URL.String(), bytes.NewReader(body))
+	if err != nil {
+		return failed(err.Error())
+	}
+	outbound.Header = r.Header.Clone()
+	for _, header := range unrecordedHeaders {
+		outbound.Header.Del(header)
+	}
+
+	resp, err := p.transport.RoundTrip(outbound)
+	if err != nil {
+		return failed(err.Error())
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	responseBody, err := io.ReadAll(io.LimitReader(resp.Body, maxRecordedBodyBytes+1))
+	if err != nil {
+		return failed(err.Error())
+	}
+	if len(responseBody) > maxRecordedBodyBytes {
+		return failed("response body is too large")
+	}
+
+	header := resp.Header.Clone()
+	for _, name := range unrecordedHeaders {
+		header.Del(name)
+	}
+	exchange.StatusCode = resp.StatusCode
+	exchange.Header = header
+	exchange.ResponseBody = responseBody
+	if resp.TLS != nil && len(resp.TLS.PeerCertificates) > 0 {
+		exchange.CertificateHash = proof.CertificateHash(resp.TLS.PeerCertificates[0].Raw)
+	}
+	return exchange
+}
+
+// replay finds the first exchange of the recording not served yet that matches
+// the request
+func (p *EgressProxy) replay(session *EgressSession, method string, url string, body []byte) (types.HTTPExchange, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for i, exchange := range session.exchanges {
+		if session.replayed[i] || exchange.Method != method || exchange.URL != url || !bytes.Equal(exchange.RequestBody, body) {
+			continue
+		}
+		session.replayed[i] = true
+		return exchange, true
+	}
+	session.unmatched = append(session.unmatched, method+" "+url)
+	return types.HTTPExchange{}, false
+}
+
+func (p *EgressProxy) forward(w http.ResponseWriter, r *http.Request) {
+	outbound := r.Clone(r.Context())
+	outbound.RequestURI = ""
+	outbound.Header.Del("Proxy-Authorization")
+	outbound.Header.Del("Proxy-Connection")
+
+	resp, err := p.transport.RoundTrip(outbound)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+	defer func() { _ = resp.Body.Close() }()
+	writeResponse(w, resp)
+}
+
+func writeResponse(w http.ResponseWriter, resp *http.Response) {
+	for key, values := range resp.Header {
+		for _, value := range values {
+			w.Header().Add(key, value)
+		}
+	}
+	w.WriteHeader(resp.StatusCode)
+	_, _ = io.Copy(w, resp.Body)
+}
+
+func recordedResponse(r *http.Request, exchange types.HTTPExchange) *http.Response {
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", exchange.StatusCode, http.StatusText(exchange.StatusCode)),
+		StatusCode:    exchange.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        http.Header(exchange.Header).Clone(),
+		Body:          io.NopCloser(bytes.NewReader(exchange.ResponseBody)),
+		ContentLength: int64(len(exchange.ResponseBody)),
+		Request:       r,
+	}
+}
+
+func errorResponse(r *http.Request, status int, message string) *http.Response {
+	return recordedResponse(r, types.HTTPExchange{
+		StatusCode:   status,
+		Header:       map[string][]string{"Content-Type": {"text/plain; charset=utf-8"}},
+		ResponseBody: []byte(message),
+	})
+}
+
+// certificate issues a certificate for host from the proxy's CA
+func (p *EgressProxy) certificate(host string) (*tls.Certificate, error) {
+	p.certsMu.Lock()
+	defer p.certsMu.Unlock()
+	if cert, ok := p.certs[host]; ok && time.Until(cert.Leaf.NotAfter) > time.Hour {
+		return cert, nil
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	template := &x509.Certificate{
+		SerialNumber: randomSerial(),
+		Subject:      pkix.Name{CommonName: host},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(24 * time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	if ip := net.ParseIP(host); ip != nil {
+		template.IPAddresses = []net.IP{ip}
+	} else {
+		template.DNSNames = []string{host}
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, p.ca, &key.PublicKey, p.caKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to issue certificate for %s: %w", host, err)
+	}
+	leaf, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, err
+	}
+
+	cert := &tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key, Leaf: leaf}
+	p.certs[host] = cert
+	return cert, nil
+}
+
+// newEgressCA creates the CA of a proxy. It only lives as long as the proxy.
+func newEgressCA() (*x509.Certificate, *ecdsa.PrivateKey, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to generate egress CA key: %w", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          randomSerial(),
+		Subject:               pkix.Name{CommonName: "TriggerX script egress CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().AddDate(1, 0, 0),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create egress CA: %w", err)
+	}
+	ca, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, nil, err
+	}
+	return ca, key, nil
+}
+
+func randomSerial() *big.Int {
+	serial, _ := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	return serial
+}
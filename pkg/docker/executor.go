@@ -15,11 +15,16 @@ import (
 	"github.com/docker/docker/api/types/container"
 	"github.com/docker/docker/client"
 	"github.com/trigg3rX/triggerx-backend-imua/pkg/logging"
+	"github.com/trigg3rX/triggerx-backend-imua/pkg/types"
 )
 
+// Scripts larger than this are not executed
+const MaxScriptBytes = 1 << 20
+
 type CodeExecutor struct {
 	DockerManager *Manager
 	Downloader    *Downloader
+	Pools         map[Language]*ContainerPool // Runtimes without one run every script in a fresh container
 	config        ExecutorConfig
 	logger        logging.Logger
 }
@@ -32,37 +37,182 @@ func NewCodeExecutor(ctx context.Context, cfg ExecutorConfig, logger logging.Log
 
 	manager := NewManager(cli, cfg.Docker, logger)
 
-	// if err := manager.PullImage(ctx, cfg.Docker.Image); err != nil {
-	// 	return nil, fmt.Errorf("failed to pull image: %w", err)
-	// }
-
-	// logger.Infof("pulled image: %s", cfg.Docker.Image)
-
-	downloader, err := NewDownloader(logger)
+	downloader, err := NewDownloader(cfg.IPFS, logger)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create downloader: %w", err)
 	}
 
+	if cfg.Runtimes == nil {
+		cfg.Runtimes = DefaultRuntimes()
+	}
+	for language, runtime := range cfg.Runtimes {
+		if runtime.Digest == "" {
+			return nil, fmt.Errorf("no image digest pinned for the %s runtime", language)
+		}
+	}
+
+	pools := make(map[Language]*ContainerPool)
+	if cfg.Pool.Size > 0 {
+		for _, language := range cfg.Pool.Languages {
+			runtime, ok := cfg.Runtimes[language]
+			if !ok {
+				return nil, fmt.Errorf("no runtime for pool language %s", language)
+			}
+			pool := NewContainerPool(manager, cfg.Pool, runtime, logger)
+			if err := pool.Start(ctx); err != nil {
+				for _, started := range pools {
+					started.Close(ctx)
+				}
+				return nil, fmt.Errorf("failed to start %s container pool: %w", language, err)
+			}
+			pools[language] = pool
+		}
+	}
+
 	return &CodeExecutor{
 		DockerManager: manager,
 		Downloader:    downloader,
+		Pools:         pools,
 		config:        cfg,
 		logger:        logger,
 	}, nil
 }
 
+// ExecuteScript runs a script in a warm container of its runtime's pool, or in a
+// fresh container if the runtime has no pool
+func (e *CodeExecutor) ExecuteScript(ctx context.Context, fileURL string, noOfAttesters int) (*ExecutionResult, error) {
+	script, err := e.ResolveScript(ctx, fileURL)
+	if err != nil {
+		return &ExecutionResult{
+			Success: false,
+			Error:   fmt.Errorf("IPFS download failed: %w", err),
+		}, nil
+	}
+
+	pool, ok := e.Pools[script.Runtime.Language]
+	if !ok {
+		return e.executeInContainer(ctx, script, noOfAttesters)
+	}
+
+	result, executionTime, err := pool.Run(ctx, script.Content, BinaryCacheKey(script.URL, script.Content))
+	if err != nil {
+		return &ExecutionResult{
+			Success: false,
+			Error:   fmt.Errorf("execution failed: %w", err),
+		}, nil
+	}
+
+	result.Stats.NoOfAttesters = noOfAttesters
+	result.Stats.ExecutionTime = executionTime
+	result.Stats.TotalCost = e.calculateFees(script.Content, &result.Stats, executionTime)
+	if result.Recording != nil {
+		result.Recording.ScriptURL = script.Ref
+	}
+	return result, nil
+}
+
+// Execute runs a script in a fresh container
 func (e *CodeExecutor) Execute(ctx context.Context, fileURL string, noOfAttesters int) (*ExecutionResult, error) {
 	// 1. Download code from IPFS
-	codePath, err := e.Downloader.DownloadFile(ctx, fileURL, e.logger)
+	script, err := e.ResolveScript(ctx, fileURL)
+	if err != nil {
+		return &ExecutionResult{
+			Success: false,
+			Error:   fmt.Errorf("IPFS download failed: %w", err),
+		}, nil
+	}
+
+	return e.executeInContainer(ctx, script, noOfAttesters)
+}
+
+func (e *CodeExecutor) executeInContainer(ctx context.Context, script *Script, noOfAttesters int) (*ExecutionResult, error) {
+	return e.runInContainer(ctx, script, nil, noOfAttesters)
+}
+
+// ReplayScript runs a script again as recorded: on the image and modules it ran
+// with, answered with the responses it got. A deterministic script gives the
+// recorded output.
+func (e *CodeExecutor) ReplayScript(ctx context.Context, recording *types.ScriptRecording, noOfAttesters int) (*ExecutionResult, error) {
+	if recording == nil {
+		return nil, fmt.Errorf("no recording to replay")
+	}
+	script, err := e.ResolveScript(ctx, recording.ScriptURL)
 	if err != nil {
 		return &ExecutionResult{
 			Success: false,
 			Error:   fmt.Errorf("IPFS download failed: %w", err),
 		}, nil
 	}
+	if script.Runtime.Language != Language(recording.Runtime) {
+		return nil, fmt.Errorf("script resolves to the %s runtime, recorded with %s", script.Runtime.Language, recording.Runtime)
+	}
+	// The performer must have run the script on the image this keeper pins too
+	if recording.ImageDigest != script.Runtime.Digest {
+		return nil, fmt.Errorf("script was recorded on image %s, the %s runtime is pinned to %s", recording.ImageDigest, script.Runtime.Language, script.Runtime.Digest)
+	}
+
+	return e.runInContainer(ctx, script, recording, noOfAttesters)
+}
+
+// runInContainer runs a script in a fresh container, recording its execution,
+// or replaying the one given
+func (e *CodeExecutor) runInContainer(ctx context.Context, script *Script, replay *types.ScriptRecording, noOfAttesters int) (*ExecutionResult, error) {
+	codeDir, err := os.MkdirTemp("", "ipfs-code")
+	if err != nil {
+		return &ExecutionResult{
+			Success: false,
+			Error:   fmt.Errorf("failed to create temp directory: %w", err),
+		}, nil
+	}
+	defer func() {
+		if err := os.RemoveAll(codeDir); err != nil {
+			e.logger.Errorf("failed to remove temp directory %s: %v", codeDir, err)
+		}
+	}()
+
+	if err := os.WriteFile(filepath.Join(codeDir, script.Runtime.EntryFile), script.Content, 0644); err != nil {
+		return &ExecutionResult{
+			Success: false,
+			Error:   fmt.Errorf("failed to write script: %w", err),
+		}, nil
+	}
+
+	var session *EgressSession
+	var env []string
+	if replay != nil {
+		if err := writeModules(codeDir, replay.GoMod, replay.GoSum); err != nil {
+			return &ExecutionResult{
+				Success: false,
+				Error:   fmt.Errorf("failed to write recorded modules: %w", err),
+			}, nil
+		}
+		session, env, err = e.DockerManager.ReplaySession(ctx, replay.Exchanges)
+	} else {
+		session, env, err = e.DockerManager.EgressSession(ctx)
+	}
+	if err != nil {
+		return &ExecutionResult{
+			Success: false,
+			Error:   fmt.Errorf("failed to set up script network: %w", err),
+		}, nil
+	}
+	defer session.Close()
+
+	resultPath, err := newResultDir("script-result")
+	if err != nil {
+		return &ExecutionResult{
+			Success: false,
+			Error:   err,
+		}, nil
+	}
+	defer func() {
+		if err := os.RemoveAll(resultPath); err != nil {
+			e.logger.Errorf("failed to remove result directory %s: %v", resultPath, err)
+		}
+	}()
 
 	// 2. Create and setup container
-	containerID, err := e.DockerManager.CreateContainer(ctx, filepath.Dir(codePath))
+	containerID, err := e.DockerManager.CreateContainer(ctx, codeDir, resultPath, script.Runtime, env)
 	if err != nil {
 		return &ExecutionResult{
 			Success: false,
@@ -83,9 +233,57 @@ func (e *CodeExecutor) Execute(ctx context.Context, fileURL string, noOfAttester
 		}, nil
 	}
 
+	readScriptResult(result, resultPath)
+	result.Runtime = script.Runtime.Language
+	_, digest, err := e.DockerManager.PinnedImage(ctx, script.Runtime)
+	if err != nil {
+		return &ExecutionResult{
+			Success: false,
+			Error:   fmt.Errorf("failed to resolve image digest: %w", err),
+		}, nil
+	}
+	result.ImageDigest = digest
+	result.NetworkRequests = session.Close()
+	if replay != nil {
+		if err := session.Err(); err != nil {
+			result.Success = false
+			result.Error = fmt.Errorf("replay diverged from the recording: %w", err)
+		}
+		return result, nil
+	}
+
+	goMod, _ := os.ReadFile(filepath.Join(codeDir, "go.mod"))
+	goSum, _ := os.ReadFile(filepath.Join(codeDir, "go.sum"))
+	result.Recording = newRecording(script, result, session, string(goMod), string(goSum))
 	return result, nil
 }
 
+// newRecording is the recording of an execution, if its requests were recorded
+func newRecording(script *Script, result *ExecutionResult, session *EgressSession, goMod string, goSum string) *types.ScriptRecording {
+	if session == nil {
+		return nil
+	}
+	return &types.ScriptRecording{
+		ScriptURL:   script.Ref,
+		Runtime:     string(script.Runtime.Language),
+		ImageDigest: result.ImageDigest,
+		GoMod:       goMod,
+		GoSum:       goSum,
+		Exchanges:   session.Exchanges(),
+		Output:      result.Output,
+	}
+}
+
+func writeModules(codeDir string, goMod string, goSum string) error {
+	if goMod == "" {
+		return nil
+	}
+	if err := os.WriteFile(filepath.Join(codeDir, "go.mod"), []byte(goMod), 0644); err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(codeDir, "go.sum"), []byte(goSum), 0644)
+}
+
 func (e *CodeExecutor) MonitorExecution(ctx context.Context, cli *client.Client, containerID string, noOfAttesters int) (*ExecutionResult, error) {
 	result := &ExecutionResult{}
 	var executionStartTime time.Time
@@ -103,10 +301,14 @@ func (e *CodeExecutor) MonitorExecution(ctx context.Context, cli *client.Client,
 		}, nil
 	}
 
+	entryFile := containerInfo.Config.Labels[entryFileLabel]
+	if entryFile == "" {
+		entryFile = "code.go"
+	}
 	var codePath string
 	for _, mount := range containerInfo.Mounts {
 		if mount.Destination == "/code" {
-			codePath = filepath.Join(mount.Source, "code.go")
+			codePath = filepath.Join(mount.Source, entryFile)
 			break
 		}
 	}
@@ -299,23 +501,36 @@ func (e *CodeExecutor) calculateFees(content []byte, stats *ResourceStats, execu
 	// static complexity is the size of the file in KB
 	staticComplexity := float64(len(content)) / (1024)
 
-	execTimeInSeconds := executionTime.Seconds()
 	// memory used is the memory usage of the container in MB
 	memoryUsedMB := float64(stats.MemoryUsage) / (1024 * 1024)
 
-	computationCost := (execTimeInSeconds * 2) + (memoryUsedMB / 128 * 1) + (staticComplexity / 1024 * 1)
-	networkScalingFactor := (1 + stats.NoOfAttesters)
-
-	totalTG := (computationCost * float64(networkScalingFactor)) + e.config.Fees.FixedCost + e.config.Fees.TransactionSimulation + e.config.Fees.OverheadCost
-
-	totalFee := totalTG * e.config.Fees.PricePerTG
+	totalFee := ComputeFee(e.config.Fees, staticComplexity, memoryUsedMB, executionTime, stats.NoOfAttesters)
 
 	stats.TotalCost = totalFee
 
 	return totalFee
 }
 
+// ComputeFee prices an execution of a script of staticComplexity KB that ran for
+// executionTime using memoryUsedMB, attested by noOfAttesters keepers
+func ComputeFee(fees FeeConfig, staticComplexity float64, memoryUsedMB float64, executionTime time.Duration, noOfAttesters int) float64 {
+	execTimeInSeconds := executionTime.Seconds()
+
+	computationCost := (execTimeInSeconds * 2) + (memoryUsedMB / 128 * 1) + (staticComplexity / 1024 * 1)
+	networkScalingFactor := (1 + noOfAttesters)
+
+	totalTG := (computationCost * float64(networkScalingFactor)) + fees.FixedCost + fees.TransactionSimulation + fees.OverheadCost
+
+	return totalTG * fees.PricePerTG
+}
+
 func (e *CodeExecutor) Close() error {
+	for _, pool := range e.Pools {
+		pool.Close(context.Background())
+	}
+	if err := e.DockerManager.Close(); err != nil {
+		e.logger.Errorf("failed to stop egress proxy: %v", err)
+	}
 	if err := e.DockerManager.CleanupImages(context.Background()); err != nil {
 		e.logger.Error("Error closing code executor", "error", err)
 		return err
@@ -3,8 +3,11 @@ package docker
 import (
 	"context"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"strings"
+	"sync"
 
 	"github.com/docker/docker/api/types/container"
 	"github.com/docker/docker/api/types/image"
@@ -14,10 +17,17 @@ import (
 )
 
 const (
+	// SetupScript resolves the modules of code.go, unless it comes with the
+	// go.mod and go.sum of an execution being replayed, which are used as they are
 	SetupScript = `#!/bin/sh
 cd /code
-go mod init code
-go mod tidy
+if [ -f go.mod ]; then
+    export GOFLAGS=-mod=readonly
+    go mod download
+else
+    go mod init code
+    go mod tidy
+fi
 echo "START_EXECUTION"
 go run code.go 2>&1 || {
     echo "Error executing Go program. Exit code: $?"
@@ -25,12 +35,69 @@ go run code.go 2>&1 || {
 }
 echo "END_EXECUTION"
 `
+
+	// BuildScript compiles code.go into the binary cache, in the pool's builder.
+	// The modules are kept with the binary, for the recording of each run, and
+	// copied first so that a binary in the cache always has them.
+	BuildScript = `cd /code
+go mod init code >/dev/null 2>&1
+go mod tidy
+go build -o "$SCRIPT_BIN.$$" code.go || {
+    echo "Error building Go program. Exit code: $?"
+    exit 1
+}
+cp go.mod "$SCRIPT_BIN.mod" && { cp go.sum "$SCRIPT_BIN.sum" 2>/dev/null || : > "$SCRIPT_BIN.sum"; } && mv "$SCRIPT_BIN.$$" "$SCRIPT_BIN"
+`
+
+	// PooledRunScript runs the binary code.go was compiled into, in a warm
+	// container that only has the binary cache, read-only
+	PooledRunScript = `cd /code
+if [ ! -x "$SCRIPT_BIN" ]; then
+    echo "Error executing Go program: $SCRIPT_BIN was not built"
+    exit 1
+fi
+echo "START_EXECUTION"
+"$SCRIPT_BIN" 2>&1 || {
+    echo "Error executing Go program. Exit code: $?"
+    exit 1
+}
+echo "END_EXECUTION"
+`
+
+	// WarmScript fetches the modules it is given into the module cache, and
+	// compiles the standard library into the build cache
+	WarmScript = `mkdir -p /tmp/warm && cd /tmp/warm
+go mod init warm >/dev/null 2>&1
+for module in "$@"; do
+    go get "$module" || echo "Error fetching $module"
+done
+go build std
+cd / && rm -rf /tmp/warm
+`
+
+	// ResetScript clears what an execution left behind in a warm container
+	ResetScript = `find /code /tmp ` + resultDir + ` -mindepth 1 -delete`
 )
 
+// Where the pool's caches are mounted in its containers
+const (
+	poolModCacheDir   = "/cache/mod"
+	poolBuildCacheDir = "/cache/build"
+	poolBinCacheDir   = "/cache/bin"
+)
+
+// Label of a container naming the file its script is saved as
+const entryFileLabel = "triggerx.entry_file"
+
 type Manager struct {
 	Cli    *client.Client
 	config DockerConfig
 	logger logging.Logger
+
+	mu     sync.Mutex
+	egress *EgressProxy // Started with the sandbox network
+
+	egressCADir string // Host directory with the certificate of the proxy's CA
 }
 
 func NewManager(cli *client.Client, config DockerConfig, logger logging.Logger) *Manager {
@@ -41,12 +108,59 @@ func NewManager(cli *client.Client, config DockerConfig, logger logging.Logger)
 	}
 }
 
+// PinnedImage returns the reference of a runtime's image by digest, and the
+// digest. The image is pulled if it is not present, and must resolve to the
+// digest the runtime is pinned to.
+func (m *Manager) PinnedImage(ctx context.Context, runtime Runtime) (string, string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if runtime.Digest == "" {
+		return "", "", fmt.Errorf("no image digest pinned for the %s runtime", runtime.Language)
+	}
+	ref := repository(runtime.Image) + "@" + runtime.Digest
+
+	inspect, err := m.Cli.ImageInspect(ctx, ref)
+	if client.IsErrNotFound(err) {
+		if err := m.PullImage(ctx, ref); err != nil {
+			return "", "", err
+		}
+		inspect, err = m.Cli.ImageInspect(ctx, ref)
+	}
+	if err != nil {
+		return "", "", fmt.Errorf("failed to inspect image %s: %w", ref, err)
+	}
+
+	resolved := ""
+	for _, repoDigest := range inspect.RepoDigests {
+		if name, d, ok := strings.Cut(repoDigest, "@"); ok && repository(name) == repository(runtime.Image) {
+			resolved = d
+			break
+		}
+	}
+	if resolved == "" {
+		// Built locally, so only known by its ID
+		resolved = inspect.ID
+		ref = inspect.ID
+	}
+	if resolved != runtime.Digest {
+		return "", "", fmt.Errorf("image %s resolved to digest %s, pinned to %s", runtime.Image, resolved, runtime.Digest)
+	}
+	return ref, resolved, nil
+}
+
 func (m *Manager) PullImage(ctx context.Context, imageName string) error {
-	_, err := m.Cli.ImagePull(ctx, imageName, image.PullOptions{})
+	reader, err := m.Cli.ImagePull(ctx, imageName, image.PullOptions{})
 	if err != nil {
 		m.logger.Errorf("failed to pull image: %v", err)
 		return fmt.Errorf("failed to pull image: %w", err)
 	}
+	defer func() { _ = reader.Close() }()
+
+	// The pull is only done once its progress has been read to the end
+	if _, err := io.Copy(io.Discard, reader); err != nil {
+		return fmt.Errorf("failed to pull image: %w", err)
+	}
 	return nil
 }
 
@@ -66,7 +180,9 @@ func (m *Manager) CleanupImages(ctx context.Context) error {
 	return nil
 }
 
-func (m *Manager) CreateContainer(ctx context.Context, codePath string) (string, error) {
+// CreateContainer creates a container of a runtime for the script in codePath,
+// with env added to its environment. The script writes its result to resultPath.
+func (m *Manager) CreateContainer(ctx context.Context, codePath string, resultPath string, runtime Runtime, env []string) (string, error) {
 	absPath, err := filepath.Abs(codePath)
 	if err != nil {
 		m.logger.Errorf("failed to get absolute path: %v", err)
@@ -78,7 +194,7 @@ func (m *Manager) CreateContainer(ctx context.Context, codePath string) (string,
 	setupScriptPath := filepath.Join(absPath, "setup.sh")
 	m.logger.Infof("Writing setup script to: %s", setupScriptPath)
 
-	if err := os.WriteFile(setupScriptPath, []byte(SetupScript), 0755); err != nil {
+	if err := os.WriteFile(setupScriptPath, []byte(runtime.SetupScript), 0755); err != nil {
 		m.logger.Errorf("failed to write setup script: %v", err)
 		return "", fmt.Errorf("failed to write setup script: %w", err)
 	}
@@ -103,22 +219,34 @@ func (m *Manager) CreateContainer(ctx context.Context, codePath string) (string,
 		}
 	}
 
+	imageRef, _, err := m.PinnedImage(ctx, runtime)
+	if err != nil {
+		return "", err
+	}
+	if err := m.prepareSandbox(ctx, absPath); err != nil {
+		return "", err
+	}
+
 	config := &container.Config{
-		Image:      m.config.Image,
+		Image:      imageRef,
 		Cmd:        []string{"/code/setup.sh"},
 		Tty:        true,
 		WorkingDir: "/code",
+		Env:        append([]string{ResultFileEnv + "=" + resultDir + "/" + resultFileName}, env...),
+		Labels:     map[string]string{entryFileLabel: runtime.EntryFile},
 	}
 
 	hostConfig := &container.HostConfig{
-		Binds: []string{
+		Binds: append([]string{
 			fmt.Sprintf("%s:/code", absPath),
-		},
+			fmt.Sprintf("%s:%s", resultPath, resultDir),
+		}, m.sandboxBinds()...),
 		Resources: container.Resources{
 			Memory:   int64(m.config.MemoryLimitBytes()),
 			NanoCPUs: int64(m.config.CPULimit * 1e9),
 		},
 	}
+	applySandbox(config, hostConfig, m.config.Sandbox, sandboxNetworkName)
 
 	resp, err := m.Cli.ContainerCreate(ctx, config, hostConfig, nil, nil, "")
 	if err != nil {
@@ -129,6 +257,93 @@ func (m *Manager) CreateContainer(ctx context.Context, codePath string) (string,
 	return resp.ID, nil
 }
 
+// CreatePoolContainer creates and starts an idle container of a runtime for the
+// pool, with workspace mounted as its code directory, results as the directory
+// scripts write their result to, and cacheDir under /cache. Only a builder,
+// which never runs a script, can write to the caches: the containers scripts
+// run in get the binary cache alone, read-only.
+func (m *Manager) CreatePoolContainer(ctx context.Context, workspace string, results string, cacheDir string, builder bool, runtime Runtime) (string, error) {
+	imageRef, _, err := m.PinnedImage(ctx, runtime)
+	if err != nil {
+		return "", err
+	}
+	if err := m.prepareSandbox(ctx, workspace); err != nil {
+		return "", err
+	}
+
+	config := &container.Config{
+		Image:      imageRef,
+		Cmd:        []string{"sleep", "infinity"},
+		WorkingDir: "/code",
+		Env:        append([]string{ResultFileEnv + "=" + resultDir + "/" + resultFileName}, runtime.Env...),
+		Labels:     map[string]string{entryFileLabel: runtime.EntryFile},
+	}
+
+	binds := []string{
+		fmt.Sprintf("%s:/code", workspace),
+		fmt.Sprintf("%s:%s", results, resultDir),
+	}
+	if builder {
+		binds = append(binds,
+			fmt.Sprintf("%s:%s", filepath.Join(cacheDir, "mod"), poolModCacheDir),
+			fmt.Sprintf("%s:%s", filepath.Join(cacheDir, "build"), poolBuildCacheDir),
+			fmt.Sprintf("%s:%s", filepath.Join(cacheDir, "bin"), poolBinCacheDir),
+		)
+	} else {
+		binds = append(binds, fmt.Sprintf("%s:%s:ro", filepath.Join(cacheDir, "bin"), poolBinCacheDir))
+	}
+
+	hostConfig := &container.HostConfig{
+		Binds: append(binds, m.sandboxBinds()...),
+		Resources: container.Resources{
+			Memory:   int64(m.config.MemoryLimitBytes()),
+			NanoCPUs: int64(m.config.CPULimit * 1e9),
+		},
+	}
+	applySandbox(config, hostConfig, m.config.Sandbox, sandboxNetworkName)
+
+	resp, err := m.Cli.ContainerCreate(ctx, config, hostConfig, nil, nil, "")
+	if err != nil {
+		m.logger.Errorf("failed to create pool container: %v", err)
+		return "", fmt.Errorf("failed to create pool container: %w", err)
+	}
+
+	if err := m.Cli.ContainerStart(ctx, resp.ID, container.StartOptions{}); err != nil {
+		_ = m.Cli.ContainerRemove(ctx, resp.ID, container.RemoveOptions{Force: true})
+		m.logger.Errorf("failed to start pool container: %v", err)
+		return "", fmt.Errorf("failed to start pool container: %w", err)
+	}
+
+	return resp.ID, nil
+}
+
+// prepareSandbox gets the sandbox network up, and opens the code directory to
+// the unprivileged user scripts run as
+func (m *Manager) prepareSandbox(ctx context.Context, codeDir string) error {
+	if m.config.Sandbox.Network.Mode == NetworkProxy {
+		if err := m.ensureSandboxNetwork(ctx); err != nil {
+			return err
+		}
+	}
+	if m.config.Sandbox.User != "" {
+		if err := os.Chmod(codeDir, 0777); err != nil {
+			return fmt.Errorf("failed to open code directory to the sandbox user: %w", err)
+		}
+	}
+	return nil
+}
+
+// Close stops the egress proxy
+func (m *Manager) Close() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.egress == nil {
+		return nil
+	}
+	_ = os.RemoveAll(m.egressCADir)
+	return m.egress.Close()
+}
+
 func (m *Manager) CleanupContainer(ctx context.Context, containerID string) error {
 	if !m.config.AutoCleanup {
 		m.logger.Infof("auto cleanup is disabled, skipping container cleanup")
@@ -0,0 +1,594 @@
+package docker
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/trigg3rX/triggerx-backend-imua/pkg/logging"
+	"github.com/trigg3rX/triggerx-backend-imua/pkg/types"
+)
+
+// ErrPoolClosed is returned for executions requested after the pool was closed
+var ErrPoolClosed = errors.New("container pool is closed")
+
+var cidPattern = regexp.MustCompile(`^[A-Za-z0-9]+$`)
+
+type pooledContainer struct {
+	id        string
+	workspace string // Host directory mounted at /code
+	results   string // Host directory the script writes its result to
+	digest    string // Digest of the image it was created from
+	uses      int
+}
+
+// ContainerPool keeps a number of containers of a runtime running idle, so that
+// a script is executed in one with docker exec instead of in a container created
+// for it. Scripts are compiled the first time they run into a cache of binaries,
+// by a builder container that holds the module and build caches and never runs
+// a script. The containers scripts run in only read the binary cache, so no
+// script can change what another runs.
+type ContainerPool struct {
+	manager  *Manager
+	config   PoolConfig
+	runtime  Runtime
+	cacheDir string // Host directory of the runtime's caches
+	logger   logging.Logger
+
+	idle chan *pooledContainer
+
+	buildMu sync.Mutex // Held by a build, or while the caches are warmed
+
+	mu      sync.Mutex
+	closed  bool
+	builder *pooledContainer // Nil until first needed, and after a build broke it
+}
+
+func NewContainerPool(manager *Manager, config PoolConfig, runtime Runtime, logger logging.Logger) *ContainerPool {
+	if config.MaxUses <= 0 {
+		config.MaxUses = DefaultPoolConfig().MaxUses
+	}
+	return &ContainerPool{
+		manager:  manager,
+		config:   config,
+		runtime:  runtime,
+		cacheDir: filepath.Join(config.CacheDir, string(runtime.Language)),
+		logger:   logger,
+		idle:     make(chan *pooledContainer, config.Size),
+	}
+}
+
+// Start creates the pool's containers, and the builder of a runtime whose scripts
+// are compiled, which warms the shared caches.
+func (p *ContainerPool) Start(ctx context.Context) error {
+	for _, dir := range []string{"mod", "build", "bin"} {
+		if err := os.MkdirAll(filepath.Join(p.cacheDir, dir), 0755); err != nil {
+			return fmt.Errorf("failed to create cache directory: %w", err)
+		}
+		// The builder fills the caches as the unprivileged sandbox user
+		if p.manager.config.Sandbox.User != "" {
+			if err := os.Chmod(filepath.Join(p.cacheDir, dir), 0777); err != nil {
+				return fmt.Errorf("failed to open cache directory to the sandbox user: %w", err)
+			}
+		}
+	}
+
+	for i := 0; i < p.config.Size; i++ {
+		c, err := p.createContainer(ctx, false)
+		if err != nil {
+			p.Close(ctx)
+			return err
+		}
+		p.idle <- c
+	}
+
+	if p.runtime.BuildScript != "" {
+		builder, err := p.builderContainer(ctx)
+		if err != nil {
+			p.Close(ctx)
+			return err
+		}
+		if p.runtime.WarmScript != "" {
+			// Builds wait for the caches to be warm
+			p.buildMu.Lock()
+			go func() {
+				defer p.buildMu.Unlock()
+				warmCtx, cancel := context.WithTimeout(context.Background(), time.Duration(p.manager.config.TimeoutSeconds)*time.Second)
+				defer cancel()
+
+				start := time.Now()
+				cmd := append([]string{"sh", "-c", p.runtime.WarmScript, "warm"}, p.config.WarmModules...)
+				if output, exitCode, err := p.runCommand(warmCtx, builder.id, cmd, nil); err != nil || exitCode != 0 {
+					p.logger.Warnf("failed to warm script caches (exit code %d): %v\n%s", exitCode, err, output)
+				} else {
+					p.logger.Infof("warmed script caches in %v", time.Since(start))
+				}
+			}()
+		}
+	}
+
+	p.logger.Infof("started pool of %d %s containers, caches in %s", p.config.Size, p.runtime.Language, p.cacheDir)
+	return nil
+}
+
+// Run executes a script in an idle container, waiting for one if all are busy.
+// cacheKey names the script's compiled binary in the cache. Returns the result,
+// and how long the script itself ran.
+func (p *ContainerPool) Run(ctx context.Context, content []byte, cacheKey string) (*ExecutionResult, time.Duration, error) {
+	if p.isClosed() {
+		return nil, 0, ErrPoolClosed
+	}
+	if err := p.build(ctx, content, cacheKey); err != nil {
+		return nil, 0, err
+	}
+
+	var c *pooledContainer
+	select {
+	case c = <-p.idle:
+	case <-ctx.Done():
+		return nil, 0, fmt.Errorf("no container available: %w", ctx.Err())
+	}
+
+	if err := os.WriteFile(filepath.Join(c.workspace, p.runtime.EntryFile), content, 0644); err != nil {
+		p.release(c, true)
+		return nil, 0, fmt.Errorf("failed to write script: %w", err)
+	}
+
+	execCtx, cancel := context.WithTimeout(ctx, time.Duration(p.manager.config.TimeoutSeconds)*time.Second)
+	result, executionTime, err := p.exec(execCtx, c, cacheKey)
+	timedOut := execCtx.Err() != nil
+	cancel()
+
+	// A script still running after its deadline cannot be trusted to leave the
+	// container alone, so the container is replaced instead of reset
+	resetCtx, cancelReset := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancelReset()
+	healthy := !timedOut
+	if healthy {
+		if output, exitCode, resetErr := p.runCommand(resetCtx, c.id, []string{"sh", "-c", ResetScript}, nil); resetErr != nil || exitCode != 0 {
+			p.logger.Warnf("failed to reset pool container %s (exit code %d): %v\n%s", c.id, exitCode, resetErr, output)
+			healthy = false
+		}
+	}
+	p.release(c, healthy)
+
+	return result, executionTime, err
+}
+
+// Close removes the pool's idle containers. Busy ones are removed once their
+// execution is done.
+func (p *ContainerPool) Close(ctx context.Context) {
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		return
+	}
+	p.closed = true
+	builder := p.builder
+	p.builder = nil
+	p.mu.Unlock()
+
+	if builder != nil {
+		p.removeContainer(ctx, builder)
+	}
+	for {
+		select {
+		case c := <-p.idle:
+			p.removeContainer(ctx, c)
+		default:
+			return
+		}
+	}
+}
+
+// build compiles a script into the binary cache in the builder, unless it is
+// there already. Builds run one at a time, so a script is only compiled once.
+func (p *ContainerPool) build(ctx context.Context, content []byte, cacheKey string) error {
+	if p.runtime.BuildScript == "" {
+		return nil
+	}
+	p.buildMu.Lock()
+	defer p.buildMu.Unlock()
+	if _, err := os.Stat(filepath.Join(p.cacheDir, "bin", cacheKey)); err == nil {
+		return nil
+	}
+
+	builder, err := p.builderContainer(ctx)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(builder.workspace, p.runtime.EntryFile), content, 0644); err != nil {
+		return fmt.Errorf("failed to write script: %w", err)
+	}
+
+	// Modules are fetched through the proxy like any request of a script
+	session, env, err := p.manager.EgressSession(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to set up script network: %w", err)
+	}
+	defer session.Close()
+
+	buildCtx, cancel := context.WithTimeout(ctx, time.Duration(p.manager.config.TimeoutSeconds)*time.Second)
+	output, exitCode, err := p.runCommand(buildCtx, builder.id, []string{"sh", "-c", p.runtime.BuildScript}, append(env, "SCRIPT_BIN="+poolBinCacheDir+"/"+cacheKey))
+	timedOut := buildCtx.Err() != nil
+	cancel()
+
+	// A build still running after its deadline may write to the caches at any
+	// time, so the builder is replaced instead of reset
+	resetCtx, cancelReset := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancelReset()
+	healthy := !timedOut
+	if healthy {
+		if output, exitCode, resetErr := p.runCommand(resetCtx, builder.id, []string{"sh", "-c", ResetScript}, nil); resetErr != nil || exitCode != 0 {
+			p.logger.Warnf("failed to reset pool builder %s (exit code %d): %v\n%s", builder.id, exitCode, resetErr, output)
+			healthy = false
+		}
+	}
+	if !healthy {
+		p.mu.Lock()
+		if p.builder == builder {
+			p.builder = nil
+		}
+		p.mu.Unlock()
+		p.removeContainer(resetCtx, builder)
+	}
+
+	if err != nil {
+		return fmt.Errorf("failed to build script: %w", err)
+	}
+	if exitCode != 0 {
+		return fmt.Errorf("failed to build script (exit code %d): %s", exitCode, strings.TrimSpace(output))
+	}
+	return nil
+}
+
+// builderContainer returns the pool's builder, creating one if there is none
+func (p *ContainerPool) builderContainer(ctx context.Context) (*pooledContainer, error) {
+	p.mu.Lock()
+	builder, closed := p.builder, p.closed
+	p.mu.Unlock()
+	if closed {
+		return nil, ErrPoolClosed
+	}
+	if builder != nil {
+		return builder, nil
+	}
+
+	builder, err := p.createContainer(ctx, true)
+	if err != nil {
+		return nil, err
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		p.removeContainer(ctx, builder)
+		return nil, ErrPoolClosed
+	}
+	p.builder = builder
+	return builder, nil
+}
+
+func (p *ContainerPool) exec(ctx context.Context, c *pooledContainer, cacheKey string) (*ExecutionResult, time.Duration, error) {
+	cli := p.manager.Cli
+	result := &ExecutionResult{Runtime: p.runtime.Language, ImageDigest: c.digest}
+
+	session, env, err := p.manager.EgressSession(ctx)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to set up script network: %w", err)
+	}
+	defer session.Close()
+
+	created, err := cli.ContainerExecCreate(ctx, c.id, container.ExecOptions{
+		Cmd:          []string{"sh", "-c", p.runtime.PooledRunScript},
+		Env:          append(env, "SCRIPT_BIN="+poolBinCacheDir+"/"+cacheKey),
+		WorkingDir:   "/code",
+		Tty:          true,
+		AttachStdout: true,
+		AttachStderr: true,
+	})
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to create exec: %w", err)
+	}
+
+	baseline, _ := p.containerStats(ctx, c.id)
+	sampler := p.sampleStats(ctx, c.id)
+
+	attach, err := cli.ContainerExecAttach(ctx, created.ID, container.ExecAttachOptions{Tty: true})
+	if err != nil {
+		sampler.stop()
+		return nil, 0, fmt.Errorf("failed to attach to exec: %w", err)
+	}
+	go func() {
+		<-ctx.Done()
+		attach.Close()
+	}()
+
+	var outputBuffer bytes.Buffer
+	var executionStartTime, executionEndTime time.Time
+	scanner := bufio.NewScanner(attach.Reader)
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case strings.Contains(line, "START_EXECUTION"):
+			executionStartTime = time.Now().UTC()
+		case strings.Contains(line, "END_EXECUTION") && !executionStartTime.IsZero():
+			executionEndTime = time.Now().UTC()
+		case !executionStartTime.IsZero() && executionEndTime.IsZero():
+			outputBuffer.WriteString(line + "\n")
+		default:
+			p.logger.Debugf("Container Log: %s", line)
+		}
+	}
+	peak := sampler.stop()
+
+	if ctx.Err() != nil {
+		return nil, 0, fmt.Errorf("operation timed out: %v", ctx.Err())
+	}
+	inspect, err := cli.ContainerExecInspect(ctx, created.ID)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to inspect exec: %w", err)
+	}
+	if inspect.ExitCode != 0 {
+		return nil, 0, fmt.Errorf("script exited with status %d: %s", inspect.ExitCode, strings.TrimSpace(outputBuffer.String()))
+	}
+
+	var executionTime time.Duration
+	if !executionStartTime.IsZero() && !executionEndTime.IsZero() {
+		executionTime = executionEndTime.Sub(executionStartTime)
+	}
+
+	// Counters of a warm container include earlier executions, only the
+	// difference is this one's
+	result.Stats.MemoryUsage = peak.memoryUsage
+	for name, nw := range peak.last.Networks {
+		before := baseline.Networks[name]
+		result.Stats.RxBytes += nw.RxBytes - before.RxBytes
+		result.Stats.RxPackets += nw.RxPackets - before.RxPackets
+		result.Stats.RxErrors += nw.RxErrors - before.RxErrors
+		result.Stats.RxDropped += nw.RxDropped - before.RxDropped
+		result.Stats.TxBytes += nw.TxBytes - before.TxBytes
+		result.Stats.TxPackets += nw.TxPackets - before.TxPackets
+		result.Stats.TxErrors += nw.TxErrors - before.TxErrors
+		result.Stats.TxDropped += nw.TxDropped - before.TxDropped
+	}
+	result.Stats.BandwidthRate = float64(result.Stats.RxBytes + result.Stats.TxBytes)
+	result.Stats.BlockRead, result.Stats.BlockWrite = blkioDelta(baseline, peak.last)
+	if executionTime > 0 && peak.last.CPUStats.CPUUsage.TotalUsage > baseline.CPUStats.CPUUsage.TotalUsage {
+		cpuSeconds := float64(peak.last.CPUStats.CPUUsage.TotalUsage-baseline.CPUStats.CPUUsage.TotalUsage) / 1e9
+		result.Stats.CPUPercentage = cpuSeconds / executionTime.Seconds() * 100.0
+	}
+
+	result.Output = outputBuffer.String()
+	result.Success = !executionStartTime.IsZero() && executionTime > 0
+	readScriptResult(result, c.results)
+	result.NetworkRequests = session.Close()
+	if session != nil {
+		// The modules are those the cached binary was built with
+		binary := filepath.Join(p.cacheDir, "bin", cacheKey)
+		goMod, _ := os.ReadFile(binary + ".mod")
+		goSum, _ := os.ReadFile(binary + ".sum")
+		result.Recording = &types.ScriptRecording{
+			Runtime:     string(p.runtime.Language),
+			ImageDigest: c.digest,
+			GoMod:       string(goMod),
+			GoSum:       string(goSum),
+			Exchanges:   session.Exchanges(),
+			Output:      result.Output,
+		}
+	}
+	return result, executionTime, nil
+}
+
+type statsSample struct {
+	last        container.StatsResponse
+	memoryUsage uint64 // Peak over the samples
+}
+
+type statsSampler struct {
+	cancel context.CancelFunc
+	done   chan statsSample
+}
+
+func (s *statsSampler) stop() statsSample {
+	s.cancel()
+	return <-s.done
+}
+
+// sampleStats follows the container's stats until stopped
+func (p *ContainerPool) sampleStats(ctx context.Context, containerID string) *statsSampler {
+	ctx, cancel := context.WithCancel(ctx)
+	sampler := &statsSampler{cancel: cancel, done: make(chan statsSample, 1)}
+
+	go func() {
+		var sample statsSample
+		defer func() { sampler.done <- sample }()
+
+		stats, err := p.manager.Cli.ContainerStats(ctx, containerID, true)
+		if err != nil {
+			return
+		}
+		defer func() { _ = stats.Body.Close() }()
+
+		decoder := json.NewDecoder(stats.Body)
+		for {
+			var statsJSON container.StatsResponse
+			if err := decoder.Decode(&statsJSON); err != nil {
+				return
+			}
+			sample.last = statsJSON
+			if statsJSON.MemoryStats.Usage > sample.memoryUsage {
+				sample.memoryUsage = statsJSON.MemoryStats.Usage
+			}
+		}
+	}()
+	return sampler
+}
+
+func (p *ContainerPool) containerStats(ctx context.Context, containerID string) (container.StatsResponse, error) {
+	var statsJSON container.StatsResponse
+	stats, err := p.manager.Cli.ContainerStatsOneShot(ctx, containerID)
+	if err != nil {
+		return statsJSON, err
+	}
+	defer func() { _ = stats.Body.Close() }()
+	err = json.NewDecoder(stats.Body).Decode(&statsJSON)
+	return statsJSON, err
+}
+
+// runCommand runs cmd in a container and waits for it to exit
+func (p *ContainerPool) runCommand(ctx context.Context, containerID string, cmd []string, env []string) (string, int, error) {
+	cli := p.manager.Cli
+	created, err := cli.ContainerExecCreate(ctx, containerID, container.ExecOptions{
+		Cmd:          cmd,
+		Env:          env,
+		Tty:          true,
+		AttachStdout: true,
+		AttachStderr: true,
+	})
+	if err != nil {
+		return "", -1, fmt.Errorf("failed to create exec: %w", err)
+	}
+
+	attach, err := cli.ContainerExecAttach(ctx, created.ID, container.ExecAttachOptions{Tty: true})
+	if err != nil {
+		return "", -1, fmt.Errorf("failed to attach to exec: %w", err)
+	}
+	defer attach.Close()
+
+	var output bytes.Buffer
+	if _, err := output.ReadFrom(attach.Reader); err != nil && ctx.Err() != nil {
+		return output.String(), -1, ctx.Err()
+	}
+
+	inspect, err := cli.ContainerExecInspect(ctx, created.ID)
+	if err != nil {
+		return output.String(), -1, fmt.Errorf("failed to inspect exec: %w", err)
+	}
+	return output.String(), inspect.ExitCode, nil
+}
+
+// release returns a container to the pool, or replaces it if it is no longer fit
+// to take executions
+func (p *ContainerPool) release(c *pooledContainer, healthy bool) {
+	c.uses++
+
+	switch {
+	case p.isClosed():
+		p.removeContainer(context.Background(), c)
+	case !healthy || c.uses >= p.config.MaxUses:
+		go p.replaceContainer(c)
+	default:
+		p.idle <- c
+	}
+}
+
+func (p *ContainerPool) replaceContainer(old *pooledContainer) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	p.removeContainer(ctx, old)
+	cancel()
+
+	for attempt := 1; !p.isClosed(); attempt++ {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		c, err := p.createContainer(ctx, false)
+		cancel()
+		if err == nil {
+			if p.isClosed() {
+				p.removeContainer(context.Background(), c)
+			} else {
+				p.idle <- c
+			}
+			return
+		}
+
+		p.logger.Errorf("failed to replace pool container (attempt %d): %v", attempt, err)
+		time.Sleep(min(time.Duration(attempt)*time.Second, 30*time.Second))
+	}
+}
+
+func (p *ContainerPool) isClosed() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.closed
+}
+
+func (p *ContainerPool) createContainer(ctx context.Context, builder bool) (*pooledContainer, error) {
+	workspace, err := os.MkdirTemp("", "pool-code")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create workspace: %w", err)
+	}
+
+	results, err := newResultDir("pool-result")
+	if err != nil {
+		_ = os.RemoveAll(workspace)
+		return nil, err
+	}
+	cleanup := func() {
+		_ = os.RemoveAll(workspace)
+		_ = os.RemoveAll(results)
+	}
+
+	_, digest, err := p.manager.PinnedImage(ctx, p.runtime)
+	if err != nil {
+		cleanup()
+		return nil, err
+	}
+	id, err := p.manager.CreatePoolContainer(ctx, workspace, results, p.cacheDir, builder, p.runtime)
+	if err != nil {
+		cleanup()
+		return nil, err
+	}
+	return &pooledContainer{id: id, workspace: workspace, results: results, digest: digest}, nil
+}
+
+func (p *ContainerPool) removeContainer(ctx context.Context, c *pooledContainer) {
+	if err := p.manager.Cli.ContainerRemove(ctx, c.id, container.RemoveOptions{Force: true}); err != nil {
+		p.logger.Errorf("failed to remove pool container %s: %v", c.id, err)
+	}
+	for _, dir := range []string{c.workspace, c.results} {
+		if err := os.RemoveAll(dir); err != nil {
+			p.logger.Errorf("failed to remove workspace %s: %v", dir, err)
+		}
+	}
+}
+
+func blkioDelta(before, after container.StatsResponse) (read, write uint64) {
+	for _, stat := range after.BlkioStats.IoServiceBytesRecursive {
+		switch stat.Op {
+		case "Read":
+			read += stat.Value
+		case "Write":
+			write += stat.Value
+		}
+	}
+	for _, stat := range before.BlkioStats.IoServiceBytesRecursive {
+		switch stat.Op {
+		case "Read":
+			read -= min(read, stat.Value)
+		case "Write":
+			write -= min(write, stat.Value)
+		}
+	}
+	return read, write
+}
+
+// BinaryCacheKey names the compiled binary of a script: its CID, or for a URL
+// without one the hash of its content
+func BinaryCacheKey(url string, content []byte) string {
+	if cid := ScriptCID(url); cid != strings.TrimSpace(url) && cidPattern.MatchString(cid) {
+		return cid
+	}
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,28 @@
+package docker
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScriptCID(t *testing.T) {
+	assert.Equal(t, "bafy123", ScriptCID(" https://ipfs.io/ipfs/bafy123 "))
+	assert.Equal(t, "bafy123", ScriptCID("https://x.mypinata.cloud/ipfs/bafy123/code.go?download=1"))
+	assert.Equal(t, "https://example.com/code.go", ScriptCID("https://example.com/code.go"))
+}
+
+func TestBinaryCacheKey(t *testing.T) {
+	content := []byte("package main\n\nfunc main() {}\n")
+
+	// Content behind a CID never changes, so the CID names the binary
+	assert.Equal(t, "bafy123", BinaryCacheKey("https://ipfs.io/ipfs/bafy123", content))
+
+	// Content behind any other URL may, so its hash does
+	key := BinaryCacheKey("https://example.com/code.go", content)
+	assert.Len(t, key, 64)
+	assert.NotEqual(t, key, BinaryCacheKey("https://example.com/code.go", append(content, '\n')))
+
+	// A CID is used as a file name, anything that is not one is hashed
+	assert.Len(t, BinaryCacheKey("https://ipfs.io/ipfs/../../etc", content), 64)
+}
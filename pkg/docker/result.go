@@ -0,0 +1,135 @@
+package docker
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// Version of the result protocol scripts write
+const ResultVersion = 1
+
+// Results larger than this are rejected
+const MaxResultBytes = 1 << 20
+
+// A script writes its result to the file named by ResultFileEnv, in a directory
+// mounted for it alone, so nothing the script or its toolchain prints can be
+// mistaken for its result
+const (
+	ResultFileEnv  = "TRIGGERX_RESULT_FILE"
+	resultDir      = "/triggerx"
+	resultFileName = "result.json"
+)
+
+// ScriptResult is what a dynamic arguments script reports:
+//
+//	{
+//	  "version": 1,
+//	  "arguments": [{"type": "uint256", "value": "1000000000000000000"}, {"type": "address", "value": "0x..."}],
+//	  "skip": false,
+//	  "reason": "",
+//	  "error": "",
+//	  "logs": ["price is 3120.55"]
+//	}
+//
+// A script either fails with an error, asks for the task to be skipped, or gives
+// the arguments of the target function.
+type ScriptResult struct {
+	Version   int        `json:"version"`
+	Arguments []Argument `json:"arguments,omitempty"`
+	Skip      bool       `json:"skip,omitempty"`   // The task should not act this time
+	Reason    string     `json:"reason,omitempty"` // Why it skips
+	Error     string     `json:"error,omitempty"`  // Why the script failed
+	Logs      []string   `json:"logs,omitempty"`
+}
+
+// Argument is an argument of the target function, with its ABI type. Integers
+// are given as decimal or 0x strings so they keep their precision.
+type Argument struct {
+	Type  string          `json:"type"`
+	Value json.RawMessage `json:"value"`
+}
+
+// ParseScriptResult decodes and checks a result a script wrote
+func ParseScriptResult(data []byte) (*ScriptResult, error) {
+	if len(data) > MaxResultBytes {
+		return nil, fmt.Errorf("result is larger than %d bytes", MaxResultBytes)
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	var result ScriptResult
+	if err := decoder.Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to decode result: %w", err)
+	}
+	if decoder.More() {
+		return nil, errors.New("result has data after its JSON object")
+	}
+
+	if result.Version != ResultVersion {
+		return nil, fmt.Errorf("unsupported result version %d", result.Version)
+	}
+	if (result.Error != "" || result.Skip) && len(result.Arguments) > 0 {
+		return nil, errors.New("a result that fails or skips has no arguments")
+	}
+	if result.Error != "" && result.Skip {
+		return nil, errors.New("a result cannot both fail and skip")
+	}
+	for i, argument := range result.Arguments {
+		if argument.Type == "" {
+			return nil, fmt.Errorf("argument %d has no type", i)
+		}
+		if len(argument.Value) == 0 {
+			return nil, fmt.Errorf("argument %d has no value", i)
+		}
+	}
+	return &result, nil
+}
+
+// readScriptResult takes the result a script wrote in dir as the output of its
+// execution, and what it printed as its logs. Scripts that write no result are
+// left with what they printed as their output.
+func readScriptResult(result *ExecutionResult, dir string) {
+	data, err := os.ReadFile(filepath.Join(dir, resultFileName))
+	if errors.Is(err, os.ErrNotExist) {
+		return
+	}
+
+	result.Logs = result.Output
+	result.Output = ""
+	if err != nil {
+		result.Success = false
+		result.Error = fmt.Errorf("failed to read script result: %w", err)
+		return
+	}
+	parsed, err := ParseScriptResult(data)
+	if err != nil {
+		result.Success = false
+		result.Error = fmt.Errorf("invalid script result: %w", err)
+		return
+	}
+
+	result.Output = string(data)
+	result.Result = parsed
+	if parsed.Error != "" {
+		result.Success = false
+		result.Error = fmt.Errorf("script failed: %s", parsed.Error)
+	}
+}
+
+// newResultDir creates the host directory mounted at resultDir, writable by the
+// sandbox user
+func newResultDir(pattern string) (string, error) {
+	dir, err := os.MkdirTemp("", pattern)
+	if err != nil {
+		return "", fmt.Errorf("failed to create result directory: %w", err)
+	}
+	if err := os.Chmod(dir, 0777); err != nil {
+		_ = os.RemoveAll(dir)
+		return "", fmt.Errorf("failed to create result directory: %w", err)
+	}
+	return dir, nil
+}
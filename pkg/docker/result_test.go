@@ -0,0 +1,62 @@
+package docker
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseScriptResult(t *testing.T) {
+	result, err := ParseScriptResult([]byte(`{"version": 1, "arguments": [{"type": "uint256", "value": "1000000000000000000000"}], "logs": ["fetched price"]}`))
+	require.NoError(t, err)
+	require.Len(t, result.Arguments, 1)
+	assert.Equal(t, "uint256", result.Arguments[0].Type)
+	assert.JSONEq(t, `"1000000000000000000000"`, string(result.Arguments[0].Value))
+
+	result, err = ParseScriptResult([]byte(`{"version": 1, "skip": true, "reason": "rewards below gas"}`))
+	require.NoError(t, err)
+	assert.True(t, result.Skip)
+
+	invalid := map[string]string{
+		"not json":             `[1, 2]`,
+		"unknown version":      `{"version": 2, "arguments": []}`,
+		"unknown field":        `{"version": 1, "args": []}`,
+		"skip with arguments":  `{"version": 1, "skip": true, "arguments": [{"type": "bool", "value": true}]}`,
+		"error and skip":       `{"version": 1, "skip": true, "error": "boom"}`,
+		"untyped argument":     `{"version": 1, "arguments": [{"value": true}]}`,
+		"trailing output":      `{"version": 1} done`,
+		"argument of no value": `{"version": 1, "arguments": [{"type": "bool"}]}`,
+	}
+	for name, data := range invalid {
+		_, err := ParseScriptResult([]byte(data))
+		assert.Error(t, err, name)
+	}
+}
+
+func TestReadScriptResult(t *testing.T) {
+	dir := t.TempDir()
+
+	// A script that writes no result keeps what it printed as its output
+	result := &ExecutionResult{Success: true, Output: "[42]\n"}
+	readScriptResult(result, dir)
+	assert.Equal(t, "[42]\n", result.Output)
+	assert.Nil(t, result.Result)
+
+	data := `{"version": 1, "arguments": [{"type": "bool", "value": true}]}`
+	require.NoError(t, os.WriteFile(filepath.Join(dir, resultFileName), []byte(data), 0644))
+	result = &ExecutionResult{Success: true, Output: "go: downloading github.com/ethereum/go-ethereum v1.16.0\n"}
+	readScriptResult(result, dir)
+	assert.True(t, result.Success)
+	assert.Equal(t, data, result.Output)
+	assert.Contains(t, result.Logs, "go: downloading")
+	require.NotNil(t, result.Result)
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, resultFileName), []byte(`{"version": 1, "error": "price feed is down"}`), 0644))
+	result = &ExecutionResult{Success: true}
+	readScriptResult(result, dir)
+	assert.False(t, result.Success)
+	assert.ErrorContains(t, result.Error, "price feed is down")
+}
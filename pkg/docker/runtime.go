@@ -0,0 +1,217 @@
+package docker
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"path"
+	"strings"
+)
+
+// Language a dynamic arguments script is written in
+type Language string
+
+const (
+	LanguageGo         Language = "go"
+	LanguageJavaScript Language = "javascript"
+	LanguageTypeScript Language = "typescript"
+	LanguagePython     Language = "python"
+	LanguageWASM       Language = "wasm"
+)
+
+// Runtime is how scripts of one language are run. Performer and attesters must
+// run a script on the same image to get the same result, so containers are only
+// ever created from the image's digest, which every keeper configures.
+type Runtime struct {
+	Language  Language
+	Image     string // Repository and exact version tag
+	Digest    string // Digest the image is pinned to, required to run scripts
+	EntryFile string // Name the script is saved as in /code
+
+	SetupScript     string // Runs the script in a fresh container
+	BuildScript     string // Compiles the script into $SCRIPT_BIN in the pool's builder, if it is compiled
+	PooledRunScript string // Runs the script in a warm container, $SCRIPT_BIN is its cache entry
+	WarmScript      string // Fills a pool's caches on start
+	Env             []string
+}
+
+const (
+	nodeSetupScript = `#!/bin/sh
+cd /code
+echo "START_EXECUTION"
+node --no-warnings %s 2>&1 || {
+    echo "Error executing script. Exit code: $?"
+    exit 1
+}
+echo "END_EXECUTION"
+`
+
+	pythonSetupScript = `#!/bin/sh
+cd /code
+echo "START_EXECUTION"
+python3 -B code.py 2>&1 || {
+    echo "Error executing script. Exit code: $?"
+    exit 1
+}
+echo "END_EXECUTION"
+`
+
+	// WASM modules run under Node's WASI, with no access to the filesystem
+	wasmSetupScript = `#!/bin/sh
+cd /code
+cat > /tmp/run-wasm.cjs <<'EOF'
+const { readFileSync } = require('node:fs');
+const { WASI } = require('node:wasi');
+const wasi = new WASI({ version: 'preview1', args: ['code.wasm'], env: {} });
+const module = new WebAssembly.Module(readFileSync('/code/code.wasm'));
+wasi.start(new WebAssembly.Instance(module, wasi.getImportObject()));
+EOF
+echo "START_EXECUTION"
+node --no-warnings /tmp/run-wasm.cjs 2>&1 || {
+    echo "Error executing script. Exit code: $?"
+    exit 1
+}
+echo "END_EXECUTION"
+`
+)
+
+// DefaultRuntimes are the runtimes scripts can be written for
+func DefaultRuntimes() map[Language]Runtime {
+	return map[Language]Runtime{
+		LanguageGo: {
+			Language:        LanguageGo,
+			Image:           "golang:1.24.5-bookworm",
+			EntryFile:       "code.go",
+			SetupScript:     SetupScript,
+			BuildScript:     BuildScript,
+			PooledRunScript: PooledRunScript,
+			WarmScript:      WarmScript,
+			Env: []string{
+				"GOMODCACHE=" + poolModCacheDir,
+				"GOCACHE=" + poolBuildCacheDir,
+				"GOFLAGS=-mod=mod",
+			},
+		},
+		LanguageJavaScript: {
+			Language:        LanguageJavaScript,
+			Image:           "node:22.17.0-bookworm-slim",
+			EntryFile:       "code.js",
+			SetupScript:     fmt.Sprintf(nodeSetupScript, "code.js"),
+			PooledRunScript: fmt.Sprintf(nodeSetupScript, "code.js"),
+		},
+		LanguageTypeScript: {
+			Language:        LanguageTypeScript,
+			Image:           "node:22.17.0-bookworm-slim",
+			EntryFile:       "code.ts",
+			SetupScript:     fmt.Sprintf(nodeSetupScript, "--experimental-strip-types code.ts"),
+			PooledRunScript: fmt.Sprintf(nodeSetupScript, "--experimental-strip-types code.ts"),
+		},
+		LanguagePython: {
+			Language:        LanguagePython,
+			Image:           "python:3.12.11-slim-bookworm",
+			EntryFile:       "code.py",
+			SetupScript:     pythonSetupScript,
+			PooledRunScript: pythonSetupScript,
+		},
+		LanguageWASM: {
+			Language:        LanguageWASM,
+			Image:           "node:22.17.0-bookworm-slim",
+			EntryFile:       "code.wasm",
+			SetupScript:     wasmSetupScript,
+			PooledRunScript: wasmSetupScript,
+		},
+	}
+}
+
+// Languages by the extension of a script's file name
+var extensionLanguages = map[string]Language{
+	".go":   LanguageGo,
+	".js":   LanguageJavaScript,
+	".cjs":  LanguageJavaScript,
+	".mjs":  LanguageJavaScript,
+	".ts":   LanguageTypeScript,
+	".py":   LanguagePython,
+	".wasm": LanguageWASM,
+}
+
+var wasmMagic = []byte{0x00, 'a', 's', 'm'}
+
+// ScriptManifest names the runtime of a script whose URL does not tell it, such
+// as a bare IPFS CID. A manifest is fetched in place of the script, and points
+// to it.
+type ScriptManifest struct {
+	Runtime Language `json:"runtime"`
+	Source  string   `json:"source"`
+}
+
+// Script is a dynamic arguments script ready to be run
+type Script struct {
+	Ref     string // URL the script was asked for, its manifest's if it has one
+	URL     string
+	Content []byte
+	Runtime Runtime
+}
+
+// ResolveScript fetches the script url names by CID, following it if it is a manifest,
+// and picks its runtime: from the manifest, the file extension, or the content.
+// Scripts that give no hint are Go, as they have always been.
+func (e *CodeExecutor) ResolveScript(ctx context.Context, url string) (*Script, error) {
+	ref := url
+	content, err := e.Downloader.DownloadScript(ctx, url)
+	if err != nil {
+		return nil, err
+	}
+
+	language := languageOf(url, content)
+	if manifest, ok := parseManifest(content); ok {
+		url = manifest.Source
+		if content, err = e.Downloader.DownloadScript(ctx, url); err != nil {
+			return nil, fmt.Errorf("failed to download manifest source: %w", err)
+		}
+		language = manifest.Runtime
+	}
+
+	runtime, ok := e.config.Runtimes[language]
+	if !ok {
+		return nil, fmt.Errorf("unsupported script runtime: %s", language)
+	}
+	return &Script{Ref: ref, URL: url, Content: content, Runtime: runtime}, nil
+}
+
+func languageOf(url string, content []byte) Language {
+	name := url
+	if i := strings.IndexAny(name, "?#"); i >= 0 {
+		name = name[:i]
+	}
+	if language, ok := extensionLanguages[strings.ToLower(path.Ext(name))]; ok {
+		return language
+	}
+	if bytes.HasPrefix(content, wasmMagic) {
+		return LanguageWASM
+	}
+	return LanguageGo
+}
+
+func parseManifest(content []byte) (ScriptManifest, bool) {
+	trimmed := bytes.TrimSpace(content)
+	if len(trimmed) == 0 || trimmed[0] != '{' {
+		return ScriptManifest{}, false
+	}
+	var manifest ScriptManifest
+	if err := json.Unmarshal(trimmed, &manifest); err != nil || manifest.Runtime == "" || manifest.Source == "" {
+		return ScriptManifest{}, false
+	}
+	return manifest, true
+}
+
+// repository is an image reference without its tag or digest
+func repository(image string) string {
+	if i := strings.Index(image, "@"); i >= 0 {
+		image = image[:i]
+	}
+	if i := strings.LastIndex(image, ":"); i > strings.LastIndex(image, "/") {
+		image = image[:i]
+	}
+	return image
+}
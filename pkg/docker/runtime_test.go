@@ -0,0 +1,98 @@
+package docker
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/trigg3rX/triggerx-backend-imua/pkg/logging"
+)
+
+type MockLogger struct{}
+
+func (l *MockLogger) Debug(msg string, tags ...any)               {}
+func (l *MockLogger) Info(msg string, tags ...any)                {}
+func (l *MockLogger) Warn(msg string, tags ...any)                {}
+func (l *MockLogger) Error(msg string, tags ...any)               {}
+func (l *MockLogger) Fatal(msg string, tags ...any)               {}
+func (l *MockLogger) Debugf(template string, args ...interface{}) {}
+func (l *MockLogger) Infof(template string, args ...interface{})  {}
+func (l *MockLogger) Warnf(template string, args ...interface{})  {}
+func (l *MockLogger) Errorf(template string, args ...interface{}) {}
+func (l *MockLogger) Fatalf(template string, args ...interface{}) {}
+func (l *MockLogger) With(tags ...any) logging.Logger             { return l }
+
+func TestLanguageOf(t *testing.T) {
+	tests := []struct {
+		url      string
+		content  string
+		expected Language
+	}{
+		{"https://example.com/price.ts", "", LanguageTypeScript},
+		{"https://example.com/price.mjs?v=2", "", LanguageJavaScript},
+		{"https://example.com/price.PY", "", LanguagePython},
+		{"https://ipfs.io/ipfs/bafy123", "\x00asm\x01\x00\x00\x00", LanguageWASM},
+		// Scripts that give no hint are Go
+		{"https://ipfs.io/ipfs/bafy123", "package main", LanguageGo},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.expected, languageOf(tt.url, []byte(tt.content)), tt.url)
+	}
+}
+
+func TestResolveScriptFollowsManifest(t *testing.T) {
+	blocks := map[string][]byte{}
+	source := rawBlock(blocks, "print(42)")
+	manifest := rawBlock(blocks, `{"runtime": "python", "source": "ipfs://`+source.String()+`"}`)
+	gateway := newFakeGateway(t, blocks)
+
+	executor := &CodeExecutor{Downloader: newTestDownloader(t, gateway.URL), config: DefaultConfig(), logger: &MockLogger{}}
+
+	script, err := executor.ResolveScript(context.Background(), "https://ipfs.io/ipfs/"+manifest.String())
+	require.NoError(t, err)
+	assert.Equal(t, LanguagePython, script.Runtime.Language)
+	assert.Equal(t, "code.py", script.Runtime.EntryFile)
+	assert.Equal(t, "print(42)", string(script.Content))
+	// Compiled and cached under the source's CID, not the manifest's
+	assert.Equal(t, source.String(), BinaryCacheKey(script.URL, script.Content))
+}
+
+func TestDefaultRuntimesPinVersions(t *testing.T) {
+	for language, runtime := range DefaultRuntimes() {
+		assert.Equal(t, language, runtime.Language)
+		assert.NotContains(t, runtime.Image, ":latest", language)
+		assert.NotEmpty(t, runtime.EntryFile, language)
+		assert.Contains(t, runtime.SetupScript, "START_EXECUTION", language)
+		assert.Contains(t, runtime.PooledRunScript, "START_EXECUTION", language)
+	}
+}
+
+func TestPooledScriptsOnlyRunBinaries(t *testing.T) {
+	// Scripts run where the binary cache is read-only, only the builder compiles
+	runtime := DefaultRuntimes()[LanguageGo]
+	assert.Contains(t, runtime.BuildScript, "go build")
+	assert.NotContains(t, runtime.PooledRunScript, "go build")
+	assert.NotContains(t, runtime.PooledRunScript, "go mod")
+}
+
+func TestRepository(t *testing.T) {
+	assert.Equal(t, "node", repository("node:22.17.0-bookworm-slim"))
+	assert.Equal(t, "golang", repository("golang@sha256:abc"))
+	assert.Equal(t, "registry:5000/team/runner", repository("registry:5000/team/runner:1.0"))
+	assert.Equal(t, "registry:5000/team/runner", repository("registry:5000/team/runner"))
+}
+
+func TestPinRuntimes(t *testing.T) {
+	digest := "sha256:" + strings.Repeat("ab", 32)
+	cfg := DefaultConfig()
+	require.NoError(t, cfg.PinRuntimes("go="+digest+", python = "+digest))
+	assert.Equal(t, digest, cfg.Runtimes[LanguageGo].Digest)
+	assert.Equal(t, digest, cfg.Runtimes[LanguagePython].Digest)
+	assert.Empty(t, cfg.Runtimes[LanguageJavaScript].Digest)
+
+	assert.Error(t, cfg.PinRuntimes("cobol="+digest))
+	assert.Error(t, cfg.PinRuntimes("go=sha256:abc"))
+	assert.Error(t, cfg.PinRuntimes(digest))
+}
@@ -0,0 +1,211 @@
+package docker
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/network"
+	"github.com/docker/docker/client"
+	"github.com/trigg3rX/triggerx-backend-imua/pkg/types"
+)
+
+// How a script container reaches the network
+type NetworkMode string
+
+const (
+	NetworkOpen  NetworkMode = ""      // Docker's default bridge
+	NetworkNone  NetworkMode = "none"  // No network at all
+	NetworkProxy NetworkMode = "proxy" // Only through the egress proxy
+)
+
+// Network scripts are isolated on when their egress goes through the proxy
+const sandboxNetworkName = "triggerx-sandbox"
+
+// SandboxConfig hardens the containers user scripts run in. The zero value
+// leaves containers as Docker creates them.
+type SandboxConfig struct {
+	User           string // User scripts run as, "uid:gid"
+	ReadOnlyRootfs bool   // Only /code and a tmpfs at /tmp are writable
+	TmpfsSizeMB    int
+	PidsLimit      int64
+	NoFileLimit    int64  // Open files
+	Runtime        string // OCI runtime, "runsc" to run under gVisor
+	Network        NetworkPolicy
+}
+
+type NetworkPolicy struct {
+	Mode         NetworkMode
+	AllowedHosts []string // Hosts or *.domain patterns the proxy lets through, none if empty
+	ProxyPort    int
+	// Hosts tunnelled without being recorded, whose content scripts verify
+	// themselves, like Go modules against go.sum
+	PassthroughHosts []string
+}
+
+func DefaultSandboxConfig() SandboxConfig {
+	return SandboxConfig{
+		User:           "65534:65534",
+		ReadOnlyRootfs: true,
+		TmpfsSizeMB:    512,
+		PidsLimit:      256,
+		NoFileLimit:    1024,
+		Network: NetworkPolicy{
+			Mode:             NetworkProxy,
+			ProxyPort:        3128,
+			PassthroughHosts: []string{"proxy.golang.org", "sum.golang.org"},
+		},
+	}
+}
+
+// Hardened reports whether containers run with the sandbox's restrictions
+func (s SandboxConfig) Hardened() bool {
+	return s.User != "" || s.ReadOnlyRootfs
+}
+
+// env moves what the runtimes write outside of /code to the tmpfs
+func (s SandboxConfig) env() []string {
+	if !s.Hardened() {
+		return nil
+	}
+	return []string{
+		"HOME=/tmp",
+		"XDG_CACHE_HOME=/tmp/.cache",
+		"GOPATH=/tmp/go",
+		"GOCACHE=/tmp/go-build",
+		"npm_config_cache=/tmp/.npm",
+	}
+}
+
+// applySandbox restricts a script container to the sandbox profile
+func applySandbox(config *container.Config, hostConfig *container.HostConfig, sandbox SandboxConfig, networkName string) {
+	config.User = sandbox.User
+	config.Env = append(sandbox.env(), config.Env...)
+
+	hostConfig.ReadonlyRootfs = sandbox.ReadOnlyRootfs
+	if sandbox.ReadOnlyRootfs {
+		// Not noexec, go run executes the binary it builds from /tmp
+		options := "rw,nosuid,nodev"
+		if sandbox.TmpfsSizeMB > 0 {
+			options += ",size=" + strconv.Itoa(sandbox.TmpfsSizeMB) + "m"
+		}
+		hostConfig.Tmpfs = map[string]string{"/tmp": options}
+	}
+	if sandbox.Hardened() {
+		hostConfig.CapDrop = []string{"ALL"}
+		hostConfig.SecurityOpt = append(hostConfig.SecurityOpt, "no-new-privileges:true")
+	}
+	if sandbox.PidsLimit > 0 {
+		pidsLimit := sandbox.PidsLimit
+		hostConfig.PidsLimit = &pidsLimit
+		hostConfig.Ulimits = append(hostConfig.Ulimits, &container.Ulimit{Name: "nproc", Soft: pidsLimit, Hard: pidsLimit})
+	}
+	if sandbox.NoFileLimit > 0 {
+		hostConfig.Ulimits = append(hostConfig.Ulimits, &container.Ulimit{Name: "nofile", Soft: sandbox.NoFileLimit, Hard: sandbox.NoFileLimit})
+	}
+	hostConfig.Runtime = sandbox.Runtime
+
+	switch sandbox.Network.Mode {
+	case NetworkNone:
+		hostConfig.NetworkMode = "none"
+	case NetworkProxy:
+		hostConfig.NetworkMode = container.NetworkMode(networkName)
+	}
+}
+
+// EgressSession starts recording the requests of an execution, and returns the
+// environment that sends them through the proxy. Both are nil if scripts do not
+// go through the proxy.
+func (m *Manager) EgressSession(ctx context.Context) (*EgressSession, []string, error) {
+	if m.config.Sandbox.Network.Mode != NetworkProxy {
+		return nil, nil, nil
+	}
+	if err := m.ensureSandboxNetwork(ctx); err != nil {
+		return nil, nil, err
+	}
+	session := m.egress.Session()
+	return session, session.Env(m.egress.Addr()), nil
+}
+
+// ReplaySession starts an execution answered from recorded exchanges. Replays
+// need the proxy, so fail if scripts do not go through it.
+func (m *Manager) ReplaySession(ctx context.Context, exchanges []types.HTTPExchange) (*EgressSession, []string, error) {
+	if m.config.Sandbox.Network.Mode != NetworkProxy {
+		return nil, nil, fmt.Errorf("replaying a script needs the %s network mode", NetworkProxy)
+	}
+	if err := m.ensureSandboxNetwork(ctx); err != nil {
+		return nil, nil, err
+	}
+	session := m.egress.ReplaySession(exchanges)
+	return session, session.Env(m.egress.Addr()), nil
+}
+
+// ensureSandboxNetwork creates the internal network script containers are put on,
+// and starts the egress proxy on its gateway, the one address they can reach
+func (m *Manager) ensureSandboxNetwork(ctx context.Context) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.egress != nil {
+		return nil
+	}
+
+	inspect, err := m.Cli.NetworkInspect(ctx, sandboxNetworkName, network.InspectOptions{})
+	if client.IsErrNotFound(err) {
+		if _, err := m.Cli.NetworkCreate(ctx, sandboxNetworkName, network.CreateOptions{
+			Driver:   "bridge",
+			Internal: true,
+			Labels:   map[string]string{"triggerx.sandbox": "true"},
+		}); err != nil {
+			return fmt.Errorf("failed to create sandbox network: %w", err)
+		}
+		inspect, err = m.Cli.NetworkInspect(ctx, sandboxNetworkName, network.InspectOptions{})
+	}
+	if err != nil {
+		return fmt.Errorf("failed to inspect sandbox network: %w", err)
+	}
+	if !inspect.Internal {
+		return fmt.Errorf("sandbox network %s is not internal", sandboxNetworkName)
+	}
+	if len(inspect.IPAM.Config) == 0 || inspect.IPAM.Config[0].Gateway == "" {
+		return fmt.Errorf("sandbox network %s has no gateway", sandboxNetworkName)
+	}
+
+	addr := net.JoinHostPort(inspect.IPAM.Config[0].Gateway, strconv.Itoa(m.config.Sandbox.Network.ProxyPort))
+	proxy, err := NewEgressProxy(addr, m.config.Sandbox.Network, m.logger)
+	if err != nil {
+		return err
+	}
+
+	// Script containers mount the proxy's CA to trust the certificates it issues
+	caDir, err := os.MkdirTemp("", "egress-ca")
+	if err == nil {
+		err = os.Chmod(caDir, 0755)
+	}
+	if err == nil {
+		err = os.WriteFile(filepath.Join(caDir, filepath.Base(egressCAFile)), proxy.CACertificate(), 0644)
+	}
+	if err != nil {
+		_ = proxy.Close()
+		_ = os.RemoveAll(caDir)
+		return fmt.Errorf("failed to write egress CA certificate: %w", err)
+	}
+
+	m.egress = proxy
+	m.egressCADir = caDir
+	m.logger.Infof("script egress goes through proxy at %s", addr)
+	return nil
+}
+
+// sandboxBinds are the mounts script containers need for the sandbox network
+func (m *Manager) sandboxBinds() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.egressCADir == "" {
+		return nil
+	}
+	return []string{fmt.Sprintf("%s:%s:ro", m.egressCADir, egressCADir)}
+}
@@ -0,0 +1,252 @@
+package docker
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApplySandbox(t *testing.T) {
+	config := &container.Config{Env: []string{"GOCACHE=/cache/build"}}
+	hostConfig := &container.HostConfig{}
+	applySandbox(config, hostConfig, DefaultSandboxConfig(), sandboxNetworkName)
+
+	assert.Equal(t, "65534:65534", config.User)
+	// The runtime's own environment wins over the sandbox's
+	assert.Equal(t, "GOCACHE=/cache/build", config.Env[len(config.Env)-1])
+	assert.True(t, hostConfig.ReadonlyRootfs)
+	assert.Equal(t, "rw,nosuid,nodev,size=512m", hostConfig.Tmpfs["/tmp"])
+	assert.Equal(t, []string{"ALL"}, []string(hostConfig.CapDrop))
+	assert.Contains(t, hostConfig.SecurityOpt, "no-new-privileges:true")
+	require.NotNil(t, hostConfig.PidsLimit)
+	assert.Equal(t, int64(256), *hostConfig.PidsLimit)
+	assert.Len(t, hostConfig.Ulimits, 2)
+	assert.Equal(t, container.NetworkMode(sandboxNetworkName), hostConfig.NetworkMode)
+
+	// The zero profile leaves the container alone
+	config, hostConfig = &container.Config{}, &container.HostConfig{}
+	applySandbox(config, hostConfig, SandboxConfig{}, sandboxNetworkName)
+	assert.Empty(t, config.User)
+	assert.False(t, hostConfig.ReadonlyRootfs)
+	assert.Empty(t, hostConfig.CapDrop)
+	assert.Nil(t, hostConfig.PidsLimit)
+	assert.Equal(t, container.NetworkMode(""), hostConfig.NetworkMode)
+}
+
+// proxiedClient is a script's HTTP client, trusting the proxy's CA
+func proxiedClient(t *testing.T, proxy *EgressProxy, session *EgressSession) *http.Client {
+	proxyURL, err := url.Parse(strings.TrimPrefix(session.Env(proxy.Addr())[0], "HTTP_PROXY="))
+	require.NoError(t, err)
+	roots := x509.NewCertPool()
+	require.True(t, roots.AppendCertsFromPEM(proxy.CACertificate()))
+	return &http.Client{Transport: &http.Transport{
+		Proxy:           http.ProxyURL(proxyURL),
+		TLSClientConfig: &tls.Config{RootCAs: roots},
+	}}
+}
+
+// trustUpstream has the proxy trust the test server in place of the system roots
+func trustUpstream(proxy *EgressProxy, server *httptest.Server) {
+	proxy.transport.TLSClientConfig = server.Client().Transport.(*http.Transport).TLSClientConfig
+}
+
+// newTestEgressProxy starts a proxy that can reach the test servers on loopback
+func newTestEgressProxy(t *testing.T, policy NetworkPolicy) *EgressProxy {
+	proxy, err := NewEgressProxy("127.0.0.1:0", policy, &MockLogger{})
+	require.NoError(t, err)
+	proxy.allowLocal = true
+	t.Cleanup(func() { _ = proxy.Close() })
+	return proxy
+}
+
+func get(t *testing.T, client *http.Client, url string) (int, string) {
+	resp, err := client.Get(url)
+	require.NoError(t, err)
+	body, _ := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	return resp.StatusCode, string(body)
+}
+
+func TestEgressProxyLogsRequestsPerSession(t *testing.T) {
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("price=42"))
+	}))
+	defer target.Close()
+	tlsTarget := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("secure"))
+	}))
+	defer tlsTarget.Close()
+
+	proxy := newTestEgressProxy(t, NetworkPolicy{AllowedHosts: []string{"127.0.0.1"}})
+	trustUpstream(proxy, tlsTarget)
+
+	session := proxy.Session()
+	client := proxiedClient(t, proxy, session)
+
+	_, body := get(t, client, target.URL+"/price")
+	assert.Equal(t, "price=42", body)
+	_, body = get(t, client, tlsTarget.URL+"/secure")
+	assert.Equal(t, "secure", body)
+
+	requests := session.Close()
+	require.Len(t, requests, 2)
+	assert.Equal(t, http.MethodGet, requests[0].Method)
+	assert.Equal(t, target.URL+"/price", requests[0].URL)
+	assert.True(t, requests[0].Allowed)
+	// HTTPS is intercepted, so its URL is known too
+	assert.Equal(t, http.MethodGet, requests[1].Method)
+	assert.Equal(t, tlsTarget.URL+"/secure", requests[1].URL)
+
+	// A closed session can no longer use the proxy
+	status, _ := get(t, client, target.URL)
+	assert.Equal(t, http.StatusProxyAuthRequired, status)
+}
+
+func TestEgressProxyRecordsAndReplays(t *testing.T) {
+	hits := 0
+	tlsTarget := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits++
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("X-Hit", fmt.Sprint(hits))
+		_, _ = fmt.Fprintf(w, "%s %s %s", r.Method, r.URL.Path, body)
+	}))
+	defer tlsTarget.Close()
+
+	proxy := newTestEgressProxy(t, NetworkPolicy{AllowedHosts: []string{"127.0.0.1"}})
+	trustUpstream(proxy, tlsTarget)
+
+	script := func(client *http.Client) []string {
+		_, first := get(t, client, tlsTarget.URL+"/price")
+		resp, err := client.Post(tlsTarget.URL+"/quote", "application/json", strings.NewReader(`{"amount":1}`))
+		require.NoError(t, err)
+		second, _ := io.ReadAll(resp.Body)
+		_ = resp.Body.Close()
+		return []string{first, string(second), resp.Header.Get("X-Hit")}
+	}
+
+	session := proxy.Session()
+	recorded := script(proxiedClient(t, proxy, session))
+	session.Close()
+	exchanges := session.Exchanges()
+	require.Len(t, exchanges, 2)
+	assert.Equal(t, tlsTarget.URL+"/quote", exchanges[1].URL)
+	assert.Equal(t, `{"amount":1}`, string(exchanges[1].RequestBody))
+
+	// The replay gets the recorded responses without reaching the server
+	replay := proxy.ReplaySession(exchanges)
+	client := proxiedClient(t, proxy, replay)
+	assert.Equal(t, recorded, script(client))
+	assert.Equal(t, 2, hits)
+	assert.NoError(t, replay.Err())
+
+	// Requests the recording has no response for fail, and are reported
+	status, _ := get(t, client, tlsTarget.URL+"/price")
+	assert.Equal(t, http.StatusBadGateway, status)
+	assert.Error(t, replay.Err())
+	replay.Close()
+}
+
+func TestEgressProxyTunnelsPassthroughHosts(t *testing.T) {
+	tlsTarget := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("module"))
+	}))
+	defer tlsTarget.Close()
+
+	proxy := newTestEgressProxy(t, NetworkPolicy{PassthroughHosts: []string{"127.0.0.1"}})
+
+	session := proxy.Session()
+	client := proxiedClient(t, proxy, session)
+	// The server's own certificate reaches the client
+	client.Transport.(*http.Transport).TLSClientConfig = tlsTarget.Client().Transport.(*http.Transport).TLSClientConfig
+	_, body := get(t, client, tlsTarget.URL)
+	assert.Equal(t, "module", body)
+
+	requests := session.Close()
+	require.Len(t, requests, 1)
+	assert.Equal(t, http.MethodConnect, requests[0].Method)
+	assert.Empty(t, session.Exchanges())
+}
+
+func TestEgressProxyEnforcesAllowList(t *testing.T) {
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer target.Close()
+
+	proxy, err := NewEgressProxy("127.0.0.1:0", NetworkPolicy{AllowedHosts: []string{"*.coingecko.com", "api.binance.com"}}, &MockLogger{})
+	require.NoError(t, err)
+	defer func() { _ = proxy.Close() }()
+
+	assert.True(t, proxy.isAllowed("pro-api.coingecko.com:443"))
+	assert.True(t, proxy.isAllowed("API.binance.com"))
+	assert.False(t, proxy.isAllowed("coingecko.com.attacker.io"))
+
+	session := proxy.Session()
+	resp, err := proxiedClient(t, proxy, session).Get(target.URL)
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+
+	requests := session.Close()
+	require.Len(t, requests, 1)
+	assert.False(t, requests[0].Allowed)
+}
+
+func TestEgressProxyDeniesByDefault(t *testing.T) {
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("metadata"))
+	}))
+	defer target.Close()
+
+	// No host is allowed without an allow list
+	proxy := newTestEgressProxy(t, DefaultSandboxConfig().Network)
+	assert.False(t, proxy.isAllowed("api.coingecko.com"))
+	assert.True(t, proxy.isAllowed("proxy.golang.org:443"))
+
+	session := proxy.Session()
+	status, _ := get(t, proxiedClient(t, proxy, session), target.URL)
+	assert.Equal(t, http.StatusForbidden, status)
+	session.Close()
+}
+
+func TestEgressProxyRefusesInternalAddresses(t *testing.T) {
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("keeper"))
+	}))
+	defer target.Close()
+	tlsTarget := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer tlsTarget.Close()
+
+	// Allowed hosts are still not reached on the keeper's host
+	proxy, err := NewEgressProxy("127.0.0.1:0", NetworkPolicy{AllowedHosts: []string{"127.0.0.1", "localhost"}, PassthroughHosts: []string{"localhost"}}, &MockLogger{})
+	require.NoError(t, err)
+	defer func() { _ = proxy.Close() }()
+
+	session := proxy.Session()
+	client := proxiedClient(t, proxy, session)
+	status, body := get(t, client, target.URL)
+	assert.Equal(t, http.StatusBadGateway, status)
+	assert.Contains(t, body, "internal address")
+
+	// Nor through a tunnel
+	_, err = client.Get(strings.Replace(tlsTarget.URL, "127.0.0.1", "localhost", 1))
+	assert.Error(t, err)
+	session.Close()
+
+	for ip, internal := range map[string]bool{
+		"127.0.0.1": true, "10.1.2.3": true, "172.16.0.1": true, "192.168.1.1": true,
+		"169.254.169.254": true, "0.0.0.0": true, "100.64.0.1": true, "::1": true, "fe80::1": true, "fd00::1": true,
+		"8.8.8.8": false, "2606:4700:4700::1111": false,
+	} {
+		assert.Equal(t, internal, isInternalIP(net.ParseIP(ip)), ip)
+	}
+}
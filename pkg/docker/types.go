@@ -1,6 +1,10 @@
 package docker
 
-import "time"
+import (
+	"time"
+
+	"github.com/trigg3rX/triggerx-backend-imua/pkg/types"
+)
 
 type ResourceStats struct {
 	NoOfAttesters     int           `json:"no_of_attesters"`
@@ -26,9 +30,17 @@ type ResourceStats struct {
 }
 
 type ExecutionResult struct {
-	Stats    ResourceStats `json:"stats"`
-	Output   string        `json:"output"`
-	Success  bool          `json:"success"`
-	Error    error         `json:"error,omitempty"`
-	Warnings []string      `json:"warnings,omitempty"`
+	Stats       ResourceStats `json:"stats"`
+	Output      string        `json:"output"`         // The result the script wrote, or what it printed if it wrote none
+	Logs        string        `json:"logs,omitempty"` // What the script printed, if it wrote a result
+	Result      *ScriptResult `json:"result,omitempty"`
+	Success     bool          `json:"success"`
+	Error       error         `json:"error,omitempty"`
+	Warnings    []string      `json:"warnings,omitempty"`
+	Runtime     Language      `json:"runtime,omitempty"`
+	ImageDigest string        `json:"image_digest,omitempty"` // Image the script ran on
+	// Outbound requests the script made, if its egress went through the proxy
+	NetworkRequests []NetworkRequest `json:"network_requests,omitempty"`
+	// What attesters need to replay the execution, if its requests were recorded
+	Recording *types.ScriptRecording `json:"recording,omitempty"`
 }
@@ -0,0 +1,37 @@
+package email
+
+import (
+	"github.com/go-gomail/gomail"
+)
+
+// SMTP relay emails are sent through
+const (
+	smtpHost = "smtp.zeptomail.in"
+	smtpPort = 587
+)
+
+// Sender sends HTML emails from an account of the SMTP relay
+type Sender struct {
+	from     string
+	password string
+}
+
+func NewSender(from string, password string) *Sender {
+	return &Sender{from: from, password: password}
+}
+
+// Enabled reports whether the sender has an account to send from
+func (s *Sender) Enabled() bool {
+	return s != nil && s.from != ""
+}
+
+func (s *Sender) Send(to, subject, body string) error {
+	m := gomail.NewMessage()
+	m.SetHeader("From", s.from)
+	m.SetHeader("To", to)
+	m.SetHeader("Subject", subject)
+	m.SetBody("text/html", body)
+
+	d := gomail.NewDialer(smtpHost, smtpPort, s.from, s.password)
+	return d.DialAndSend(m)
+}
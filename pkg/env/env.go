@@ -41,6 +41,18 @@ func GetEnvInt(key string, defaultValue int) int {
 	return defaultValue
 }
 
+func GetEnvFloat(key string, defaultValue float64) float64 {
+	if value, exists := os.LookupEnv(key); exists {
+		floatValue, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return defaultValue
+		}
+		return floatValue
+	}
+	fmt.Printf("Environment variable %s not found, using default value: %g\n", key, defaultValue)
+	return defaultValue
+}
+
 func GetEnvDuration(key string, defaultValue time.Duration) time.Duration {
 	if value, exists := os.LookupEnv(key); exists {
 		duration, err := time.ParseDuration(value)
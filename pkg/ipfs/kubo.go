@@ -0,0 +1,90 @@
+package ipfs
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// KuboStore keeps content on an IPFS node through its Kubo RPC API
+type KuboStore struct {
+	api    string
+	client *http.Client
+}
+
+func NewKuboStore(api string, client *http.Client) *KuboStore {
+	return &KuboStore{api: strings.TrimSuffix(api, "/"), client: client}
+}
+
+func (s *KuboStore) Put(ctx context.Context, name string, data []byte) (string, error) {
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	part, err := writer.CreateFormFile("file", name)
+	if err != nil {
+		return "", fmt.Errorf("failed to create form file: %w", err)
+	}
+	if _, err := part.Write(data); err != nil {
+		return "", fmt.Errorf("failed to write data to form: %w", err)
+	}
+	if err := writer.Close(); err != nil {
+		return "", fmt.Errorf("failed to close writer: %w", err)
+	}
+
+	respBody, err := s.call(ctx, "add", url.Values{"cid-version": {"1"}, "pin": {"true"}}, body, writer.FormDataContentType())
+	if err != nil {
+		return "", fmt.Errorf("failed to add to IPFS node: %w", err)
+	}
+	var response struct {
+		Hash string `json:"Hash"`
+	}
+	if err := json.Unmarshal(respBody, &response); err != nil {
+		return "", fmt.Errorf("failed to unmarshal add response: %w", err)
+	}
+	if response.Hash == "" {
+		return "", fmt.Errorf("add response has no CID")
+	}
+	return response.Hash, nil
+}
+
+func (s *KuboStore) Get(ctx context.Context, cid string) ([]byte, error) {
+	data, err := s.call(ctx, "cat", url.Values{"arg": {cid}}, nil, "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch IPFS content: %w", err)
+	}
+	if err := verifyContent(cid, data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+func (s *KuboStore) Pin(ctx context.Context, cid string) error {
+	if _, err := s.call(ctx, "pin/add", url.Values{"arg": {cid}}, nil, ""); err != nil {
+		return fmt.Errorf("failed to pin %s: %w", cid, err)
+	}
+	return nil
+}
+
+func (s *KuboStore) Unpin(ctx context.Context, cid string) error {
+	if _, err := s.call(ctx, "pin/rm", url.Values{"arg": {cid}}, nil, ""); err != nil {
+		return fmt.Errorf("failed to unpin %s: %w", cid, err)
+	}
+	return nil
+}
+
+// call sends a command to the RPC API, which only takes POST requests
+func (s *KuboStore) call(ctx context.Context, command string, query url.Values, body io.Reader, contentType string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.api+"/api/v0/"+command+"?"+query.Encode(), body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	return do(s.client, req)
+}
@@ -0,0 +1,188 @@
+package ipfs
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ipfs/go-cid"
+)
+
+// MemoryStore keeps content in memory, for tests and development. Content is
+// addressed by its raw block CID, as a node would add it.
+type MemoryStore struct {
+	mu      sync.Mutex
+	content map[string]memoryEntry
+}
+
+type memoryEntry struct {
+	data   []byte
+	pinned Pinned
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{content: make(map[string]memoryEntry)}
+}
+
+func (s *MemoryStore) Put(ctx context.Context, name string, data []byte) (string, error) {
+	c, err := ComputeCID(data)
+	if err != nil {
+		return "", err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.content[c] = memoryEntry{
+		data:   append([]byte(nil), data...),
+		pinned: Pinned{CID: c, Name: name, Size: int64(len(data)), CreatedAt: time.Now()},
+	}
+	return c, nil
+}
+
+func (s *MemoryStore) Get(ctx context.Context, cid string) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entry, ok := s.content[cid]
+	if !ok {
+		return nil, fmt.Errorf("content %s not found", cid)
+	}
+	return append([]byte(nil), entry.data...), nil
+}
+
+// Pin only succeeds for content the store has, as it cannot fetch any
+func (s *MemoryStore) Pin(ctx context.Context, cid string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.content[cid]; !ok {
+		return fmt.Errorf("content %s not found", cid)
+	}
+	return nil
+}
+
+func (s *MemoryStore) Unpin(ctx context.Context, cid string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.content[cid]; !ok {
+		return fmt.Errorf("content %s not found", cid)
+	}
+	delete(s.content, cid)
+	return nil
+}
+
+func (s *MemoryStore) List(ctx context.Context) ([]Pinned, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	pinned := make([]Pinned, 0, len(s.content))
+	for _, entry := range s.content {
+		pinned = append(pinned, entry.pinned)
+	}
+	sort.Slice(pinned, func(i, j int) bool { return pinned[i].CID < pinned[j].CID })
+	return pinned, nil
+}
+
+// FileStore keeps content as files named by CID in a directory, standing in for
+// a node where there is none
+type FileStore struct {
+	dir string
+}
+
+func NewFileStore(dir string) (*FileStore, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create store directory: %w", err)
+	}
+	return &FileStore{dir: dir}, nil
+}
+
+func (s *FileStore) Put(ctx context.Context, name string, data []byte) (string, error) {
+	c, err := ComputeCID(data)
+	if err != nil {
+		return "", err
+	}
+	tmp, err := os.CreateTemp(s.dir, ".put-*")
+	if err != nil {
+		return "", fmt.Errorf("failed to store content: %w", err)
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return "", fmt.Errorf("failed to store content: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return "", fmt.Errorf("failed to store content: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), filepath.Join(s.dir, c)); err != nil {
+		return "", fmt.Errorf("failed to store content: %w", err)
+	}
+	return c, nil
+}
+
+func (s *FileStore) Get(ctx context.Context, c string) ([]byte, error) {
+	path, err := s.path(c)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read content %s: %w", c, err)
+	}
+	if err := verifyContent(c, data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// Pin only succeeds for content the store has, as it cannot fetch any
+func (s *FileStore) Pin(ctx context.Context, c string) error {
+	path, err := s.path(c)
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(path); err != nil {
+		return fmt.Errorf("content %s not found: %w", c, err)
+	}
+	return nil
+}
+
+func (s *FileStore) Unpin(ctx context.Context, c string) error {
+	path, err := s.path(c)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil {
+		return fmt.Errorf("failed to remove content %s: %w", c, err)
+	}
+	return nil
+}
+
+func (s *FileStore) List(ctx context.Context) ([]Pinned, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list store directory: %w", err)
+	}
+	var pinned []Pinned
+	for _, entry := range entries {
+		if _, err := cid.Decode(entry.Name()); err != nil || entry.IsDir() {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		pinned = append(pinned, Pinned{CID: entry.Name(), Name: entry.Name(), Size: info.Size(), CreatedAt: info.ModTime()})
+	}
+	return pinned, nil
+}
+
+// path is the file of a CID, which must be one so it cannot name another file
+func (s *FileStore) path(c string) (string, error) {
+	if _, err := cid.Decode(c); err != nil {
+		return "", fmt.Errorf("invalid CID %s: %w", c, err)
+	}
+	if c != filepath.Base(c) {
+		return "", fmt.Errorf("invalid CID %s", c)
+	}
+	return filepath.Join(s.dir, c), nil
+}
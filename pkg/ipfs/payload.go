@@ -0,0 +1,239 @@
+package ipfs
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/klauspost/compress/zstd"
+
+	"github.com/trigg3rX/triggerx-backend-imua/pkg/types"
+)
+
+// Schema versions of proof payloads. Readers accept every version.
+const (
+	// A single IPFSData as plain JSON, uploaded per target
+	SchemaVersionJSON = 1
+	// A zstd compressed Payload of one or more proofs, with ABIs as their own CIDs
+	SchemaVersionEnvelope = 2
+)
+
+// Fields smaller than this are kept inline rather than uploaded as blobs
+const MinBlobBytes = 1024
+
+// Blobs are uploaded again after this long, so content in use is never older
+// than the time a pinning manager holds it for
+const BlobReuploadInterval = time.Hour
+
+var zstdMagic = []byte{0x28, 0xb5, 0x2f, 0xfd}
+
+// Payload is what a proof upload of schema version 2 decompresses to
+type Payload struct {
+	SchemaVersion int              `json:"schema_version"`
+	Proofs        []types.IPFSData `json:"proofs"`
+	Blobs         []BlobRef        `json:"blobs,omitempty"`
+}
+
+// BlobRef names the CID of an ABI left out of a proof. Call 0 is the target's
+// own call, call i its i-th extra target.
+type BlobRef struct {
+	Proof  int    `json:"proof"`
+	Target int    `json:"target"`
+	Call   int    `json:"call"`
+	CID    string `json:"cid"`
+}
+
+// PayloadWriter packs proofs into envelopes, uploading each distinct ABI once
+// per BlobReuploadInterval
+type PayloadWriter struct {
+	store Store
+
+	mu    sync.Mutex
+	blobs map[[32]byte]uploadedBlob // Blobs uploaded, by SHA-256 of their content
+}
+
+type uploadedBlob struct {
+	cid string
+	at  time.Time
+}
+
+func NewPayloadWriter(store Store) *PayloadWriter {
+	return &PayloadWriter{store: store, blobs: make(map[[32]byte]uploadedBlob)}
+}
+
+// Encode uploads the large ABIs of the proofs and returns the compressed
+// envelope referencing them. The proofs given are not modified.
+func (w *PayloadWriter) Encode(ctx context.Context, proofs []types.IPFSData) ([]byte, error) {
+	if len(proofs) == 0 {
+		return nil, errors.New("no proofs to encode")
+	}
+
+	payload := Payload{SchemaVersion: SchemaVersionEnvelope, Proofs: make([]types.IPFSData, len(proofs))}
+	for p, proof := range proofs {
+		if proof.TaskData != nil {
+			task := *proof.TaskData
+			task.TargetData = make([]types.TaskTargetData, len(proof.TaskData.TargetData))
+			for t, target := range proof.TaskData.TargetData {
+				target.ExtraTargets = append([]types.TargetCall(nil), target.ExtraTargets...)
+				abis := []*string{&target.ABI}
+				for i := range target.ExtraTargets {
+					abis = append(abis, &target.ExtraTargets[i].ABI)
+				}
+				for call, abi := range abis {
+					if len(*abi) < MinBlobBytes {
+						continue
+					}
+					cid, err := w.putBlob(ctx, *abi)
+					if err != nil {
+						return nil, err
+					}
+					payload.Blobs = append(payload.Blobs, BlobRef{Proof: p, Target: t, Call: call, CID: cid})
+					*abi = ""
+				}
+				task.TargetData[t] = target
+			}
+			proof.TaskData = &task
+		}
+		payload.Proofs[p] = proof
+	}
+
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal payload: %w", err)
+	}
+	encoder, err := zstd.NewWriter(nil, zstd.WithEncoderLevel(zstd.SpeedBetterCompression))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create zstd encoder: %w", err)
+	}
+	defer func() { _ = encoder.Close() }()
+	return encoder.EncodeAll(data, nil), nil
+}
+
+func (w *PayloadWriter) putBlob(ctx context.Context, blob string) (string, error) {
+	sum := sha256.Sum256([]byte(blob))
+	w.mu.Lock()
+	uploaded, ok := w.blobs[sum]
+	w.mu.Unlock()
+	if ok && time.Since(uploaded.at) < BlobReuploadInterval {
+		return uploaded.cid, nil
+	}
+
+	cid, err := w.store.Put(ctx, fmt.Sprintf("abi_%x.json", sum[:8]), []byte(blob))
+	if err != nil {
+		return "", fmt.Errorf("failed to upload ABI: %w", err)
+	}
+	w.mu.Lock()
+	w.blobs[sum] = uploadedBlob{cid: cid, at: time.Now()}
+	w.mu.Unlock()
+	return cid, nil
+}
+
+// Upload is a proof upload as read from a store
+type Upload struct {
+	Proofs []types.IPFSData
+	Size   int64            // Bytes of the upload itself
+	Blobs  map[string]int64 // Bytes of each blob it references, by CID
+}
+
+// FetchUpload fetches the proofs uploaded under a CID, with the blobs they reference
+func FetchUpload(ctx context.Context, store Store, cid string) (Upload, error) {
+	body, err := store.Get(ctx, cid)
+	if err != nil {
+		return Upload{}, fmt.Errorf("failed to fetch IPFS content: %v", err)
+	}
+	proofs, blobs, err := decodePayload(ctx, store, body)
+	if err != nil {
+		return Upload{}, err
+	}
+	return Upload{Proofs: proofs, Size: int64(len(body)), Blobs: blobs}, nil
+}
+
+// DecodePayload reads proofs of any schema version, fetching the blobs an
+// envelope references from the store
+func DecodePayload(ctx context.Context, store Store, data []byte) ([]types.IPFSData, error) {
+	proofs, _, err := decodePayload(ctx, store, data)
+	return proofs, err
+}
+
+func decodePayload(ctx context.Context, store Store, data []byte) ([]types.IPFSData, map[string]int64, error) {
+	if bytes.HasPrefix(data, zstdMagic) {
+		decoder, err := zstd.NewReader(nil, zstd.WithDecoderMaxMemory(MaxContentBytes))
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to create zstd decoder: %w", err)
+		}
+		defer decoder.Close()
+		if data, err = decoder.DecodeAll(data, nil); err != nil {
+			return nil, nil, fmt.Errorf("failed to decompress payload: %w", err)
+		}
+	}
+
+	var version struct {
+		SchemaVersion int `json:"schema_version"`
+	}
+	if err := json.Unmarshal(data, &version); err != nil {
+		return nil, nil, fmt.Errorf("failed to unmarshal IPFS data: %w", err)
+	}
+
+	switch version.SchemaVersion {
+	case 0, SchemaVersionJSON:
+		// Version 1 proofs are a bare IPFSData, which has no schema version
+		var ipfsData types.IPFSData
+		if err := json.Unmarshal(data, &ipfsData); err != nil {
+			return nil, nil, fmt.Errorf("failed to unmarshal IPFS data: %w", err)
+		}
+		return []types.IPFSData{ipfsData}, nil, nil
+	case SchemaVersionEnvelope:
+		var payload Payload
+		if err := json.Unmarshal(data, &payload); err != nil {
+			return nil, nil, fmt.Errorf("failed to unmarshal payload: %w", err)
+		}
+		blobs, err := restoreBlobs(ctx, store, &payload)
+		if err != nil {
+			return nil, nil, err
+		}
+		if len(payload.Proofs) == 0 {
+			return nil, nil, errors.New("payload has no proofs")
+		}
+		return payload.Proofs, blobs, nil
+	default:
+		return nil, nil, fmt.Errorf("unsupported payload schema version %d", version.SchemaVersion)
+	}
+}
+
+func restoreBlobs(ctx context.Context, store Store, payload *Payload) (map[string]int64, error) {
+	fetched := make(map[string]string)
+	sizes := make(map[string]int64)
+	for _, ref := range payload.Blobs {
+		if ref.Proof < 0 || ref.Proof >= len(payload.Proofs) || payload.Proofs[ref.Proof].TaskData == nil {
+			return nil, fmt.Errorf("blob %s refers to no proof", ref.CID)
+		}
+		targets := payload.Proofs[ref.Proof].TaskData.TargetData
+		if ref.Target < 0 || ref.Target >= len(targets) || ref.Call < 0 || ref.Call > len(targets[ref.Target].ExtraTargets) {
+			return nil, fmt.Errorf("blob %s refers to no target call", ref.CID)
+		}
+
+		blob, ok := fetched[ref.CID]
+		if !ok {
+			data, err := store.Get(ctx, ref.CID)
+			if err != nil {
+				return nil, fmt.Errorf("failed to fetch blob %s: %w", ref.CID, err)
+			}
+			blob = string(data)
+			fetched[ref.CID] = blob
+			sizes[ref.CID] = int64(len(data))
+		}
+
+		target := &targets[ref.Target]
+		if ref.Call == 0 {
+			target.ABI = blob
+		} else {
+			target.ExtraTargets[ref.Call-1].ABI = blob
+		}
+	}
+	return sizes, nil
+}
@@ -0,0 +1,107 @@
+package ipfs
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/trigg3rX/triggerx-backend-imua/pkg/types"
+)
+
+// countingStore counts the content put in a store
+type countingStore struct {
+	*MemoryStore
+	puts int
+}
+
+func (s *countingStore) Put(ctx context.Context, name string, data []byte) (string, error) {
+	s.puts++
+	return s.MemoryStore.Put(ctx, name, data)
+}
+
+func payloadTestProofs() []types.IPFSData {
+	abi := `[{"inputs":[],"name":"execute","outputs":[],"type":"function"}` + strings.Repeat(`,{"name":"padding","type":"event"}`, 40) + `]`
+	proofs := make([]types.IPFSData, 3)
+	for i := range proofs {
+		proofs[i] = types.IPFSData{
+			TaskData: &types.SendTaskDataToKeeper{
+				TaskID: 9,
+				TargetData: []types.TaskTargetData{{
+					TaskID:         9,
+					TargetFunction: "execute",
+					ABI:            abi,
+					ExtraTargets:   []types.TargetCall{{TargetFunction: "execute", ABI: abi}, {TargetFunction: "ping", ABI: "[]"}},
+				}},
+			},
+			ActionData: &types.PerformerActionData{TaskID: 9, ActionTxHash: "0x01"},
+			ProofData:  &types.ProofData{TaskID: 9, ProofOfTask: "0x02"},
+		}
+	}
+	return proofs
+}
+
+func TestPayloadRoundTrip(t *testing.T) {
+	store := &countingStore{MemoryStore: NewMemoryStore()}
+	writer := NewPayloadWriter(store)
+	ctx := context.Background()
+	proofs := payloadTestProofs()
+
+	data, err := writer.Encode(ctx, proofs)
+	require.NoError(t, err)
+	plain, _ := json.Marshal(proofs)
+	assert.Less(t, len(data), len(plain)/10)
+
+	// The ABI shared by every call is uploaded once, the small one stays inline
+	assert.Equal(t, 1, store.puts)
+	assert.Equal(t, proofs[0].TaskData.TargetData[0].ABI, payloadTestProofs()[0].TaskData.TargetData[0].ABI, "proofs given are not modified")
+
+	decoded, err := DecodePayload(ctx, store, data)
+	require.NoError(t, err)
+	assert.Equal(t, proofs, decoded)
+
+	// Later payloads reuse the ABI already uploaded
+	_, err = writer.Encode(ctx, proofs[:1])
+	require.NoError(t, err)
+	assert.Equal(t, 1, store.puts)
+}
+
+func TestDecodePayloadVersions(t *testing.T) {
+	ctx := context.Background()
+	proof := payloadTestProofs()[0]
+
+	// Proofs uploaded before envelopes are a bare IPFSData
+	legacy, _ := json.Marshal(proof)
+	decoded, err := DecodePayload(ctx, NewMemoryStore(), legacy)
+	require.NoError(t, err)
+	assert.Equal(t, []types.IPFSData{proof}, decoded)
+
+	// Envelopes may also be uploaded uncompressed
+	envelope, _ := json.Marshal(Payload{SchemaVersion: SchemaVersionEnvelope, Proofs: []types.IPFSData{proof}})
+	decoded, err = DecodePayload(ctx, NewMemoryStore(), envelope)
+	require.NoError(t, err)
+	assert.Equal(t, []types.IPFSData{proof}, decoded)
+
+	_, err = DecodePayload(ctx, NewMemoryStore(), []byte(`{"schema_version":3}`))
+	assert.ErrorContains(t, err, "unsupported payload schema version 3")
+
+	// A blob the store does not have fails the payload
+	missing, _ := json.Marshal(Payload{
+		SchemaVersion: SchemaVersionEnvelope,
+		Proofs:        []types.IPFSData{proof},
+		Blobs:         []BlobRef{{CID: "bafkreihdwdcefgh4dqkjv67uzcmw7ojee6xedzdetojuzjevtenxquvyku"}},
+	})
+	_, err = DecodePayload(ctx, NewMemoryStore(), missing)
+	assert.ErrorContains(t, err, "failed to fetch blob")
+
+	outOfRange, _ := json.Marshal(Payload{
+		SchemaVersion: SchemaVersionEnvelope,
+		Proofs:        []types.IPFSData{proof},
+		Blobs:         []BlobRef{{Target: 0, Call: 5, CID: "bafkreihdwdcefgh4dqkjv67uzcmw7ojee6xedzdetojuzjevtenxquvyku"}},
+	})
+	_, err = DecodePayload(ctx, NewMemoryStore(), outOfRange)
+	assert.ErrorContains(t, err, "refers to no target call")
+}
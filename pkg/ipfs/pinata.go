@@ -0,0 +1,178 @@
+package ipfs
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// PinataStore keeps content with Pinata's v3 files API
+type PinataStore struct {
+	jwt     string
+	network string
+	gateway string
+	client  *http.Client
+
+	uploadURL string
+	apiURL    string
+}
+
+func NewPinataStore(jwt, network, gateway string, client *http.Client) *PinataStore {
+	if network == "" {
+		network = "public"
+	}
+	return &PinataStore{
+		jwt:       jwt,
+		network:   network,
+		gateway:   gateway,
+		client:    client,
+		uploadURL: "https://uploads.pinata.cloud/v3/files",
+		apiURL:    "https://api.pinata.cloud/v3/files",
+	}
+}
+
+// pinataFile is a file in Pinata's v3 API
+type pinataFile struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	CID       string    `json:"cid"`
+	Size      int64     `json:"size"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (s *PinataStore) Put(ctx context.Context, name string, data []byte) (string, error) {
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	if err := writer.WriteField("network", s.network); err != nil {
+		return "", fmt.Errorf("failed to write network field: %w", err)
+	}
+	part, err := writer.CreateFormFile("file", name)
+	if err != nil {
+		return "", fmt.Errorf("failed to create form file: %w", err)
+	}
+	if _, err := part.Write(data); err != nil {
+		return "", fmt.Errorf("failed to write data to form: %w", err)
+	}
+	if err := writer.Close(); err != nil {
+		return "", fmt.Errorf("failed to close writer: %w", err)
+	}
+
+	req, err := s.request(ctx, http.MethodPost, s.uploadURL, body)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	respBody, err := do(s.client, req)
+	if err != nil {
+		return "", fmt.Errorf("failed to upload to Pinata: %w", err)
+	}
+
+	var response struct {
+		Data pinataFile `json:"data"`
+	}
+	if err := json.Unmarshal(respBody, &response); err != nil {
+		return "", fmt.Errorf("failed to unmarshal Pinata response: %w", err)
+	}
+	if response.Data.CID == "" {
+		return "", fmt.Errorf("pinata response has no CID")
+	}
+	return response.Data.CID, nil
+}
+
+func (s *PinataStore) Get(ctx context.Context, cid string) ([]byte, error) {
+	if s.gateway == "" {
+		return nil, fmt.Errorf("no Pinata gateway configured")
+	}
+	return gatewayGet(ctx, s.client, s.gateway, cid)
+}
+
+func (s *PinataStore) Pin(ctx context.Context, cid string) error {
+	payload, _ := json.Marshal(map[string]string{"cid": cid})
+	req, err := s.request(ctx, http.MethodPost, s.apiURL+"/"+s.network+"/pin_by_cid", bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if _, err := do(s.client, req); err != nil {
+		return fmt.Errorf("failed to pin %s on Pinata: %w", cid, err)
+	}
+	return nil
+}
+
+// Unpin deletes the Pinata file holding the CID
+func (s *PinataStore) Unpin(ctx context.Context, cid string) error {
+	files, err := s.files(ctx, url.Values{"cid": {cid}, "limit": {"1"}})
+	if err != nil {
+		return err
+	}
+	if len(files) == 0 {
+		return fmt.Errorf("no Pinata file found for CID %s", cid)
+	}
+
+	req, err := s.request(ctx, http.MethodDelete, s.apiURL+"/"+s.network+"/"+files[0].ID, nil)
+	if err != nil {
+		return err
+	}
+	if _, err := do(s.client, req); err != nil {
+		return fmt.Errorf("failed to delete Pinata file %s: %w", files[0].ID, err)
+	}
+	return nil
+}
+
+func (s *PinataStore) List(ctx context.Context) ([]Pinned, error) {
+	files, err := s.files(ctx, url.Values{"limit": {"1000"}})
+	if err != nil {
+		return nil, err
+	}
+	pinned := make([]Pinned, 0, len(files))
+	for _, file := range files {
+		pinned = append(pinned, Pinned{CID: file.CID, Name: file.Name, Size: file.Size, CreatedAt: file.CreatedAt})
+	}
+	return pinned, nil
+}
+
+// files lists the files matching query, through all pages
+func (s *PinataStore) files(ctx context.Context, query url.Values) ([]pinataFile, error) {
+	var files []pinataFile
+	for {
+		req, err := s.request(ctx, http.MethodGet, s.apiURL+"/"+s.network+"?"+query.Encode(), nil)
+		if err != nil {
+			return nil, err
+		}
+		body, err := do(s.client, req)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list Pinata files: %w", err)
+		}
+
+		var response struct {
+			Data struct {
+				Files         []pinataFile `json:"files"`
+				NextPageToken string       `json:"next_page_token,omitempty"`
+			} `json:"data"`
+		}
+		if err := json.Unmarshal(body, &response); err != nil {
+			return nil, fmt.Errorf("failed to decode Pinata files: %w", err)
+		}
+		files = append(files, response.Data.Files...)
+
+		if response.Data.NextPageToken == "" || query.Get("cid") != "" {
+			return files, nil
+		}
+		query.Set("pageToken", response.Data.NextPageToken)
+	}
+}
+
+func (s *PinataStore) request(ctx context.Context, method, endpoint string, body io.Reader) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+s.jwt)
+	return req, nil
+}
@@ -0,0 +1,424 @@
+package ipfs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/trigg3rX/triggerx-backend-imua/pkg/logging"
+)
+
+type PinStatus string
+
+const (
+	PinHeld     PinStatus = "held"     // Kept until the challenge window and dispute period end
+	PinDisputed PinStatus = "disputed" // Kept until the dispute is resolved
+	PinArchived PinStatus = "archived" // Unpinned, with a copy in cold storage
+)
+
+// ErrNotPinned is returned for a task with no proofs pinned
+var ErrNotPinned = errors.New("no proofs of task are pinned")
+
+// PinRecord is content the pinning manager keeps, and why
+type PinRecord struct {
+	CID          string    `json:"cid"`
+	TaskID       int64     `json:"task_id"`
+	JobID        int64     `json:"job_id"`
+	UserID       int64     `json:"user_id"`
+	TaskStatus   string    `json:"task_status"`
+	Status       PinStatus `json:"status"`
+	Size         int64     `json:"size"`
+	Blobs        []string  `json:"blobs,omitempty"` // Content the proof references, held with it
+	PinnedAt     time.Time `json:"pinned_at"`
+	ReleaseAfter time.Time `json:"release_after"`
+	ArchivedCID  string    `json:"archived_cid,omitempty"`
+}
+
+// PinRegistry keeps the pin records
+type PinRegistry interface {
+	Save(ctx context.Context, record PinRecord) error
+	// Get returns nil for content not tracked
+	Get(ctx context.Context, cid string) (*PinRecord, error)
+	Delete(ctx context.Context, cid string) error
+	// Due returns the held records whose hold ended by the time given
+	Due(ctx context.Context, at time.Time) ([]PinRecord, error)
+	// ByTask returns the records owned by a task
+	ByTask(ctx context.Context, taskID int64) ([]PinRecord, error)
+	All(ctx context.Context) ([]PinRecord, error)
+}
+
+type PinningConfig struct {
+	ChallengeWindow time.Duration // Attesters can challenge a task this long after its proof
+	DisputePeriod   time.Duration // A challenge is settled within this long
+	SweepInterval   time.Duration
+	// Content is copied here before it is unpinned, if set
+	ColdStore Store
+}
+
+// PinTask is a proof upload to keep pinned
+type PinTask struct {
+	CID        string
+	TaskID     int64
+	JobID      int64
+	UserID     int64
+	TaskStatus string
+	Size       int64
+	Blobs      map[string]int64 // Content the proof references, by CID
+}
+
+// PinReport is what the pinning manager keeps pinned
+type PinReport struct {
+	GeneratedAt   time.Time       `json:"generated_at"`
+	PinnedCount   int             `json:"pinned_count"`
+	PinnedBytes   int64           `json:"pinned_bytes"`
+	DisputedCount int             `json:"disputed_count"`
+	ArchivedCount int             `json:"archived_count"`
+	ArchivedBytes int64           `json:"archived_bytes"`
+	BytesByJob    map[int64]int64 `json:"bytes_by_job"`
+	BytesByUser   map[int64]int64 `json:"bytes_by_user"`
+}
+
+// PinManager keeps proofs of tasks pinned until they can no longer be
+// challenged, then archives and unpins them
+type PinManager struct {
+	store    Store
+	registry PinRegistry
+	config   PinningConfig
+	logger   logging.Logger
+	now      func() time.Time
+	mu       sync.Mutex // Serialises updates of records shared by several proofs
+}
+
+func NewPinManager(store Store, registry PinRegistry, cfg PinningConfig, logger logging.Logger) *PinManager {
+	if cfg.SweepInterval <= 0 {
+		cfg.SweepInterval = 10 * time.Minute
+	}
+	return &PinManager{store: store, registry: registry, config: cfg, logger: logger, now: func() time.Time { return time.Now().UTC() }}
+}
+
+func (m *PinManager) holdUntil(from time.Time) time.Time {
+	return from.Add(m.config.ChallengeWindow + m.config.DisputePeriod)
+}
+
+// Track holds a proof upload, and the blobs it references, until the challenge
+// window and dispute period after now end. A blob shared by several proofs is
+// held for the longest of them, and counted against the job that first used it.
+func (m *PinManager) Track(ctx context.Context, task PinTask) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.now()
+	releaseAfter := m.holdUntil(now)
+	blobs := make([]string, 0, len(task.Blobs))
+	for blob := range task.Blobs {
+		blobs = append(blobs, blob)
+	}
+	sort.Strings(blobs)
+
+	records := []PinRecord{{
+		CID:        task.CID,
+		TaskID:     task.TaskID,
+		JobID:      task.JobID,
+		UserID:     task.UserID,
+		TaskStatus: task.TaskStatus,
+		Size:       task.Size,
+		Blobs:      blobs,
+	}}
+	for _, blob := range blobs {
+		records = append(records, PinRecord{CID: blob, TaskID: task.TaskID, JobID: task.JobID, UserID: task.UserID, Size: task.Blobs[blob]})
+	}
+
+	for _, record := range records {
+		existing, err := m.registry.Get(ctx, record.CID)
+		if err != nil {
+			return err
+		}
+		switch {
+		case existing == nil || existing.Status == PinArchived:
+			record.Status = PinHeld
+			record.PinnedAt = now
+			record.ReleaseAfter = releaseAfter
+		case existing.Status == PinDisputed:
+			continue
+		default:
+			// Content already held keeps its owner, and is only extended
+			held := *existing
+			if releaseAfter.After(held.ReleaseAfter) {
+				held.ReleaseAfter = releaseAfter
+			}
+			record = held
+		}
+		if err := m.registry.Save(ctx, record); err != nil {
+			return fmt.Errorf("failed to track %s: %w", record.CID, err)
+		}
+	}
+	m.logger.Debug("Tracking proof of task", "cid", task.CID, "task_id", task.TaskID, "blobs", len(blobs), "release_after", releaseAfter)
+	return nil
+}
+
+// Dispute holds the proofs of a challenged task, and their blobs, until the
+// dispute is resolved
+func (m *PinManager) Dispute(ctx context.Context, taskID int64) (int, error) {
+	return m.setTaskStatus(ctx, taskID, PinDisputed, time.Time{})
+}
+
+// Resolve releases the proofs of a task once the dispute period after the
+// resolution ends
+func (m *PinManager) Resolve(ctx context.Context, taskID int64) (int, error) {
+	return m.setTaskStatus(ctx, taskID, PinHeld, m.now().Add(m.config.DisputePeriod))
+}
+
+func (m *PinManager) setTaskStatus(ctx context.Context, taskID int64, status PinStatus, releaseAfter time.Time) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	records, err := m.registry.ByTask(ctx, taskID)
+	if err != nil {
+		return 0, err
+	}
+	if len(records) == 0 {
+		return 0, fmt.Errorf("%w: task %d", ErrNotPinned, taskID)
+	}
+
+	updated := 0
+	seen := make(map[string]bool)
+	for _, owned := range records {
+		for _, cid := range append([]string{owned.CID}, owned.Blobs...) {
+			if seen[cid] {
+				continue
+			}
+			seen[cid] = true
+			record, err := m.registry.Get(ctx, cid)
+			if err != nil {
+				return updated, err
+			}
+			if record == nil || record.Status == PinArchived {
+				continue
+			}
+			record.Status = status
+			if !releaseAfter.IsZero() && releaseAfter.After(record.ReleaseAfter) {
+				record.ReleaseAfter = releaseAfter
+			}
+			if err := m.registry.Save(ctx, *record); err != nil {
+				return updated, err
+			}
+			updated++
+		}
+	}
+	return updated, nil
+}
+
+// Sweep archives and unpins the content whose hold has ended, and content the
+// store keeps that no task holds once it is older than a hold
+func (m *PinManager) Sweep(ctx context.Context) (int, error) {
+	now := m.now()
+	due, err := m.registry.Due(ctx, now)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read pins due: %w", err)
+	}
+
+	released := 0
+	var errs []error
+	for _, record := range due {
+		if err := m.release(ctx, record); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		released++
+	}
+
+	untracked, err := m.sweepUntracked(ctx, now)
+	released += untracked
+	if err != nil {
+		errs = append(errs, err)
+	}
+	return released, errors.Join(errs...)
+}
+
+func (m *PinManager) release(ctx context.Context, record PinRecord) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	// The record may have been extended or disputed since it was read
+	current, err := m.registry.Get(ctx, record.CID)
+	if err != nil {
+		return err
+	}
+	if current == nil || current.Status != PinHeld || current.ReleaseAfter.After(m.now()) {
+		return nil
+	}
+	record = *current
+
+	if m.config.ColdStore != nil {
+		data, err := m.store.Get(ctx, record.CID)
+		if err != nil {
+			return fmt.Errorf("failed to read %s for archiving: %w", record.CID, err)
+		}
+		record.ArchivedCID, err = m.config.ColdStore.Put(ctx, fmt.Sprintf("%s.archive", record.CID), data)
+		if err != nil {
+			return fmt.Errorf("failed to archive %s: %w", record.CID, err)
+		}
+	}
+
+	if err := m.store.Unpin(ctx, record.CID); err != nil {
+		return fmt.Errorf("failed to unpin %s: %w", record.CID, err)
+	}
+
+	if record.ArchivedCID == "" {
+		if err := m.registry.Delete(ctx, record.CID); err != nil {
+			return err
+		}
+	} else {
+		record.Status = PinArchived
+		if err := m.registry.Save(ctx, record); err != nil {
+			return err
+		}
+	}
+	m.logger.Info("Released proof of task", "cid", record.CID, "task_id", record.TaskID, "archived_cid", record.ArchivedCID)
+	return nil
+}
+
+// sweepUntracked unpins content of stores that can list it which no task
+// holds, such as proofs never validated, once it is older than a hold
+func (m *PinManager) sweepUntracked(ctx context.Context, now time.Time) (int, error) {
+	lister, ok := m.store.(Lister)
+	if !ok {
+		return 0, nil
+	}
+	pinned, err := lister.List(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list pinned content: %w", err)
+	}
+
+	cutoff := now.Add(-(m.config.ChallengeWindow + m.config.DisputePeriod))
+	unpinned := 0
+	for _, content := range pinned {
+		if !content.CreatedAt.Before(cutoff) {
+			continue
+		}
+		record, err := m.registry.Get(ctx, content.CID)
+		if err != nil {
+			return unpinned, err
+		}
+		if record != nil && record.Status != PinArchived {
+			continue
+		}
+		if err := m.store.Unpin(ctx, content.CID); err != nil {
+			m.logger.Warn("Failed to unpin untracked content", "cid", content.CID, "error", err)
+			continue
+		}
+		unpinned++
+	}
+	return unpinned, nil
+}
+
+// Run sweeps until the context is done
+func (m *PinManager) Run(ctx context.Context) {
+	ticker := time.NewTicker(m.config.SweepInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			released, err := m.Sweep(ctx)
+			if err != nil {
+				m.logger.Error("Pin sweep failed", "released", released, "error", err)
+			} else if released > 0 {
+				m.logger.Info("Pin sweep completed", "released", released)
+			}
+		}
+	}
+}
+
+// Report sums the bytes kept pinned per job and user
+func (m *PinManager) Report(ctx context.Context) (PinReport, error) {
+	records, err := m.registry.All(ctx)
+	if err != nil {
+		return PinReport{}, err
+	}
+	report := PinReport{
+		GeneratedAt: m.now(),
+		BytesByJob:  make(map[int64]int64),
+		BytesByUser: make(map[int64]int64),
+	}
+	for _, record := range records {
+		if record.Status == PinArchived {
+			report.ArchivedCount++
+			report.ArchivedBytes += record.Size
+			continue
+		}
+		if record.Status == PinDisputed {
+			report.DisputedCount++
+		}
+		report.PinnedCount++
+		report.PinnedBytes += record.Size
+		report.BytesByJob[record.JobID] += record.Size
+		report.BytesByUser[record.UserID] += record.Size
+	}
+	return report, nil
+}
+
+// MemoryPinRegistry keeps pin records in memory, for tests and development
+type MemoryPinRegistry struct {
+	mu      sync.Mutex
+	records map[string]PinRecord
+}
+
+func NewMemoryPinRegistry() *MemoryPinRegistry {
+	return &MemoryPinRegistry{records: make(map[string]PinRecord)}
+}
+
+func (r *MemoryPinRegistry) Save(ctx context.Context, record PinRecord) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.records[record.CID] = record
+	return nil
+}
+
+func (r *MemoryPinRegistry) Get(ctx context.Context, cid string) (*PinRecord, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	record, ok := r.records[cid]
+	if !ok {
+		return nil, nil
+	}
+	return &record, nil
+}
+
+func (r *MemoryPinRegistry) Delete(ctx context.Context, cid string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.records, cid)
+	return nil
+}
+
+func (r *MemoryPinRegistry) Due(ctx context.Context, at time.Time) ([]PinRecord, error) {
+	return r.filter(func(record PinRecord) bool {
+		return record.Status == PinHeld && !record.ReleaseAfter.After(at)
+	}), nil
+}
+
+func (r *MemoryPinRegistry) ByTask(ctx context.Context, taskID int64) ([]PinRecord, error) {
+	return r.filter(func(record PinRecord) bool { return record.TaskID == taskID }), nil
+}
+
+func (r *MemoryPinRegistry) All(ctx context.Context) ([]PinRecord, error) {
+	return r.filter(func(PinRecord) bool { return true }), nil
+}
+
+func (r *MemoryPinRegistry) filter(keep func(PinRecord) bool) []PinRecord {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var records []PinRecord
+	for _, record := range r.records {
+		if keep(record) {
+			records = append(records, record)
+		}
+	}
+	sort.Slice(records, func(i, j int) bool { return records[i].CID < records[j].CID })
+	return records
+}
@@ -0,0 +1,117 @@
+package ipfs
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// PinningServiceStore keeps content with a web3.storage-style service: files
+// are uploaded to its /upload endpoint, pins are managed through the IPFS
+// Pinning Service API, and content is fetched from a gateway
+type PinningServiceStore struct {
+	endpoint string
+	token    string
+	gateway  string
+	client   *http.Client
+}
+
+func NewPinningServiceStore(endpoint, token, gateway string, client *http.Client) *PinningServiceStore {
+	return &PinningServiceStore{
+		endpoint: strings.TrimSuffix(endpoint, "/"),
+		token:    token,
+		gateway:  gateway,
+		client:   client,
+	}
+}
+
+func (s *PinningServiceStore) Put(ctx context.Context, name string, data []byte) (string, error) {
+	req, err := s.request(ctx, http.MethodPost, "/upload", bytes.NewReader(data))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/octet-stream")
+	req.Header.Set("X-Name", url.PathEscape(name))
+	body, err := do(s.client, req)
+	if err != nil {
+		return "", fmt.Errorf("failed to upload to pinning service: %w", err)
+	}
+
+	var response struct {
+		CID string `json:"cid"`
+	}
+	if err := json.Unmarshal(body, &response); err != nil {
+		return "", fmt.Errorf("failed to unmarshal upload response: %w", err)
+	}
+	if response.CID == "" {
+		return "", fmt.Errorf("upload response has no CID")
+	}
+	return response.CID, nil
+}
+
+func (s *PinningServiceStore) Get(ctx context.Context, cid string) ([]byte, error) {
+	return gatewayGet(ctx, s.client, s.gateway, cid)
+}
+
+func (s *PinningServiceStore) Pin(ctx context.Context, cid string) error {
+	payload, _ := json.Marshal(map[string]string{"cid": cid})
+	req, err := s.request(ctx, http.MethodPost, "/pins", bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if _, err := do(s.client, req); err != nil {
+		return fmt.Errorf("failed to pin %s: %w", cid, err)
+	}
+	return nil
+}
+
+// Unpin removes every pin request the service holds for the CID
+func (s *PinningServiceStore) Unpin(ctx context.Context, cid string) error {
+	req, err := s.request(ctx, http.MethodGet, "/pins?"+url.Values{"cid": {cid}}.Encode(), nil)
+	if err != nil {
+		return err
+	}
+	body, err := do(s.client, req)
+	if err != nil {
+		return fmt.Errorf("failed to find pins of %s: %w", cid, err)
+	}
+	var response struct {
+		Results []struct {
+			RequestID string `json:"requestid"`
+		} `json:"results"`
+	}
+	if err := json.Unmarshal(body, &response); err != nil {
+		return fmt.Errorf("failed to decode pins of %s: %w", cid, err)
+	}
+	if len(response.Results) == 0 {
+		return fmt.Errorf("no pin found for CID %s", cid)
+	}
+
+	for _, result := range response.Results {
+		req, err := s.request(ctx, http.MethodDelete, "/pins/"+url.PathEscape(result.RequestID), nil)
+		if err != nil {
+			return err
+		}
+		if _, err := do(s.client, req); err != nil {
+			return fmt.Errorf("failed to remove pin %s: %w", result.RequestID, err)
+		}
+	}
+	return nil
+}
+
+func (s *PinningServiceStore) request(ctx context.Context, method, path string, body io.Reader) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, method, s.endpoint+path, body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	if s.token != "" {
+		req.Header.Set("Authorization", "Bearer "+s.token)
+	}
+	return req, nil
+}
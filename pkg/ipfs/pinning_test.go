@@ -0,0 +1,149 @@
+package ipfs
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/trigg3rX/triggerx-backend-imua/pkg/logging"
+)
+
+type MockLogger struct{}
+
+func (l *MockLogger) Debug(msg string, tags ...any)               {}
+func (l *MockLogger) Info(msg string, tags ...any)                {}
+func (l *MockLogger) Warn(msg string, tags ...any)                {}
+func (l *MockLogger) Error(msg string, tags ...any)               {}
+func (l *MockLogger) Fatal(msg string, tags ...any)               {}
+func (l *MockLogger) Debugf(template string, args ...interface{}) {}
+func (l *MockLogger) Infof(template string, args ...interface{})  {}
+func (l *MockLogger) Warnf(template string, args ...interface{})  {}
+func (l *MockLogger) Errorf(template string, args ...interface{}) {}
+func (l *MockLogger) Fatalf(template string, args ...interface{}) {}
+func (l *MockLogger) With(tags ...any) logging.Logger             { return l }
+
+func newTestPinManager(t *testing.T, cold Store) (*PinManager, *MemoryStore, *time.Time) {
+	store := NewMemoryStore()
+	manager := NewPinManager(store, NewMemoryPinRegistry(), PinningConfig{
+		ChallengeWindow: 24 * time.Hour,
+		DisputePeriod:   72 * time.Hour,
+		ColdStore:       cold,
+	}, &MockLogger{})
+	clock := time.Now().UTC()
+	manager.now = func() time.Time { return clock }
+	return manager, store, &clock
+}
+
+func putTestProof(t *testing.T, store Store, content string) string {
+	cid, err := store.Put(context.Background(), "proof.json", []byte(content))
+	require.NoError(t, err)
+	return cid
+}
+
+func TestPinManagerHoldsUntilDisputePeriodEnds(t *testing.T) {
+	manager, store, clock := newTestPinManager(t, nil)
+	ctx := context.Background()
+	blob := putTestProof(t, store, `[{"name":"execute"}]`)
+	first := putTestProof(t, store, `{"task_id":1}`)
+	second := putTestProof(t, store, `{"task_id":2}`)
+
+	require.NoError(t, manager.Track(ctx, PinTask{CID: first, TaskID: 1, JobID: 10, UserID: 100, TaskStatus: "completed", Size: 13, Blobs: map[string]int64{blob: 20}}))
+	*clock = clock.Add(48 * time.Hour)
+	require.NoError(t, manager.Track(ctx, PinTask{CID: second, TaskID: 2, JobID: 11, UserID: 100, TaskStatus: "failed", Size: 13, Blobs: map[string]int64{blob: 20}}))
+
+	// Nothing is released within the challenge window and dispute period
+	released, err := manager.Sweep(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, released)
+
+	// The first proof is released, the blob is held for the second
+	*clock = clock.Add(49 * time.Hour)
+	released, err = manager.Sweep(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, released)
+	_, err = store.Get(ctx, first)
+	assert.Error(t, err)
+	_, err = store.Get(ctx, blob)
+	assert.NoError(t, err)
+
+	report, err := manager.Report(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 2, report.PinnedCount)
+	assert.Equal(t, int64(33), report.PinnedBytes)
+	assert.Equal(t, map[int64]int64{10: 20, 11: 13}, report.BytesByJob, "blobs count against the job that first used them")
+	assert.Equal(t, map[int64]int64{100: 33}, report.BytesByUser)
+
+	*clock = clock.Add(48 * time.Hour)
+	released, err = manager.Sweep(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 2, released)
+	pinned, _ := store.List(ctx)
+	assert.Empty(t, pinned)
+}
+
+func TestPinManagerDisputes(t *testing.T) {
+	manager, store, clock := newTestPinManager(t, nil)
+	ctx := context.Background()
+	cid := putTestProof(t, store, `{"task_id":3}`)
+	require.NoError(t, manager.Track(ctx, PinTask{CID: cid, TaskID: 3, TaskStatus: "completed", Size: 13}))
+
+	_, err := manager.Dispute(ctx, 4)
+	assert.ErrorIs(t, err, ErrNotPinned)
+	updated, err := manager.Dispute(ctx, 3)
+	require.NoError(t, err)
+	assert.Equal(t, 1, updated)
+
+	// A disputed proof is held however long the dispute takes
+	*clock = clock.Add(30 * 24 * time.Hour)
+	released, err := manager.Sweep(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, released)
+
+	report, _ := manager.Report(ctx)
+	assert.Equal(t, 1, report.DisputedCount)
+
+	// Once resolved it is held for another dispute period
+	_, err = manager.Resolve(ctx, 3)
+	require.NoError(t, err)
+	*clock = clock.Add(71 * time.Hour)
+	released, _ = manager.Sweep(ctx)
+	assert.Zero(t, released)
+	*clock = clock.Add(2 * time.Hour)
+	released, err = manager.Sweep(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, released)
+}
+
+func TestPinManagerArchives(t *testing.T) {
+	cold := NewMemoryStore()
+	manager, store, clock := newTestPinManager(t, cold)
+	ctx := context.Background()
+	cid := putTestProof(t, store, `{"task_id":5}`)
+	untracked := putTestProof(t, store, `{"task_id":6}`)
+	require.NoError(t, manager.Track(ctx, PinTask{CID: cid, TaskID: 5, JobID: 12, TaskStatus: "completed", Size: 13}))
+
+	*clock = clock.Add(97 * time.Hour)
+	released, err := manager.Sweep(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 2, released)
+
+	record, err := manager.registry.Get(ctx, cid)
+	require.NoError(t, err)
+	require.NotNil(t, record)
+	assert.Equal(t, PinArchived, record.Status)
+	data, err := cold.Get(ctx, record.ArchivedCID)
+	require.NoError(t, err)
+	assert.Equal(t, `{"task_id":5}`, string(data))
+
+	// Content no task holds is unpinned without archiving
+	_, err = cold.Get(ctx, untracked)
+	assert.Error(t, err)
+
+	report, _ := manager.Report(ctx)
+	assert.Zero(t, report.PinnedBytes)
+	assert.Equal(t, 1, report.ArchivedCount)
+	assert.Equal(t, int64(13), report.ArchivedBytes)
+}
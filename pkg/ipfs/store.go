@@ -0,0 +1,171 @@
+package ipfs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/ipfs/go-cid"
+	mh "github.com/multiformats/go-multihash"
+)
+
+// Store keeps content on IPFS, addressed by CID
+type Store interface {
+	// Put adds data as a file of the given name and pins it, returning its CID
+	Put(ctx context.Context, name string, data []byte) (string, error)
+	// Get fetches the content of a CID
+	Get(ctx context.Context, cid string) ([]byte, error)
+	// Pin keeps content added elsewhere
+	Pin(ctx context.Context, cid string) error
+	// Unpin releases content, which the backend may then drop
+	Unpin(ctx context.Context, cid string) error
+}
+
+// Lister is a store that can tell what it keeps pinned
+type Lister interface {
+	List(ctx context.Context) ([]Pinned, error)
+}
+
+// Pinned is content a store keeps
+type Pinned struct {
+	CID       string
+	Name      string
+	Size      int64
+	CreatedAt time.Time
+}
+
+type Backend string
+
+const (
+	BackendPinata      Backend = "pinata"
+	BackendKubo        Backend = "kubo"        // A node's Kubo RPC API
+	BackendWeb3Storage Backend = "web3storage" // Upload endpoint and IPFS Pinning Service API
+	BackendFilesystem  Backend = "filesystem"
+	BackendMemory      Backend = "memory"
+)
+
+// Content larger than this is not read from a store
+const MaxContentBytes = 32 << 20
+
+const defaultTimeout = 30 * time.Second
+
+type Config struct {
+	Backend     Backend
+	GatewayHost string // Gateway content is fetched from, for backends that do not serve it
+	APIURL      string // Kubo RPC API or service endpoint
+	Token       string // Pinata JWT or service token
+	Network     string // Pinata network, public by default
+	Dir         string // Directory of the filesystem backend
+	Timeout     time.Duration
+}
+
+// NewStore creates the store of the configured backend
+func NewStore(cfg Config) (Store, error) {
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = defaultTimeout
+	}
+	client := &http.Client{Timeout: cfg.Timeout}
+
+	switch cfg.Backend {
+	case BackendPinata, "":
+		// Services that only fetch content need no JWT
+		if cfg.Token == "" && cfg.GatewayHost == "" {
+			return nil, errors.New("pinata backend needs a JWT or a gateway")
+		}
+		return NewPinataStore(cfg.Token, cfg.Network, cfg.GatewayHost, client), nil
+	case BackendKubo:
+		if cfg.APIURL == "" {
+			return nil, errors.New("kubo backend needs an API URL")
+		}
+		return NewKuboStore(cfg.APIURL, client), nil
+	case BackendWeb3Storage:
+		if cfg.APIURL == "" || cfg.GatewayHost == "" {
+			return nil, errors.New("web3storage backend needs an API URL and a gateway")
+		}
+		return NewPinningServiceStore(cfg.APIURL, cfg.Token, cfg.GatewayHost, client), nil
+	case BackendFilesystem:
+		if cfg.Dir == "" {
+			return nil, errors.New("filesystem backend needs a directory")
+		}
+		return NewFileStore(cfg.Dir)
+	case BackendMemory:
+		return NewMemoryStore(), nil
+	default:
+		return nil, fmt.Errorf("unknown IPFS backend: %s", cfg.Backend)
+	}
+}
+
+// ComputeCID is the CIDv1 of data stored as a single raw block, which is how
+// Kubo and most services add small files with CIDv1
+func ComputeCID(data []byte) (string, error) {
+	c, err := cid.NewPrefixV1(cid.Raw, mh.SHA2_256).Sum(data)
+	if err != nil {
+		return "", fmt.Errorf("failed to compute CID: %w", err)
+	}
+	return c.String(), nil
+}
+
+// verifyContent checks data against a CID naming a single raw block. Content of
+// other CIDs spans a DAG a gateway does not hand over, and is not checked.
+func verifyContent(c string, data []byte) error {
+	parsed, err := cid.Decode(c)
+	if err != nil {
+		return fmt.Errorf("invalid CID %s: %w", c, err)
+	}
+	if parsed.Type() != cid.Raw {
+		return nil
+	}
+	sum, err := parsed.Prefix().Sum(data)
+	if err != nil {
+		return fmt.Errorf("failed to hash content: %w", err)
+	}
+	if !sum.Equals(parsed) {
+		return fmt.Errorf("content of %s hashes to %s", c, sum)
+	}
+	return nil
+}
+
+// gatewayGet fetches and checks the content of a CID from a gateway, given as a
+// host or a base URL
+func gatewayGet(ctx context.Context, client *http.Client, gateway string, c string) ([]byte, error) {
+	if !strings.Contains(gateway, "://") {
+		gateway = "https://" + gateway
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimSuffix(gateway, "/")+"/ipfs/"+c, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	data, err := do(client, req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch IPFS content: %w", err)
+	}
+	if err := verifyContent(c, data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// do sends a request and reads its response, failing on statuses other than 2xx
+func do(client *http.Client, req *http.Request) ([]byte, error) {
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, MaxContentBytes+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, fmt.Errorf("status code %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	if len(body) > MaxContentBytes {
+		return nil, fmt.Errorf("response is larger than %d bytes", MaxContentBytes)
+	}
+	return body, nil
+}
@@ -0,0 +1,182 @@
+package ipfs
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLocalStores(t *testing.T) {
+	fileStore, err := NewFileStore(t.TempDir())
+	require.NoError(t, err)
+
+	for name, store := range map[string]Store{"memory": NewMemoryStore(), "filesystem": fileStore} {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			cid, err := store.Put(ctx, "proof.json", []byte(`{"task_id":1}`))
+			require.NoError(t, err)
+			expected, _ := ComputeCID([]byte(`{"task_id":1}`))
+			assert.Equal(t, expected, cid)
+
+			data, err := store.Get(ctx, cid)
+			require.NoError(t, err)
+			assert.Equal(t, `{"task_id":1}`, string(data))
+			assert.NoError(t, store.Pin(ctx, cid))
+
+			pinned, err := store.(Lister).List(ctx)
+			require.NoError(t, err)
+			require.Len(t, pinned, 1)
+			assert.Equal(t, cid, pinned[0].CID)
+
+			require.NoError(t, store.Unpin(ctx, cid))
+			_, err = store.Get(ctx, cid)
+			assert.Error(t, err)
+			assert.Error(t, store.Pin(ctx, cid))
+		})
+	}
+
+	_, err = fileStore.Get(context.Background(), "../../etc/passwd")
+	assert.Error(t, err)
+}
+
+func TestKuboStore(t *testing.T) {
+	content := map[string][]byte{}
+	pins := map[string]bool{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		arg := r.URL.Query().Get("arg")
+		switch r.URL.Path {
+		case "/api/v0/add":
+			assert.Equal(t, "1", r.URL.Query().Get("cid-version"))
+			file, _, err := r.FormFile("file")
+			require.NoError(t, err)
+			data, _ := io.ReadAll(file)
+			cid, _ := ComputeCID(data)
+			content[cid], pins[cid] = data, true
+			_ = json.NewEncoder(w).Encode(map[string]string{"Name": "proof.json", "Hash": cid})
+		case "/api/v0/cat":
+			_, _ = w.Write(content[arg])
+		case "/api/v0/pin/add":
+			pins[arg] = true
+		case "/api/v0/pin/rm":
+			delete(pins, arg)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	store, err := NewStore(Config{Backend: BackendKubo, APIURL: server.URL})
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	cid, err := store.Put(ctx, "proof.json", []byte("proof"))
+	require.NoError(t, err)
+	data, err := store.Get(ctx, cid)
+	require.NoError(t, err)
+	assert.Equal(t, "proof", string(data))
+
+	require.NoError(t, store.Unpin(ctx, cid))
+	assert.False(t, pins[cid])
+	require.NoError(t, store.Pin(ctx, cid))
+	assert.True(t, pins[cid])
+
+	// A node serving other content than the CID names is caught
+	content[cid] = []byte("forged")
+	_, err = store.Get(ctx, cid)
+	assert.ErrorContains(t, err, "hashes to")
+}
+
+func TestPinataStore(t *testing.T) {
+	cid, _ := ComputeCID([]byte("proof"))
+	var deleted string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// The gateway is public, the API needs the JWT
+		if !strings.HasPrefix(r.URL.Path, "/ipfs/") {
+			assert.Equal(t, "Bearer jwt", r.Header.Get("Authorization"))
+		}
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/upload":
+			assert.Equal(t, "public", r.FormValue("network"))
+			_, _ = w.Write([]byte(`{"data":{"id":"file-1","cid":"` + cid + `"}}`))
+		case r.Method == http.MethodGet && r.URL.Path == "/files/public":
+			assert.Equal(t, cid, r.URL.Query().Get("cid"))
+			_, _ = w.Write([]byte(`{"data":{"files":[{"id":"file-1","cid":"` + cid + `"}]}}`))
+		case r.Method == http.MethodDelete:
+			deleted = strings.TrimPrefix(r.URL.Path, "/files/public/")
+		case r.Method == http.MethodGet && r.URL.Path == "/ipfs/"+cid:
+			_, _ = w.Write([]byte("proof"))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	store := NewPinataStore("jwt", "", server.URL, server.Client())
+	store.uploadURL = server.URL + "/upload"
+	store.apiURL = server.URL + "/files"
+	ctx := context.Background()
+
+	uploaded, err := store.Put(ctx, "proof.json", []byte("proof"))
+	require.NoError(t, err)
+	assert.Equal(t, cid, uploaded)
+	data, err := store.Get(ctx, cid)
+	require.NoError(t, err)
+	assert.Equal(t, "proof", string(data))
+	require.NoError(t, store.Unpin(ctx, cid))
+	assert.Equal(t, "file-1", deleted)
+}
+
+func TestPinningServiceStore(t *testing.T) {
+	cid, _ := ComputeCID([]byte("proof"))
+	var removed []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/upload":
+			assert.Equal(t, "Bearer token", r.Header.Get("Authorization"))
+			_, _ = w.Write([]byte(`{"cid":"` + cid + `"}`))
+		case r.Method == http.MethodPost && r.URL.Path == "/pins":
+			w.WriteHeader(http.StatusAccepted)
+			_, _ = w.Write([]byte(`{"requestid":"pin-1","status":"queued"}`))
+		case r.Method == http.MethodGet && r.URL.Path == "/pins":
+			_, _ = w.Write([]byte(`{"count":2,"results":[{"requestid":"pin-1"},{"requestid":"pin-2"}]}`))
+		case r.Method == http.MethodDelete:
+			removed = append(removed, strings.TrimPrefix(r.URL.Path, "/pins/"))
+			w.WriteHeader(http.StatusAccepted)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	store, err := NewStore(Config{Backend: BackendWeb3Storage, APIURL: server.URL, Token: "token", GatewayHost: server.URL})
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	uploaded, err := store.Put(ctx, "proof.json", []byte("proof"))
+	require.NoError(t, err)
+	assert.Equal(t, cid, uploaded)
+	require.NoError(t, store.Pin(ctx, cid))
+	require.NoError(t, store.Unpin(ctx, cid))
+	assert.Equal(t, []string{"pin-1", "pin-2"}, removed)
+}
+
+func TestNewStore(t *testing.T) {
+	_, err := NewStore(Config{Backend: "s3"})
+	assert.ErrorContains(t, err, "unknown IPFS backend")
+	_, err = NewStore(Config{Backend: BackendPinata})
+	assert.Error(t, err)
+	_, err = NewStore(Config{Backend: BackendKubo})
+	assert.Error(t, err)
+
+	store, err := NewStore(Config{Backend: BackendMemory})
+	require.NoError(t, err)
+	assert.IsType(t, &MemoryStore{}, store)
+}
@@ -0,0 +1,84 @@
+package parser
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/trigg3rX/triggerx-backend-imua/pkg/types"
+)
+
+// maxMisfireScan bounds how many missed executions are walked for schedules
+// whose next execution cannot be computed arithmetically
+const maxMisfireScan = 100000
+
+// IsMisfired reports whether an execution due at scheduled is later than maxLateness at now
+func IsMisfired(scheduled time.Time, now time.Time, maxLateness time.Duration) bool {
+	return now.After(scheduled.Add(maxLateness))
+}
+
+// MaxLateness returns the lateness tolerance of a job, defaulting when unset
+func MaxLateness(maxLatenessSeconds int64) time.Duration {
+	if maxLatenessSeconds <= 0 {
+		maxLatenessSeconds = types.DefaultMaxLatenessSeconds
+	}
+	return time.Duration(maxLatenessSeconds) * time.Second
+}
+
+// CalculateNextExecutionAfterAck returns the execution that follows current once
+// it has been handed to the keepers, applying the misfire policy to executions
+// that are already late at now. With fire_all the most recent maxCatchup missed
+// executions are kept, counting current if it was itself late; every other
+// policy skips to the first execution that is not late.
+func CalculateNextExecutionAfterAck(current time.Time, now time.Time, scheduleType string, timeInterval int64, cronExpression string, specificSchedule string, policy string, maxCatchup int, maxLateness time.Duration) (time.Time, error) {
+	next, err := CalculateNextExecutionTime(current, scheduleType, timeInterval, cronExpression, specificSchedule)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if !IsMisfired(next, now, maxLateness) {
+		return next, nil
+	}
+
+	keep := 0
+	if policy == types.MisfireFireAll {
+		if maxCatchup <= 0 {
+			maxCatchup = types.DefaultMisfireMaxCatchup
+		}
+		keep = maxCatchup
+		if IsMisfired(current, now, maxLateness) {
+			keep--
+		}
+	}
+
+	// Interval schedules are resolved without walking every missed execution
+	if scheduleType == "interval" && timeInterval > 0 {
+		step := time.Duration(timeInterval) * time.Second
+		behind := now.Add(-maxLateness).Sub(next)
+		missed := int64((behind + step - 1) / step)
+		if missed <= int64(keep) {
+			return next, nil
+		}
+		return next.Add(time.Duration(missed-int64(keep)) * step), nil
+	}
+
+	// Walk the missed executions, remembering the last keep of them
+	recent := make([]time.Time, 0, keep)
+	for i := 0; IsMisfired(next, now, maxLateness); i++ {
+		if i >= maxMisfireScan {
+			return time.Time{}, fmt.Errorf("more than %d missed executions", maxMisfireScan)
+		}
+		if keep > 0 {
+			if len(recent) == keep {
+				recent = recent[1:]
+			}
+			recent = append(recent, next)
+		}
+		next, err = CalculateNextExecutionTime(next, scheduleType, timeInterval, cronExpression, specificSchedule)
+		if err != nil {
+			return time.Time{}, err
+		}
+	}
+	if len(recent) > 0 {
+		return recent[0], nil
+	}
+	return next, nil
+}
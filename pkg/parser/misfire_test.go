@@ -0,0 +1,73 @@
+package parser
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/trigg3rX/triggerx-backend-imua/pkg/types"
+)
+
+func TestCalculateNextExecutionAfterAck(t *testing.T) {
+	now := time.Date(2025, time.June, 2, 12, 0, 0, 0, time.UTC)
+	lateness := time.Minute
+
+	tests := []struct {
+		name       string
+		current    time.Time
+		policy     string
+		maxCatchup int
+		expected   time.Time
+	}{
+		{
+			name:     "On time execution advances one interval",
+			current:  now.Add(-30 * time.Second),
+			policy:   types.MisfireFireOnceNow,
+			expected: now.Add(9*time.Minute + 30*time.Second),
+		},
+		{
+			name:     "Fire once now skips every missed execution",
+			current:  now.Add(-time.Hour),
+			policy:   types.MisfireFireOnceNow,
+			expected: now,
+		},
+		{
+			name:     "Skip lands on the first execution within the lateness",
+			current:  now.Add(-time.Hour - 30*time.Second),
+			policy:   types.MisfireSkip,
+			expected: now.Add(-30 * time.Second),
+		},
+		{
+			// Missed: -50m ... -10m; current was itself late, so two more are kept
+			name:       "Fire all keeps the most recent missed executions",
+			current:    now.Add(-time.Hour),
+			policy:     types.MisfireFireAll,
+			maxCatchup: 3,
+			expected:   now.Add(-20 * time.Minute),
+		},
+		{
+			name:       "Fire all replays everything within the bound",
+			current:    now.Add(-20 * time.Minute),
+			policy:     types.MisfireFireAll,
+			maxCatchup: 3,
+			expected:   now.Add(-10 * time.Minute),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			next, err := CalculateNextExecutionAfterAck(tt.current, now, "interval", 600, "", "", tt.policy, tt.maxCatchup, lateness)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, next)
+		})
+	}
+}
+
+func TestIsMisfired(t *testing.T) {
+	now := time.Now()
+	assert.False(t, IsMisfired(now.Add(-30*time.Second), now, MaxLateness(0)))
+	assert.True(t, IsMisfired(now.Add(-2*time.Minute), now, MaxLateness(0)))
+	assert.True(t, IsMisfired(now.Add(-30*time.Second), now, MaxLateness(10)))
+}
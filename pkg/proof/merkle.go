@@ -0,0 +1,114 @@
+package proof
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// Domain prefixes keep a leaf from being passed off as an inner node
+const (
+	leafPrefix byte = 0x00
+	nodePrefix byte = 0x01
+)
+
+// LeafHash commits to a named piece of evidence
+func LeafHash(name string, data []byte) common.Hash {
+	return crypto.Keccak256Hash([]byte{leafPrefix}, []byte(name), []byte{0x00}, data)
+}
+
+func nodeHash(left, right common.Hash) common.Hash {
+	return crypto.Keccak256Hash([]byte{nodePrefix}, left[:], right[:])
+}
+
+// MerkleRoot of the leaves. A node without a sibling moves up a level as it is,
+// so no two leaf lists share a root.
+func MerkleRoot(leaves []common.Hash) (common.Hash, error) {
+	if len(leaves) == 0 {
+		return common.Hash{}, errors.New("no leaves to commit to")
+	}
+	level := leaves
+	for len(level) > 1 {
+		level = nextLevel(level)
+	}
+	return level[0], nil
+}
+
+// MerklePath is the siblings proving the leaf at index is under the root,
+// from the bottom level up
+func MerklePath(leaves []common.Hash, index int) ([]common.Hash, error) {
+	if index < 0 || index >= len(leaves) {
+		return nil, fmt.Errorf("leaf %d out of %d", index, len(leaves))
+	}
+	var path []common.Hash
+	level := leaves
+	for len(level) > 1 {
+		sibling := index ^ 1
+		if sibling < len(level) {
+			path = append(path, level[sibling])
+		}
+		level = nextLevel(level)
+		index /= 2
+	}
+	return path, nil
+}
+
+// VerifyMerklePath checks the leaf at index of count leaves against the root
+func VerifyMerklePath(root common.Hash, leaf common.Hash, index int, count int, path []common.Hash) bool {
+	if index < 0 || index >= count {
+		return false
+	}
+	hash := leaf
+	for width := count; width > 1; width = (width + 1) / 2 {
+		sibling := index ^ 1
+		if sibling < width {
+			if len(path) == 0 {
+				return false
+			}
+			if index%2 == 0 {
+				hash = nodeHash(hash, path[0])
+			} else {
+				hash = nodeHash(path[0], hash)
+			}
+			path = path[1:]
+		}
+		index /= 2
+	}
+	return len(path) == 0 && bytes.Equal(hash[:], root[:])
+}
+
+func nextLevel(level []common.Hash) []common.Hash {
+	next := make([]common.Hash, 0, (len(level)+1)/2)
+	for i := 0; i < len(level); i += 2 {
+		if i+1 == len(level) {
+			next = append(next, level[i])
+			continue
+		}
+		next = append(next, nodeHash(level[i], level[i+1]))
+	}
+	return next
+}
+
+func encodeHashes(hashes []common.Hash) []string {
+	encoded := make([]string, len(hashes))
+	for i, hash := range hashes {
+		encoded[i] = hash.Hex()
+	}
+	return encoded
+}
+
+func decodeHashes(encoded []string) ([]common.Hash, error) {
+	hashes := make([]common.Hash, len(encoded))
+	for i, s := range encoded {
+		b, err := hexutil.Decode(s)
+		if err != nil || len(b) != common.HashLength {
+			return nil, fmt.Errorf("invalid hash %q", s)
+		}
+		hashes[i] = common.BytesToHash(b)
+	}
+	return hashes, nil
+}
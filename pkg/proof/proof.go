@@ -1,10 +1,8 @@
 package proof
 
 import (
-	"crypto/sha256"
 	"crypto/tls"
 	"crypto/x509"
-	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -35,7 +33,8 @@ func DefaultTLSProofConfig(host string) *TLSProofConfig {
 	}
 }
 
-// GenerateProofWithTLSConnection generates a proof using a real TLS connection
+// GenerateProofWithTLSConnection generates a proof attesting a TLS connection
+// the keeper makes to the configured host
 func GenerateProofWithTLSConnection(ipfsData types.IPFSData, config *TLSProofConfig) (types.ProofData, error) {
 	connState, err := EstablishTLSConnection(config)
 	if err != nil {
@@ -100,27 +99,22 @@ func EstablishTLSConnection(config *TLSProofConfig) (*tls.ConnectionState, error
 	return &connState, nil
 }
 
-// GenerateProof takes the action execution data and generates a proof by:
-// 1. Creating a hash of the action data (tx hash, gas used, status etc)
-// 2. Adding a timestamp when the proof was generated
-// 3. Including TLS certificate information for verification
-// This provides cryptographic proof that the action was executed
+// GenerateProof commits to the task, trigger, action and performer in a Merkle
+// root (see BuildProof). A TLS connection state, if given, is attested alongside
+// it; that only shows which certificate the keeper saw, not how the action ran.
 func GenerateProof(ipfsData types.IPFSData, connState *tls.ConnectionState) (types.ProofData, error) {
-	// Validate inputs
+	proofData, err := BuildProof(ipfsData)
+	if err != nil {
+		return types.ProofData{}, err
+	}
 	if connState == nil {
-		return types.ProofData{}, errors.New("TLS connection state cannot be nil")
+		return proofData, nil
 	}
 
 	if len(connState.PeerCertificates) == 0 {
 		return types.ProofData{}, errors.New("no TLS certificates found in connection state")
 	}
 
-	// Stringify the ipfs data, after converting all the strings to lowercase
-	dataStr, err := StringifyIPFSData(ipfsData)
-	if err != nil {
-		return types.ProofData{}, fmt.Errorf("failed to stringify IPFS data: %w", err)
-	}
-
 	// Validate and process the first certificate
 	cert := connState.PeerCertificates[0]
 	if cert == nil {
@@ -132,22 +126,8 @@ func GenerateProof(ipfsData types.IPFSData, connState *tls.ConnectionState) (typ
 		return types.ProofData{}, fmt.Errorf("certificate validation failed: %w", err)
 	}
 
-	// Hash the certificate
-	certHash := sha256.Sum256(cert.Raw)
-	certHashStr := hex.EncodeToString(certHash[:])
-
-	// Generate proof hash from the data
-	proofHash := sha256.Sum256([]byte(dataStr))
-	proofHashStr := hex.EncodeToString(proofHash[:])
-
-	// Create enhanced proof with additional TLS information
-	proofData := types.ProofData{
-		TaskID:               ipfsData.TaskData.TaskID,
-		ProofOfTask:          proofHashStr,
-		CertificateHash:      certHashStr,
-		CertificateTimestamp: time.Now().UTC(),
-	}
-
+	proofData.CertificateHash = CertificateHash(cert.Raw)
+	proofData.CertificateTimestamp = time.Now().UTC()
 	return proofData, nil
 }
 
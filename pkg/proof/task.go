@@ -0,0 +1,266 @@
+package proof
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	ethtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+
+	"github.com/trigg3rX/triggerx-backend-imua/pkg/types"
+)
+
+// Version of the proof format built here
+const ProofVersion = 2
+
+// Leaves of the proof of task, in the order they are committed to
+const (
+	LeafTask      = "task"      // The scheduler-signed task
+	LeafTrigger   = "trigger"   // What fired the task
+	LeafAction    = "action"    // The transactions sent and their receipts, or the skip
+	LeafPerformer = "performer" // Who performed the task
+	LeafFetches   = "fetches"   // External data the dynamic arguments script fetched, with TLS certificates
+)
+
+// LeafNames are the leaves a proof of the data has. Fetches are only committed
+// to when a script made requests.
+func LeafNames(ipfsData types.IPFSData) []string {
+	names := []string{LeafTask, LeafTrigger, LeafAction, LeafPerformer}
+	if action := ipfsData.ActionData; action != nil && action.ScriptRecording != nil && len(action.ScriptRecording.Exchanges) > 0 {
+		names = append(names, LeafFetches)
+	}
+	return names
+}
+
+type taskEvidence struct {
+	TaskID             int64                         `json:"task_id"`
+	PerformerData      types.PerformerData           `json:"performer_data"`
+	TargetData         []types.TaskTargetData        `json:"target_data"`
+	SchedulerSignature *types.SchedulerSignatureData `json:"scheduler_signature"`
+}
+
+type actionEvidence struct {
+	TaskID        int64                    `json:"task_id"`
+	ActionTxHash  string                   `json:"action_tx_hash"`
+	Status        bool                     `json:"status"`
+	TargetResults []types.TargetCallResult `json:"target_results"`
+	Receipts      []types.ActionReceipt    `json:"receipts"`
+	Skipped       bool                     `json:"skipped"`
+	SkipReason    string                   `json:"skip_reason"`
+}
+
+type performerEvidence struct {
+	TaskID                  int64  `json:"task_id"`
+	PerformerSigningAddress string `json:"performer_signing_address"`
+}
+
+// A request the script made, with hashes of the bodies the recording holds
+type fetchEvidence struct {
+	Method          string `json:"method"`
+	URL             string `json:"url"`
+	RequestHash     string `json:"request_hash"`
+	StatusCode      int    `json:"status_code"`
+	ResponseHash    string `json:"response_hash"`
+	CertificateHash string `json:"certificate_hash"`
+}
+
+type fetchesEvidence struct {
+	ScriptURL   string          `json:"script_url"`
+	ImageDigest string          `json:"image_digest"`
+	Output      string          `json:"output"`
+	Fetches     []fetchEvidence `json:"fetches"`
+}
+
+// LeafData is the evidence a leaf commits to, rebuilt from the data uploaded
+// with the proof
+func LeafData(ipfsData types.IPFSData, name string) ([]byte, error) {
+	switch name {
+	case LeafTask:
+		task := ipfsData.TaskData
+		if task == nil {
+			return nil, errors.New("task data is missing")
+		}
+		return json.Marshal(taskEvidence{
+			TaskID:             task.TaskID,
+			PerformerData:      task.PerformerData,
+			TargetData:         task.TargetData,
+			SchedulerSignature: task.SchedulerSignature,
+		})
+	case LeafTrigger:
+		if ipfsData.TaskData == nil {
+			return nil, errors.New("task data is missing")
+		}
+		return json.Marshal(ipfsData.TaskData.TriggerData)
+	case LeafAction:
+		action := ipfsData.ActionData
+		if action == nil {
+			return nil, errors.New("action data is missing")
+		}
+		return json.Marshal(actionEvidence{
+			TaskID:        action.TaskID,
+			ActionTxHash:  action.ActionTxHash,
+			Status:        action.Status,
+			TargetResults: action.TargetResults,
+			Receipts:      action.Receipts,
+			Skipped:       action.Skipped,
+			SkipReason:    action.SkipReason,
+		})
+	case LeafPerformer:
+		// A proof without a performer commits to none; the performer signature
+		// check rejects it
+		var evidence performerEvidence
+		if performer := ipfsData.PerformerSignature; performer != nil {
+			evidence = performerEvidence{
+				TaskID:                  performer.TaskID,
+				PerformerSigningAddress: performer.PerformerSigningAddress,
+			}
+		}
+		return json.Marshal(evidence)
+	case LeafFetches:
+		if ipfsData.ActionData == nil || ipfsData.ActionData.ScriptRecording == nil {
+			return nil, errors.New("script recording is missing")
+		}
+		recording := ipfsData.ActionData.ScriptRecording
+		evidence := fetchesEvidence{
+			ScriptURL:   recording.ScriptURL,
+			ImageDigest: recording.ImageDigest,
+			Output:      recording.Output,
+			Fetches:     make([]fetchEvidence, len(recording.Exchanges)),
+		}
+		for i, exchange := range recording.Exchanges {
+			evidence.Fetches[i] = fetchEvidence{
+				Method:          exchange.Method,
+				URL:             exchange.URL,
+				RequestHash:     crypto.Keccak256Hash(exchange.RequestBody).Hex(),
+				StatusCode:      exchange.StatusCode,
+				ResponseHash:    crypto.Keccak256Hash(exchange.ResponseBody).Hex(),
+				CertificateHash: exchange.CertificateHash,
+			}
+		}
+		return json.Marshal(evidence)
+	default:
+		return nil, fmt.Errorf("unknown proof leaf %q", name)
+	}
+}
+
+// BuildProof commits to the task, its trigger, the action, the performer and
+// the external data fetched, with a Merkle path for every leaf
+func BuildProof(ipfsData types.IPFSData) (types.ProofData, error) {
+	names := LeafNames(ipfsData)
+	hashes := make([]common.Hash, len(names))
+	for i, name := range names {
+		data, err := LeafData(ipfsData, name)
+		if err != nil {
+			return types.ProofData{}, fmt.Errorf("failed to build %s leaf: %w", name, err)
+		}
+		hashes[i] = LeafHash(name, data)
+	}
+
+	root, err := MerkleRoot(hashes)
+	if err != nil {
+		return types.ProofData{}, err
+	}
+
+	proofData := types.ProofData{
+		Version:     ProofVersion,
+		ProofOfTask: root.Hex(),
+		Leaves:      make([]types.ProofLeaf, len(names)),
+	}
+	if ipfsData.TaskData != nil {
+		proofData.TaskID = ipfsData.TaskData.TaskID
+	}
+	for i, name := range names {
+		path, err := MerklePath(hashes, i)
+		if err != nil {
+			return types.ProofData{}, err
+		}
+		proofData.Leaves[i] = types.ProofLeaf{Name: name, Hash: hashes[i].Hex(), Path: encodeHashes(path)}
+	}
+	return proofData, nil
+}
+
+// BatchRoot commits to the proofs of all targets of a task uploaded together.
+// The root of a single proof is its own proof of task.
+func BatchRoot(proofs []types.IPFSData) (string, error) {
+	roots := make([]string, len(proofs))
+	for i, ipfsData := range proofs {
+		if ipfsData.ProofData == nil {
+			return "", fmt.Errorf("proof %d has no proof data", i)
+		}
+		roots[i] = ipfsData.ProofData.ProofOfTask
+	}
+	hashes, err := decodeHashes(roots)
+	if err != nil {
+		return "", fmt.Errorf("invalid proof of task: %w", err)
+	}
+	root, err := MerkleRoot(hashes)
+	if err != nil {
+		return "", err
+	}
+	return root.Hex(), nil
+}
+
+// VerifyLeaf checks that the proof commits to data under the named leaf. Each
+// leaf is checked against the root on its own, without the others' data.
+func VerifyLeaf(proofData *types.ProofData, name string, data []byte) error {
+	if proofData == nil {
+		return errors.New("proof data is missing")
+	}
+	root, err := decodeHashes([]string{proofData.ProofOfTask})
+	if err != nil {
+		return fmt.Errorf("invalid proof of task: %w", err)
+	}
+
+	for i, leaf := range proofData.Leaves {
+		if leaf.Name != name {
+			continue
+		}
+		expected := LeafHash(name, data)
+		if leaf.Hash != expected.Hex() {
+			return fmt.Errorf("%s leaf is %s, the data hashes to %s", name, leaf.Hash, expected.Hex())
+		}
+		path, err := decodeHashes(leaf.Path)
+		if err != nil {
+			return fmt.Errorf("invalid path of %s leaf: %w", name, err)
+		}
+		if !VerifyMerklePath(root[0], expected, i, len(proofData.Leaves), path) {
+			return fmt.Errorf("%s leaf is not under the proof of task", name)
+		}
+		return nil
+	}
+	return fmt.Errorf("proof has no %s leaf", name)
+}
+
+// ReceiptEvidence is what the action leaf records of a receipt
+func ReceiptEvidence(chainID string, receipt *ethtypes.Receipt) (types.ActionReceipt, error) {
+	if receipt == nil {
+		return types.ActionReceipt{}, errors.New("receipt is nil")
+	}
+	encoded, err := receipt.MarshalBinary()
+	if err != nil {
+		return types.ActionReceipt{}, fmt.Errorf("failed to encode receipt: %w", err)
+	}
+	var blockNumber uint64
+	if receipt.BlockNumber != nil {
+		blockNumber = receipt.BlockNumber.Uint64()
+	}
+	return types.ActionReceipt{
+		ChainID:     chainID,
+		TxHash:      receipt.TxHash.Hex(),
+		BlockNumber: blockNumber,
+		BlockHash:   receipt.BlockHash.Hex(),
+		Status:      receipt.Status,
+		GasUsed:     receipt.GasUsed,
+		ReceiptHash: crypto.Keccak256Hash(encoded).Hex(),
+	}, nil
+}
+
+// CertificateHash is how certificates are identified in proofs
+func CertificateHash(raw []byte) string {
+	sum := sha256.Sum256(raw)
+	return hex.EncodeToString(sum[:])
+}
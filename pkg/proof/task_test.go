@@ -0,0 +1,114 @@
+package proof
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/trigg3rX/triggerx-backend-imua/pkg/types"
+)
+
+func TestMerklePaths(t *testing.T) {
+	for count := 1; count <= 7; count++ {
+		leaves := make([]common.Hash, count)
+		for i := range leaves {
+			leaves[i] = LeafHash("leaf", []byte(fmt.Sprint(i)))
+		}
+		root, err := MerkleRoot(leaves)
+		require.NoError(t, err)
+
+		for i, leaf := range leaves {
+			path, err := MerklePath(leaves, i)
+			require.NoError(t, err)
+			assert.True(t, VerifyMerklePath(root, leaf, i, count, path), "leaf %d of %d", i, count)
+			if count > 1 {
+				assert.False(t, VerifyMerklePath(root, leaf, (i+1)%count, count, path), "leaf %d of %d at the wrong index", i, count)
+			}
+		}
+	}
+
+	// An inner node does not pass for a leaf
+	a, b := LeafHash("leaf", []byte("a")), LeafHash("leaf", []byte("b"))
+	root, _ := MerkleRoot([]common.Hash{a, b})
+	assert.NotEqual(t, root, LeafHash("leaf", append(a.Bytes(), b.Bytes()...)))
+
+	_, err := MerkleRoot(nil)
+	assert.Error(t, err)
+}
+
+func TestBuildProof(t *testing.T) {
+	ipfsData := types.IPFSData{
+		TaskData: &types.SendTaskDataToKeeper{
+			TaskID:      7,
+			TargetData:  []types.TaskTargetData{{TaskID: 7, TaskDefinitionID: 2}},
+			TriggerData: []types.TaskTriggerData{{TaskID: 7}},
+		},
+		ActionData: &types.PerformerActionData{
+			TaskID:       7,
+			ActionTxHash: "0x01",
+			Receipts:     []types.ActionReceipt{{ChainID: "17000", TxHash: "0x01", BlockHash: "0x02"}},
+			ScriptRecording: &types.ScriptRecording{
+				Exchanges: []types.HTTPExchange{{Method: "GET", URL: "https://api.example.com/price", CertificateHash: "abcd"}},
+				Output:    `{"arguments":[1]}`,
+			},
+		},
+		PerformerSignature: &types.PerformerSignatureData{TaskID: 7, PerformerSigningAddress: "0x04"},
+	}
+
+	proofData, err := BuildProof(ipfsData)
+	require.NoError(t, err)
+	assert.Equal(t, ProofVersion, proofData.Version)
+	assert.Equal(t, int64(7), proofData.TaskID)
+	require.Len(t, proofData.Leaves, 5)
+
+	for _, name := range LeafNames(ipfsData) {
+		data, err := LeafData(ipfsData, name)
+		require.NoError(t, err)
+		assert.NoError(t, VerifyLeaf(&proofData, name, data), name)
+	}
+
+	// The certificate an upstream server presented is committed to
+	ipfsData.ActionData.ScriptRecording.Exchanges[0].CertificateHash = "ef01"
+	fetches, err := LeafData(ipfsData, LeafFetches)
+	require.NoError(t, err)
+	assert.ErrorContains(t, VerifyLeaf(&proofData, LeafFetches, fetches), "fetches leaf")
+
+	assert.ErrorContains(t, VerifyLeaf(&proofData, "receipts", nil), "no receipts leaf")
+
+	// Without a TLS connection the proof stands on its own
+	generated, err := GenerateProof(ipfsData, nil)
+	require.NoError(t, err)
+	assert.Empty(t, generated.CertificateHash)
+	assert.NotEqual(t, proofData.ProofOfTask, generated.ProofOfTask)
+}
+
+func TestBatchRoot(t *testing.T) {
+	proofs := make([]types.IPFSData, 3)
+	for i := range proofs {
+		ipfsData := types.IPFSData{
+			TaskData:   &types.SendTaskDataToKeeper{TaskID: 8, TriggerData: []types.TaskTriggerData{{TaskID: 8}}},
+			ActionData: &types.PerformerActionData{TaskID: 8, ActionTxHash: fmt.Sprintf("0x%02x", i)},
+		}
+		proofData, err := BuildProof(ipfsData)
+		require.NoError(t, err)
+		ipfsData.ProofData = &proofData
+		proofs[i] = ipfsData
+	}
+
+	// A proof uploaded on its own is its own proof of task
+	root, err := BatchRoot(proofs[:1])
+	require.NoError(t, err)
+	assert.Equal(t, proofs[0].ProofData.ProofOfTask, root)
+
+	all, err := BatchRoot(proofs)
+	require.NoError(t, err)
+	reordered, err := BatchRoot([]types.IPFSData{proofs[1], proofs[0], proofs[2]})
+	require.NoError(t, err)
+	assert.NotEqual(t, all, reordered)
+
+	_, err = BatchRoot([]types.IPFSData{{}})
+	assert.Error(t, err)
+}
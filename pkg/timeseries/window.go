@@ -0,0 +1,161 @@
+package timeseries
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/trigg3rX/triggerx-backend-imua/pkg/types"
+)
+
+// Windowed condition types
+const (
+	ConditionPercentChangeAbove = "percent_change_above"
+	ConditionPercentChangeBelow = "percent_change_below"
+	ConditionSMACrossAbove      = "sma_cross_above"
+	ConditionSMACrossBelow      = "sma_cross_below"
+	ConditionEMACrossAbove      = "ema_cross_above"
+	ConditionEMACrossBelow      = "ema_cross_below"
+	ConditionZScoreAbove        = "zscore_above"
+)
+
+// IsWindowedCondition reports whether the condition type is evaluated over a window of samples
+func IsWindowedCondition(conditionType string) bool {
+	switch conditionType {
+	case ConditionPercentChangeAbove, ConditionPercentChangeBelow,
+		ConditionSMACrossAbove, ConditionSMACrossBelow,
+		ConditionEMACrossAbove, ConditionEMACrossBelow,
+		ConditionZScoreAbove:
+		return true
+	}
+	return false
+}
+
+// Evaluate checks a windowed condition against the samples, ordered oldest first.
+// Crossing types compare the indicator at the latest sample with the one at the
+// sample before it, so callers should retain samples for twice the window.
+func Evaluate(conditionType string, samples []types.ConditionSample, window time.Duration, lowerLimit, upperLimit float64) (bool, error) {
+	if window <= 0 {
+		return false, fmt.Errorf("window must be positive for condition type %s", conditionType)
+	}
+	if len(samples) < 2 {
+		return false, nil
+	}
+	samples = sorted(samples)
+	last := len(samples) - 1
+
+	switch conditionType {
+	case ConditionPercentChangeAbove:
+		change, ok := PercentChange(samples, last, window)
+		return ok && change > lowerLimit, nil
+	case ConditionPercentChangeBelow:
+		// The limit is the size of the drop, as limits are positive
+		change, ok := PercentChange(samples, last, window)
+		return ok && change < -upperLimit, nil
+	case ConditionSMACrossAbove:
+		return crossed(SMA, samples, window, lowerLimit, true), nil
+	case ConditionSMACrossBelow:
+		return crossed(SMA, samples, window, upperLimit, false), nil
+	case ConditionEMACrossAbove:
+		return crossed(EMA, samples, window, lowerLimit, true), nil
+	case ConditionEMACrossBelow:
+		return crossed(EMA, samples, window, upperLimit, false), nil
+	case ConditionZScoreAbove:
+		z, ok := ZScore(samples, last, window)
+		return ok && math.Abs(z) > lowerLimit, nil
+	default:
+		return false, fmt.Errorf("unsupported windowed condition type: %s", conditionType)
+	}
+}
+
+// Indicator computes a value over the window ending at samples[end]
+type Indicator func(samples []types.ConditionSample, end int, window time.Duration) (float64, bool)
+
+// PercentChange returns the change in percent from the oldest sample in the window to samples[end]
+func PercentChange(samples []types.ConditionSample, end int, window time.Duration) (float64, bool) {
+	start := windowStart(samples, end, window)
+	if start == end || samples[start].Value == 0 {
+		return 0, false
+	}
+	return (samples[end].Value - samples[start].Value) / samples[start].Value * 100, true
+}
+
+// SMA returns the simple moving average of the samples in the window ending at samples[end]
+func SMA(samples []types.ConditionSample, end int, window time.Duration) (float64, bool) {
+	start := windowStart(samples, end, window)
+	sum := 0.0
+	for i := start; i <= end; i++ {
+		sum += samples[i].Value
+	}
+	return sum / float64(end-start+1), true
+}
+
+// EMA returns the exponential moving average of the samples in the window ending at samples[end].
+// The smoothing factor is derived from the number of samples in the window.
+func EMA(samples []types.ConditionSample, end int, window time.Duration) (float64, bool) {
+	start := windowStart(samples, end, window)
+	alpha := 2 / float64(end-start+2)
+	ema := samples[start].Value
+	for i := start + 1; i <= end; i++ {
+		ema = alpha*samples[i].Value + (1-alpha)*ema
+	}
+	return ema, true
+}
+
+// ZScore returns the deviation of samples[end] from the window mean in standard deviations
+func ZScore(samples []types.ConditionSample, end int, window time.Duration) (float64, bool) {
+	start := windowStart(samples, end, window)
+	n := float64(end - start + 1)
+	if n < 2 {
+		return 0, false
+	}
+	mean, _ := SMA(samples, end, window)
+	variance := 0.0
+	for i := start; i <= end; i++ {
+		variance += (samples[i].Value - mean) * (samples[i].Value - mean)
+	}
+	stddev := math.Sqrt(variance / n)
+	if stddev == 0 {
+		return 0, false
+	}
+	return (samples[end].Value - mean) / stddev, true
+}
+
+// crossed reports whether the indicator moved across the threshold between the
+// previous sample and the latest one
+func crossed(indicator Indicator, samples []types.ConditionSample, window time.Duration, threshold float64, above bool) bool {
+	last := len(samples) - 1
+	current, ok := indicator(samples, last, window)
+	if !ok {
+		return false
+	}
+	previous, ok := indicator(samples, last-1, window)
+	if !ok {
+		return false
+	}
+	if above {
+		return previous <= threshold && current > threshold
+	}
+	return previous >= threshold && current < threshold
+}
+
+// windowStart returns the index of the oldest sample within window of samples[end]
+func windowStart(samples []types.ConditionSample, end int, window time.Duration) int {
+	cutoff := samples[end].Timestamp.Add(-window)
+	start := end
+	for start > 0 && !samples[start-1].Timestamp.Before(cutoff) {
+		start--
+	}
+	return start
+}
+
+func sorted(samples []types.ConditionSample) []types.ConditionSample {
+	if sort.SliceIsSorted(samples, func(i, j int) bool { return samples[i].Timestamp.Before(samples[j].Timestamp) }) {
+		return samples
+	}
+	out := make([]types.ConditionSample, len(samples))
+	copy(out, samples)
+	sort.Slice(out, func(i, j int) bool { return out[i].Timestamp.Before(out[j].Timestamp) })
+	return out
+}
@@ -0,0 +1,144 @@
+package timeseries
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/trigg3rX/triggerx-backend-imua/pkg/types"
+)
+
+func series(values ...float64) []types.ConditionSample {
+	start := time.Date(2025, time.June, 2, 17, 0, 0, 0, time.UTC)
+	samples := make([]types.ConditionSample, len(values))
+	for i, v := range values {
+		samples[i] = types.ConditionSample{Timestamp: start.Add(time.Duration(i) * time.Minute), Value: v}
+	}
+	return samples
+}
+
+func TestPercentChange(t *testing.T) {
+	samples := series(100, 100, 98, 96, 94)
+
+	change, ok := PercentChange(samples, 4, 10*time.Minute)
+	assert.True(t, ok)
+	assert.InDelta(t, -6.0, change, 1e-9)
+
+	// window only reaches back two minutes
+	change, ok = PercentChange(samples, 4, 2*time.Minute)
+	assert.True(t, ok)
+	assert.InDelta(t, (94.0-98.0)/98.0*100, change, 1e-9)
+}
+
+func TestEvaluate(t *testing.T) {
+	tests := []struct {
+		name          string
+		conditionType string
+		samples       []types.ConditionSample
+		window        time.Duration
+		lower, upper  float64
+		expected      bool
+		expectErr     bool
+	}{
+		{
+			name:          "drop more than 5 percent",
+			conditionType: ConditionPercentChangeBelow,
+			samples:       series(100, 99, 97, 94),
+			window:        10 * time.Minute,
+			upper:         5,
+			expected:      true,
+		},
+		{
+			name:          "drop less than 5 percent",
+			conditionType: ConditionPercentChangeBelow,
+			samples:       series(100, 99, 97, 96),
+			window:        10 * time.Minute,
+			upper:         5,
+			expected:      false,
+		},
+		{
+			name:          "rise is not a drop",
+			conditionType: ConditionPercentChangeBelow,
+			samples:       series(100, 101, 102),
+			window:        10 * time.Minute,
+			upper:         5,
+			expected:      false,
+		},
+		{
+			name:          "rise more than 5 percent",
+			conditionType: ConditionPercentChangeAbove,
+			samples:       series(100, 103, 106),
+			window:        10 * time.Minute,
+			lower:         5,
+			expected:      true,
+		},
+		{
+			name:          "sma crosses above",
+			conditionType: ConditionSMACrossAbove,
+			samples:       series(10, 10, 10, 13),
+			window:        2 * time.Minute,
+			lower:         10.5,
+			expected:      true,
+		},
+		{
+			name:          "sma already above does not cross",
+			conditionType: ConditionSMACrossAbove,
+			samples:       series(12, 12, 12, 13),
+			window:        2 * time.Minute,
+			lower:         10.5,
+			expected:      false,
+		},
+		{
+			name:          "ema crosses below",
+			conditionType: ConditionEMACrossBelow,
+			samples:       series(10, 10, 10, 4),
+			window:        3 * time.Minute,
+			upper:         8,
+			expected:      true,
+		},
+		{
+			name:          "zscore outlier",
+			conditionType: ConditionZScoreAbove,
+			samples:       series(10, 11, 10, 11, 10, 11, 20),
+			window:        10 * time.Minute,
+			lower:         2,
+			expected:      true,
+		},
+		{
+			name:          "not enough samples",
+			conditionType: ConditionPercentChangeAbove,
+			samples:       series(100),
+			window:        10 * time.Minute,
+			lower:         5,
+			expected:      false,
+		},
+		{
+			name:          "zero window",
+			conditionType: ConditionPercentChangeAbove,
+			samples:       series(100, 110),
+			lower:         5,
+			expectErr:     true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := Evaluate(tt.conditionType, tt.samples, tt.window, tt.lower, tt.upper)
+			if tt.expectErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, result)
+		})
+	}
+}
+
+func TestEvaluateUnsortedSamples(t *testing.T) {
+	samples := series(100, 99, 97, 94)
+	samples[0], samples[3] = samples[3], samples[0]
+
+	result, err := Evaluate(ConditionPercentChangeBelow, samples, 10*time.Minute, 0, 5)
+	assert.NoError(t, err)
+	assert.True(t, result)
+}
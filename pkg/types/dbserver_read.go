@@ -27,3 +27,12 @@ type UserLeaderboardEntry struct {
 	TasksCompleted int64   `json:"tasks_completed"`
 	UserPoints     float64 `json:"user_points"`
 }
+
+// JobBudgetData is what the user of a job has left to spend, and what one task of
+// the job is expected to cost. Amounts are in TG.
+type JobBudgetData struct {
+	JobID        int64   `json:"job_id"`
+	UserID       int64   `json:"user_id"`
+	Balance      float64 `json:"balance"`
+	EstimatedFee float64 `json:"estimated_fee"`
+}
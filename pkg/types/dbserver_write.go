@@ -17,4 +17,15 @@ type UpdateTaskExecutionDataRequest struct {
 	ExecutionTxHash    string    `json:"execution_tx_hash" validate:"required"`
 	ProofOfTask        string    `json:"proof_of_task" validate:"required"`
 	TaskOpXCost        float64   `json:"task_opx_cost" validate:"required"`
-}
\ No newline at end of file
+	IsSuccessful       bool      `json:"is_successful"`
+	// Script the task's arguments came from, its cost is recorded against it
+	DynamicArgumentsScriptUrl string `json:"dynamic_arguments_script_url,omitempty"`
+	// The script decided not to act, so there is no transaction
+	IsSkipped bool `json:"is_skipped,omitempty"`
+}
+
+// FailTaskExecutionRequest reports a task the keepers gave up on
+type FailTaskExecutionRequest struct {
+	TaskID int64  `json:"task_id" validate:"required"`
+	Error  string `json:"error"`
+}
@@ -39,16 +39,88 @@ type PerformerActionData struct {
 	DynamicComplexity  float64   `json:"dynamic_complexity"`
 	ComplexityIndex    float64   `json:"complexity_index"`
 	ExecutionTimestamp time.Time `json:"execution_timestamp"`
+
+	// One result per target call, in the order the job defines them
+	TargetResults []TargetCallResult `json:"target_results,omitempty"`
+	// Receipts of the transactions sent, one per chain
+	Receipts []ActionReceipt `json:"receipts,omitempty"`
+
+	// The dynamic arguments script decided not to act, so no transaction was sent
+	Skipped    bool   `json:"skipped,omitempty"`
+	SkipReason string `json:"skip_reason,omitempty"`
+
+	// How the dynamic arguments script ran, for attesters to replay it
+	ScriptRecording *ScriptRecording `json:"script_recording,omitempty"`
+}
+
+// Everything a dynamic arguments script's output depends on. Replaying the
+// script on the same image, modules and network responses gives the same output.
+type ScriptRecording struct {
+	ScriptURL   string         `json:"script_url"`
+	Runtime     string         `json:"runtime"`
+	ImageDigest string         `json:"image_digest"`
+	GoMod       string         `json:"go_mod,omitempty"`
+	GoSum       string         `json:"go_sum,omitempty"`
+	Exchanges   []HTTPExchange `json:"exchanges"`
+	Output      string         `json:"output"`
+}
+
+// An HTTP request a script made, and the response it got, in the order it made them
+type HTTPExchange struct {
+	Method       string              `json:"method"`
+	URL          string              `json:"url"`
+	RequestBody  []byte              `json:"request_body,omitempty"`
+	StatusCode   int                 `json:"status_code"`
+	Header       map[string][]string `json:"header,omitempty"`
+	ResponseBody []byte              `json:"response_body,omitempty"`
+	// SHA-256 of the certificate the upstream server presented, for HTTPS requests
+	CertificateHash string `json:"certificate_hash,omitempty"`
+}
+
+// Outcome of a single target call. Calls batched on one chain share a transaction.
+type TargetCallResult struct {
+	Index                 int    `json:"index"`
+	TargetChainID         string `json:"target_chain_id"`
+	TargetContractAddress string `json:"target_contract_address"`
+	TargetFunction        string `json:"target_function"`
+	ActionTxHash          string `json:"action_tx_hash"`
+	GasUsed               string `json:"gas_used"`
+	Status                bool   `json:"status"`
+	Error                 string `json:"error,omitempty"`
+}
+
+// The receipt of an action transaction, as the chain included it
+type ActionReceipt struct {
+	ChainID     string `json:"chain_id"`
+	TxHash      string `json:"tx_hash"`
+	BlockNumber uint64 `json:"block_number"`
+	BlockHash   string `json:"block_hash"`
+	Status      uint64 `json:"status"`
+	GasUsed     uint64 `json:"gas_used"`
+	ReceiptHash string `json:"receipt_hash"` // Keccak256 of the receipt's consensus encoding
 }
 
-// Data from keeper's proof generation for execution done above
+// Data from keeper's proof generation for execution done above. ProofOfTask is
+// the Merkle root of the leaves, each of which commits to one piece of evidence.
 type ProofData struct {
-	TaskID               int64     `json:"task_id"`
-	ProofOfTask          string    `json:"proof_of_task"`
+	TaskID      int64       `json:"task_id"`
+	Version     int         `json:"version,omitempty"`
+	ProofOfTask string      `json:"proof_of_task"`
+	Leaves      []ProofLeaf `json:"leaves,omitempty"`
+
+	// Optional attestation of a TLS connection the keeper made itself
 	CertificateHash      string    `json:"certificate_hash"`
 	CertificateTimestamp time.Time `json:"certificate_timestamp"`
 }
 
+// A piece of evidence under the proof of task, with the sibling hashes proving
+// it is in the root
+type ProofLeaf struct {
+	Name string   `json:"name"`
+	Hash string   `json:"hash"`
+	Path []string `json:"path"`
+}
+
 type PerformerSignatureData struct {
 	TaskID                  int64  `json:"task_id"`
 	PerformerSigningAddress string `json:"performer_signing_address"`
@@ -63,6 +135,35 @@ type IPFSData struct {
 	PerformerSignature *PerformerSignatureData `json:"performer_signature_data"`
 }
 
+// A step of executing a task, as the performer timed it. Target is the index of
+// the target the step was for, -1 for steps of the whole task.
+type ExecutionStep struct {
+	Name       string    `json:"name"`
+	Target     int       `json:"target"`
+	StartedAt  time.Time `json:"started_at"`
+	DurationMs int64     `json:"duration_ms"`
+	Detail     string    `json:"detail,omitempty"`
+	Error      string    `json:"error,omitempty"`
+}
+
+// The performer's own account of executing a task, signed with its keeper key
+type ExecutionTrace struct {
+	TaskID           int64           `json:"task_id"`
+	TraceID          string          `json:"trace_id"`
+	PerformerAddress string          `json:"performer_address"`
+	Success          bool            `json:"success"`
+	Error            string          `json:"error,omitempty"`
+	StartedAt        time.Time       `json:"started_at"`
+	DurationMs       int64           `json:"duration_ms"`
+	Steps            []ExecutionStep `json:"steps"`
+	Signature        string          `json:"signature,omitempty"`
+}
+
+// Result of a task the performer reports to the orchestrator
+type TaskResultData struct {
+	ExecutionTrace *ExecutionTrace `json:"execution_trace"`
+}
+
 // Data to Broadcast to Attesters from performer
 type BroadcastDataForValidators struct {
 	ProofOfTask        string `json:"proof_of_task"`
@@ -15,6 +15,34 @@ type TaskTargetData struct {
 	ArgType                   int      `json:"arg_type"`
 	Arguments                 []string `json:"arguments"`
 	DynamicArgumentsScriptUrl string   `json:"dynamic_arguments_script_url"`
+
+	// Further calls made on the same trigger, after the one above
+	ExtraTargets []TargetCall `json:"extra_targets,omitempty"`
+}
+
+// TargetCall is one more contract call of a job. Calls on the same chain are
+// executed together in one transaction, so they either all succeed or all revert.
+type TargetCall struct {
+	TargetChainID         string   `json:"target_chain_id" validate:"required,chain_id"`
+	TargetContractAddress string   `json:"target_contract_address" validate:"required,ethereum_address"`
+	TargetFunction        string   `json:"target_function" validate:"required"`
+	ABI                   string   `json:"abi" validate:"required"`
+	ArgType               int      `json:"arg_type"`
+	Arguments             []string `json:"arguments"`
+}
+
+// TargetCalls returns every call of the task in execution order, the primary target first
+func (t *TaskTargetData) TargetCalls() []TargetCall {
+	calls := make([]TargetCall, 0, 1+len(t.ExtraTargets))
+	calls = append(calls, TargetCall{
+		TargetChainID:         t.TargetChainID,
+		TargetContractAddress: t.TargetContractAddress,
+		TargetFunction:        t.TargetFunction,
+		ABI:                   t.ABI,
+		ArgType:               t.ArgType,
+		Arguments:             t.Arguments,
+	})
+	return append(calls, t.ExtraTargets...)
 }
 
 // Monitoring Data for even and condition workers
@@ -35,6 +63,19 @@ type ConditionWorkerData struct {
 	LowerLimit      float64   `json:"lower_limit"`
 	ValueSourceType string    `json:"value_source_type"`
 	ValueSourceUrl  string    `json:"value_source_url"`
+	// Trigger policy, zero values keep the fire-on-every-check behaviour
+	ConsecutiveHits int     `json:"consecutive_hits"`
+	CooldownSeconds int64   `json:"cooldown_seconds"`
+	EdgeTriggered   bool    `json:"edge_triggered"`
+	RearmPercent    float64 `json:"rearm_percent"`
+	// Window for rate-of-change, moving-average and z-score condition types
+	WindowSeconds int64 `json:"window_seconds"`
+}
+
+// Value sample recorded by a condition worker, used by windowed condition types
+type ConditionSample struct {
+	Timestamp time.Time `json:"timestamp"`
+	Value     float64   `json:"value"`
 }
 
 // Data to pass to time scheduler
@@ -50,6 +91,44 @@ type ScheduleTimeTaskData struct {
 	SpecificSchedule       string         `json:"specific_schedule"`
 	TaskTargetData         TaskTargetData `json:"task_target_data"`
 	IsImua                 bool           `json:"is_imua"`
+	// What to do with an execution found later than MaxLatenessSeconds
+	MisfirePolicy      string `json:"misfire_policy"`
+	MisfireMaxCatchup  int    `json:"misfire_max_catchup"`
+	MaxLatenessSeconds int64  `json:"max_lateness_seconds"`
+	// The claim is only valid until the lease expires, after which another scheduler may take the job
+	LeaseExpiresAt time.Time `json:"lease_expires_at"`
+}
+
+// Misfire policies for time jobs, applied to executions that are late by more than their max lateness
+const (
+	MisfireFireOnceNow = "fire_once_now" // Execute once now and skip the other missed executions
+	MisfireFireAll     = "fire_all"      // Execute the missed executions, at most MisfireMaxCatchup of them
+	MisfireSkip        = "skip"          // Skip to the next execution without executing
+	MisfireFail        = "fail"          // Skip to the next execution and mark the missed task as misfired
+
+	DefaultMisfirePolicy      = MisfireFireOnceNow
+	DefaultMisfireMaxCatchup  = 10
+	DefaultMaxLatenessSeconds = 60
+)
+
+// Claim of due time jobs by one time scheduler instance
+type ClaimTimeJobsRequest struct {
+	SchedulerID  string `json:"scheduler_id"`
+	LeaseSeconds int64  `json:"lease_seconds"`
+}
+
+// Ack or release of claimed time jobs. Ack advances the schedule, release hands the jobs back.
+type TimeJobLeaseRequest struct {
+	SchedulerID string  `json:"scheduler_id"`
+	JobIDs      []int64 `json:"job_ids"`
+	// Tasks of acknowledged jobs that were dropped by the fail misfire policy
+	MisfiredTaskIDs []int64 `json:"misfired_task_ids,omitempty"`
+}
+
+type TimeJobLeaseResponse struct {
+	Applied []int64 `json:"applied"`
+	// Jobs whose lease had already been taken over by another scheduler
+	Lost []int64 `json:"lost"`
 }
 
 // Data to pass to condition scheduler
@@ -63,6 +142,12 @@ type ScheduleConditionJobData struct {
 	IsImua              bool                `json:"is_imua"`
 }
 
+// Redis keys shared by the dbserver and condition scheduler instances
+const (
+	ConditionJobCatalogKey     = "condition:jobs"         // Hash of job ID -> ScheduleConditionJobData JSON
+	ConditionJobCatalogChannel = "condition:jobs:changed" // Published whenever the catalog changes
+)
+
 // Trigger data from schedulers to keepers for validation
 type TaskTriggerData struct {
 	TaskID           int64     `json:"task_id"`
@@ -73,22 +158,27 @@ type TaskTriggerData struct {
 	CurrentTriggerTimestamp time.Time `json:"trigger_timestamp"`
 
 	NextTriggerTimestamp time.Time `json:"next_trigger_timestamp"`
-	TimeScheduleType     string    `json:"time_schedule_type"`
-	TimeCronExpression   string    `json:"time_cron_expression"`
-	TimeSpecificSchedule string    `json:"time_specific_schedule"`
-	TimeInterval         int64     `json:"time_interval"`
+	// Keepers refuse a time task received later than this past NextTriggerTimestamp, 0 means no limit
+	MaxLatenessSeconds   int64  `json:"max_lateness_seconds"`
+	TimeScheduleType     string `json:"time_schedule_type"`
+	TimeCronExpression   string `json:"time_cron_expression"`
+	TimeSpecificSchedule string `json:"time_specific_schedule"`
+	TimeInterval         int64  `json:"time_interval"`
 
 	EventChainId                string `json:"event_chain_id"`
 	EventTxHash                 string `json:"event_tx_hash"`
 	EventTriggerContractAddress string `json:"event_trigger_contract_address"`
 	EventTriggerName            string `json:"event_trigger_name"`
 
-	ConditionType           string `json:"condition_type"`
-	ConditionSourceType     string `json:"condition_source_type"`
-	ConditionSourceUrl      string `json:"condition_source_url"`
-	ConditionUpperLimit     int    `json:"condition_upper_limit"`
-	ConditionLowerLimit     int    `json:"condition_lower_limit"`
-	ConditionSatisfiedValue int    `json:"condition_satisfied_value"`
+	ConditionType           string  `json:"condition_type"`
+	ConditionSourceType     string  `json:"condition_source_type"`
+	ConditionSourceUrl      string  `json:"condition_source_url"`
+	ConditionUpperLimit     float64 `json:"condition_upper_limit"`
+	ConditionLowerLimit     float64 `json:"condition_lower_limit"`
+	ConditionSatisfiedValue float64 `json:"condition_satisfied_value"`
+	// For windowed condition types, the samples the worker evaluated
+	ConditionWindowSeconds int64             `json:"condition_window_seconds"`
+	ConditionSamples       []ConditionSample `json:"condition_samples,omitempty"`
 }
 
 type SchedulerSignatureData struct {